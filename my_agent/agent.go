@@ -2,9 +2,13 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"strings"
 	"test/vm"
+	"time"
 
 	"github.com/joho/godotenv"
 	"google.golang.org/adk/agent"
@@ -16,51 +20,200 @@ import (
 	"google.golang.org/genai"
 )
 
+// defaultModelName - модель Gemini, используемая, если GEMINI_MODEL не задана
+const defaultModelName = "gemini-2.5-flash"
+
 func main() {
     // Загружаем переменные из .env файла
     if err := godotenv.Load(".env"); err != nil {
         log.Println("Warning: .env file not found, using environment variables")
     }
 
+    // Подкоманда apply работает без модели: vm-agent apply inventory.yaml
+    if len(os.Args) > 1 && os.Args[1] == "apply" {
+        os.Exit(runApply(os.Args[2:]))
+    }
+
+    // Проверяем настройки до создания модели, чтобы не получить невнятную ошибку SDK
+    apiKey := os.Getenv("GOOGLE_API_KEY")
+    if apiKey == "" {
+        log.Fatal("GOOGLE_API_KEY is not set: add it to the .env file or export it in the environment")
+    }
+    modelName := defaultModelName
+    if name, ok := os.LookupEnv("GEMINI_MODEL"); ok {
+        modelName = strings.TrimSpace(name)
+    }
+    if modelName == "" {
+        log.Fatal("Model name is empty: set GEMINI_MODEL to a Gemini model name or unset it to use " + defaultModelName)
+    }
+
     ctx := context.Background()
 
-    model, err := gemini.NewModel(ctx, "gemini-2.5-flash", &genai.ClientConfig{
-        APIKey: os.Getenv("GOOGLE_API_KEY"),
+    model, err := gemini.NewModel(ctx, modelName, &genai.ClientConfig{
+        APIKey: apiKey,
     })
     if err != nil {
         log.Fatalf("Failed to create model: %v", err)
     }
 
-    VMTools := getVMTools()
+    manager := newVMManager()
+    // Close сохраняет постоянное хранилище (VM_STORE_PATH) при завершении агента
+    defer manager.Close()
+    VMTools, diskTools := getVMTools(manager)
 
+    // vm_agent отвечает за жизненный цикл ВМ, disk_agent - за диски,
+    // а координатор передает запрос подходящему агенту
     VMAgent, err := llmagent.New(llmagent.Config{
         Name:        "vm_agent",
         Model:       model,
-        Description: "Manage some virtual machines using common interface",
-        Instruction: "You are a manager of virtual machines, you can creating, starting, stopping, deleting virtual machines, get some information about them.",
+        Description: "Manages the lifecycle of virtual machines: creating, starting, stopping, deleting, cloning, snapshots, labels and information about them",
+        Instruction: "You are a manager of virtual machines, you can creating, starting, stopping, deleting virtual machines, get some information about them. Use the backend_type tool to tell the user whether real infrastructure is managed: on the mock backend nothing real is created.",
         Tools: VMTools,
     })
     if err != nil {
         log.Fatalf("Failed to create agent: %v", err)
     }
 
+    diskAgent, err := llmagent.New(llmagent.Config{
+        Name:        "disk_agent",
+        Model:       model,
+        Description: "Manages virtual machine disks: attaching, detaching, resizing, compacting, disk IOPS limits, ISO images, disk usage, disk conflicts and orphaned disk images",
+        Instruction: "You are a manager of virtual machine disks. You can attach and detach additional disks, attach ISO images, grow disks, compact (trim) disks of stopped VMs, set read and write IOPS limits on disks, report disk usage, find disks used by several VMs at once and find disk images not attached to any VM.",
+        Tools: diskTools,
+    })
+    if err != nil {
+        log.Fatalf("Failed to create disk agent: %v", err)
+    }
+
+    coordinator, err := llmagent.New(llmagent.Config{
+        Name:        "coordinator",
+        Model:       model,
+        Description: "Routes virtual machine requests to the lifecycle and disk agents",
+        Instruction: "You coordinate virtual machine management. Transfer disk-related requests (attach, detach, resize, compact or trim, disk IOPS limits, ISO images, usage, disk conflicts, orphaned disks) to disk_agent and every other virtual machine request to vm_agent.",
+        SubAgents:   []agent.Agent{VMAgent, diskAgent},
+    })
+    if err != nil {
+        log.Fatalf("Failed to create coordinator agent: %v", err)
+    }
+
     config := &launcher.Config{
-        AgentLoader: agent.NewSingleLoader(VMAgent),
+        AgentLoader: agent.NewSingleLoader(coordinator),
     }
 
     l := full.NewLauncher()
     if err = l.Execute(ctx, config, os.Args[1:]); err != nil {
+        manager.Close()
         log.Fatalf("Run failed: %v\n\n%s", err, l.CommandLineSyntax())
     }
 }
 
 
-func getVMTools() []tool.Tool {
-    manager := vm.NewMockVMManager()
+// newVMManager создает менеджер ВМ с настройками агента
+func newVMManager() vm.VMManagerInterface {
+    defaults := vm.WithDefaults(vm.VMConfig{
+        Memory:  2048,
+        VCPUs:   2,
+        Network: "default",
+    })
+    var manager vm.VMManagerInterface
+
+    // VM_STORE_PATH (например, "vms.json") сохраняет ВМ между запусками агента в файле;
+    // VM_STORE_KEY шифрует его
+    if path := strings.TrimSpace(os.Getenv("VM_STORE_PATH")); path != "" {
+        store, err := vm.NewMockVMManagerWithStore(path, defaults, vm.WithStoreKey(os.Getenv("VM_STORE_KEY")))
+        if err != nil {
+            log.Fatalf("Failed to open VM store: %v", err)
+        }
+        manager = store
+    } else {
+        manager = vm.NewMockVMManager(defaults)
+    }
+
+    // VM_OPERATION_TIMEOUT (например, "30s") ограничивает время каждой операции бэкенда
+    if value := strings.TrimSpace(os.Getenv("VM_OPERATION_TIMEOUT")); value != "" {
+        timeout, err := time.ParseDuration(value)
+        if err != nil {
+            log.Fatalf("Invalid VM_OPERATION_TIMEOUT '%s': %v", value, err)
+        }
+        manager = vm.NewTimeoutManager(manager, vm.OperationTimeouts{Default: timeout})
+    }
+    return manager
+}
+
+// runApply читает файл инвентаря (YAML или JSON), приводит к нему ВМ и печатает
+// выполненные действия. Возвращает код выхода: 1, если хотя бы одна ВМ не применена
+func runApply(args []string) int {
+    if len(args) != 1 {
+        fmt.Fprintln(os.Stderr, "usage: vm-agent apply <inventory.yaml|inventory.json>")
+        return 2
+    }
+
+    data, err := os.ReadFile(args[0])
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "Failed to read inventory: %v\n", err)
+        return 1
+    }
+    inventory, err := vm.ParseInventory(data)
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "%v\n", err)
+        return 1
+    }
+
+    manager := newVMManager()
+    defer manager.Close()
+
+    results, err := manager.Reconcile(context.Background(), inventory.VMs)
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "Failed to apply inventory: %v\n", err)
+        return 1
+    }
+
+    code := 0
+    for _, result := range results {
+        if result.Err != nil {
+            fmt.Printf("%-10s %s: FAILED: %v\n", result.Action, result.Name, result.Err)
+            code = 1
+            continue
+        }
+        fmt.Printf("%-10s %s\n", result.Action, result.Name)
+        for _, change := range result.Changes {
+            fmt.Printf("           %s: %q -> %q\n", change.Field, change.A, change.B)
+        }
+    }
+    return code
+}
+
+// serveEvents запускает HTTP-сервер с потоком событий ВМ (GET /events, Server-Sent
+// Events) на адресе из VM_EVENTS_ADDR, например ":8090". Без переменной сервер не запускается
+func serveEvents(manager vm.VMManagerInterface) {
+    addr := strings.TrimSpace(os.Getenv("VM_EVENTS_ADDR"))
+    if addr == "" {
+        return
+    }
+
+    mux := http.NewServeMux()
+    mux.Handle("/events", vm.NewEventStream(manager.Subscribe))
+    go func() {
+        log.Printf("Serving VM events on http://%s/events", addr)
+        if err := http.ListenAndServe(addr, mux); err != nil {
+            log.Printf("VM events server stopped: %v", err)
+        }
+    }()
+}
+
+// getVMTools возвращает инструменты жизненного цикла и дисков, работающие с одним и тем
+// же менеджером manager
+func getVMTools(manager vm.VMManagerInterface) ([]tool.Tool, []tool.Tool) {
+    serveEvents(manager)
+
     VMTools, err := vm.NewVMTools(manager)
     if err != nil {
-        log.Fatalf("Failed to create VM tools: %w", err)
+        log.Fatalf("Failed to create VM tools: %v", err)
     }
-    
-    return VMTools
+    diskTools, err := vm.NewDiskTools(manager)
+    if err != nil {
+        log.Fatalf("Failed to create disk tools: %v", err)
+    }
+
+    return VMTools, diskTools
 }
\ No newline at end of file
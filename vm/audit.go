@@ -0,0 +1,210 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+)
+
+// auditLogLimit - максимальное количество записей в журнале операций
+const auditLogLimit = 100
+
+// AuditOperation - тип изменяющей операции в журнале
+type AuditOperation string
+
+const (
+	AuditCreate AuditOperation = "create"
+	AuditClone  AuditOperation = "clone"
+	AuditStart  AuditOperation = "start"
+	AuditStop   AuditOperation = "stop"
+	AuditDelete AuditOperation = "delete"
+	AuditRename AuditOperation = "rename"
+	AuditUpdate AuditOperation = "update_config"
+	AuditSwap   AuditOperation = "swap_names"
+)
+
+// Изменяющие операции, которые записываются в журнал, но не отменяются: UndoLast
+// отказывается отменять их, а не пропускает, чтобы не отменить вместо них более раннюю
+// операцию
+const (
+	AuditTransition       AuditOperation = "transition"
+	AuditError            AuditOperation = "error"
+	AuditClearError       AuditOperation = "clear_error"
+	AuditRestart          AuditOperation = "restart"
+	AuditFreeze           AuditOperation = "freeze"
+	AuditResume           AuditOperation = "resume"
+	AuditLock             AuditOperation = "lock_config"
+	AuditUnlock           AuditOperation = "unlock_config"
+	AuditLabels           AuditOperation = "labels"
+	AuditCPUPinning       AuditOperation = "set_cpu_pinning"
+	AuditDiskIOPS         AuditOperation = "set_disk_iops"
+	AuditBandwidth        AuditOperation = "set_network_bandwidth"
+	AuditBalloon          AuditOperation = "set_memory_balloon"
+	AuditNextBoot         AuditOperation = "set_next_boot"
+	AuditBootTimeout      AuditOperation = "set_boot_timeout"
+	AuditCreateSnapshot   AuditOperation = "create_snapshot"
+	AuditRestoreSnapshot  AuditOperation = "restore_snapshot"
+	AuditDeleteSnapshot   AuditOperation = "delete_snapshot"
+	AuditRenameSnapshot   AuditOperation = "rename_snapshot"
+	AuditSnapshotEstimate AuditOperation = "set_snapshot_space_estimate"
+	AuditAttachDisk       AuditOperation = "attach_disk"
+	AuditDetachDisk       AuditOperation = "detach_disk"
+	AuditResizeDisk       AuditOperation = "resize_disk"
+	AuditCompactDisk      AuditOperation = "compact_disk"
+	AuditAttachISO        AuditOperation = "attach_iso"
+	AuditWriteGuestFile   AuditOperation = "write_guest_file"
+)
+
+// AuditEntry - запись журнала операций с данными, достаточными для ее отмены
+type AuditEntry struct {
+	Time      time.Time      `json:"time"`
+	Operation AuditOperation `json:"operation"`
+	VMName    string         `json:"vm_name"`
+	NewName   string         `json:"new_name,omitempty"` // новое имя для AuditRename, вторая ВМ для AuditSwap
+	// VMs - ВМ, фактически сменившие состояние при AuditStart/AuditStop, в порядке операции
+	VMs []string `json:"vms,omitempty"`
+	// RenameDisk - при AuditRename вместе с ВМ были переименованы ее диски
+	RenameDisk bool `json:"rename_disk,omitempty"`
+	// PrevConfig - конфигурация до AuditUpdate
+	PrevConfig *VMConfig `json:"-"`
+}
+
+// AuditLog возвращает копию журнала операций от старых к новым
+func (m *MockVMManager) AuditLog() []AuditEntry {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return slices.Clone(m.audit)
+}
+
+// recordLocked добавляет запись в журнал и публикует событие о ней; вызывающий код
+// должен удерживать m.mu
+func (m *MockVMManager) recordLocked(entry AuditEntry) {
+	entry.Time = m.now()
+	m.audit = append(m.audit, entry)
+	if len(m.audit) > auditLogLimit {
+		m.audit = slices.Delete(m.audit, 0, len(m.audit)-auditLogLimit)
+	}
+	m.publishLocked(entry)
+}
+
+// statesLocked возвращает текущие состояния всех ВМ; вызывающий код должен удерживать m.mu
+func (m *MockVMManager) statesLocked() map[string]VMState {
+	states := make(map[string]VMState, len(m.vms))
+	for name, vm := range m.vms {
+		states[name] = vm.State
+	}
+	return states
+}
+
+// recordTransitionLocked записывает запуск или остановку ВМ из order, состояние
+// которых изменилось относительно before. Операции, ничего не изменившие, не записываются.
+// Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) recordTransitionLocked(op AuditOperation, name string, order []string, before map[string]VMState) {
+	var changed []string
+	for _, n := range order {
+		if vm, exists := m.vms[n]; exists && vm.State != before[n] {
+			changed = append(changed, n)
+		}
+	}
+	if len(changed) > 0 {
+		m.recordLocked(AuditEntry{Operation: op, VMName: name, VMs: changed})
+	}
+}
+
+// Reversible сообщает, умеет ли UndoLast отменять операцию записи
+func (e AuditEntry) Reversible() bool {
+	switch e.Operation {
+	case AuditCreate, AuditClone, AuditStart, AuditStop, AuditRename, AuditSwap, AuditUpdate:
+		return true
+	}
+	return false
+}
+
+// LastAuditEntry возвращает последнюю запись журнала операций - ту, которую отменит UndoLast
+func (m *MockVMManager) LastAuditEntry() (AuditEntry, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if len(m.audit) == 0 {
+		return AuditEntry{}, false
+	}
+	return m.audit[len(m.audit)-1], true
+}
+
+// notifyLocked публикует событие об операции op с ВМ, не записывая ее в журнал: фоновые
+// операции (снапшоты планировщика) не должны мешать UndoLast и вытеснять из журнала
+// операции пользователя. Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) notifyLocked(op AuditOperation, name string) {
+	m.publishLocked(AuditEntry{Time: m.now(), Operation: op, VMName: name})
+}
+
+// recordVMLocked записывает в журнал операцию op с одной ВМ; вызывающий код должен
+// удерживать m.mu
+func (m *MockVMManager) recordVMLocked(op AuditOperation, name string) {
+	m.recordLocked(AuditEntry{Operation: op, VMName: name})
+}
+
+// UndoLast отменяет последнюю изменяющую операцию из журнала: созданную или
+// клонированную ВМ удаляет, запущенные ВМ останавливает, остановленные - запускает,
+// возвращает прежнее имя или конфигурацию. Удаление и остальные операции (снапшоты,
+// диски, метки и т.д.) отменить нельзя: если последней была такая операция, UndoLast
+// возвращает ошибку и ничего не меняет. Отмененная запись удаляется из журнала, поэтому
+// повторные вызовы отменяют операции одну за другой. Хуки операций получают имя ВМ
+// из последней записи журнала на момент вызова
+func (m *MockVMManager) UndoLast() error {
+	entry, _ := m.LastAuditEntry()
+	return m.runHooks("undo", entry.VMName, m.undoLast)
+}
+
+// undoLast выполняет UndoLast без хуков операций
+func (m *MockVMManager) undoLast() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.audit) == 0 {
+		return errors.New("no operations to undo")
+	}
+	entry := m.audit[len(m.audit)-1]
+	if err := m.undoLocked(entry); err != nil {
+		return fmt.Errorf("cannot undo %s of virtual machine '%s': %w", entry.Operation, entry.VMName, err)
+	}
+	m.audit = m.audit[:len(m.audit)-1]
+
+	log.Printf("[MOCK] Undid %s of virtual machine '%s'", entry.Operation, entry.VMName)
+	return nil
+}
+
+// undoLocked выполняет обратную операцию для записи; вызывающий код должен удерживать m.mu
+func (m *MockVMManager) undoLocked(entry AuditEntry) error {
+	switch entry.Operation {
+	case AuditCreate, AuditClone:
+		return m.deleteVMLocked(entry.VMName, DeleteVMOptions{})
+	case AuditStart:
+		for _, n := range slices.Backward(entry.VMs) {
+			if err := m.stopVMLocked(n); err != nil {
+				return err
+			}
+		}
+		return nil
+	case AuditStop:
+		for _, n := range slices.Backward(entry.VMs) {
+			if err := m.startVMLocked(n); err != nil {
+				return err
+			}
+		}
+		return nil
+	case AuditRename:
+		return m.renameVMLocked(entry.NewName, entry.VMName, RenameVMOptions{RenameDisk: entry.RenameDisk})
+	case AuditSwap:
+		return m.swapVMNamesLocked(entry.VMName, entry.NewName)
+	case AuditUpdate:
+		return m.updateVMConfigLocked(entry.VMName, *entry.PrevConfig)
+	case AuditDelete:
+		return errors.New("deleted virtual machines are not retained")
+	default:
+		return errors.New("this operation cannot be undone")
+	}
+}
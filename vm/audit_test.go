@@ -0,0 +1,108 @@
+package vm
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestUndoLastRefusesUnrecordedOperation(t *testing.T) {
+	m := newTestManager(t)
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+	if err := m.AttachDisk("web", DiskSpec{Path: "/tmp/undo-data.qcow2", Size: 10}); err != nil {
+		t.Fatalf("AttachDisk: %v", err)
+	}
+
+	err := m.UndoLast()
+	if err == nil || !strings.Contains(err.Error(), "attach_disk") {
+		t.Fatalf("UndoLast after attach_disk = %v, want an error naming attach_disk", err)
+	}
+	if len(m.Snapshot()) != 1 {
+		t.Fatalf("UndoLast deleted the VM instead of refusing")
+	}
+	if last, _ := m.LastAuditEntry(); last.Operation != AuditAttachDisk {
+		t.Errorf("last audit entry = %s, want it kept as %s", last.Operation, AuditAttachDisk)
+	}
+}
+
+func TestUndoLastRevertsReversibleOperations(t *testing.T) {
+	m := newTestManager(t)
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+	if err := m.StopVM(context.Background(), "web"); err != nil {
+		t.Fatalf("StopVM: %v", err)
+	}
+	if err := m.RenameVM("web", "frontend", RenameVMOptions{}); err != nil {
+		t.Fatalf("RenameVM: %v", err)
+	}
+
+	if err := m.UndoLast(); err != nil {
+		t.Fatalf("undo rename: %v", err)
+	}
+	if got := stateOf(t, m, "web"); got != VMStateStopped {
+		t.Errorf("state after undoing rename = %s, want stopped", got)
+	}
+	if err := m.UndoLast(); err != nil {
+		t.Fatalf("undo stop: %v", err)
+	}
+	if got := stateOf(t, m, "web"); got != VMStateRunning {
+		t.Errorf("state after undoing stop = %s, want running", got)
+	}
+	if err := m.UndoLast(); err != nil {
+		t.Fatalf("undo create: %v", err)
+	}
+	if n := len(m.Snapshot()); n != 0 {
+		t.Errorf("%d VMs left after undoing create, want 0", n)
+	}
+}
+
+func TestMutationsAreRecorded(t *testing.T) {
+	m := newTestManager(t)
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+
+	steps := []struct {
+		op  AuditOperation
+		run func() error
+	}{
+		{AuditCreateSnapshot, func() error { return m.CreateSnapshot("web", "s1", "") }},
+		{AuditLabels, func() error { return m.AddLabelToVMs([]string{"web"}, "env", "prod")["web"] }},
+		{AuditBalloon, func() error { return m.SetMemoryBalloon("web", 512) }},
+		{AuditRestart, func() error { return m.RestartAllRunning()["web"] }},
+		{AuditLock, func() error { return m.LockVMConfig("web") }},
+		{AuditUnlock, func() error { return m.UnlockVMConfig("web") }},
+		{AuditRestoreSnapshot, func() error { return m.RestoreSnapshot("web", "s1") }},
+	}
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: %v", step.op, err)
+		}
+		last, ok := m.LastAuditEntry()
+		if !ok || last.Operation != step.op || last.VMName != "web" {
+			t.Fatalf("last audit entry after %s = %+v", step.op, last)
+		}
+		if last.Reversible() {
+			t.Errorf("%s is reported as reversible", step.op)
+		}
+	}
+}
+
+func TestUndoToolDryRunNamesEntry(t *testing.T) {
+	m := newTestManager(t)
+	tools := newTestTools(t, m)
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+
+	resp := callTool(t, tools, "undo_last_operation", map[string]any{"dry_run": true})
+	if plan, _ := resp["plan"].(string); !strings.Contains(plan, "create of VM 'web'") {
+		t.Errorf("plan = %q, want it to name the create of 'web'", plan)
+	}
+
+	if err := m.CreateSnapshot("web", "s1", ""); err != nil {
+		t.Fatalf("CreateSnapshot: %v", err)
+	}
+	resp = callTool(t, tools, "undo_last_operation", map[string]any{"dry_run": true})
+	if errText, _ := resp["error"].(string); resp["success"] == true || !strings.Contains(errText, "create_snapshot of VM 'web'") {
+		t.Errorf("dry run after create_snapshot = %v, want a failure naming the snapshot entry", resp)
+	}
+	if len(m.Snapshot()) != 1 {
+		t.Errorf("dry run changed the inventory")
+	}
+}
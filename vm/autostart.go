@@ -0,0 +1,19 @@
+package vm
+
+// WithAutoStartOnCreate задает, запускает ли CreateVM новую ВМ, если в ее конфигурации
+// не задан StartOnCreate (по умолчанию true). Явный VMConfig.StartOnCreate всегда имеет
+// приоритет над этой настройкой
+func WithAutoStartOnCreate(enabled bool) MockOption {
+	return func(m *MockVMManager) {
+		m.autoStartOnCreate = enabled
+	}
+}
+
+// startOnCreate сообщает, нужно ли запускать ВМ после создания: решает
+// VMConfig.StartOnCreate, а если он не задан - настройка менеджера
+func (m *MockVMManager) startOnCreate(config VMConfig) bool {
+	if config.StartOnCreate != nil {
+		return *config.StartOnCreate
+	}
+	return m.autoStartOnCreate
+}
@@ -0,0 +1,48 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestAutoStartOnCreatePrecedence(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		autoStart     bool
+		startOnCreate *bool
+		want          VMState
+	}{
+		// Без StartOnCreate решает настройка менеджера
+		{true, nil, VMStateRunning},
+		{false, nil, VMStateStopped},
+		// Явный StartOnCreate имеет приоритет в обе стороны
+		{true, &no, VMStateStopped},
+		{false, &yes, VMStateRunning},
+		{true, &yes, VMStateRunning},
+		{false, &no, VMStateStopped},
+	}
+	for _, tt := range tests {
+		flag := "unset"
+		if tt.startOnCreate != nil {
+			flag = fmt.Sprint(*tt.startOnCreate)
+		}
+		t.Run(fmt.Sprintf("auto=%v,start_on_create=%s", tt.autoStart, flag), func(t *testing.T) {
+			m := newTestManager(t, WithAutoStartOnCreate(tt.autoStart))
+			config := VMConfig{Name: "web", Memory: 1024, VCPUs: 1, StartOnCreate: tt.startOnCreate}
+			if err := m.CreateVM(context.Background(), config); err != nil {
+				t.Fatalf("CreateVM: %v", err)
+			}
+			if state := stateOf(t, m, "web"); state != tt.want {
+				t.Errorf("state after create = %s, want %s", state, tt.want)
+			}
+		})
+	}
+
+	// По умолчанию менеджер запускает созданные ВМ
+	m := newTestManager(t)
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+	if state := stateOf(t, m, "web"); state != VMStateRunning {
+		t.Errorf("state after create with the default setting = %s, want %s", state, VMStateRunning)
+	}
+}
@@ -0,0 +1,40 @@
+package vm
+
+import (
+	"fmt"
+	"log"
+)
+
+// SetMemoryBalloon изменяет текущую память запущенной ВМ в пределах от 1 МБ до
+// настроенного максимума (Config.Memory), имитируя balloon-драйвер. При каждом
+// запуске ВМ выделяется вся настроенная память
+func (m *MockVMManager) SetMemoryBalloon(name string, targetMB uint64) error {
+	return m.runHooks("set_memory_balloon", name, func() error { return m.setMemoryBalloon(name, targetMB) })
+}
+
+// setMemoryBalloon выполняет SetMemoryBalloon без хуков операций
+func (m *MockVMManager) setMemoryBalloon(name string, targetMB uint64) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = m.resolveNameLocked(name)
+	defer func() { m.noteResultLocked(name, err) }()
+
+	vm, exists := m.vms[name]
+	if !exists {
+		return fmt.Errorf("virtual machine '%s' not found", name)
+	}
+	if vm.State != VMStateRunning {
+		return fmt.Errorf("virtual machine '%s' must be running to adjust its memory balloon (current state: %s)", name, vm.State)
+	}
+	if targetMB == 0 {
+		return fmt.Errorf("balloon target memory cannot be zero")
+	}
+	if targetMB > vm.Config.Memory {
+		return fmt.Errorf("balloon target %d MB exceeds maximum memory %d MB of virtual machine '%s'", targetMB, vm.Config.Memory, name)
+	}
+
+	vm.CurrentMemoryMB = targetMB
+	log.Printf("[MOCK] Virtual machine '%s' memory balloon set to %d MB (max %d MB)", name, targetMB, vm.Config.Memory)
+	m.recordVMLocked(AuditBalloon, name)
+	return nil
+}
@@ -0,0 +1,103 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// WithBatchConcurrency задает, сколько элементов пакетной операции (StartVMs, StopVMs,
+// CreateVMs, RestartAllRunning) выполняется одновременно. По умолчанию 1 - элементы
+// выполняются последовательно в заданном порядке; значения меньше 1 означают 1
+func WithBatchConcurrency(n int) MockOption {
+	return func(m *MockVMManager) {
+		m.batchConcurrency = max(n, 1)
+	}
+}
+
+// StartVMs запускает перечисленные ВМ, не более WithBatchConcurrency одновременно.
+// Возвращает результат для каждого имени: nil при успехе или ошибку; повторяющиеся имена
+// не запускаются и получают ошибку
+func (m *MockVMManager) StartVMs(ctx context.Context, names []string) map[string]error {
+	return runBatch(names, m.batchConcurrency, func(i int) error { return m.StartVM(ctx, names[i]) })
+}
+
+// StopVMs останавливает перечисленные ВМ, не более WithBatchConcurrency одновременно.
+// Возвращает результат для каждого имени: nil при успехе или ошибку; повторяющиеся имена
+// не останавливаются и получают ошибку
+func (m *MockVMManager) StopVMs(ctx context.Context, names []string) map[string]error {
+	return runBatch(names, m.batchConcurrency, func(i int) error { return m.StopVM(ctx, names[i]) })
+}
+
+// CreateVMs создает ВМ с перечисленными конфигурациями, не более WithBatchConcurrency
+// одновременно. Возвращает результат для каждого имени ВМ: nil при успехе или ошибку;
+// конфигурации с повторяющимся именем не создаются и получают ошибку
+func (m *MockVMManager) CreateVMs(ctx context.Context, configs []VMConfig) map[string]error {
+	names := make([]string, len(configs))
+	for i, config := range configs {
+		names[i] = config.Name
+	}
+	return runBatch(names, m.batchConcurrency, func(i int) error { return m.CreateVM(ctx, configs[i]) })
+}
+
+// batchLimiter - менеджер, сам задающий параллельность пакетных операций; декораторы
+// (TimeoutManager) выполняют пакеты с той же параллельностью, что и обернутый менеджер
+type batchLimiter interface {
+	batchLimit() int
+}
+
+// batchLimit возвращает параллельность, заданную WithBatchConcurrency
+func (m *MockVMManager) batchLimit() int {
+	return m.batchConcurrency
+}
+
+// batchLimitOf возвращает параллельность пакетных операций менеджера; менеджеры без
+// собственного ограничения выполняют пакеты последовательно
+func batchLimitOf(manager VMManagerInterface) int {
+	if limiter, ok := manager.(batchLimiter); ok {
+		return limiter.batchLimit()
+	}
+	return 1
+}
+
+// runBatch вызывает fn для каждого элемента пакета в пуле из concurrency потоков
+// и собирает результаты по names[i]. Имя, повторяющееся в пакете, не выполняется ни разу
+// и получает ошибку: иначе результаты повторов перезаписывали бы друг друга в
+// непредсказуемом порядке. Вызывающий код не должен удерживать m.mu
+func runBatch(names []string, concurrency int, fn func(i int) error) map[string]error {
+	results := make(map[string]error, len(names))
+	count := make(map[string]int, len(names))
+	for _, name := range names {
+		count[name]++
+	}
+	for name, n := range count {
+		if n > 1 {
+			results[name] = fmt.Errorf("virtual machine '%s' is listed %d times in the batch", name, n)
+		}
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(concurrency, 1))
+	for i, name := range names {
+		if count[name] > 1 {
+			continue
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			err := fn(i)
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[name] = err
+		}()
+	}
+	wg.Wait()
+
+	log.Printf("Batch of %d item(s) finished (concurrency %d)", len(names), cap(sem))
+	return results
+}
@@ -0,0 +1,143 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunBatchLimitsConcurrency(t *testing.T) {
+	names := make([]string, 20)
+	for i := range names {
+		names[i] = fmt.Sprintf("vm%d", i)
+	}
+
+	var running, peak atomic.Int32
+	results := runBatch(names, 3, func(i int) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		return nil
+	})
+
+	if len(results) != len(names) {
+		t.Errorf("%d results, want %d", len(results), len(names))
+	}
+	if p := peak.Load(); p > 3 {
+		t.Errorf("%d items ran at once, want at most 3", p)
+	}
+}
+
+func TestBatchRejectsDuplicateNames(t *testing.T) {
+	m := newTestManager(t, WithAutoStartOnCreate(false), WithBatchConcurrency(4))
+
+	results := m.CreateVMs(context.Background(), []VMConfig{
+		{Name: "web", Memory: 1024, VCPUs: 1},
+		{Name: "db", Memory: 2048, VCPUs: 2},
+		{Name: "web", Memory: 4096, VCPUs: 4},
+	})
+	if len(results) != 2 {
+		t.Fatalf("results = %v, want one entry per distinct name", results)
+	}
+	if err := results["web"]; err == nil || !strings.Contains(err.Error(), "listed 2 times") {
+		t.Errorf("result for the duplicated name = %v, want a duplicate error", err)
+	}
+	if err := results["db"]; err != nil {
+		t.Errorf("result for db = %v, want nil", err)
+	}
+	if _, err := m.GetVMInfo("web"); err == nil {
+		t.Error("a duplicated config was created")
+	}
+
+	if err := m.StartVMs(context.Background(), []string{"db", "db"})["db"]; err == nil {
+		t.Error("StartVMs with a duplicated name succeeded")
+	}
+	if got := stateOf(t, m, "db"); got != VMStateStopped {
+		t.Errorf("db state = %s, want stopped", got)
+	}
+}
+
+// TestBatchOperationsConcurrently запускает пакеты параллельно; предназначен для go test -race
+func TestBatchOperationsConcurrently(t *testing.T) {
+	m := newTestManager(t, WithAutoStartOnCreate(false), WithBatchConcurrency(8), WithSimulatedStartDelay(time.Millisecond))
+
+	configs := make([]VMConfig, 16)
+	names := make([]string, len(configs))
+	for i := range configs {
+		names[i] = fmt.Sprintf("vm%02d", i)
+		configs[i] = VMConfig{Name: names[i], Memory: 512, VCPUs: 1}
+	}
+	for name, err := range m.CreateVMs(context.Background(), configs) {
+		if err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+	}
+	for name, err := range m.StartVMs(context.Background(), names) {
+		if err != nil {
+			t.Errorf("start %s: %v", name, err)
+		}
+	}
+	for name, err := range m.RestartAllRunning() {
+		if err != nil {
+			t.Errorf("restart %s: %v", name, err)
+		}
+	}
+	for name, err := range m.StopVMs(context.Background(), names) {
+		if err != nil {
+			t.Errorf("stop %s: %v", name, err)
+		}
+	}
+	for _, name := range names {
+		if got := stateOf(t, m, name); got != VMStateStopped {
+			t.Errorf("%s state = %s, want stopped", name, got)
+		}
+	}
+}
+
+// TestOverlappingBatches запускает и останавливает одни и те же ВМ двумя пакетами
+// одновременно; предназначен для go test -race
+func TestOverlappingBatches(t *testing.T) {
+	m := newTestManager(t, WithBatchConcurrency(4), WithSimulatedStartDelay(time.Millisecond))
+	names := make([]string, 8)
+	for i := range names {
+		names[i] = fmt.Sprintf("vm%d", i)
+		mustCreate(t, m, VMConfig{Name: names[i], Memory: 512, VCPUs: 1})
+	}
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			m.StopVMs(context.Background(), names)
+		}()
+		go func() {
+			defer wg.Done()
+			m.StartVMs(context.Background(), names)
+		}()
+	}
+	wg.Wait()
+
+	for _, info := range m.Snapshot() {
+		if info.State != VMStateRunning && info.State != VMStateStopped {
+			t.Errorf("%s state = %s, want running or stopped", info.Config.Name, info.State)
+		}
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for name, vm := range m.vms {
+		if vm.busy {
+			t.Errorf("%s is still busy after the batches", name)
+		}
+	}
+}
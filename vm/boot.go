@@ -0,0 +1,104 @@
+package vm
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Устройства загрузки ВМ (значения атрибута dev элемента <boot> в libvirt)
+const (
+	BootDeviceDisk    = "hd"
+	BootDeviceCDROM   = "cdrom"
+	BootDeviceNetwork = "network"
+)
+
+// BootDevices - известные устройства загрузки; по умолчанию ВМ загружается с диска
+var BootDevices = []string{BootDeviceDisk, BootDeviceCDROM, BootDeviceNetwork}
+
+// validateBootDevice проверяет, что устройство загрузки входит в BootDevices
+func validateBootDevice(device string) error {
+	for _, known := range BootDevices {
+		if device == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown boot device '%s' (supported: %s)", device, strings.Join(BootDevices, ", "))
+}
+
+// SetNextBoot задает устройство загрузки только для следующего запуска ВМ; после него
+// ВМ снова загружается с диска. Пустое устройство отменяет ранее заданную загрузку
+func (m *MockVMManager) SetNextBoot(name, device string) error {
+	return m.runHooks("set_next_boot", name, func() error { return m.setNextBoot(name, device) })
+}
+
+// setNextBoot выполняет SetNextBoot без хуков операций
+func (m *MockVMManager) setNextBoot(name, device string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = m.resolveNameLocked(name)
+
+	vm, exists := m.vms[name]
+	if !exists {
+		return fmt.Errorf("virtual machine '%s' not found", name)
+	}
+	if device != "" {
+		if err := validateBootDevice(device); err != nil {
+			return err
+		}
+	}
+
+	vm.nextBoot = device
+	if device == "" {
+		log.Printf("[MOCK] Next boot override of virtual machine '%s' cleared", name)
+	} else {
+		log.Printf("[MOCK] Virtual machine '%s' will boot from '%s' on next start", name, device)
+	}
+	m.recordVMLocked(AuditNextBoot, name)
+	return nil
+}
+
+// SetBootTimeout задает, сколько ВМ может загружаться: WaitForIP без явного ограничения
+// времени ждет не дольше этого. 0 снимает ограничение
+func (m *MockVMManager) SetBootTimeout(name string, timeout time.Duration) error {
+	return m.runHooks("set_boot_timeout", name, func() error { return m.setBootTimeout(name, timeout) })
+}
+
+// setBootTimeout выполняет SetBootTimeout без хуков операций
+func (m *MockVMManager) setBootTimeout(name string, timeout time.Duration) error {
+	if timeout < 0 {
+		return fmt.Errorf("boot timeout must not be negative")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = m.resolveNameLocked(name)
+
+	vm, exists := m.vms[name]
+	if !exists {
+		return fmt.Errorf("virtual machine '%s' not found", name)
+	}
+
+	vm.BootTimeout = timeout
+	if timeout == 0 {
+		log.Printf("[MOCK] Boot timeout of virtual machine '%s' cleared", name)
+	} else {
+		log.Printf("[MOCK] Boot timeout of virtual machine '%s' set to %s", name, timeout)
+	}
+	m.recordVMLocked(AuditBootTimeout, name)
+	return nil
+}
+
+// BootTimeout возвращает время загрузки ВМ, заданное SetBootTimeout (0 - не задано)
+func (m *MockVMManager) BootTimeout(name string) (time.Duration, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	name = m.resolveNameLocked(name)
+
+	vm, exists := m.vms[name]
+	if !exists {
+		return 0, fmt.Errorf("virtual machine '%s' not found", name)
+	}
+	return vm.BootTimeout, nil
+}
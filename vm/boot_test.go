@@ -0,0 +1,38 @@
+package vm
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNextBootOverrideClearedAfterStart(t *testing.T) {
+	m := newTestManager(t, WithAutoStartOnCreate(false))
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+	if err := m.SetNextBoot("web", BootDeviceCDROM); err != nil {
+		t.Fatalf("SetNextBoot: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := m.StartVM(ctx, "web"); err != nil {
+		t.Fatalf("StartVM: %v", err)
+	}
+	if info, _ := m.GetVMInfo("web"); info.BootDevice != BootDeviceCDROM {
+		t.Errorf("boot device of the first start = %q, want %q", info.BootDevice, BootDeviceCDROM)
+	}
+	m.mu.RLock()
+	pending := m.vms["web"].nextBoot
+	m.mu.RUnlock()
+	if pending != "" {
+		t.Errorf("next boot after the start = %q, want it cleared", pending)
+	}
+
+	if err := m.StopVM(ctx, "web"); err != nil {
+		t.Fatalf("StopVM: %v", err)
+	}
+	if err := m.StartVM(ctx, "web"); err != nil {
+		t.Fatalf("StartVM: %v", err)
+	}
+	if info, _ := m.GetVMInfo("web"); info.BootDevice != BootDeviceDisk {
+		t.Errorf("boot device of the second start = %q, want %q", info.BootDevice, BootDeviceDisk)
+	}
+}
@@ -0,0 +1,104 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// WithSimulatedStartDelay добавляет задержку запуска ВМ, имитирующую медленный бэкенд.
+// В отличие от WithSimulatedCreateDelay, менеджер на это время не блокируется: ВМ
+// помечается занятой, и конфликтующие операции (удаление, переименование, повторный
+// запуск) отклоняются с ErrVMBusy
+func WithSimulatedStartDelay(delay time.Duration) MockOption {
+	return func(m *MockVMManager) {
+		m.simulatedStartDelay = delay
+	}
+}
+
+// WithSimulatedStopDelay добавляет задержку корректного завершения работы ВМ, имитирующую
+// гостевую ОС, которая не сразу выключается. На это время ВМ, как и при
+// WithSimulatedStartDelay, помечается занятой, а менеджер не блокируется
+func WithSimulatedStopDelay(delay time.Duration) MockOption {
+	return func(m *MockVMManager) {
+		m.simulatedStopDelay = delay
+	}
+}
+
+// checkNotBusyLocked возвращает ErrVMBusy, если над ВМ выполняется длительная операция.
+// Вызывающий код должен удерживать m.mu
+func checkNotBusyLocked(vm *MockVM, name string) error {
+	if vm.busy {
+		return fmt.Errorf("virtual machine '%s' has an operation in progress: %w", name, ErrVMBusy)
+	}
+	return nil
+}
+
+// waitStartLocked имитирует длительный запуск ВМ: помечает ее занятой, отпускает
+// m.mu на время задержки и снова захватывает его. Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) waitStartLocked(ctx context.Context, name string) error {
+	vm, exists := m.vms[name]
+	if !exists {
+		return fmt.Errorf("virtual machine '%s' not found", name)
+	}
+	if vm.State == VMStateRunning {
+		return nil
+	}
+	if err := checkNotBusyLocked(vm, name); err != nil {
+		return err
+	}
+
+	vm.busy = true
+	log.Printf("[MOCK] Virtual machine '%s' is starting", name)
+	m.mu.Unlock()
+
+	timer := time.NewTimer(m.simulatedStartDelay)
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+	timer.Stop()
+
+	m.mu.Lock()
+	vm.busy = false
+	return ctx.Err()
+}
+
+// waitStopLocked имитирует корректное завершение работы ВМ: помечает ее занятой и ждет,
+// отпустив m.mu, пока ВМ не выключится, но не дольше limit. Если ВМ не успела
+// выключиться, возвращает ошибку с context.DeadlineExceeded. Вызывающий код должен
+// удерживать m.mu
+func (m *MockVMManager) waitStopLocked(ctx context.Context, name string, limit time.Duration) error {
+	vm, exists := m.vms[name]
+	if !exists {
+		return fmt.Errorf("virtual machine '%s' not found", name)
+	}
+	if vm.State != VMStateRunning || m.simulatedStopDelay == 0 {
+		return nil
+	}
+	if err := checkNotBusyLocked(vm, name); err != nil {
+		return err
+	}
+
+	vm.busy = true
+	log.Printf("[MOCK] Virtual machine '%s' is shutting down", name)
+	m.mu.Unlock()
+
+	timer := time.NewTimer(min(m.simulatedStopDelay, limit))
+	select {
+	case <-ctx.Done():
+	case <-timer.C:
+	}
+	timer.Stop()
+
+	m.mu.Lock()
+	vm.busy = false
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if m.simulatedStopDelay > limit {
+		return fmt.Errorf("virtual machine '%s' did not shut down within %s: %w", name, limit, context.DeadlineExceeded)
+	}
+	return nil
+}
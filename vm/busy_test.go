@@ -0,0 +1,70 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStartingVMCannotBeDeletedOrRenamed(t *testing.T) {
+	m := newTestManager(t, WithAutoStartOnCreate(false), WithSimulatedStartDelay(100*time.Millisecond))
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+
+	started := make(chan error, 1)
+	go func() { started <- m.StartVM(context.Background(), "web") }()
+	waitBusy(t, m, "web")
+
+	// Все конкурирующие операции отклоняются, пока ВМ запускается
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := m.DeleteVM(context.Background(), "web", DeleteVMOptions{}); !errors.Is(err, ErrVMBusy) {
+				t.Errorf("DeleteVM during start = %v, want ErrVMBusy", err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := m.RenameVM("web", "api", RenameVMOptions{}); !errors.Is(err, ErrVMBusy) {
+				t.Errorf("RenameVM during start = %v, want ErrVMBusy", err)
+			}
+		}()
+	}
+	// Чтение не блокируется запуском
+	m.Snapshot()
+	wg.Wait()
+
+	if err := <-started; err != nil {
+		t.Fatalf("StartVM: %v", err)
+	}
+	if got := stateOf(t, m, "web"); got != VMStateRunning {
+		t.Errorf("state after start = %s, want running", got)
+	}
+	if err := m.RenameVM("web", "api", RenameVMOptions{}); err != nil {
+		t.Errorf("RenameVM after start: %v", err)
+	}
+}
+
+func TestStartCancelledWhileStarting(t *testing.T) {
+	m := newTestManager(t, WithAutoStartOnCreate(false), WithSimulatedStartDelay(10*time.Second))
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	started := make(chan error, 1)
+	go func() { started <- m.StartVM(ctx, "web") }()
+	waitBusy(t, m, "web")
+	cancel()
+
+	if err := <-started; !errors.Is(err, context.Canceled) {
+		t.Fatalf("StartVM = %v, want context.Canceled", err)
+	}
+	if got := stateOf(t, m, "web"); got != VMStateStopped {
+		t.Errorf("state after cancelled start = %s, want stopped", got)
+	}
+	if err := m.DeleteVM(context.Background(), "web", DeleteVMOptions{}); err != nil {
+		t.Errorf("DeleteVM after cancelled start: %v", err)
+	}
+}
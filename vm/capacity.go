@@ -0,0 +1,165 @@
+package vm
+
+import "fmt"
+
+// Limits - ограничения ресурсов менеджера. Нулевое значение поля означает отсутствие ограничения
+type Limits struct {
+	MaxVMs        int    // квота на количество ВМ
+	MaxMemoryMB   uint64 // квота на суммарную память всех ВМ
+	MaxVCPUs      uint   // квота на суммарное количество VCPU всех ВМ
+	StoragePoolGB uint64 // емкость пула хранения для дисков ВМ
+	MaxVMMemoryMB uint64 // максимальная память одной ВМ
+	MaxVMVCPUs    uint   // максимальное количество VCPU одной ВМ
+}
+
+// Resources - объем ресурсов, занятых ВМ или запрошенных для них
+type Resources struct {
+	VMs      int
+	MemoryMB uint64
+	VCPUs    uint
+	DiskGB   uint64
+}
+
+// WithLimits задает квоты, емкость пула хранения и ограничения на одну ВМ
+func WithLimits(limits Limits) MockOption {
+	return func(m *MockVMManager) {
+		m.limits = limits
+	}
+}
+
+// configDiskGB возвращает суммарный размер всех дисков ВМ
+func configDiskGB(config VMConfig) uint64 {
+	total := config.DiskSize
+	for _, disk := range config.Disks {
+		total += disk.Size
+	}
+	return total
+}
+
+// usageLocked возвращает ресурсы, выделенные всем ВМ; вызывающий код должен удерживать m.mu
+func (m *MockVMManager) usageLocked() Resources {
+	usage := Resources{VMs: len(m.vms)}
+	for _, vm := range m.vms {
+		usage.MemoryMB += vm.Config.Memory
+		usage.VCPUs += vm.Config.VCPUs
+		usage.DiskGB += configDiskGB(vm.Config)
+	}
+	return usage
+}
+
+// CanSchedule проверяет, можно ли создать ВМ с данной конфигурацией с учетом
+// ограничений на одну ВМ, квот и емкости пула хранения
+func (m *MockVMManager) CanSchedule(config VMConfig) (bool, string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	config = m.applyDefaults(config)
+	if config.Memory == 0 {
+		return false, "", fmt.Errorf("VM memory cannot be zero")
+	}
+	if config.VCPUs == 0 {
+		return false, "", fmt.Errorf("VM VCPUs cannot be zero")
+	}
+
+	if reason := m.scheduleReasonLocked(config); reason != "" {
+		return false, reason, nil
+	}
+	return true, "", nil
+}
+
+// QuotaForecast проверяет, поместятся ли ВМ с данными конфигурациями в квоты и пул
+// хранения, если создать их все сверх текущего использования (с учетом активных
+// резервирований и снапшотов), ничего не создавая. shortfall - на сколько превышено каждое
+// измерение квот; при fits == true он нулевой. Ограничения на одну ВМ и размещение по
+// хостам проверяет CanSchedule
+func (m *MockVMManager) QuotaForecast(pending []VMConfig) (fits bool, shortfall Resources, err error) {
+	if len(pending) == 0 {
+		return false, Resources{}, fmt.Errorf("no pending VMs to forecast")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	usage := m.quotaUsageLocked()
+	usage.DiskGB = m.storageUsedGBLocked()
+	for _, config := range pending {
+		config = m.applyDefaults(config)
+		if config.Memory == 0 {
+			return false, Resources{}, fmt.Errorf("memory of pending VM '%s' cannot be zero", config.Name)
+		}
+		if config.VCPUs == 0 {
+			return false, Resources{}, fmt.Errorf("VCPUs of pending VM '%s' cannot be zero", config.Name)
+		}
+		usage.VMs++
+		usage.MemoryMB += config.Memory
+		usage.VCPUs += config.VCPUs
+		usage.DiskGB += configDiskGB(config)
+	}
+
+	l := m.limits
+	if l.MaxVMs > 0 && usage.VMs > l.MaxVMs {
+		shortfall.VMs = usage.VMs - l.MaxVMs
+	}
+	if l.MaxMemoryMB > 0 && usage.MemoryMB > l.MaxMemoryMB {
+		shortfall.MemoryMB = usage.MemoryMB - l.MaxMemoryMB
+	}
+	if l.MaxVCPUs > 0 && usage.VCPUs > l.MaxVCPUs {
+		shortfall.VCPUs = usage.VCPUs - l.MaxVCPUs
+	}
+	if l.StoragePoolGB > 0 && usage.DiskGB > l.StoragePoolGB {
+		shortfall.DiskGB = usage.DiskGB - l.StoragePoolGB
+	}
+	return shortfall == Resources{}, shortfall, nil
+}
+
+// scheduleReasonLocked возвращает причину, по которой ВМ не помещается в ограничения,
+// или пустую строку. Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) scheduleReasonLocked(config VMConfig) string {
+	l := m.limits
+
+	if reason := m.perVMLimitReasonLocked(config); reason != "" {
+		return reason
+	}
+
+	usage := m.quotaUsageExceptLocked(config.ReservationID)
+	if l.MaxVMs > 0 && usage.VMs >= l.MaxVMs {
+		return fmt.Sprintf("VM quota reached: %d of %d VMs in use", usage.VMs, l.MaxVMs)
+	}
+	if reason := m.quotaReasonLocked(usage, config.Memory, config.VCPUs); reason != "" {
+		return reason
+	}
+	if disk, used := configDiskGB(config), m.storageUsedGBLocked(); l.StoragePoolGB > 0 && used+disk > l.StoragePoolGB {
+		return fmt.Sprintf("insufficient storage: %d GB available in pool, %d GB requested", l.StoragePoolGB-min(used, l.StoragePoolGB), disk)
+	}
+	if _, reason := m.placeLocked(config); reason != "" {
+		return reason
+	}
+
+	return ""
+}
+
+// perVMLimitReasonLocked возвращает причину, по которой ВМ превышает ограничения на одну
+// ВМ, или пустую строку. Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) perVMLimitReasonLocked(config VMConfig) string {
+	l := m.limits
+	if l.MaxVMMemoryMB > 0 && config.Memory > l.MaxVMMemoryMB {
+		return fmt.Sprintf("memory exceeds per-VM maximum: %d MB allowed, %d MB requested", l.MaxVMMemoryMB, config.Memory)
+	}
+	if l.MaxVMVCPUs > 0 && config.VCPUs > l.MaxVMVCPUs {
+		return fmt.Sprintf("VCPUs exceed per-VM maximum: %d allowed, %d requested", l.MaxVMVCPUs, config.VCPUs)
+	}
+	return ""
+}
+
+// quotaReasonLocked возвращает причину, по которой memoryMB памяти и vcpus VCPU не помещаются
+// в квоты сверх уже занятых usage, или пустую строку. Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) quotaReasonLocked(usage Resources, memoryMB uint64, vcpus uint) string {
+	l := m.limits
+	if l.MaxMemoryMB > 0 && usage.MemoryMB+memoryMB > l.MaxMemoryMB {
+		return fmt.Sprintf("insufficient memory: %d available, %d requested", l.MaxMemoryMB-min(usage.MemoryMB, l.MaxMemoryMB), memoryMB)
+	}
+	if l.MaxVCPUs > 0 && usage.VCPUs+vcpus > l.MaxVCPUs {
+		return fmt.Sprintf("insufficient VCPUs: %d available, %d requested", l.MaxVCPUs-min(usage.VCPUs, l.MaxVCPUs), vcpus)
+	}
+	return ""
+}
@@ -0,0 +1,369 @@
+package vm
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// RenameVMOptions - параметры переименования ВМ
+type RenameVMOptions struct {
+	// RenameDisk также переименовывает диски, в имени файла которых есть старое имя ВМ:
+	// оно заменяется новым в путях DiskPath и Disks (и в снапшотах), а сами файлы
+	// переименовываются (см. WithFileRenamer). Общие диски (DiskSpec.Shared) не меняются
+	RenameDisk bool
+}
+
+// RenameVM переименовывает виртуальную машину
+func (m *MockVMManager) RenameVM(oldName, newName string, opts RenameVMOptions) error {
+	return m.runHooks("rename", oldName, func() error { return m.renameVM(oldName, newName, opts) })
+}
+
+// renameVM выполняет RenameVM без хуков операций
+func (m *MockVMManager) renameVM(oldName, newName string, opts RenameVMOptions) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	oldName = m.resolveNameLocked(oldName)
+
+	if err := m.renameVMLocked(oldName, newName, opts); err != nil {
+		return err
+	}
+	m.recordLocked(AuditEntry{Operation: AuditRename, VMName: oldName, NewName: newName, RenameDisk: opts.RenameDisk})
+	return nil
+}
+
+// renameVMLocked выполняет переименование; вызывающий код должен удерживать m.mu
+func (m *MockVMManager) renameVMLocked(oldName, newName string, opts RenameVMOptions) error {
+	vm, exists := m.vms[oldName]
+	if !exists {
+		return fmt.Errorf("virtual machine '%s' not found", oldName)
+	}
+	if err := checkNotBusyLocked(vm, oldName); err != nil {
+		return err
+	}
+	if err := checkUnlockedLocked(vm, oldName, "rename"); err != nil {
+		return err
+	}
+	if err := m.checkNameFreeLocked(newName, oldName); err != nil {
+		return err
+	}
+	if err := m.validateName(newName); err != nil {
+		return err
+	}
+
+	if opts.RenameDisk {
+		if clones := m.linkedClonesLocked(oldName); len(clones) > 0 {
+			return fmt.Errorf("cannot rename disks of virtual machine '%s': linked clones depend on them: %s", oldName, strings.Join(clones, ", "))
+		}
+		renames := renamedDiskPaths(vm.Config, oldName, newName)
+		if err := m.renameDisksLocked(oldName, renames); err != nil {
+			return err
+		}
+		vm.Config = withRenamedPaths(vm.Config, renames)
+		for _, r := range renames {
+			if used, compacted := vm.compactedGB[r.From]; compacted {
+				delete(vm.compactedGB, r.From)
+				vm.compactedGB[r.To] = used
+			}
+		}
+		for i := range vm.Snapshots {
+			vm.Snapshots[i].Config = withRenamedPaths(vm.Snapshots[i].Config, renames)
+		}
+	}
+
+	vm.Config.Name = newName
+	delete(m.vms, oldName)
+	m.vms[newName] = vm
+	for _, other := range m.vms {
+		if other.LinkedSource == oldName {
+			other.LinkedSource = newName
+		}
+		renameReferences(&other.Config, oldName, newName)
+		for i := range other.Snapshots {
+			renameReferences(&other.Snapshots[i].Config, oldName, newName)
+		}
+	}
+	for _, path := range diskPaths(vm.Config) {
+		m.disks[path] = newName
+	}
+
+	log.Printf("[MOCK] Virtual machine '%s' renamed to '%s'", oldName, newName)
+	return nil
+}
+
+// renameReferences заменяет oldName на newName в DependsOn, Affinity и AntiAffinity
+// конфигурации, чтобы ссылки других ВМ следовали за переименованной ВМ
+func renameReferences(config *VMConfig, oldName, newName string) {
+	for _, names := range [][]string{config.DependsOn, config.Affinity, config.AntiAffinity} {
+		for i, name := range names {
+			if name == oldName {
+				names[i] = newName
+			}
+		}
+	}
+}
+
+// SwapVMNames атомарно меняет местами имена двух ВМ, например при переключении
+// blue/green: в отличие от двух переименований через временное имя, ни в какой момент
+// не существует ВМ со временным именем или двух ВМ с одним именем
+func (m *MockVMManager) SwapVMNames(a, b string) error {
+	return m.runHooks("swap_names", a, func() error { return m.swapVMNames(a, b) })
+}
+
+// swapVMNames выполняет SwapVMNames без хуков операций
+func (m *MockVMManager) swapVMNames(a, b string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	a = m.resolveNameLocked(a)
+	b = m.resolveNameLocked(b)
+
+	if err := m.swapVMNamesLocked(a, b); err != nil {
+		return err
+	}
+	m.recordLocked(AuditEntry{Operation: AuditSwap, VMName: a, NewName: b})
+	return nil
+}
+
+// swapVMNamesLocked выполняет обмен именами; вызывающий код должен удерживать m.mu
+func (m *MockVMManager) swapVMNamesLocked(a, b string) error {
+	if a == b {
+		return fmt.Errorf("cannot swap virtual machine '%s' with itself", a)
+	}
+	vmA, exists := m.vms[a]
+	if !exists {
+		return fmt.Errorf("virtual machine '%s' not found", a)
+	}
+	vmB, exists := m.vms[b]
+	if !exists {
+		return fmt.Errorf("virtual machine '%s' not found", b)
+	}
+	if err := checkNotBusyLocked(vmA, a); err != nil {
+		return err
+	}
+	if err := checkNotBusyLocked(vmB, b); err != nil {
+		return err
+	}
+	if err := checkUnlockedLocked(vmA, a, "swap the name of"); err != nil {
+		return err
+	}
+	if err := checkUnlockedLocked(vmB, b, "swap the name of"); err != nil {
+		return err
+	}
+
+	vmA.Config.Name, vmB.Config.Name = b, a
+	m.vms[a], m.vms[b] = vmB, vmA
+	for _, clone := range m.vms {
+		switch clone.LinkedSource {
+		case a:
+			clone.LinkedSource = b
+		case b:
+			clone.LinkedSource = a
+		}
+	}
+	for path, owner := range m.disks {
+		switch owner {
+		case a:
+			m.disks[path] = b
+		case b:
+			m.disks[path] = a
+		}
+	}
+
+	log.Printf("[MOCK] Virtual machines '%s' and '%s' swapped names", a, b)
+	return nil
+}
+
+// CloneVM создает остановленную копию виртуальной машины с новым именем.
+// Диски клона располагаются рядом с дисками источника и называются по имени клона.
+// Связанный клон (linked) не копирует диски, а ссылается на диски источника как на
+// backing-файлы; пока такие клоны существуют, источник нельзя удалить
+func (m *MockVMManager) CloneVM(source, target string, linked bool) error {
+	return m.runHooks("clone", source, func() error { return m.cloneVM(source, target, linked) })
+}
+
+// cloneVM выполняет CloneVM без хуков операций
+func (m *MockVMManager) cloneVM(source, target string, linked bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	source = m.resolveNameLocked(source)
+
+	if err := m.cloneVMLocked(source, target, nil); err != nil {
+		return err
+	}
+	if linked {
+		m.vms[target].LinkedSource = source
+		log.Printf("[MOCK] Virtual machine '%s' is a linked clone of '%s'", target, source)
+	}
+	return nil
+}
+
+// CloneVMWithOverrides клонирует ВМ так же, как CloneVM без linked, но заменяет в
+// конфигурации клона ненулевые поля overrides: Memory, VCPUs, Network, ISOImage и Labels
+// (метки заменяются целиком). Остальные поля overrides, в том числе имя и диски,
+// игнорируются: диски клона всегда копируются с дисков источника. Итоговая конфигурация
+// проверяется так же, как при создании ВМ: превышение MaxVMMemoryMB или MaxVMVCPUs
+// возвращает ErrInvalidConfig, квоты и пул хранения - ту же ошибку, что CreateVM
+func (m *MockVMManager) CloneVMWithOverrides(source, target string, overrides VMConfig) error {
+	return m.runHooks("clone", source, func() error { return m.cloneVMWithOverrides(source, target, overrides) })
+}
+
+// cloneVMWithOverrides выполняет CloneVMWithOverrides без хуков операций
+func (m *MockVMManager) cloneVMWithOverrides(source, target string, overrides VMConfig) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	source = m.resolveNameLocked(source)
+
+	return m.cloneVMLocked(source, target, &overrides)
+}
+
+// applyCloneOverrides заменяет поля конфигурации клона ненулевыми полями overrides
+func applyCloneOverrides(config, overrides VMConfig) VMConfig {
+	if overrides.Memory != 0 {
+		config.Memory = overrides.Memory
+	}
+	if overrides.VCPUs != 0 {
+		config.VCPUs = overrides.VCPUs
+	}
+	if overrides.Network != "" {
+		config.Network = overrides.Network
+	}
+	if overrides.ISOImage != "" {
+		config.ISOImage = overrides.ISOImage
+	}
+	if overrides.Labels != nil {
+		config.Labels = maps.Clone(overrides.Labels)
+	}
+	return config
+}
+
+// linkedClonesLocked возвращает отсортированные имена связанных клонов ВМ.
+// Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) linkedClonesLocked(name string) []string {
+	var clones []string
+	for cloneName, vm := range m.vms {
+		if vm.LinkedSource == name {
+			clones = append(clones, cloneName)
+		}
+	}
+	sort.Strings(clones)
+	return clones
+}
+
+// CloneVMFull клонирует ВМ и, если includeSnapshots, копирует ее снапшоты.
+// Имена снапшотов сохраняются, а их конфигурация перенаправляется на клон
+func (m *MockVMManager) CloneVMFull(source, target string, includeSnapshots bool) error {
+	return m.runHooks("clone", source, func() error { return m.cloneVMFull(source, target, includeSnapshots) })
+}
+
+// cloneVMFull выполняет CloneVMFull без хуков операций
+func (m *MockVMManager) cloneVMFull(source, target string, includeSnapshots bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	source = m.resolveNameLocked(source)
+
+	if err := m.cloneVMLocked(source, target, nil); err != nil {
+		return err
+	}
+	if !includeSnapshots {
+		return nil
+	}
+
+	clone := m.vms[target]
+	for _, snap := range m.vms[source].Snapshots {
+		snap.Config = cloneConfig(snap.Config, target)
+		clone.Snapshots = append(clone.Snapshots, snap)
+	}
+	log.Printf("[MOCK] Copied %d snapshot(s) from '%s' to '%s'", len(clone.Snapshots), source, target)
+	return nil
+}
+
+// ValidateClone проверяет клонирование source в target так же, как CloneVM и
+// CloneVMWithOverrides (при непустом overrides), но ничего не изменяет
+func (m *MockVMManager) ValidateClone(source, target string, overrides *VMConfig) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, _, err := m.checkCloneLocked(m.resolveNameLocked(source), target, overrides)
+	return err
+}
+
+// checkCloneLocked выполняет проверки клонирования и возвращает конфигурацию клона и
+// выбранный для него хост. Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) checkCloneLocked(source, target string, overrides *VMConfig) (VMConfig, string, error) {
+	src, exists := m.vms[source]
+	if !exists {
+		return VMConfig{}, "", fmt.Errorf("virtual machine '%s' not found", source)
+	}
+	if err := m.checkNameFreeLocked(target, ""); err != nil {
+		return VMConfig{}, "", err
+	}
+	if err := m.validateName(target); err != nil {
+		return VMConfig{}, "", err
+	}
+
+	config := cloneConfig(src.Config, target)
+	if overrides != nil {
+		config = applyCloneOverrides(config, *overrides)
+		if err := m.validateConfig(config); err != nil {
+			return VMConfig{}, "", withCategory(err, ErrInvalidConfig)
+		}
+		if reason := m.perVMLimitReasonLocked(config); reason != "" {
+			return VMConfig{}, "", withCategory(fmt.Errorf("cannot apply overrides to clone '%s': %s", target, reason), ErrInvalidConfig)
+		}
+	}
+	if err := m.checkDiskConflictsLocked(target, diskSpecs(config)); err != nil {
+		return VMConfig{}, "", err
+	}
+	// Клон занимает ресурсы так же, как новая ВМ, поэтому проверяется теми же квотами
+	// и ограничениями, что и CreateVM
+	if reason := m.scheduleReasonLocked(config); reason != "" {
+		return VMConfig{}, "", fmt.Errorf("cannot schedule clone '%s': %s", target, reason)
+	}
+	host, _ := m.placeLocked(config)
+	return config, host, nil
+}
+
+// cloneVMLocked выполняет клонирование, при непустом overrides заменяя поля конфигурации
+// клона (см. CloneVMWithOverrides); вызывающий код должен удерживать m.mu
+func (m *MockVMManager) cloneVMLocked(source, target string, overrides *VMConfig) error {
+	config, host, err := m.checkCloneLocked(source, target, overrides)
+	if err != nil {
+		return err
+	}
+
+	m.vms[target] = &MockVM{
+		Config: config,
+		State:  VMStateStopped,
+		Host:   host,
+	}
+	for _, path := range diskPaths(config) {
+		m.disks[path] = target
+	}
+
+	log.Printf("[MOCK] Virtual machine '%s' cloned to '%s'", source, target)
+	m.recordLocked(AuditEntry{Operation: AuditClone, VMName: target})
+	return nil
+}
+
+// cloneConfig копирует конфигурацию ВМ под новым именем, перенаправляя диски на копии
+func cloneConfig(src VMConfig, target string) VMConfig {
+	config := copyConfig(src)
+	config.Name = target
+	if config.DiskPath != "" {
+		config.DiskPath = cloneDiskPath(config.DiskPath, target)
+	}
+	for i := range config.Disks {
+		if config.Disks[i].Shared {
+			continue // общий диск подключается к клону без копирования
+		}
+		config.Disks[i].Path = cloneDiskPath(config.Disks[i].Path, fmt.Sprintf("%s-disk%d", target, i+1))
+	}
+	return config
+}
+
+// cloneDiskPath возвращает путь к копии диска в том же каталоге с новым базовым именем
+func cloneDiskPath(path, base string) string {
+	return filepath.Join(filepath.Dir(path), base+filepath.Ext(path))
+}
@@ -0,0 +1,106 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCloneRespectsQuotas(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		limits Limits
+		want   string
+	}{
+		{"vm count", Limits{MaxVMs: 1}, "VM quota reached"},
+		{"memory", Limits{MaxMemoryMB: 3072}, "insufficient memory"},
+		{"vcpus", Limits{MaxVCPUs: 3}, "insufficient VCPUs"},
+		{"storage pool", Limits{StoragePoolGB: 30}, "insufficient storage"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := newTestManager(t, WithLimits(tc.limits))
+			mustCreate(t, m, VMConfig{Name: "web", Memory: 2048, VCPUs: 2, DiskPath: "/tmp/clone-web.qcow2", DiskSize: 20})
+
+			for _, clone := range []func() error{
+				func() error { return m.CloneVM("web", "web-copy", false) },
+				func() error { return m.CloneVM("web", "web-copy", true) },
+				func() error { return m.CloneVMFull("web", "web-copy", true) },
+				func() error { return m.CloneVMWithOverrides("web", "web-copy", VMConfig{Network: "lan"}) },
+			} {
+				if err := clone(); err == nil || !strings.Contains(err.Error(), tc.want) {
+					t.Errorf("clone = %v, want an error containing %q", err, tc.want)
+				}
+			}
+			if n := len(m.Snapshot()); n != 1 {
+				t.Errorf("%d VMs after rejected clones, want 1", n)
+			}
+		})
+	}
+}
+
+func TestCloneRespectsReservations(t *testing.T) {
+	m := newTestManager(t, WithLimits(Limits{MaxMemoryMB: 4096}))
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 2048, VCPUs: 1})
+	id, err := m.Reserve(1024, 0)
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+
+	if err := m.CloneVM("web", "web-copy", false); err == nil {
+		t.Fatal("CloneVM succeeded into memory held by a reservation")
+	}
+	if err := m.ReleaseReservation(id); err != nil {
+		t.Fatalf("ReleaseReservation: %v", err)
+	}
+	if err := m.CloneVM("web", "web-copy", false); err != nil {
+		t.Fatalf("CloneVM after release: %v", err)
+	}
+}
+
+func TestCloneOverridesRespectPerVMLimits(t *testing.T) {
+	m := newTestManager(t, WithLimits(Limits{MaxVMMemoryMB: 4096, MaxVMVCPUs: 2}))
+	tools := newTestTools(t, m)
+	mustCreate(t, m, VMConfig{Name: "vm1", Memory: 2048, VCPUs: 2})
+
+	for _, overrides := range []VMConfig{{Memory: 65536}, {VCPUs: 8}} {
+		err := m.CloneVMWithOverrides("vm1", "vm1-big", overrides)
+		if !errors.Is(err, ErrInvalidConfig) || !strings.Contains(err.Error(), "per-VM maximum") {
+			t.Errorf("CloneVMWithOverrides(%+v) = %v, want a per-VM limit error", overrides, err)
+		}
+	}
+	if err := m.CloneVMWithOverrides("vm1", "vm1-big", VMConfig{Memory: 4096}); err != nil {
+		t.Fatalf("CloneVMWithOverrides within limits: %v", err)
+	}
+
+	resp := callTool(t, tools, "clone_vm", map[string]any{"source": "vm1", "target": "vm1-huge", "memory": "64GB", "dry_run": true})
+	if msg, _ := resp["error"].(string); resp["success"] != false || !strings.Contains(msg, "per-VM maximum") {
+		t.Errorf("clone_vm dry run with 64GB = %v, want a per-VM limit failure", resp)
+	}
+}
+
+func TestDeleteBaseOfLinkedCloneFails(t *testing.T) {
+	m := newTestManager(t, WithAutoStartOnCreate(false))
+	mustCreate(t, m, VMConfig{Name: "base", Memory: 1024, VCPUs: 1, DiskPath: "/vms/base.qcow2"})
+	if err := m.CloneVM("base", "clone", true); err != nil {
+		t.Fatalf("CloneVM: %v", err)
+	}
+	ctx := context.Background()
+
+	for _, opts := range []DeleteVMOptions{{}, {Force: true}} {
+		err := m.DeleteVM(ctx, "base", opts)
+		if err == nil || !strings.Contains(err.Error(), "linked clones depend on it: clone") {
+			t.Errorf("DeleteVM(base, %+v) = %v, want a linked clone error", opts, err)
+		}
+	}
+	if _, err := m.LookupVM("base"); err != nil {
+		t.Fatalf("base VM was deleted while a linked clone uses it: %v", err)
+	}
+
+	if err := m.DeleteVM(ctx, "clone", DeleteVMOptions{}); err != nil {
+		t.Fatalf("DeleteVM(clone): %v", err)
+	}
+	if err := m.DeleteVM(ctx, "base", DeleteVMOptions{}); err != nil {
+		t.Errorf("DeleteVM(base) after deleting the clone: %v", err)
+	}
+}
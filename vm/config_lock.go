@@ -0,0 +1,50 @@
+package vm
+
+import (
+	"fmt"
+	"log"
+)
+
+// LockVMConfig закрепляет конфигурацию ВМ: пока ВМ заблокирована, изменение конфигурации
+// (UpdateVMConfig, диски, ISO-образ, привязка CPU, ограничения IOPS и трафика,
+// восстановление снапшота), переименование и удаление завершаются ошибкой ErrVMLocked.
+// Чтение, запуск, остановка, метки и снапшоты по-прежнему доступны
+func (m *MockVMManager) LockVMConfig(name string) error {
+	return m.runHooks("lock_config", name, func() error { return m.setConfigLocked(name, true) })
+}
+
+// UnlockVMConfig снимает блокировку LockVMConfig
+func (m *MockVMManager) UnlockVMConfig(name string) error {
+	return m.runHooks("unlock_config", name, func() error { return m.setConfigLocked(name, false) })
+}
+
+// setConfigLocked устанавливает или снимает блокировку конфигурации ВМ
+func (m *MockVMManager) setConfigLocked(name string, locked bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = m.resolveNameLocked(name)
+
+	vm, exists := m.vms[name]
+	if !exists {
+		return fmt.Errorf("virtual machine '%s' not found", name)
+	}
+
+	vm.Locked = locked
+	if locked {
+		log.Printf("[MOCK] Configuration of virtual machine '%s' locked", name)
+		m.recordVMLocked(AuditLock, name)
+	} else {
+		log.Printf("[MOCK] Configuration of virtual machine '%s' unlocked", name)
+		m.recordVMLocked(AuditUnlock, name)
+	}
+	return nil
+}
+
+// checkUnlockedLocked возвращает ErrVMLocked, если конфигурация ВМ заблокирована
+// LockVMConfig; action описывает операцию для сообщения об ошибке
+func checkUnlockedLocked(vm *MockVM, name, action string) error {
+	if vm.Locked {
+		return fmt.Errorf("cannot %s virtual machine '%s': its configuration is locked, unlock it first: %w", action, name, ErrVMLocked)
+	}
+	return nil
+}
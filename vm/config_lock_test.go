@@ -0,0 +1,114 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestLockedVMRejectsConfigChanges(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t, WithAutoStartOnCreate(false))
+	mustCreate(t, m, VMConfig{
+		Name:    "db",
+		Memory:  1024,
+		VCPUs:   1,
+		Network: "default",
+		Disks:   []DiskSpec{{Path: "/tmp/locked-extra.qcow2", Size: 1}},
+	})
+	mustCreate(t, m, VMConfig{Name: "other", Memory: 1024, VCPUs: 1})
+	if err := m.CreateSnapshot("db", "s1", ""); err != nil {
+		t.Fatalf("CreateSnapshot: %v", err)
+	}
+	if err := m.LockVMConfig("db"); err != nil {
+		t.Fatalf("LockVMConfig: %v", err)
+	}
+
+	for name, op := range map[string]func() error{
+		"UpdateVMConfig":         func() error { return m.UpdateVMConfig("db", VMConfig{Name: "db", Memory: 2048, VCPUs: 2}) },
+		"DeleteVM":               func() error { return m.DeleteVM(ctx, "db", DeleteVMOptions{Force: true}) },
+		"DeleteVMGraceful":       func() error { return m.DeleteVMGraceful(ctx, "db", 0) },
+		"RenameVM":               func() error { return m.RenameVM("db", "db2", RenameVMOptions{}) },
+		"SwapVMNames":            func() error { return m.SwapVMNames("db", "other") },
+		"SwapVMNames (reversed)": func() error { return m.SwapVMNames("other", "db") },
+		"AttachDisk":             func() error { return m.AttachDisk("db", DiskSpec{Path: "/tmp/locked-data.qcow2", Size: 1}) },
+		"AttachISO":              func() error { return m.AttachISO("db", "/tmp/locked.iso") },
+		"SetCPUPinning":          func() error { return m.SetCPUPinning("db", map[uint]uint{0: 1}) },
+		"RestoreSnapshot":        func() error { return m.RestoreSnapshot("db", "s1") },
+		"RevertToLatestSnapshot": func() error { _, err := m.RevertToLatestSnapshot("db"); return err },
+		"DetachDisk":             func() error { return m.DetachDisk("db", "/tmp/locked-extra.qcow2") },
+		"ResizeDisk":             func() error { return m.ResizeDisk("db", "/tmp/locked-extra.qcow2", 2) },
+		"SetDiskIOPS":            func() error { return m.SetDiskIOPS("db", "/tmp/locked-extra.qcow2", 100, 100) },
+		"SetNetworkBandwidth":    func() error { return m.SetNetworkBandwidth("db", 1000, 1000) },
+	} {
+		if err := op(); !errors.Is(err, ErrVMLocked) {
+			t.Errorf("%s on a locked VM: %v, want ErrVMLocked", name, err)
+		}
+	}
+
+	names, _ := m.ListVMs()
+	if len(names) != 2 {
+		t.Errorf("VMs after rejected operations: %v, want db and other", names)
+	}
+	for _, info := range m.Snapshot() {
+		if info.Config.Name == "db" && (!info.Locked || info.Config.Memory != 1024) {
+			t.Errorf("locked VM changed: %+v", info)
+		}
+	}
+}
+
+func TestLockedVMCanStartAndStop(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t, WithAutoStartOnCreate(false))
+	mustCreate(t, m, VMConfig{Name: "db", Memory: 1024, VCPUs: 1})
+	if err := m.LockVMConfig("db"); err != nil {
+		t.Fatalf("LockVMConfig: %v", err)
+	}
+
+	if err := m.StartVM(ctx, "db"); err != nil {
+		t.Fatalf("StartVM on a locked VM: %v", err)
+	}
+	if err := m.StopVM(ctx, "db"); err != nil {
+		t.Fatalf("StopVM on a locked VM: %v", err)
+	}
+	if err := m.CreateSnapshot("db", "s1", ""); err != nil {
+		t.Errorf("CreateSnapshot on a locked VM: %v", err)
+	}
+
+	if err := m.UnlockVMConfig("db"); err != nil {
+		t.Fatalf("UnlockVMConfig: %v", err)
+	}
+	if err := m.DeleteVM(ctx, "db", DeleteVMOptions{Force: true}); err != nil {
+		t.Errorf("DeleteVM after unlock: %v", err)
+	}
+}
+
+func TestLockToolsUnknownVM(t *testing.T) {
+	m := newTestManager(t)
+	if err := m.LockVMConfig("missing"); err == nil {
+		t.Error("LockVMConfig of a missing VM succeeded")
+	}
+	tools := newTestTools(t, m)
+	if resp := callTool(t, tools, "lock_vm", map[string]any{"name": ""}); resp["success"] == true {
+		t.Errorf("lock_vm without a name succeeded: %v", resp)
+	}
+}
+
+func TestLockTools(t *testing.T) {
+	m := newTestManager(t, WithAutoStartOnCreate(false))
+	mustCreate(t, m, VMConfig{Name: "db", Memory: 1024, VCPUs: 1})
+	tools := newTestTools(t, m)
+
+	if resp := callTool(t, tools, "lock_vm", map[string]any{"name": "db"}); resp["success"] != true {
+		t.Fatalf("lock_vm = %v", resp)
+	}
+	if resp := callTool(t, tools, "delete_vm", map[string]any{"name": "db"}); resp["success"] != false {
+		t.Errorf("delete_vm of a locked VM = %v, want a failure", resp)
+	}
+	if resp := callTool(t, tools, "unlock_vm", map[string]any{"name": "db"}); resp["success"] != true {
+		t.Fatalf("unlock_vm = %v", resp)
+	}
+	if resp := callTool(t, tools, "delete_vm", map[string]any{"name": "db"}); resp["success"] != true {
+		t.Errorf("delete_vm after unlock_vm = %v", resp)
+	}
+}
@@ -0,0 +1,44 @@
+package vm
+
+import (
+	"fmt"
+	"math"
+)
+
+// hoursPerMonth - среднее количество часов в месяце, используемое при оценке стоимости
+const hoursPerMonth = 730
+
+// CostModel - тарифы для оценки месячной стоимости ВМ
+type CostModel struct {
+	PerVCPUHour     float64
+	PerGBMemoryHour float64
+	PerGBDiskMonth  float64
+	// IncludeStoppedCompute учитывает CPU и память остановленных ВМ так же, как запущенных.
+	// Диски оплачиваются для всех ВМ независимо от состояния
+	IncludeStoppedCompute bool
+}
+
+// EstimateCost оценивает месячную стоимость каждой ВМ и общую стоимость по их конфигурациям.
+// CPU и память считаются за месяц непрерывной работы, диски - по суммарному размеру
+func (m *MockVMManager) EstimateCost(pricing CostModel) (map[string]float64, float64, error) {
+	if pricing.PerVCPUHour < 0 || pricing.PerGBMemoryHour < 0 || pricing.PerGBDiskMonth < 0 {
+		return nil, 0, fmt.Errorf("prices cannot be negative")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	costs := make(map[string]float64, len(m.vms))
+	var total float64
+	for name, vm := range m.vms {
+		cost := float64(configDiskGB(vm.Config)) * pricing.PerGBDiskMonth
+		if vm.State == VMStateRunning || pricing.IncludeStoppedCompute {
+			memoryGB := float64(vm.Config.Memory) / 1024
+			cost += (float64(vm.Config.VCPUs)*pricing.PerVCPUHour + memoryGB*pricing.PerGBMemoryHour) * hoursPerMonth
+		}
+		cost = math.Round(cost*100) / 100
+		costs[name] = cost
+		total += cost
+	}
+	return costs, math.Round(total*100) / 100, nil
+}
@@ -0,0 +1,85 @@
+package vm
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// validateCPUPinning проверяет, что привязка ссылается только на существующие vCPU
+func validateCPUPinning(pinning map[uint]uint, vcpus uint) error {
+	for _, vcpu := range slices.Sorted(maps.Keys(pinning)) {
+		if vcpu >= vcpus {
+			return fmt.Errorf("CPU pinning refers to vCPU %d, but the VM has %d vCPU(s)", vcpu, vcpus)
+		}
+	}
+	return nil
+}
+
+// formatCPUPinning возвращает привязку в виде "vCPU->CPU, ..." по возрастанию vCPU
+func formatCPUPinning(pinning map[uint]uint) string {
+	parts := make([]string, 0, len(pinning))
+	for _, vcpu := range slices.Sorted(maps.Keys(pinning)) {
+		parts = append(parts, fmt.Sprintf("%d->%d", vcpu, pinning[vcpu]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// SetCPUPinning заменяет привязку vCPU к физическим CPU остановленной ВМ;
+// пустая привязка снимает ее. Индексы vCPU должны быть меньше VCPUs
+func (m *MockVMManager) SetCPUPinning(name string, pinning map[uint]uint) error {
+	return m.runHooks("set_cpu_pinning", name, func() error { return m.setCPUPinning(name, pinning) })
+}
+
+// setCPUPinning выполняет SetCPUPinning без хуков операций
+func (m *MockVMManager) setCPUPinning(name string, pinning map[uint]uint) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = m.resolveNameLocked(name)
+
+	vm, err := m.checkCPUPinningLocked(name, pinning)
+	if err != nil {
+		return err
+	}
+
+	vm.Config.CPUPinning = maps.Clone(pinning)
+	if len(vm.Config.CPUPinning) == 0 {
+		vm.Config.CPUPinning = nil
+	}
+	log.Printf("[MOCK] CPU pinning of virtual machine '%s' set to [%s]", name, formatCPUPinning(vm.Config.CPUPinning))
+	m.recordVMLocked(AuditCPUPinning, name)
+	return nil
+}
+
+// ValidateCPUPinning выполняет проверки SetCPUPinning, ничего не меняя
+func (m *MockVMManager) ValidateCPUPinning(name string, pinning map[uint]uint) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, err := m.checkCPUPinningLocked(m.resolveNameLocked(name), pinning)
+	return err
+}
+
+// checkCPUPinningLocked проверяет, что привязку ВМ можно заменить на pinning, и
+// возвращает ВМ. Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) checkCPUPinningLocked(name string, pinning map[uint]uint) (*MockVM, error) {
+	vm, exists := m.vms[name]
+	if !exists {
+		return nil, fmt.Errorf("virtual machine '%s' not found", name)
+	}
+	if err := checkNotBusyLocked(vm, name); err != nil {
+		return nil, err
+	}
+	if err := checkUnlockedLocked(vm, name, "change CPU pinning of"); err != nil {
+		return nil, err
+	}
+	if vm.State != VMStateStopped {
+		return nil, fmt.Errorf("virtual machine '%s' must be stopped to change CPU pinning (current state: %s)", name, vm.State)
+	}
+	if err := validateCPUPinning(pinning, vm.Config.VCPUs); err != nil {
+		return nil, err
+	}
+	return vm, nil
+}
@@ -0,0 +1,108 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCreateVMRollsBackFailedStep(t *testing.T) {
+	stepErr := errors.New("hypervisor refused to start")
+	m := newTestManager(t, WithCreateStepHook(func(step CreateStep, name string) error {
+		if step == CreateStepStart && name == "web" {
+			return stepErr
+		}
+		return nil
+	}))
+
+	err := m.CreateVM(context.Background(), VMConfig{Name: "web", Memory: 1024, VCPUs: 1, DiskPath: "/tmp/rollback-web.qcow2"})
+	if !errors.Is(err, stepErr) {
+		t.Fatalf("CreateVM = %v, want the step error", err)
+	}
+	if _, err := m.LookupVM("web"); err == nil {
+		t.Error("VM exists after a rolled back create")
+	}
+	if len(m.AuditLog()) != 0 {
+		t.Errorf("audit log after a rolled back create = %v, want it empty", m.AuditLog())
+	}
+	// Диск освобожден: его может занять другая ВМ
+	mustCreate(t, m, VMConfig{Name: "db", Memory: 1024, VCPUs: 1, DiskPath: "/tmp/rollback-web.qcow2"})
+}
+
+func TestCreateVMTimeoutRollsBack(t *testing.T) {
+	m := newTestManager(t, WithSimulatedCreateDelay(10*time.Second), WithCreateTimeout(20*time.Millisecond))
+
+	start := time.Now()
+	err := m.CreateVM(context.Background(), VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("CreateVM = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("CreateVM took %s, want the create timeout to cut it", elapsed)
+	}
+	if _, err := m.LookupVM("web"); err == nil {
+		t.Error("VM exists after a timed out create")
+	}
+}
+
+func TestCreateVMCancelledDuringDelay(t *testing.T) {
+	m := newTestManager(t, WithSimulatedCreateDelay(10*time.Second))
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	err := m.CreateVM(ctx, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("CreateVM = %v, want context.Canceled", err)
+	}
+	if _, err := m.LookupVM("web"); err == nil {
+		t.Error("VM exists after a cancelled create")
+	}
+}
+
+func TestCreateDelayDoesNotBlockManager(t *testing.T) {
+	m := newTestManager(t, WithAutoStartOnCreate(false), WithSimulatedCreateDelay(10*time.Second))
+	mustCreate(t, m, VMConfig{Name: "db", Memory: 1024, VCPUs: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	start := true
+	created := make(chan error, 1)
+	go func() {
+		created <- m.CreateVM(ctx, VMConfig{Name: "web", Memory: 1024, VCPUs: 1, StartOnCreate: &start})
+	}()
+	waitBusy(t, m, "web")
+
+	within(t, time.Second, func() {
+		if err := m.StartVM(context.Background(), "db"); err != nil {
+			t.Errorf("StartVM of another VM during a slow create: %v", err)
+		}
+	})
+	if err := m.DeleteVM(context.Background(), "web", DeleteVMOptions{}); !errors.Is(err, ErrVMBusy) {
+		t.Errorf("DeleteVM of a VM being created = %v, want ErrVMBusy", err)
+	}
+	cancel()
+
+	if err := <-created; !errors.Is(err, context.Canceled) {
+		t.Fatalf("CreateVM = %v, want context.Canceled", err)
+	}
+	if _, err := m.LookupVM("web"); err == nil {
+		t.Error("VM exists after a cancelled create")
+	}
+}
+
+func TestLifecycleMethodsRejectCancelledContext(t *testing.T) {
+	m := newTestManager(t)
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := m.StopVM(ctx, "web"); !errors.Is(err, context.Canceled) {
+		t.Errorf("StopVM = %v, want context.Canceled", err)
+	}
+	if err := m.DeleteVM(ctx, "web", DeleteVMOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("DeleteVM = %v, want context.Canceled", err)
+	}
+	if got := stateOf(t, m, "web"); got != VMStateRunning {
+		t.Errorf("state after cancelled calls = %s, want running", got)
+	}
+}
@@ -0,0 +1,40 @@
+package vm
+
+// WithDefaults задает конфигурацию по умолчанию: нулевые поля конфигурации,
+// переданной в CreateVM, заполняются из defaults до валидации.
+// Имя ВМ и списки (диски, зависимости) не заполняются
+func WithDefaults(defaults VMConfig) MockOption {
+	return func(m *MockVMManager) {
+		m.defaults = defaults
+	}
+}
+
+// applyDefaults заполняет нулевые поля конфигурации значениями по умолчанию
+func (m *MockVMManager) applyDefaults(config VMConfig) VMConfig {
+	d := m.defaults
+	if config.Memory == 0 {
+		config.Memory = d.Memory
+	}
+	if config.VCPUs == 0 {
+		config.VCPUs = d.VCPUs
+	}
+	if config.DiskPath == "" {
+		config.DiskPath = d.DiskPath
+	}
+	if config.DiskSize == 0 {
+		config.DiskSize = d.DiskSize
+	}
+	if config.ISOImage == "" {
+		config.ISOImage = d.ISOImage
+	}
+	if config.Network == "" {
+		config.Network = d.Network
+	}
+	if config.Firmware == "" {
+		config.Firmware = d.Firmware
+	}
+	if config.OSType == "" && config.OSVariant == "" {
+		config.OSType, config.OSVariant = d.OSType, d.OSVariant
+	}
+	return config
+}
@@ -0,0 +1,68 @@
+package vm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApplyDefaultsFillsOnlyZeroFields(t *testing.T) {
+	defaults := VMConfig{
+		Name:      "template",
+		Memory:    2048,
+		VCPUs:     2,
+		DiskPath:  "/vms/default.qcow2",
+		DiskSize:  20,
+		ISOImage:  "/isos/default.iso",
+		Network:   "default",
+		Firmware:  "bios",
+		OSType:    "linux",
+		OSVariant: "ubuntu22.04",
+		Disks:     []DiskSpec{{Path: "/vms/default-data.qcow2"}},
+		DependsOn: []string{"db"},
+	}
+	m := newTestManager(t, WithDefaults(defaults))
+
+	tests := []struct {
+		name   string
+		config VMConfig
+		want   VMConfig
+	}{
+		{
+			"empty config takes every default except name and lists",
+			VMConfig{Name: "web"},
+			VMConfig{
+				Name: "web", Memory: 2048, VCPUs: 2, DiskPath: "/vms/default.qcow2", DiskSize: 20,
+				ISOImage: "/isos/default.iso", Network: "default", Firmware: "bios",
+				OSType: "linux", OSVariant: "ubuntu22.04",
+			},
+		},
+		{
+			"set fields are kept",
+			VMConfig{
+				Name: "web", Memory: 4096, VCPUs: 4, DiskPath: "/vms/web.qcow2", DiskSize: 40,
+				ISOImage: "/isos/web.iso", Network: "lan", Firmware: "uefi",
+				OSType: "windows", OSVariant: "win11",
+			},
+			VMConfig{
+				Name: "web", Memory: 4096, VCPUs: 4, DiskPath: "/vms/web.qcow2", DiskSize: 40,
+				ISOImage: "/isos/web.iso", Network: "lan", Firmware: "uefi",
+				OSType: "windows", OSVariant: "win11",
+			},
+		},
+		{
+			"OS type without a variant does not take the default variant",
+			VMConfig{Name: "web", Memory: 1024, OSType: "bsd"},
+			VMConfig{
+				Name: "web", Memory: 1024, VCPUs: 2, DiskPath: "/vms/default.qcow2", DiskSize: 20,
+				ISOImage: "/isos/default.iso", Network: "default", Firmware: "bios", OSType: "bsd",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.applyDefaults(tt.config); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("applyDefaults(%+v) =\n%+v, want\n%+v", tt.config, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDeleteVMWithSnapshotsNeedsForce(t *testing.T) {
+	m := newTestManager(t)
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+	if err := m.CreateSnapshot("web", "before", ""); err != nil {
+		t.Fatalf("CreateSnapshot: %v", err)
+	}
+
+	if err := m.DeleteVM(context.Background(), "web", DeleteVMOptions{}); !errors.Is(err, ErrVMHasSnapshots) {
+		t.Fatalf("DeleteVM without force = %v, want ErrVMHasSnapshots", err)
+	}
+	if _, err := m.LookupVM("web"); err != nil {
+		t.Fatalf("VM with snapshots was deleted without force: %v", err)
+	}
+	if err := m.DeleteVM(context.Background(), "web", DeleteVMOptions{Force: true}); err != nil {
+		t.Fatalf("DeleteVM with force: %v", err)
+	}
+	if _, err := m.LookupVM("web"); err == nil {
+		t.Error("VM still exists after a forced delete")
+	}
+}
+
+func TestDeleteVMToolNeedsForceForSnapshots(t *testing.T) {
+	m := newTestManager(t)
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+	if err := m.CreateSnapshot("web", "before", ""); err != nil {
+		t.Fatalf("CreateSnapshot: %v", err)
+	}
+	tools := newTestTools(t, m)
+
+	if resp := callTool(t, tools, "delete_vm", map[string]any{"name": "web", "dry_run": true}); resp["success"] != false {
+		t.Errorf("delete_vm dry run without force = %v, want a failure", resp)
+	}
+	if resp := callTool(t, tools, "delete_vm", map[string]any{"name": "web"}); resp["success"] != false {
+		t.Errorf("delete_vm without force = %v, want a failure", resp)
+	}
+	if resp := callTool(t, tools, "delete_vm", map[string]any{"name": "web", "force": true}); resp["success"] != true {
+		t.Errorf("delete_vm with force = %v", resp)
+	}
+}
@@ -0,0 +1,160 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// WithDependencyOrdering включает учет DependsOn в StartVM и StopVM:
+// StartVM сначала запускает зависимости, StopVM сначала останавливает зависящие ВМ
+func WithDependencyOrdering(enabled bool) MockOption {
+	return func(m *MockVMManager) {
+		m.dependencyOrdering = enabled
+	}
+}
+
+// StartVMWithDeps запускает ВМ, предварительно запустив все ее зависимости
+// (транзитивно). Возвращает имена ВМ в порядке запуска. Если ВМ или одна из ее
+// зависимостей занята, ничего не запускается; если какую-то ВМ запустить не удалось,
+// зависимости, запущенные этим вызовом, снова останавливаются
+func (m *MockVMManager) StartVMWithDeps(ctx context.Context, name string) ([]string, error) {
+	var order []string
+	err := m.runHooks("start_with_deps", name, func() (err error) {
+		order, err = m.startVMWithDeps(ctx, name)
+		return err
+	})
+	return order, err
+}
+
+// startVMWithDeps выполняет StartVMWithDeps без хуков операций
+func (m *MockVMManager) startVMWithDeps(ctx context.Context, name string) (_ []string, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = m.resolveNameLocked(name)
+	defer func() { m.noteResultLocked(name, err) }()
+
+	if m.simulatedStartDelay > 0 {
+		if err := m.waitStartLocked(ctx, name); err != nil {
+			return nil, err
+		}
+	}
+
+	before := m.statesLocked()
+	order, err := m.startWithDepsLocked(name)
+	if err != nil {
+		return nil, err
+	}
+	m.recordTransitionLocked(AuditStart, name, order, before)
+	return order, nil
+}
+
+// startWithDepsLocked запускает ВМ и ее зависимости. Занятая ВМ в цепочке отклоняет
+// запуск целиком; при ошибке запуска ВМ, запущенные до нее, снова останавливаются.
+// Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) startWithDepsLocked(name string) ([]string, error) {
+	order, err := m.dependencyOrderLocked(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, n := range order {
+		if err := checkNotBusyLocked(m.vms[n], n); err != nil {
+			return nil, err
+		}
+	}
+
+	// Для отката запоминается разовое устройство загрузки, которое запуск сбрасывает
+	type startedVM struct {
+		name     string
+		nextBoot string
+	}
+	var started []startedVM
+	for _, n := range order {
+		vm := m.vms[n]
+		state, nextBoot := vm.State, vm.nextBoot
+		if err := m.startVMLocked(n); err != nil {
+			var errs []error
+			for _, s := range slices.Backward(started) {
+				if err := m.stopVMLocked(s.name); err != nil {
+					errs = append(errs, fmt.Errorf("failed to stop '%s' after the failed start: %w", s.name, err))
+					continue
+				}
+				m.vms[s.name].nextBoot = s.nextBoot
+			}
+			return nil, errors.Join(append([]error{err}, errs...)...)
+		}
+		if vm.State != state {
+			started = append(started, startedVM{name: n, nextBoot: nextBoot})
+		}
+	}
+	return order, nil
+}
+
+// dependencyOrderLocked возвращает ВМ и ее транзитивные зависимости так,
+// что каждая зависимость идет раньше зависящей от нее ВМ
+func (m *MockVMManager) dependencyOrderLocked(name string) ([]string, error) {
+	return m.topoOrderLocked(name, func(n string) []string {
+		return m.vms[n].Config.DependsOn
+	})
+}
+
+// dependentsOrderLocked возвращает ВМ и все ВМ, транзитивно зависящие от нее,
+// так что каждая зависящая ВМ идет раньше своей зависимости
+func (m *MockVMManager) dependentsOrderLocked(name string) ([]string, error) {
+	return m.topoOrderLocked(name, func(n string) []string {
+		var dependents []string
+		for vmName, vm := range m.vms {
+			for _, dep := range vm.Config.DependsOn {
+				if dep == n {
+					dependents = append(dependents, vmName)
+					break
+				}
+			}
+		}
+		return dependents
+	})
+}
+
+// topoOrderLocked обходит граф от name в глубину по ребрам edges и возвращает
+// вершины в порядке завершения обхода. Цикл в графе приводит к ошибке
+func (m *MockVMManager) topoOrderLocked(name string, edges func(string) []string) ([]string, error) {
+	var order []string
+	visiting := make(map[string]bool)
+	done := make(map[string]bool)
+
+	var visit func(n string, path []string) error
+	visit = func(n string, path []string) error {
+		if done[n] {
+			return nil
+		}
+		path = append(path, n)
+		if visiting[n] {
+			return fmt.Errorf("dependency cycle detected: %s", strings.Join(path, " -> "))
+		}
+		if _, exists := m.vms[n]; !exists {
+			return fmt.Errorf("virtual machine '%s' not found", n)
+		}
+
+		visiting[n] = true
+		for _, next := range edges(n) {
+			if err := visit(next, path); err != nil {
+				return err
+			}
+		}
+		visiting[n] = false
+		done[n] = true
+		order = append(order, n)
+		return nil
+	}
+
+	if err := visit(name, nil); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
@@ -0,0 +1,102 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+// newDependencyManager создает остановленные ВМ: app зависит от cache, cache - от db
+func newDependencyManager(t *testing.T, opts ...MockOption) *MockVMManager {
+	t.Helper()
+	m := newTestManager(t, append([]MockOption{WithAutoStartOnCreate(false)}, opts...)...)
+	mustCreate(t, m, VMConfig{Name: "db", Memory: 1024, VCPUs: 1})
+	mustCreate(t, m, VMConfig{Name: "cache", Memory: 1024, VCPUs: 1, DependsOn: []string{"db"}})
+	mustCreate(t, m, VMConfig{Name: "app", Memory: 1024, VCPUs: 1, DependsOn: []string{"cache"}})
+	return m
+}
+
+func TestStartVMWithDepsRejectsBusyDependency(t *testing.T) {
+	m := newDependencyManager(t)
+	m.mu.Lock()
+	m.vms["cache"].busy = true
+	m.mu.Unlock()
+
+	if _, err := m.StartVMWithDeps(context.Background(), "app"); !errors.Is(err, ErrVMBusy) {
+		t.Fatalf("StartVMWithDeps with a busy dependency = %v, want ErrVMBusy", err)
+	}
+	for _, name := range []string{"db", "cache", "app"} {
+		if state := stateOf(t, m, name); state != VMStateStopped {
+			t.Errorf("%s after a rejected start = %s, want %s", name, state, VMStateStopped)
+		}
+	}
+}
+
+func TestStartVMWithDepsRollsBackOnFailure(t *testing.T) {
+	backendErr := errors.New("backend failure")
+	m := newDependencyManager(t, WithTransitionFailure(func(operation, name string) error {
+		if operation == "start" && name == "app" {
+			return backendErr
+		}
+		return nil
+	}))
+	if err := m.SetNextBoot("db", BootDeviceNetwork); err != nil {
+		t.Fatalf("SetNextBoot: %v", err)
+	}
+	before := len(m.AuditLog())
+
+	if _, err := m.StartVMWithDeps(context.Background(), "app"); !errors.Is(err, backendErr) {
+		t.Fatalf("StartVMWithDeps = %v, want the backend error", err)
+	}
+	for _, name := range []string{"db", "cache"} {
+		if state := stateOf(t, m, name); state != VMStateStopped {
+			t.Errorf("%s after a failed start = %s, want it stopped again", name, state)
+		}
+	}
+	m.mu.RLock()
+	device := m.vms["db"].nextBoot
+	m.mu.RUnlock()
+	if device != BootDeviceNetwork {
+		t.Errorf("next boot of db after the rollback = %q, want %q", device, BootDeviceNetwork)
+	}
+	for _, entry := range m.AuditLog()[before:] {
+		if entry.Operation == AuditStart {
+			t.Errorf("rolled back start was recorded: %+v", entry)
+		}
+	}
+}
+
+func TestRenameRewritesReferences(t *testing.T) {
+	m := newDependencyManager(t, WithHosts("host-a", "host-b"))
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1, Affinity: []string{"db"}})
+	mustCreate(t, m, VMConfig{Name: "batch", Memory: 1024, VCPUs: 1, AntiAffinity: []string{"db"}})
+	if err := m.CreateSnapshot("web", "before", ""); err != nil {
+		t.Fatalf("CreateSnapshot: %v", err)
+	}
+
+	if err := m.RenameVM("db", "postgres", RenameVMOptions{}); err != nil {
+		t.Fatalf("RenameVM: %v", err)
+	}
+	cache, _ := m.GetVMInfo("cache")
+	if !slices.Equal(cache.Config.DependsOn, []string{"postgres"}) {
+		t.Errorf("cache DependsOn = %v, want [postgres]", cache.Config.DependsOn)
+	}
+	web, _ := m.GetVMInfo("web")
+	if !slices.Equal(web.Config.Affinity, []string{"postgres"}) {
+		t.Errorf("web Affinity = %v, want [postgres]", web.Config.Affinity)
+	}
+	batch, _ := m.GetVMInfo("batch")
+	if !slices.Equal(batch.Config.AntiAffinity, []string{"postgres"}) {
+		t.Errorf("batch AntiAffinity = %v, want [postgres]", batch.Config.AntiAffinity)
+	}
+	if err := m.RestoreSnapshot("web", "before"); err != nil {
+		t.Fatalf("RestoreSnapshot: %v", err)
+	}
+	if web, _ = m.GetVMInfo("web"); !slices.Equal(web.Config.Affinity, []string{"postgres"}) {
+		t.Errorf("web Affinity after restoring a snapshot = %v, want [postgres]", web.Config.Affinity)
+	}
+	if order, err := m.StartVMWithDeps(context.Background(), "app"); err != nil || !slices.Equal(order, []string{"postgres", "cache", "app"}) {
+		t.Errorf("StartVMWithDeps after the rename = %v, %v, want [postgres cache app]", order, err)
+	}
+}
@@ -0,0 +1,124 @@
+package vm
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+)
+
+// ToolDescription - описание инструмента для самоанализа агента или UI
+type ToolDescription struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  []ToolParameter `json:"parameters"`
+}
+
+// ToolParameter - описание параметра инструмента
+type ToolParameter struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+}
+
+// declarationProvider реализуется инструментами, которые публикуют декларацию функции
+type declarationProvider interface {
+	Declaration() *genai.FunctionDeclaration
+}
+
+// parametersSchema - часть JSON-схемы параметров, нужная для описания инструмента
+type parametersSchema struct {
+	Properties map[string]struct {
+		Type any `json:"type"`
+	} `json:"properties"`
+	Required []string `json:"required"`
+}
+
+// DescribeTools возвращает имя, описание и параметры каждого инструмента.
+// Параметры перечисляются в алфавитном порядке
+func DescribeTools(tools []tool.Tool) []ToolDescription {
+	descriptions := make([]ToolDescription, 0, len(tools))
+	for _, t := range tools {
+		desc := ToolDescription{
+			Name:        t.Name(),
+			Description: t.Description(),
+			Parameters:  []ToolParameter{},
+		}
+		if p, ok := t.(declarationProvider); ok {
+			desc.Parameters = describeParameters(p.Declaration())
+		}
+		descriptions = append(descriptions, desc)
+	}
+	return descriptions
+}
+
+// checkUniqueToolNames проверяет, что имена инструментов не повторяются: агент
+// различает инструменты только по имени. Ошибка называет повторяющееся имя, позиции
+// обоих инструментов и полный список имен
+func checkUniqueToolNames(tools []tool.Tool) error {
+	names := make([]string, 0, len(tools))
+	seen := make(map[string]int, len(tools))
+	for i, t := range tools {
+		names = append(names, t.Name())
+		if first, ok := seen[t.Name()]; ok {
+			for _, rest := range tools[i+1:] {
+				names = append(names, rest.Name())
+			}
+			return fmt.Errorf("duplicate tool name: %s (tools #%d and #%d of %d: %s)", t.Name(), first+1, i+1, len(tools), strings.Join(names, ", "))
+		}
+		seen[t.Name()] = i
+	}
+	return nil
+}
+
+// describeParameters извлекает параметры из JSON-схемы декларации функции
+func describeParameters(decl *genai.FunctionDeclaration) []ToolParameter {
+	params := []ToolParameter{}
+	if decl == nil || decl.ParametersJsonSchema == nil {
+		return params
+	}
+
+	raw, err := json.Marshal(decl.ParametersJsonSchema)
+	if err != nil {
+		return params
+	}
+	var schema parametersSchema
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return params
+	}
+
+	required := make(map[string]bool, len(schema.Required))
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+	for name, prop := range schema.Properties {
+		params = append(params, ToolParameter{
+			Name:     name,
+			Type:     schemaTypeName(prop.Type),
+			Required: required[name],
+		})
+	}
+	sort.Slice(params, func(i, j int) bool { return params[i].Name < params[j].Name })
+	return params
+}
+
+// schemaTypeName приводит поле "type" JSON-схемы (строку или список) к одной строке
+func schemaTypeName(t any) string {
+	switch v := t.(type) {
+	case string:
+		return v
+	case []any:
+		var types []string
+		for _, item := range v {
+			if s, ok := item.(string); ok && s != "null" {
+				types = append(types, s)
+			}
+		}
+		return strings.Join(types, "|")
+	default:
+		return ""
+	}
+}
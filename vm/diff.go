@@ -0,0 +1,112 @@
+package vm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FieldDiff - различие одного поля конфигурации двух ВМ
+type FieldDiff struct {
+	Field string `json:"field"`
+	A     string `json:"a"`
+	B     string `json:"b"`
+}
+
+// VMConfigDiff - различия конфигураций двух ВМ (имя не сравнивается)
+type VMConfigDiff struct {
+	A           string      `json:"a"`
+	B           string      `json:"b"`
+	Identical   bool        `json:"identical"`
+	Differences []FieldDiff `json:"differences"`
+}
+
+// DiffVMs сравнивает конфигурации двух ВМ и возвращает различающиеся поля.
+// Метки сравниваются по ключам: каждая отличающаяся метка - отдельное поле "labels.<ключ>"
+func (m *MockVMManager) DiffVMs(a, b string) (VMConfigDiff, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	a = m.resolveNameLocked(a)
+	b = m.resolveNameLocked(b)
+
+	vmA, exists := m.vms[a]
+	if !exists {
+		return VMConfigDiff{}, fmt.Errorf("virtual machine '%s' not found", a)
+	}
+	vmB, exists := m.vms[b]
+	if !exists {
+		return VMConfigDiff{}, fmt.Errorf("virtual machine '%s' not found", b)
+	}
+
+	differences := diffConfigs(vmA.Config, vmB.Config)
+	return VMConfigDiff{
+		A:           a,
+		B:           b,
+		Identical:   len(differences) == 0,
+		Differences: differences,
+	}, nil
+}
+
+// diffConfigs возвращает поля, которыми различаются две конфигурации (без имени)
+func diffConfigs(ca, cb VMConfig) []FieldDiff {
+	differences := []FieldDiff{}
+	add := func(field, valueA, valueB string) {
+		if valueA != valueB {
+			differences = append(differences, FieldDiff{Field: field, A: valueA, B: valueB})
+		}
+	}
+
+	add("memory", fmt.Sprint(ca.Memory), fmt.Sprint(cb.Memory))
+	add("vcpus", fmt.Sprint(ca.VCPUs), fmt.Sprint(cb.VCPUs))
+	add("disk_path", ca.DiskPath, cb.DiskPath)
+	add("disk_size", fmt.Sprint(ca.DiskSize), fmt.Sprint(cb.DiskSize))
+	add("disk_iops", formatIOPS(ca.DiskReadIOPS, ca.DiskWriteIOPS), formatIOPS(cb.DiskReadIOPS, cb.DiskWriteIOPS))
+	add("iso_image", ca.ISOImage, cb.ISOImage)
+	add("network", ca.Network, cb.Network)
+	add("bandwidth", formatBandwidth(ca.Bandwidth), formatBandwidth(cb.Bandwidth))
+	add("firmware", ca.Firmware, cb.Firmware)
+	add("os_type", ca.OSType, cb.OSType)
+	add("os_variant", ca.OSVariant, cb.OSVariant)
+	add("disks", formatDisks(ca.Disks), formatDisks(cb.Disks))
+	add("depends_on", strings.Join(ca.DependsOn, ", "), strings.Join(cb.DependsOn, ", "))
+	add("affinity", strings.Join(ca.Affinity, ", "), strings.Join(cb.Affinity, ", "))
+	add("anti_affinity", strings.Join(ca.AntiAffinity, ", "), strings.Join(cb.AntiAffinity, ", "))
+	add("cpu_pinning", formatCPUPinning(ca.CPUPinning), formatCPUPinning(cb.CPUPinning))
+	add("numa_nodes", formatNUMANodes(ca.NUMANodes), formatNUMANodes(cb.NUMANodes))
+
+	keys := make(map[string]struct{}, len(ca.Labels)+len(cb.Labels))
+	for key := range ca.Labels {
+		keys[key] = struct{}{}
+	}
+	for key := range cb.Labels {
+		keys[key] = struct{}{}
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	for _, key := range sorted {
+		add("labels."+key, ca.Labels[key], cb.Labels[key])
+	}
+	return differences
+}
+
+// formatDisks возвращает список дополнительных дисков в виде "путь (N GB), ..."
+func formatDisks(disks []DiskSpec) string {
+	parts := make([]string, 0, len(disks))
+	for _, disk := range disks {
+		part := fmt.Sprintf("%s (%d GB", disk.Path, disk.Size)
+		if disk.Shared {
+			part += ", shared"
+		}
+		if disk.ReadOnly {
+			part += ", read-only"
+		}
+		if iops := formatIOPS(disk.ReadIOPS, disk.WriteIOPS); iops != "" {
+			part += ", " + iops
+		}
+		parts = append(parts, part+")")
+	}
+	return strings.Join(parts, ", ")
+}
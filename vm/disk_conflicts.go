@@ -0,0 +1,114 @@
+package vm
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+// diskOwnersLocked возвращает для каждого пути к диску (после filepath.Clean) имена ВМ,
+// которые его используют. Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) diskOwnersLocked() map[string][]string {
+	owners := make(map[string][]string)
+	for name, vm := range m.vms {
+		for _, path := range diskPaths(vm.Config) {
+			path = filepath.Clean(path)
+			if names := owners[path]; len(names) > 0 && names[len(names)-1] == name {
+				continue
+			}
+			owners[path] = append(owners[path], name)
+		}
+	}
+	return owners
+}
+
+// diskSpecs возвращает все диски ВМ: основной (он не может быть общим) и дополнительные
+func diskSpecs(config VMConfig) []DiskSpec {
+	var disks []DiskSpec
+	if config.DiskPath != "" {
+		disks = append(disks, DiskSpec{
+			Path:      config.DiskPath,
+			Size:      config.DiskSize,
+			ReadIOPS:  config.DiskReadIOPS,
+			WriteIOPS: config.DiskWriteIOPS,
+		})
+	}
+	return append(disks, config.Disks...)
+}
+
+// sharesDisk сообщает, подключен ли к ВМ диск path (после filepath.Clean) как общий
+func sharesDisk(config VMConfig, path string) bool {
+	for _, disk := range config.Disks {
+		if filepath.Clean(disk.Path) == path && disk.Shared {
+			return true
+		}
+	}
+	return false
+}
+
+// checkDiskConflictsLocked возвращает ErrDiskInUse, если какой-либо из дисков уже
+// используется другой ВМ (не name). Диск может использоваться несколькими ВМ, только
+// если он помечен Shared у всех них. Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) checkDiskConflictsLocked(name string, disks []DiskSpec) error {
+	owners := m.diskOwnersLocked()
+	for _, disk := range disks {
+		path := filepath.Clean(disk.Path)
+		for _, owner := range owners[path] {
+			if owner == name {
+				continue
+			}
+			if disk.Shared && sharesDisk(m.vms[owner].Config, path) {
+				continue
+			}
+			if disk.Shared {
+				return fmt.Errorf("disk '%s' is used by virtual machine '%s', which does not mark it shared: %w", disk.Path, owner, ErrDiskInUse)
+			}
+			return fmt.Errorf("disk '%s' is used by virtual machine '%s': %w", disk.Path, owner, ErrDiskInUse)
+		}
+	}
+	return nil
+}
+
+// releaseDisksLocked освобождает диски ВМ name в индексе m.disks. Общий диск, который
+// еще подключен к другой ВМ, остается занятым ею. Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) releaseDisksLocked(name string, paths []string) {
+	owners := m.diskOwnersLocked()
+	for _, path := range paths {
+		delete(m.disks, path)
+		for _, owner := range owners[filepath.Clean(path)] {
+			if owner != name {
+				m.disks[path] = owner
+				break
+			}
+		}
+	}
+}
+
+// FindDiskConflicts возвращает диски, которые используются несколькими ВМ одновременно:
+// путь -> отсортированные имена ВМ. Общие диски, помеченные Shared у всех ВМ, конфликтом
+// не считаются
+func (m *MockVMManager) FindDiskConflicts() map[string][]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	conflicts := make(map[string][]string)
+	for path, names := range m.diskOwnersLocked() {
+		if len(names) < 2 || m.sharedByAllLocked(path, names) {
+			continue
+		}
+		sort.Strings(names)
+		conflicts[path] = names
+	}
+	return conflicts
+}
+
+// sharedByAllLocked сообщает, помечен ли диск Shared у всех перечисленных ВМ.
+// Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) sharedByAllLocked(path string, names []string) bool {
+	for _, name := range names {
+		if !sharesDisk(m.vms[name].Config, path) {
+			return false
+		}
+	}
+	return true
+}
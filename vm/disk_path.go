@@ -0,0 +1,39 @@
+package vm
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// defaultDiskDir - каталог образов дисков, если в настройках по умолчанию не задан DiskPath
+const defaultDiskDir = "/var/lib/libvirt/images"
+
+// diskDir возвращает каталог для образов дисков новых ВМ: каталог DiskPath настроек
+// по умолчанию (WithDefaults), иначе defaultDiskDir
+func (m *MockVMManager) diskDir() string {
+	if m.defaults.DiskPath != "" {
+		return filepath.Dir(m.defaults.DiskPath)
+	}
+	return defaultDiskDir
+}
+
+// SuggestDiskPath предлагает путь к новому диску ВМ вида <каталог>/<vmName>-<N>.qcow2,
+// не занятый ни одной ВМ и не существующий в файловой системе. Каталог берется из
+// DiskPath настроек по умолчанию (WithDefaults), иначе используется defaultDiskDir;
+// счетчик N начинается с 1, поэтому при одинаковом состоянии результат один и тот же
+func (m *MockVMManager) SuggestDiskPath(vmName string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	dir := m.diskDir()
+	for n := 1; ; n++ {
+		path := filepath.Join(dir, fmt.Sprintf("%s-%d.qcow2", vmName, n))
+		if _, used := m.disks[path]; used {
+			continue
+		}
+		if _, err := m.stat(path); err == nil {
+			continue
+		}
+		return path
+	}
+}
@@ -0,0 +1,122 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileRenamer переименовывает файл
+type FileRenamer func(oldPath, newPath string) error
+
+// renameFile переименовывает файл в файловой системе, не перезаписывая существующий.
+// Отсутствующий файл не считается ошибкой: диски mock-ВМ обычно существуют только в памяти
+func renameFile(oldPath, newPath string) error {
+	if _, err := os.Lstat(newPath); err == nil {
+		return fmt.Errorf("file '%s' already exists", newPath)
+	}
+	if err := os.Rename(oldPath, newPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// WithFileRenamer заменяет способ переименования файлов дисков в RenameVM
+// с RenameVMOptions.RenameDisk (например, в тестах)
+func WithFileRenamer(rename FileRenamer) MockOption {
+	return func(m *MockVMManager) {
+		m.renameFile = rename
+	}
+}
+
+// pathRename - переименование файла диска
+type pathRename struct {
+	From, To string
+}
+
+// renamedDiskPaths возвращает новые пути дисков ВМ oldName после ее переименования
+// в newName: старое имя заменяется новым в имени файла (но не в каталоге). Общие диски
+// и диски, в имени файла которых нет старого имени, не переименовываются
+func renamedDiskPaths(config VMConfig, oldName, newName string) []pathRename {
+	var renames []pathRename
+	for _, disk := range diskSpecs(config) {
+		dir, file := filepath.Split(disk.Path)
+		if disk.Shared || !strings.Contains(file, oldName) {
+			continue
+		}
+		renames = append(renames, pathRename{From: disk.Path, To: dir + strings.ReplaceAll(file, oldName, newName)})
+	}
+	return renames
+}
+
+// withRenamedPaths возвращает копию конфигурации с путями дисков, замененными по renames
+func withRenamedPaths(config VMConfig, renames []pathRename) VMConfig {
+	config = copyConfig(config)
+	for _, r := range renames {
+		if config.DiskPath == r.From {
+			config.DiskPath = r.To
+		}
+		for i := range config.Disks {
+			if config.Disks[i].Path == r.From {
+				config.Disks[i].Path = r.To
+			}
+		}
+	}
+	return config
+}
+
+// renameDisksLocked переименовывает файлы дисков ВМ name по renames. Если один из файлов
+// переименовать не удалось, уже переименованные возвращаются к прежним именам.
+// Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) renameDisksLocked(name string, renames []pathRename) error {
+	var specs []DiskSpec
+	for _, r := range renames {
+		specs = append(specs, DiskSpec{Path: r.To})
+	}
+	if err := m.checkDiskConflictsLocked(name, specs); err != nil {
+		return err
+	}
+
+	for i, r := range renames {
+		if err := m.renameFile(r.From, r.To); err != nil {
+			for _, done := range renames[:i] {
+				if err := m.renameFile(done.To, done.From); err != nil {
+					log.Printf("[MOCK] Failed to restore disk '%s' of virtual machine '%s': %v", done.From, name, err)
+				}
+			}
+			return fmt.Errorf("failed to rename disk '%s' to '%s': %w", r.From, r.To, err)
+		}
+	}
+	for _, r := range renames {
+		delete(m.disks, r.From)
+		log.Printf("[MOCK] Disk '%s' of virtual machine '%s' renamed to '%s'", r.From, name, r.To)
+	}
+	return nil
+}
+
+// diskRenamesOf возвращает переименования дисков ВМ name при ее переименовании в newName
+// с RenameVMOptions.RenameDisk
+func diskRenamesOf(manager VMManagerInterface, name, newName string) ([]pathRename, error) {
+	config, err := vmConfigOf(manager, name)
+	if err != nil {
+		return nil, err
+	}
+	return renamedDiskPaths(config, config.Name, newName), nil
+}
+
+// describeDiskRenames перечисляет переименования дисков через запятую, начиная с prefix
+// ("and its disk(s) 'a' -> 'b'"); пустой список дает пустую строку
+func describeDiskRenames(renames []pathRename, prefix string) string {
+	if len(renames) == 0 {
+		return ""
+	}
+	parts := make([]string, len(renames))
+	for i, r := range renames {
+		parts[i] = fmt.Sprintf("'%s' -> '%s'", r.From, r.To)
+	}
+	return " " + prefix + strings.Join(parts, ", ")
+}
@@ -0,0 +1,84 @@
+package vm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRenameVMRenamesDisks(t *testing.T) {
+	var renamed []pathRename
+	m := newTestManager(t, WithAutoStartOnCreate(false), WithFileRenamer(func(oldPath, newPath string) error {
+		renamed = append(renamed, pathRename{From: oldPath, To: newPath})
+		return nil
+	}))
+	mustCreate(t, m, VMConfig{
+		Name:     "web",
+		Memory:   1024,
+		VCPUs:    1,
+		DiskPath: "/vms/web.qcow2",
+		Disks: []DiskSpec{
+			{Path: "/vms/web-data.qcow2", Size: 10},
+			{Path: "/vms/web-shared.qcow2", Size: 10, Shared: true},
+		},
+	})
+	if err := m.CreateSnapshot("web", "before", ""); err != nil {
+		t.Fatalf("CreateSnapshot: %v", err)
+	}
+
+	if err := m.RenameVM("web", "api", RenameVMOptions{RenameDisk: true}); err != nil {
+		t.Fatalf("RenameVM: %v", err)
+	}
+	if len(renamed) != 2 {
+		t.Fatalf("renamed files = %v, want the boot and data disks", renamed)
+	}
+	info, err := m.LookupVM("api")
+	if err != nil {
+		t.Fatalf("LookupVM: %v", err)
+	}
+	if info.Config.DiskPath != "/vms/api.qcow2" || info.Config.Disks[0].Path != "/vms/api-data.qcow2" {
+		t.Errorf("disk paths = %s, %s, want them renamed", info.Config.DiskPath, info.Config.Disks[0].Path)
+	}
+	if info.Config.Disks[1].Path != "/vms/web-shared.qcow2" {
+		t.Errorf("shared disk path = %s, want it unchanged", info.Config.Disks[1].Path)
+	}
+	// Старый путь свободен, новый занят переименованной ВМ
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1, DiskPath: "/vms/web.qcow2"})
+	if conflicts := m.FindDiskConflicts(); len(conflicts) != 0 {
+		t.Errorf("disk conflicts after rename: %v", conflicts)
+	}
+}
+
+func TestRenameVMRestoresDisksOnFailure(t *testing.T) {
+	renameErr := errors.New("permission denied")
+	var calls []pathRename
+	m := newTestManager(t, WithAutoStartOnCreate(false), WithFileRenamer(func(oldPath, newPath string) error {
+		calls = append(calls, pathRename{From: oldPath, To: newPath})
+		if oldPath == "/vms/web-data.qcow2" {
+			return renameErr
+		}
+		return nil
+	}))
+	mustCreate(t, m, VMConfig{
+		Name:     "web",
+		Memory:   1024,
+		VCPUs:    1,
+		DiskPath: "/vms/web.qcow2",
+		Disks:    []DiskSpec{{Path: "/vms/web-data.qcow2", Size: 10}},
+	})
+
+	if err := m.RenameVM("web", "api", RenameVMOptions{RenameDisk: true}); !errors.Is(err, renameErr) {
+		t.Fatalf("RenameVM = %v, want the rename error", err)
+	}
+	// Первый диск переименован и возвращен обратно
+	last := calls[len(calls)-1]
+	if last.From != "/vms/api.qcow2" || last.To != "/vms/web.qcow2" {
+		t.Errorf("last rename = %v, want the boot disk restored", last)
+	}
+	info, err := m.LookupVM("web")
+	if err != nil {
+		t.Fatalf("VM was renamed despite the failure: %v", err)
+	}
+	if info.Config.DiskPath != "/vms/web.qcow2" {
+		t.Errorf("disk path = %s, want it unchanged", info.Config.DiskPath)
+	}
+}
@@ -0,0 +1,410 @@
+package vm
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// AttachDiskArgs - аргументы для подключения диска
+type AttachDiskArgs struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	Size     uint64 `json:"size,omitempty"`      // в ГБ
+	Shared   bool   `json:"shared,omitempty"`    // диск могут разделять ВМ, у которых он тоже shared
+	ReadOnly bool   `json:"read_only,omitempty"` // только для чтения
+	DryRunArg
+}
+
+// AttachDiskResult - результат подключения диска
+type AttachDiskResult struct {
+	Message string `json:"message"`
+}
+
+// DetachDiskArgs - аргументы для отключения диска
+type DetachDiskArgs struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	DryRunArg
+}
+
+// DetachDiskResult - результат отключения диска
+type DetachDiskResult struct {
+	Message string `json:"message"`
+}
+
+// AttachISOArgs - аргументы для подключения ISO-образа
+type AttachISOArgs struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	DryRunArg
+}
+
+// AttachISOResult - результат подключения ISO-образа
+type AttachISOResult struct {
+	Message string `json:"message"`
+}
+
+// FindDiskConflictsResult - диски, используемые несколькими ВМ
+type FindDiskConflictsResult struct {
+	Conflicts map[string][]string `json:"conflicts"`
+}
+
+// FindOrphanedDisksArgs - аргументы для поиска неиспользуемых дисков
+type FindOrphanedDisksArgs struct {
+	SearchDir string `json:"search_dir"`
+}
+
+// FindOrphanedDisksResult - неиспользуемые образы дисков
+type FindOrphanedDisksResult struct {
+	Disks []string `json:"disks"`
+}
+
+// ResizeDiskArgs - аргументы для изменения размера диска
+type ResizeDiskArgs struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	Size uint64 `json:"size"` // новый размер в ГБ
+	DryRunArg
+}
+
+// ResizeDiskResult - результат изменения размера диска
+type ResizeDiskResult struct {
+	Message string `json:"message"`
+}
+
+// CompactDiskArgs - аргументы для сжатия диска
+type CompactDiskArgs struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	DryRunArg
+}
+
+// CompactDiskResult - результат сжатия диска
+type CompactDiskResult struct {
+	ReclaimedGB uint64 `json:"reclaimed_gb"`
+	Message     string `json:"message"`
+}
+
+// SetDiskIOPSArgs - аргументы для ограничения IOPS диска
+type SetDiskIOPSArgs struct {
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	ReadIOPS  int    `json:"read_iops,omitempty"`  // 0 - без ограничения
+	WriteIOPS int    `json:"write_iops,omitempty"` // 0 - без ограничения
+	DryRunArg
+}
+
+// SetDiskIOPSResult - результат ограничения IOPS диска
+type SetDiskIOPSResult struct {
+	Message string `json:"message"`
+}
+
+// DiskUsageArgs - аргументы для получения использования дисков
+type DiskUsageArgs struct {
+	Name string `json:"name"`
+}
+
+// DiskUsageEntry - использование одного диска
+type DiskUsageEntry struct {
+	Path   string `json:"path"`
+	SizeGB uint64 `json:"size_gb"`
+	UsedGB uint64 `json:"used_gb"`
+}
+
+// DiskUsageResult - использование всех дисков ВМ
+type DiskUsageResult struct {
+	Disks []DiskUsageEntry `json:"disks"`
+}
+
+// NewDiskTools создает набор инструментов для управления дисками ВМ. Как и в NewVMTools,
+// инструменты, которые бэкенд не поддерживает, не регистрируются
+func NewDiskTools(manager VMManagerInterface) ([]tool.Tool, error) {
+	var tools []tool.Tool
+
+	// Инструмент для подключения диска
+	attachDiskTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "attach_disk",
+			Description: "Attaches an additional disk to an existing virtual machine. A disk already used by another VM can only be attached if both mark it shared (e.g. a shared data volume); set read_only for read-only base images",
+		},
+		func(args AttachDiskArgs) (string, error) {
+			if err := manager.ValidateAttachDisk(args.Name, DiskSpec{Path: args.Path, Size: args.Size, Shared: args.Shared, ReadOnly: args.ReadOnly}); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("would attach disk '%s' to VM '%s'", args.Path, args.Name), nil
+		},
+		func(ctx tool.Context, args AttachDiskArgs) (ToolResponse[AttachDiskResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[AttachDiskResult](err)
+			}
+			if err := manager.AttachDisk(args.Name, DiskSpec{Path: args.Path, Size: args.Size, Shared: args.Shared, ReadOnly: args.ReadOnly}); err != nil {
+				return toolFailure[AttachDiskResult](fmt.Errorf("failed to attach disk: %w", err))
+			}
+			return toolSuccess(AttachDiskResult{
+				Message: fmt.Sprintf("Disk '%s' attached to virtual machine '%s'", args.Path, args.Name),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create attach_disk tool: %w", err)
+	}
+	tools = append(tools, attachDiskTool)
+
+	// Инструмент для отключения диска
+	detachDiskTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "detach_disk",
+			Description: "Detaches an additional disk from a virtual machine by its path",
+		},
+		func(args DetachDiskArgs) (string, error) {
+			config, err := vmConfigOf(manager, args.Name)
+			if err != nil {
+				return "", err
+			}
+			if args.Path == config.DiskPath {
+				return "", fmt.Errorf("cannot detach primary disk '%s' of virtual machine '%s'", args.Path, args.Name)
+			}
+			if !slices.ContainsFunc(config.Disks, func(disk DiskSpec) bool { return disk.Path == args.Path }) {
+				return "", fmt.Errorf("disk '%s' is not attached to virtual machine '%s'", args.Path, args.Name)
+			}
+			return fmt.Sprintf("would detach disk '%s' from VM '%s'", args.Path, args.Name), nil
+		},
+		func(ctx tool.Context, args DetachDiskArgs) (ToolResponse[DetachDiskResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[DetachDiskResult](err)
+			}
+			if err := manager.DetachDisk(args.Name, args.Path); err != nil {
+				return toolFailure[DetachDiskResult](fmt.Errorf("failed to detach disk: %w", err))
+			}
+			return toolSuccess(DetachDiskResult{
+				Message: fmt.Sprintf("Disk '%s' detached from virtual machine '%s'", args.Path, args.Name),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create detach_disk tool: %w", err)
+	}
+	tools = append(tools, detachDiskTool)
+
+	// Инструмент для поиска неиспользуемых дисков
+	findOrphanedDisksTool, err := newTool(
+		functiontool.Config{
+			Name:        "find_orphaned_disks",
+			Description: "Lists disk image files (.qcow2, .raw, .img) in a directory that are not attached to any virtual machine",
+		},
+		func(ctx tool.Context, args FindOrphanedDisksArgs) (ToolResponse[FindOrphanedDisksResult], error) {
+			disks, err := manager.FindOrphanedDisks(args.SearchDir)
+			if err != nil {
+				return toolFailure[FindOrphanedDisksResult](fmt.Errorf("failed to find orphaned disks: %w", err))
+			}
+			return toolSuccess(FindOrphanedDisksResult{
+				Disks: disks,
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create find_orphaned_disks tool: %w", err)
+	}
+	tools = append(tools, findOrphanedDisksTool)
+
+	// Инструмент для подключения ISO-образа
+	attachISOTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "attach_iso",
+			Description: "Attaches an ISO image to the cdrom drive of a virtual machine, replacing the current one. The ISO file must exist",
+		},
+		func(args AttachISOArgs) (string, error) {
+			if err := requireVMs(manager, args.Name); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("would attach ISO image '%s' to VM '%s'", args.Path, args.Name), nil
+		},
+		func(ctx tool.Context, args AttachISOArgs) (ToolResponse[AttachISOResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[AttachISOResult](err)
+			}
+			if err := manager.AttachISO(args.Name, args.Path); err != nil {
+				return toolFailure[AttachISOResult](fmt.Errorf("failed to attach ISO: %w", err))
+			}
+			return toolSuccess(AttachISOResult{
+				Message: fmt.Sprintf("ISO image '%s' attached to virtual machine '%s'", args.Path, args.Name),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create attach_iso tool: %w", err)
+	}
+	tools = append(tools, attachISOTool)
+
+	// Инструмент для поиска дисков, используемых несколькими ВМ
+	findDiskConflictsTool, err := newTool(
+		functiontool.Config{
+			Name:        "find_disk_conflicts",
+			Description: "Lists disk paths that are used by more than one virtual machine, which would corrupt the disk on a real backend",
+		},
+		func(ctx tool.Context, args struct{}) (ToolResponse[FindDiskConflictsResult], error) {
+			return toolSuccess(FindDiskConflictsResult{
+				Conflicts: manager.FindDiskConflicts(),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create find_disk_conflicts tool: %w", err)
+	}
+	tools = append(tools, findDiskConflictsTool)
+
+	// Инструмент для изменения размера диска
+	resizeDiskTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "resize_disk",
+			Description: "Grows a disk of a virtual machine to a new size in GB. Shrinking is not supported",
+		},
+		func(args ResizeDiskArgs) (string, error) {
+			usage, err := manager.DiskUsage(args.Name)
+			if err != nil {
+				return "", err
+			}
+			i := slices.IndexFunc(usage, func(u DiskUsage) bool { return u.Path == args.Path })
+			if i < 0 {
+				return "", fmt.Errorf("disk '%s' is not attached to virtual machine '%s'", args.Path, args.Name)
+			}
+			if current := usage[i].SizeGB; args.Size < current {
+				return "", fmt.Errorf("cannot shrink disk '%s' from %d GB to %d GB", args.Path, current, args.Size)
+			}
+			return fmt.Sprintf("would resize disk '%s' of VM '%s' from %d GB to %d GB", args.Path, args.Name, usage[i].SizeGB, args.Size), nil
+		},
+		func(ctx tool.Context, args ResizeDiskArgs) (ToolResponse[ResizeDiskResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[ResizeDiskResult](err)
+			}
+			if err := manager.ResizeDisk(args.Name, args.Path, args.Size); err != nil {
+				return toolFailure[ResizeDiskResult](fmt.Errorf("failed to resize disk: %w", err))
+			}
+			return toolSuccess(ResizeDiskResult{
+				Message: fmt.Sprintf("Disk '%s' of virtual machine '%s' resized to %d GB", args.Path, args.Name, args.Size),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resize_disk tool: %w", err)
+	}
+	tools = append(tools, resizeDiskTool)
+
+	// Инструмент для сжатия диска
+	compactDiskTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "compact_disk",
+			Description: "Compacts a disk of a stopped virtual machine, reclaiming unused space in the image file, and returns the reclaimed GB. Use it for storage-reclamation requests; stop the VM first. Shared disks cannot be compacted",
+		},
+		func(args CompactDiskArgs) (string, error) {
+			usage, err := manager.DiskUsage(args.Name)
+			if err != nil {
+				return "", err
+			}
+			i := slices.IndexFunc(usage, func(u DiskUsage) bool { return u.Path == args.Path })
+			if i < 0 {
+				return "", fmt.Errorf("disk '%s' is not attached to virtual machine '%s'", args.Path, args.Name)
+			}
+			config, err := vmConfigOf(manager, args.Name)
+			if err != nil {
+				return "", err
+			}
+			if sharesDisk(config, filepath.Clean(args.Path)) {
+				return "", fmt.Errorf("cannot compact shared disk '%s': other virtual machines may be using it", args.Path)
+			}
+			state, err := vmStateOf(manager, args.Name)
+			if err != nil {
+				return "", err
+			}
+			if state != VMStateStopped {
+				return "", fmt.Errorf("virtual machine '%s' must be stopped to compact its disks (current state: %s)", args.Name, state)
+			}
+			return fmt.Sprintf("would compact disk '%s' of VM '%s' (%d of %d GB used)", args.Path, args.Name, usage[i].UsedGB, usage[i].SizeGB), nil
+		},
+		func(ctx tool.Context, args CompactDiskArgs) (ToolResponse[CompactDiskResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[CompactDiskResult](err)
+			}
+			reclaimed, err := manager.CompactDisk(args.Name, args.Path)
+			if err != nil {
+				return toolFailure[CompactDiskResult](fmt.Errorf("failed to compact disk: %w", err))
+			}
+			return toolSuccess(CompactDiskResult{
+				ReclaimedGB: reclaimed,
+				Message:     fmt.Sprintf("Disk '%s' of virtual machine '%s' compacted, %d GB reclaimed", args.Path, args.Name, reclaimed),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create compact_disk tool: %w", err)
+	}
+	tools = append(tools, compactDiskTool)
+
+	// Инструмент для ограничения IOPS диска
+	setDiskIOPSTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "set_disk_iops",
+			Description: "Limits the read and write operations per second of a disk of a stopped virtual machine, e.g. \"limit vm1's disk to 500 IOPS\" (set both limits to 500). 0 or an omitted limit removes it; stop the VM first",
+		},
+		func(args SetDiskIOPSArgs) (string, error) {
+			if err := manager.ValidateDiskIOPS(args.Name, args.Path, uint(args.ReadIOPS), uint(args.WriteIOPS)); err != nil {
+				return "", err
+			}
+			iops := formatIOPS(uint(args.ReadIOPS), uint(args.WriteIOPS))
+			if iops == "" {
+				return fmt.Sprintf("would remove the IOPS limits of disk '%s' of VM '%s'", args.Path, args.Name), nil
+			}
+			return fmt.Sprintf("would limit disk '%s' of VM '%s' to %s", args.Path, args.Name, iops), nil
+		},
+		func(ctx tool.Context, args SetDiskIOPSArgs) (ToolResponse[SetDiskIOPSResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[SetDiskIOPSResult](err)
+			}
+			if err := manager.SetDiskIOPS(args.Name, args.Path, uint(args.ReadIOPS), uint(args.WriteIOPS)); err != nil {
+				return toolFailure[SetDiskIOPSResult](fmt.Errorf("failed to set disk IOPS limits: %w", err))
+			}
+			message := fmt.Sprintf("Disk '%s' of virtual machine '%s' limited to %s", args.Path, args.Name, formatIOPS(uint(args.ReadIOPS), uint(args.WriteIOPS)))
+			if args.ReadIOPS == 0 && args.WriteIOPS == 0 {
+				message = fmt.Sprintf("IOPS limits of disk '%s' of virtual machine '%s' removed", args.Path, args.Name)
+			}
+			return toolSuccess(SetDiskIOPSResult{Message: message})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create set_disk_iops tool: %w", err)
+	}
+	tools = append(tools, setDiskIOPSTool)
+
+	// Инструмент для получения использования дисков
+	diskUsageTool, err := newTool(
+		functiontool.Config{
+			Name:        "disk_usage",
+			Description: "Returns size and used space in GB of every disk attached to a virtual machine",
+		},
+		func(ctx tool.Context, args DiskUsageArgs) (ToolResponse[DiskUsageResult], error) {
+			usage, err := manager.DiskUsage(args.Name)
+			if err != nil {
+				return toolFailure[DiskUsageResult](fmt.Errorf("failed to get disk usage: %w", err))
+			}
+			result := DiskUsageResult{Disks: make([]DiskUsageEntry, 0, len(usage))}
+			for _, u := range usage {
+				result.Disks = append(result.Disks, DiskUsageEntry{Path: u.Path, SizeGB: u.SizeGB, UsedGB: u.UsedGB})
+			}
+			return toolSuccess(result)
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create disk_usage tool: %w", err)
+	}
+	tools = append(tools, diskUsageTool)
+
+	tools = supportedTools(manager.Capabilities(), tools)
+	if err := checkUniqueToolNames(tools); err != nil {
+		return nil, err
+	}
+	return tools, nil
+}
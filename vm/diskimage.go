@@ -0,0 +1,53 @@
+package vm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// qcow2Magic - сигнатура в начале файла образа qcow2
+var qcow2Magic = []byte{'Q', 'F', 'I', 0xfb}
+
+// FileOpener открывает файл для чтения
+type FileOpener func(path string) (io.ReadCloser, error)
+
+// openFile открывает файл в файловой системе
+func openFile(path string) (io.ReadCloser, error) {
+	return os.Open(path)
+}
+
+// WithDiskImageOpener заменяет способ чтения образов дисков (например, в тестах)
+func WithDiskImageOpener(opener FileOpener) MockOption {
+	return func(m *MockVMManager) {
+		m.openDiskImage = opener
+	}
+}
+
+// validateDiskImage проверяет, что существующий файл диска является поддерживаемым
+// образом: qcow2 (по сигнатуре) или raw (по расширению .raw).
+// Несуществующие пути не проверяются
+func (m *MockVMManager) validateDiskImage(path string) error {
+	f, err := m.openDiskImage(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open disk image '%s': %w", path, err)
+	}
+	defer f.Close()
+
+	header := make([]byte, len(qcow2Magic))
+	n, err := io.ReadFull(f, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to read disk image '%s': %w", path, err)
+	}
+	if bytes.Equal(header[:n], qcow2Magic) || filepath.Ext(path) == ".raw" {
+		return nil
+	}
+	return fmt.Errorf("unsupported disk image format: %s", path)
+}
@@ -0,0 +1,280 @@
+package vm
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DiskSpec описывает дополнительный диск виртуальной машины
+type DiskSpec struct {
+	Path string `yaml:"path"`
+	Size uint64 `yaml:"size_gb,omitempty"` // в ГБ
+	// Shared разрешает подключать диск к нескольким ВМ (например, общий том данных);
+	// диск можно разделить, только если он помечен Shared у всех ВМ
+	Shared bool `yaml:"shared,omitempty"`
+	// ReadOnly подключает диск только для чтения (например, общий базовый образ)
+	ReadOnly bool `yaml:"read_only,omitempty"`
+	// ReadIOPS и WriteIOPS ограничивают операции чтения и записи в секунду (0 - без ограничения)
+	ReadIOPS  uint `yaml:"read_iops,omitempty"`
+	WriteIOPS uint `yaml:"write_iops,omitempty"`
+}
+
+// diskPaths возвращает пути ко всем дискам ВМ: основному и дополнительным
+func diskPaths(config VMConfig) []string {
+	var paths []string
+	if config.DiskPath != "" {
+		paths = append(paths, config.DiskPath)
+	}
+	for _, disk := range config.Disks {
+		paths = append(paths, disk.Path)
+	}
+	return paths
+}
+
+// AttachDisk подключает дополнительный диск к виртуальной машине, если в пуле хранения
+// (Limits.StoragePoolGB) есть место для него
+func (m *MockVMManager) AttachDisk(name string, disk DiskSpec) error {
+	return m.runHooks("attach_disk", name, func() error { return m.attachDisk(name, disk) })
+}
+
+// attachDisk выполняет AttachDisk без хуков операций
+func (m *MockVMManager) attachDisk(name string, disk DiskSpec) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = m.resolveNameLocked(name)
+	defer func() { m.noteResultLocked(name, err) }()
+
+	if err := m.checkAttachDiskLocked(name, disk); err != nil {
+		return err
+	}
+
+	vm := m.vms[name]
+	vm.Config.Disks = append(vm.Config.Disks, disk)
+	m.disks[disk.Path] = name
+	log.Printf("[MOCK] Disk '%s' (%d GB) attached to virtual machine '%s'", disk.Path, disk.Size, name)
+	m.recordVMLocked(AuditAttachDisk, name)
+	return nil
+}
+
+// ValidateAttachDisk проверяет, что диск можно подключить к ВМ прямо сейчас: выполняет
+// те же проверки, что AttachDisk (блокировка ВМ, конфликты дисков, образ, место в пуле
+// хранения), ничего не подключая
+func (m *MockVMManager) ValidateAttachDisk(name string, disk DiskSpec) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.checkAttachDiskLocked(m.resolveNameLocked(name), disk)
+}
+
+// checkAttachDiskLocked проверяет подключение диска к ВМ; вызывающий код должен
+// удерживать m.mu
+func (m *MockVMManager) checkAttachDiskLocked(name string, disk DiskSpec) error {
+	vm, exists := m.vms[name]
+	if !exists {
+		return fmt.Errorf("virtual machine '%s' not found", name)
+	}
+
+	if err := checkUnlockedLocked(vm, name, "attach a disk to"); err != nil {
+		return err
+	}
+	if disk.Path == "" {
+		return fmt.Errorf("disk path cannot be empty")
+	}
+	for _, path := range diskPaths(vm.Config) {
+		if path == disk.Path {
+			return fmt.Errorf("disk '%s' is already attached to virtual machine '%s'", disk.Path, name)
+		}
+	}
+	if err := m.checkDiskConflictsLocked(name, []DiskSpec{disk}); err != nil {
+		return err
+	}
+	if err := m.validateDiskImage(disk.Path); err != nil {
+		return err
+	}
+	if pool, used := m.limits.StoragePoolGB, m.storageUsedGBLocked(); pool > 0 && used+disk.Size > pool {
+		return fmt.Errorf("insufficient storage: %d GB available in pool, %d GB requested", pool-min(used, pool), disk.Size)
+	}
+	return nil
+}
+
+// DetachDisk отключает дополнительный диск от виртуальной машины
+func (m *MockVMManager) DetachDisk(name, path string) error {
+	return m.runHooks("detach_disk", name, func() error { return m.detachDisk(name, path) })
+}
+
+// detachDisk выполняет DetachDisk без хуков операций
+func (m *MockVMManager) detachDisk(name, path string) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = m.resolveNameLocked(name)
+	defer func() { m.noteResultLocked(name, err) }()
+
+	vm, exists := m.vms[name]
+	if !exists {
+		return fmt.Errorf("virtual machine '%s' not found", name)
+	}
+
+	if err := checkUnlockedLocked(vm, name, "detach a disk from"); err != nil {
+		return err
+	}
+	if path == vm.Config.DiskPath {
+		return fmt.Errorf("cannot detach primary disk '%s' of virtual machine '%s'", path, name)
+	}
+	for i, disk := range vm.Config.Disks {
+		if disk.Path == path {
+			vm.Config.Disks = append(vm.Config.Disks[:i], vm.Config.Disks[i+1:]...)
+			delete(vm.compactedGB, path)
+			m.releaseDisksLocked(name, []string{path})
+			log.Printf("[MOCK] Disk '%s' detached from virtual machine '%s'", path, name)
+			m.recordVMLocked(AuditDetachDisk, name)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("disk '%s' is not attached to virtual machine '%s'", path, name)
+}
+
+// diskImageExtensions - расширения файлов, считающихся образами дисков
+var diskImageExtensions = map[string]bool{
+	".qcow2": true,
+	".raw":   true,
+	".img":   true,
+}
+
+// DirLister возвращает имена обычных файлов в каталоге
+type DirLister func(dir string) ([]string, error)
+
+// listDir возвращает имена обычных файлов в каталоге файловой системы
+func listDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// WithDirLister заменяет способ получения содержимого каталогов (например, в тестах)
+func WithDirLister(lister DirLister) MockOption {
+	return func(m *MockVMManager) {
+		m.listDir = lister
+	}
+}
+
+// FindOrphanedDisks возвращает отсортированные пути к образам дисков в каталоге searchDir,
+// которые не используются ни одной ВМ
+func (m *MockVMManager) FindOrphanedDisks(searchDir string) ([]string, error) {
+	names, err := m.listDir(searchDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list directory '%s': %w", searchDir, err)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	used := make(map[string]bool)
+	for _, vm := range m.vms {
+		for _, path := range diskPaths(vm.Config) {
+			used[filepath.Clean(path)] = true
+		}
+	}
+
+	orphaned := []string{}
+	for _, name := range names {
+		if !diskImageExtensions[strings.ToLower(filepath.Ext(name))] {
+			continue
+		}
+		path := filepath.Join(searchDir, name)
+		if !used[path] {
+			orphaned = append(orphaned, path)
+		}
+	}
+	sort.Strings(orphaned)
+	return orphaned, nil
+}
+
+// DiskUsage - размер и занятое место диска ВМ
+type DiskUsage struct {
+	Path   string
+	SizeGB uint64
+	UsedGB uint64
+}
+
+// ResizeDisk увеличивает размер диска ВМ (основного или дополнительного) до sizeGB.
+// Уменьшение не поддерживается, так как может повредить файловую систему гостя
+func (m *MockVMManager) ResizeDisk(name, path string, sizeGB uint64) error {
+	return m.runHooks("resize_disk", name, func() error { return m.resizeDisk(name, path, sizeGB) })
+}
+
+// resizeDisk выполняет ResizeDisk без хуков операций
+func (m *MockVMManager) resizeDisk(name, path string, sizeGB uint64) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = m.resolveNameLocked(name)
+	defer func() { m.noteResultLocked(name, err) }()
+
+	vm, exists := m.vms[name]
+	if !exists {
+		return fmt.Errorf("virtual machine '%s' not found", name)
+	}
+	if err := checkUnlockedLocked(vm, name, "resize a disk of"); err != nil {
+		return err
+	}
+
+	var size *uint64
+	if path != "" && path == vm.Config.DiskPath {
+		size = &vm.Config.DiskSize
+	}
+	for i := range vm.Config.Disks {
+		if vm.Config.Disks[i].Path == path {
+			size = &vm.Config.Disks[i].Size
+		}
+	}
+	if size == nil {
+		return fmt.Errorf("disk '%s' is not attached to virtual machine '%s'", path, name)
+	}
+	if sizeGB < *size {
+		return fmt.Errorf("cannot shrink disk '%s' from %d GB to %d GB", path, *size, sizeGB)
+	}
+
+	grow := sizeGB - *size
+	if pool, used := m.limits.StoragePoolGB, m.storageUsedGBLocked(); pool > 0 && used+grow > pool {
+		return fmt.Errorf("insufficient storage: %d GB available in pool, %d GB requested", pool-min(used, pool), grow)
+	}
+
+	log.Printf("[MOCK] Disk '%s' of virtual machine '%s' resized from %d GB to %d GB", path, name, *size, sizeGB)
+	*size = sizeGB
+	m.recordVMLocked(AuditResizeDisk, name)
+	return nil
+}
+
+// DiskUsage возвращает размер и занятое место каждого диска ВМ.
+// Mock-менеджер считает занятой половину размера диска (для сжатых дисков см. CompactDisk)
+func (m *MockVMManager) DiskUsage(name string) ([]DiskUsage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	name = m.resolveNameLocked(name)
+
+	vm, exists := m.vms[name]
+	if !exists {
+		return nil, fmt.Errorf("virtual machine '%s' not found", name)
+	}
+
+	usage := []DiskUsage{}
+	if vm.Config.DiskPath != "" {
+		usage = append(usage, DiskUsage{Path: vm.Config.DiskPath, SizeGB: vm.Config.DiskSize, UsedGB: vm.diskUsedGB(vm.Config.DiskPath, vm.Config.DiskSize)})
+	}
+	for _, disk := range vm.Config.Disks {
+		usage = append(usage, DiskUsage{Path: disk.Path, SizeGB: disk.Size, UsedGB: vm.diskUsedGB(disk.Path, disk.Size)})
+	}
+	return usage, nil
+}
@@ -0,0 +1,69 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAttachDiskRespectsStoragePool(t *testing.T) {
+	m := newTestManager(t, WithLimits(Limits{StoragePoolGB: 50}))
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1, DiskPath: "/tmp/pool-web.qcow2", DiskSize: 30})
+
+	err := m.AttachDisk("web", DiskSpec{Path: "/tmp/pool-data.qcow2", Size: 30})
+	if err == nil || !strings.Contains(err.Error(), "insufficient storage") {
+		t.Fatalf("AttachDisk beyond the pool = %v, want an insufficient storage error", err)
+	}
+	if info, _ := m.GetVMInfo("web"); len(info.Config.Disks) != 0 {
+		t.Errorf("disks after rejected attach = %v, want none", info.Config.Disks)
+	}
+
+	if err := m.AttachDisk("web", DiskSpec{Path: "/tmp/pool-data.qcow2", Size: 20}); err != nil {
+		t.Fatalf("AttachDisk within the pool: %v", err)
+	}
+}
+
+func TestSharedDisks(t *testing.T) {
+	tests := []struct {
+		name          string
+		first, second bool // помечен ли диск Shared у первой и второй ВМ
+		wantErr       bool
+	}{
+		{"both shared", true, true, false},
+		{"second not shared", true, false, true},
+		{"first not shared", false, true, true},
+		{"neither shared", false, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, WithAutoStartOnCreate(false))
+			mustCreate(t, m, VMConfig{Name: "app-1", Memory: 1024, VCPUs: 1,
+				Disks: []DiskSpec{{Path: "/data/shared.qcow2", Size: 10, Shared: tt.first}}})
+			mustCreate(t, m, VMConfig{Name: "app-2", Memory: 1024, VCPUs: 1})
+
+			disk := DiskSpec{Path: "/data/shared.qcow2", Size: 10, Shared: tt.second, ReadOnly: true}
+			err := m.AttachDisk("app-2", disk)
+			createErr := m.CreateVM(context.Background(), VMConfig{Name: "app-4", Memory: 1024, VCPUs: 1, Disks: []DiskSpec{disk}})
+			if tt.wantErr {
+				if !errors.Is(err, ErrDiskInUse) {
+					t.Errorf("AttachDisk = %v, want ErrDiskInUse", err)
+				}
+				if !errors.Is(createErr, ErrDiskInUse) {
+					t.Errorf("CreateVM = %v, want ErrDiskInUse", createErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AttachDisk: %v", err)
+			}
+			if createErr != nil {
+				t.Fatalf("CreateVM: %v", createErr)
+			}
+			// Диск, помеченный Shared у всех ВМ, конфликтом не считается
+			if conflicts := m.FindDiskConflicts(); len(conflicts) != 0 {
+				t.Errorf("FindDiskConflicts = %v, want none", conflicts)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package vm
+
+import (
+	"fmt"
+	"sort"
+)
+
+// StateDrift - ВМ, состояние питания которой отличается от желаемого
+type StateDrift struct {
+	Name    string  `json:"name"`
+	Desired VMState `json:"desired"`
+	Actual  VMState `json:"actual"`
+}
+
+// ConfigDrift - ВМ, конфигурация которой отличается от желаемой
+type ConfigDrift struct {
+	Name    string      `json:"name"`
+	Changes []FieldDiff `json:"changes"` // текущее (A) и желаемое (B) значения
+}
+
+// DriftReport - расхождения между желаемым и фактическим состоянием ВМ по типам.
+// Все списки отсортированы по имени ВМ
+type DriftReport struct {
+	Unexpected  []string      `json:"unexpected"`   // ВМ существуют, но не описаны
+	Missing     []string      `json:"missing"`      // ВМ описаны, но не существуют
+	WrongState  []StateDrift  `json:"wrong_state"`  // ВМ запущены или остановлены не так, как нужно
+	ConfigDrift []ConfigDrift `json:"config_drift"` // конфигурация ВМ отличается от описанной
+	InSync      bool          `json:"in_sync"`      // расхождений нет
+}
+
+// DriftReport сравнивает ВМ с желаемыми конфигурациями и ничего не меняет: в отличие от
+// Reconcile, он только сообщает о расхождениях. desiredRunning задает желаемое состояние
+// питания (true - запущена, false - остановлена) для ВМ из desired; ВМ, не указанные в
+// нем, по состоянию не проверяются. Конфигурации сравниваются с учетом значений по умолчанию
+func (m *MockVMManager) DriftReport(desired []VMConfig, desiredRunning map[string]bool) (DriftReport, error) {
+	declared := make(map[string]bool, len(desired))
+	for _, config := range desired {
+		if config.Name == "" {
+			return DriftReport{}, fmt.Errorf("VM name cannot be empty")
+		}
+		if declared[config.Name] {
+			return DriftReport{}, fmt.Errorf("virtual machine '%s' is listed more than once", config.Name)
+		}
+		declared[config.Name] = true
+	}
+	for name := range desiredRunning {
+		if !declared[name] {
+			return DriftReport{}, fmt.Errorf("desired power state is set for undeclared virtual machine '%s'", name)
+		}
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	report := DriftReport{
+		Unexpected:  []string{},
+		Missing:     []string{},
+		WrongState:  []StateDrift{},
+		ConfigDrift: []ConfigDrift{},
+	}
+	for name := range m.vms {
+		if !declared[name] {
+			report.Unexpected = append(report.Unexpected, name)
+		}
+	}
+	for _, config := range desired {
+		vm, exists := m.vms[config.Name]
+		if !exists {
+			report.Missing = append(report.Missing, config.Name)
+			continue
+		}
+		if changes := diffConfigs(vm.Config, m.applyDefaults(config)); len(changes) > 0 {
+			report.ConfigDrift = append(report.ConfigDrift, ConfigDrift{Name: config.Name, Changes: changes})
+		}
+		if running, checked := desiredRunning[config.Name]; checked {
+			want := VMStateStopped
+			if running {
+				want = VMStateRunning
+			}
+			if vm.State != want {
+				report.WrongState = append(report.WrongState, StateDrift{Name: config.Name, Desired: want, Actual: vm.State})
+			}
+		}
+	}
+
+	sort.Strings(report.Unexpected)
+	sort.Strings(report.Missing)
+	sort.Slice(report.WrongState, func(i, j int) bool { return report.WrongState[i].Name < report.WrongState[j].Name })
+	sort.Slice(report.ConfigDrift, func(i, j int) bool { return report.ConfigDrift[i].Name < report.ConfigDrift[j].Name })
+	report.InSync = len(report.Unexpected) == 0 && len(report.Missing) == 0 &&
+		len(report.WrongState) == 0 && len(report.ConfigDrift) == 0
+	return report, nil
+}
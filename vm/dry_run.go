@@ -0,0 +1,188 @@
+package vm
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// dryRunHint дописывается к описанию каждого изменяющего инструмента
+const dryRunHint = ". Pass dry_run=true to only validate the call and get a description of what it would do, without changing anything"
+
+// DryRunArg - общий аргумент изменяющих инструментов. Встраивается в их аргументы, а
+// инструменты без собственных аргументов принимают его напрямую
+type DryRunArg struct {
+	DryRun bool `json:"dry_run,omitempty"` // только проверить вызов и описать, что он сделает
+}
+
+// isDryRun сообщает, запрошен ли пробный запуск
+func (arg DryRunArg) isDryRun() bool {
+	return arg.DryRun
+}
+
+// dryRunner реализуют аргументы изменяющих инструментов (через встроенный DryRunArg)
+type dryRunner interface {
+	isDryRun() bool
+}
+
+// validator реализуют аргументы инструментов, проверяемые до обращения к менеджеру
+type validator interface {
+	validate() error
+}
+
+// newMutatingTool создает изменяющий инструмент с поддержкой dry_run. При пробном запуске
+// обработчик не вызывается: аргументы проверяются validate (если он есть), затем plan
+// проверяет операцию, только читая состояние менеджера, и описывает ее ("would create
+// VM 'x' with 2 vCPU(s)"). Описание возвращается в поле Plan ответа с DryRun == true
+func newMutatingTool[A dryRunner, R any](config functiontool.Config, plan func(args A) (string, error), handler functiontool.Func[A, ToolResponse[R]]) (tool.Tool, error) {
+	config.Description = strings.TrimSuffix(config.Description, ".") + dryRunHint
+	t, err := newTool(config, func(ctx tool.Context, args A) (ToolResponse[R], error) {
+		if !args.isDryRun() {
+			return handler(ctx, args)
+		}
+		if v, ok := any(args).(validator); ok {
+			if err := v.validate(); err != nil {
+				return toolFailure[R](err)
+			}
+		}
+		description, err := plan(args)
+		if err != nil {
+			return toolFailure[R](err)
+		}
+		return ToolResponse[R]{Success: true, DryRun: true, Plan: description}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return mutatingTool{t.(functionTool)}, nil
+}
+
+// vmStateOf возвращает состояние ВМ или ошибку, если ВМ не существует
+func vmStateOf(manager VMManagerInterface, name string) (VMState, error) {
+	info, err := manager.LookupVM(name)
+	if err != nil {
+		return "", err
+	}
+	return info.State, nil
+}
+
+// requireVMs проверяет, что все перечисленные ВМ существуют
+func requireVMs(manager VMManagerInterface, names ...string) error {
+	for _, name := range names {
+		if _, err := vmStateOf(manager, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// requireNoVM проверяет, что ВМ с именем name еще не существует
+func requireNoVM(manager VMManagerInterface, name string) error {
+	if _, err := vmStateOf(manager, name); err == nil {
+		return fmt.Errorf("virtual machine with name '%s' already exists", name)
+	}
+	return nil
+}
+
+// requireRunningVM проверяет, что ВМ существует и запущена
+func requireRunningVM(manager VMManagerInterface, name string) error {
+	state, err := vmStateOf(manager, name)
+	if err != nil {
+		return err
+	}
+	if state != VMStateRunning {
+		return fmt.Errorf("virtual machine '%s' is not running", name)
+	}
+	return nil
+}
+
+// requireSnapshot проверяет, что у ВМ есть снапшот с именем snapshotName
+func requireSnapshot(manager VMManagerInterface, vmName, snapshotName string) error {
+	snapshots, err := manager.ListSnapshots(vmName)
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(snapshots, func(info SnapshotInfo) bool { return info.Name == snapshotName }) {
+		return fmt.Errorf("snapshot '%s' not found for virtual machine '%s'", snapshotName, vmName)
+	}
+	return nil
+}
+
+// vmView - состояние и конфигурация ВМ, полученные через интерфейс менеджера
+type vmView struct {
+	State  VMState
+	Config VMConfig
+}
+
+// vmConfigOf возвращает конфигурацию ВМ
+func vmConfigOf(manager VMManagerInterface, name string) (VMConfig, error) {
+	info, err := manager.LookupVM(name)
+	if err != nil {
+		return VMConfig{}, err
+	}
+	return info.Config, nil
+}
+
+// listVMViews возвращает состояние и конфигурацию каждой ВМ из одного согласованного снимка
+func listVMViews(manager VMManagerInterface) (map[string]vmView, error) {
+	views := make(map[string]vmView)
+	for _, info := range manager.Snapshot() {
+		views[info.Config.Name] = vmView{State: info.State, Config: info.Config}
+	}
+	return views, nil
+}
+
+// describeVMs перечисляет имена ВМ по алфавиту
+func describeVMs(names []string) string {
+	if len(names) == 0 {
+		return "no VMs"
+	}
+	names = slices.Sorted(slices.Values(names))
+	return fmt.Sprintf("%d VM(s): %s", len(names), strings.Join(names, ", "))
+}
+
+// planCreate проверяет, что ВМ с конфигурацией config можно создать, и описывает создание
+func planCreate(manager VMManagerInterface, config VMConfig) (string, error) {
+	if err := manager.ValidateCreate(config); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("would create VM '%s' with %s", config.Name, describeResources(config)), nil
+}
+
+// describeAuditEntry описывает запись журнала операций: операцию, ВМ и время
+func describeAuditEntry(entry AuditEntry) string {
+	target := fmt.Sprintf("VM '%s'", entry.VMName)
+	switch entry.Operation {
+	case AuditRename:
+		target = fmt.Sprintf("VM '%s' to '%s'", entry.VMName, entry.NewName)
+	case AuditSwap:
+		target = fmt.Sprintf("VMs '%s' and '%s'", entry.VMName, entry.NewName)
+	case AuditStart, AuditStop:
+		if len(entry.VMs) > 1 {
+			target = fmt.Sprintf("VMs %s", strings.Join(entry.VMs, ", "))
+		}
+	}
+	return fmt.Sprintf("%s of %s at %s", entry.Operation, target, entry.Time.Format(time.RFC3339))
+}
+
+// describeResources описывает заданные в конфигурации ресурсы ВМ
+func describeResources(config VMConfig) string {
+	var parts []string
+	if config.VCPUs != 0 {
+		parts = append(parts, fmt.Sprintf("%d vCPU(s)", config.VCPUs))
+	}
+	if config.Memory != 0 {
+		parts = append(parts, fmt.Sprintf("%d MB of memory", config.Memory))
+	}
+	if config.DiskSize != 0 {
+		parts = append(parts, fmt.Sprintf("a %d GB disk", config.DiskSize))
+	}
+	if len(parts) == 0 {
+		return "the default resources"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,120 @@
+package vm
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// noExportManager запрещает ExportVMYAML: пробный запуск должен читать ВМ напрямую
+type noExportManager struct {
+	VMManagerInterface
+	t *testing.T
+}
+
+func (m noExportManager) ExportVMYAML(name string) (string, error) {
+	m.t.Errorf("dry run exported VM '%s' to YAML", name)
+	return m.VMManagerInterface.ExportVMYAML(name)
+}
+
+func TestDryRunDoesNotChangeAnything(t *testing.T) {
+	m := newTestManager(t)
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+	tools := newTestTools(t, m)
+	before := len(m.AuditLog())
+
+	resp := callTool(t, tools, "create_vm", map[string]any{"name": "db", "memory": "2GB", "vcpus": 2, "dry_run": true})
+	if resp["success"] != true || resp["dry_run"] != true {
+		t.Fatalf("create_vm dry run = %v", resp)
+	}
+	if plan, _ := resp["plan"].(string); !strings.Contains(plan, "would create VM 'db'") {
+		t.Errorf("create_vm plan = %q", plan)
+	}
+	resp = callTool(t, tools, "stop_vm", map[string]any{"name": "web", "dry_run": true})
+	if plan, _ := resp["plan"].(string); !strings.Contains(plan, "would stop VM 'web' (currently running)") {
+		t.Errorf("stop_vm plan = %q", plan)
+	}
+
+	if _, err := m.LookupVM("db"); err == nil {
+		t.Error("create_vm dry run created the VM")
+	}
+	if got := stateOf(t, m, "web"); got != VMStateRunning {
+		t.Errorf("stop_vm dry run changed the state to %s", got)
+	}
+	if after := len(m.AuditLog()); after != before {
+		t.Errorf("dry runs added %d audit entries", after-before)
+	}
+}
+
+func TestDryRunReportsFailures(t *testing.T) {
+	m := newTestManager(t)
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+	tools := newTestTools(t, m)
+
+	resp := callTool(t, tools, "create_vm", map[string]any{"name": "web", "dry_run": true})
+	if resp["success"] != false {
+		t.Errorf("create_vm dry run for an existing name = %v, want a failure", resp)
+	}
+	resp = callTool(t, tools, "start_vm", map[string]any{"name": "missing", "dry_run": true})
+	if resp["success"] != false {
+		t.Errorf("start_vm dry run for a missing VM = %v, want a failure", resp)
+	}
+}
+
+func TestDryRunReadsVMsDirectly(t *testing.T) {
+	m := newTestManager(t, WithCaseInsensitiveNames(true))
+	mustCreate(t, m, VMConfig{Name: "Web", Memory: 1024, VCPUs: 1})
+	tools := newTestTools(t, noExportManager{VMManagerInterface: m, t: t})
+
+	resp := callTool(t, tools, "start_vm", map[string]any{"name": "web", "dry_run": true})
+	if plan, _ := resp["plan"].(string); !strings.Contains(plan, "already running") {
+		t.Errorf("start_vm dry run by another case = %v", resp)
+	}
+	resp = callTool(t, tools, "clone_vm", map[string]any{"source": "web", "target": "web-copy", "dry_run": true})
+	if resp["success"] != true {
+		t.Errorf("clone_vm dry run = %v", resp)
+	}
+}
+
+func TestDryRunRunsManagerChecks(t *testing.T) {
+	m := newTestManager(t)
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1, DiskPath: "/tmp/dry-web.qcow2", Network: "default"})
+	mustCreate(t, m, VMConfig{Name: "db", Memory: 1024, VCPUs: 1, Network: "default"})
+	if err := m.StopVM(context.Background(), "db"); err != nil {
+		t.Fatalf("StopVM: %v", err)
+	}
+	diskTools, err := NewDiskTools(m)
+	if err != nil {
+		t.Fatalf("NewDiskTools: %v", err)
+	}
+	tools := append(newTestTools(t, m), diskTools...)
+
+	tests := []struct {
+		tool string
+		args map[string]any
+		want string
+	}{
+		{"create_vm", map[string]any{"name": "cache", "memory": "1GB", "vcpus": 1, "disk_path": "/tmp/dry-web.qcow2"}, "is used by virtual machine 'web'"},
+		{"attach_disk", map[string]any{"name": "db", "path": "/tmp/dry-web.qcow2", "size": 10}, "is used by virtual machine 'web'"},
+		{"set_network_bandwidth", map[string]any{"name": "web", "inbound_kbps": 1000}, "must be stopped"},
+		{"set_disk_iops", map[string]any{"name": "db", "path": "/tmp/other.qcow2", "read_iops": 100}, "is not attached"},
+		{"set_cpu_pinning", map[string]any{"name": "db", "cpu_pinning": []map[string]any{{"vcpu": 4, "cpu": 0}}}, "vCPU 4"},
+		{"clone_vm", map[string]any{"source": "db", "target": "web", "vcpus": 2}, "already exists"},
+	}
+	for _, tt := range tests {
+		tt.args["dry_run"] = true
+		resp := callTool(t, tools, tt.tool, tt.args)
+		if errMsg, _ := resp["error"].(string); resp["success"] != false || !strings.Contains(errMsg, tt.want) {
+			t.Errorf("%s dry run = %v, want an error containing %q", tt.tool, resp, tt.want)
+		}
+	}
+
+	// Занятая ВМ отклоняется так же, как настоящим вызовом
+	m.mu.Lock()
+	m.vms["db"].busy = true
+	m.mu.Unlock()
+	resp := callTool(t, tools, "set_network_bandwidth", map[string]any{"name": "db", "inbound_kbps": 1000, "dry_run": true})
+	if resp["success"] != false {
+		t.Errorf("set_network_bandwidth dry run on a busy VM = %v, want a failure", resp)
+	}
+}
@@ -0,0 +1,50 @@
+package vm
+
+import "errors"
+
+// ErrVMBusy возвращается, если над ВМ уже выполняется длительная операция
+// (например, запуск), которая несовместима с запрошенной
+var ErrVMBusy = errors.New("virtual machine is busy")
+
+// ErrVMHasSnapshots возвращается при удалении ВМ со снапшотами без DeleteVMOptions.Force
+var ErrVMHasSnapshots = errors.New("virtual machine has snapshots")
+
+// ErrDiskInUse возвращается, если диск уже используется другой ВМ
+var ErrDiskInUse = errors.New("disk is already in use")
+
+// ErrVMLocked возвращается при попытке изменить, переименовать или удалить ВМ,
+// конфигурация которой заблокирована LockVMConfig
+var ErrVMLocked = errors.New("virtual machine configuration is locked")
+
+// ErrInsufficientSpace возвращается, если в пуле хранения не хватает места для снапшота
+var ErrInsufficientSpace = errors.New("insufficient storage space")
+
+// ErrVMExists возвращается, если ВМ с таким именем уже существует, в том числе если при
+// включенном WithCaseInsensitiveNames имя отличается от имени существующей только регистром
+var ErrVMExists = errors.New("virtual machine already exists")
+
+// ErrInvalidConfig - категория ошибок проверки конфигурации ВМ. Такие ошибки постоянны:
+// повтор операции с той же конфигурацией снова завершится ошибкой
+var ErrInvalidConfig = errors.New("invalid virtual machine configuration")
+
+// ErrTransient - категория временных сбоев бэкенда (например, занятый демон libvirt),
+// после которых операцию можно повторить (см. CreateVMWithRetry)
+var ErrTransient = errors.New("transient backend failure")
+
+// categorizedError - ошибка с категорией, не меняющей ее текст
+type categorizedError struct {
+	err      error
+	category error
+}
+
+func (e *categorizedError) Error() string   { return e.err.Error() }
+func (e *categorizedError) Unwrap() []error { return []error{e.err, e.category} }
+
+// withCategory помечает ошибку категорией (ErrInvalidConfig, ErrVMExists и т.д.) так, что
+// errors.Is(err, category) возвращает true, а текст ошибки остается прежним
+func withCategory(err, category error) error {
+	if err == nil {
+		return nil
+	}
+	return &categorizedError{err: err, category: category}
+}
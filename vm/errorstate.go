@@ -0,0 +1,84 @@
+package vm
+
+import (
+	"fmt"
+	"log"
+)
+
+// TransitionFailure вызывается перед сменой состояния ВМ (operation - "start", "stop",
+// "pause" и "resume" для FreezeAll, "transition" для TransitionVM или "restore" при
+// восстановлении снапшота, меняющем состояние ВМ).
+// Ошибка имитирует сбой бэкенда посреди перехода: ВМ переходит в VMStateError
+type TransitionFailure func(operation, name string) error
+
+// WithTransitionFailure задает имитацию сбоев при запуске и остановке ВМ (например, в тестах)
+func WithTransitionFailure(failure TransitionFailure) MockOption {
+	return func(m *MockVMManager) {
+		m.transitionFailure = failure
+	}
+}
+
+// checkTransitionLocked отклоняет переход ВМ в состоянии ошибки и переход в to, не
+// разрешенный правилами переходов, и применяет имитацию сбоя: при ошибке ВМ переводится
+// в VMStateError с причиной. Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) checkTransitionLocked(vm *MockVM, operation, name string, to VMState) error {
+	if vm.State == VMStateError {
+		return fmt.Errorf("virtual machine '%s' is in error state (%s): clear the error first", name, vm.ErrorReason)
+	}
+	if err := m.checkAllowedLocked(vm, operation, name, to); err != nil {
+		return err
+	}
+	if m.transitionFailure == nil {
+		return nil
+	}
+	if err := m.transitionFailure(operation, name); err != nil {
+		vm.State = VMStateError
+		vm.ErrorReason = fmt.Sprintf("%s failed: %v", operation, err)
+		log.Printf("[MOCK] Virtual machine '%s' entered error state: %s", name, vm.ErrorReason)
+		m.recordVMLocked(AuditError, name)
+		return fmt.Errorf("failed to %s virtual machine '%s': %w", operation, name, err)
+	}
+	return nil
+}
+
+// ClearError возвращает ВМ из состояния ошибки в остановленное состояние после
+// вмешательства оператора
+func (m *MockVMManager) ClearError(name string) error {
+	return m.runHooks("clear_error", name, func() error { return m.clearError(name) })
+}
+
+// clearError выполняет ClearError без хуков операций
+func (m *MockVMManager) clearError(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = m.resolveNameLocked(name)
+
+	vm, exists := m.vms[name]
+	if !exists {
+		return fmt.Errorf("virtual machine '%s' not found", name)
+	}
+	if vm.State != VMStateError {
+		return fmt.Errorf("virtual machine '%s' is not in error state (current state: %s)", name, vm.State)
+	}
+
+	vm.State = VMStateStopped
+	vm.CurrentMemoryMB = 0
+	vm.ErrorReason = ""
+	log.Printf("[MOCK] Error state of virtual machine '%s' cleared", name)
+	m.recordVMLocked(AuditClearError, name)
+	return nil
+}
+
+// noteResultLocked запоминает ошибку операции с ВМ в LastError или сбрасывает ее при
+// успехе; несуществующие ВМ пропускаются. Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) noteResultLocked(name string, err error) {
+	vm, exists := m.vms[name]
+	if !exists {
+		return
+	}
+	if err != nil {
+		vm.LastError = err.Error()
+	} else {
+		vm.LastError = ""
+	}
+}
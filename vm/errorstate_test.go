@@ -0,0 +1,42 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLastErrorSetOnFailedStartAndClearedOnSuccess(t *testing.T) {
+	backendErr := errors.New("no bootable device")
+	fail := true
+	m := newTestManager(t, WithAutoStartOnCreate(false), WithTransitionFailure(func(operation, name string) error {
+		if fail && operation == "start" {
+			return backendErr
+		}
+		return nil
+	}))
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+
+	if err := m.StartVM(context.Background(), "web"); !errors.Is(err, backendErr) {
+		t.Fatalf("StartVM = %v, want the backend error", err)
+	}
+	info, err := m.GetVMInfo("web")
+	if err != nil {
+		t.Fatalf("GetVMInfo: %v", err)
+	}
+	if !strings.Contains(info.LastError, backendErr.Error()) {
+		t.Errorf("LastError after a failed start = %q, want it to mention %q", info.LastError, backendErr)
+	}
+
+	fail = false
+	if err := m.ClearError("web"); err != nil {
+		t.Fatalf("ClearError: %v", err)
+	}
+	if err := m.StartVM(context.Background(), "web"); err != nil {
+		t.Fatalf("StartVM: %v", err)
+	}
+	if info, _ = m.GetVMInfo("web"); info.LastError != "" {
+		t.Errorf("LastError after a successful start = %q, want it cleared", info.LastError)
+	}
+}
@@ -0,0 +1,86 @@
+package vm
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// eventBufferSize - емкость канала каждой подписки; если подписчик не успевает читать,
+// новые события для него отбрасываются, не блокируя менеджер и других подписчиков
+const eventBufferSize = 64
+
+// VMEvent - событие об изменяющей операции с ВМ (тех же, что попадают в журнал операций)
+type VMEvent struct {
+	Time    time.Time      `json:"time"`
+	Type    AuditOperation `json:"type"`
+	VMName  string         `json:"vm_name"`
+	NewName string         `json:"new_name,omitempty"` // новое имя для AuditRename, вторая ВМ для AuditSwap
+}
+
+// eventBroker раздает события всем подпискам
+type eventBroker struct {
+	mu          sync.Mutex
+	subscribers map[chan VMEvent]struct{}
+}
+
+// subscribe добавляет подписку и возвращает ее канал и функцию отмены. Отмена
+// закрывает канал; повторные вызовы ничего не делают
+func (b *eventBroker) subscribe() (chan VMEvent, func()) {
+	ch := make(chan VMEvent, eventBufferSize)
+
+	b.mu.Lock()
+	if b.subscribers == nil {
+		b.subscribers = make(map[chan VMEvent]struct{})
+	}
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+
+			delete(b.subscribers, ch)
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
+
+// publish отправляет событие каждой подписке, не блокируясь на переполненных
+func (b *eventBroker) publish(event VMEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			log.Printf("[MOCK] Event subscriber is not keeping up, dropped %s event for virtual machine '%s'", event.Type, event.VMName)
+		}
+	}
+}
+
+// Subscribe создает отдельную подписку на события менеджера. Каждая подписка получает
+// все события; переполнение одной подписки не влияет на остальные. cancel отменяет
+// подписку и закрывает канал
+func (m *MockVMManager) Subscribe() (ch <-chan VMEvent, cancel func()) {
+	return m.broker.subscribe()
+}
+
+// Events возвращает канал общей подписки, создаваемой при первом вызове; события до
+// этого момента в нее не попадают. Канал должен читать один потребитель, для нескольких
+// потребителей используется Subscribe
+func (m *MockVMManager) Events() <-chan VMEvent {
+	m.eventsOnce.Do(func() {
+		m.events, _ = m.broker.subscribe()
+	})
+	return m.events
+}
+
+// publishLocked публикует событие о записи журнала. Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) publishLocked(entry AuditEntry) {
+	m.broker.publish(VMEvent{Time: entry.Time, Type: entry.Operation, VMName: entry.VMName, NewName: entry.NewName})
+}
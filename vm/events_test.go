@@ -0,0 +1,78 @@
+package vm
+
+import (
+	"testing"
+	"time"
+)
+
+// nextEvent возвращает следующее событие подписки или останавливает тест
+func nextEvent(t *testing.T, ch <-chan VMEvent) VMEvent {
+	t.Helper()
+	select {
+	case event := <-ch:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return VMEvent{}
+	}
+}
+
+func TestSubscribersEachReceiveEveryEvent(t *testing.T) {
+	m := newTestManager(t)
+	first, cancelFirst := m.Subscribe()
+	defer cancelFirst()
+	second, cancelSecond := m.Subscribe()
+	defer cancelSecond()
+
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+	if err := m.RenameVM("web", "api", RenameVMOptions{}); err != nil {
+		t.Fatalf("RenameVM: %v", err)
+	}
+
+	for _, ch := range []<-chan VMEvent{first, second} {
+		if event := nextEvent(t, ch); event.Type != AuditCreate || event.VMName != "web" {
+			t.Errorf("first event = %+v, want create of web", event)
+		}
+		if event := nextEvent(t, ch); event.Type != AuditRename || event.NewName != "api" {
+			t.Errorf("second event = %+v, want rename to api", event)
+		}
+	}
+}
+
+func TestSlowSubscriberDoesNotBlockOthers(t *testing.T) {
+	m := newTestManager(t, WithAutoStartOnCreate(false))
+	slow, cancelSlow := m.Subscribe()
+	defer cancelSlow()
+	fast, cancelFast := m.Subscribe()
+	defer cancelFast()
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+	nextEvent(t, fast)
+
+	// Медленный подписчик не читает: его буфер переполняется, а менеджер не блокируется
+	for i := range eventBufferSize + 10 {
+		label := string(rune('a' + i%26))
+		if err := m.AddLabelToVMs([]string{"web"}, "k", label)["web"]; err != nil {
+			t.Fatalf("AddLabelToVMs: %v", err)
+		}
+		nextEvent(t, fast)
+	}
+	if n := len(slow); n != eventBufferSize {
+		t.Errorf("slow subscriber has %d buffered events, want %d", n, eventBufferSize)
+	}
+}
+
+func TestCancelClosesSubscription(t *testing.T) {
+	m := newTestManager(t)
+	ch, cancel := m.Subscribe()
+	cancel()
+	cancel() // повторная отмена ничего не делает
+
+	if _, ok := <-ch; ok {
+		t.Error("channel is open after cancel")
+	}
+	// Публикация после отмены не паникует на закрытом канале
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+	if n := subscriberCount(m); n != 0 {
+		t.Errorf("%d subscriptions after cancel, want 0", n)
+	}
+}
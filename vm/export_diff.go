@@ -0,0 +1,117 @@
+package vm
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// VMChange - ВМ, конфигурация которой изменилась после экспорта
+type VMChange struct {
+	Name    string      `json:"name"`
+	Changes []FieldDiff `json:"changes"` // экспортированное (A) и текущее (B) значения
+}
+
+// InventoryDiff - изменения инвентаря после экспорта по типам.
+// Все списки отсортированы по имени ВМ
+type InventoryDiff struct {
+	Added     []string   `json:"added"`     // ВМ созданы после экспорта
+	Removed   []string   `json:"removed"`   // ВМ были в экспорте, но больше не существуют
+	Changed   []VMChange `json:"changed"`   // конфигурация ВМ изменилась
+	Unchanged []string   `json:"unchanged"` // конфигурация ВМ не изменилась
+}
+
+// ExportInventory возвращает конфигурации всех ВМ в виде JSON-документа инвентаря
+// ({"vms": [...]}, поля как в YAML), который читают ParseInventory, Reconcile и
+// DiffAgainstExport. ВМ отсортированы по имени
+func (m *MockVMManager) ExportInventory() ([]byte, error) {
+	m.mu.RLock()
+	inventory := Inventory{VMs: make([]VMConfig, 0, len(m.vms))}
+	for _, vm := range m.vms {
+		inventory.VMs = append(inventory.VMs, copyConfig(vm.Config))
+	}
+	m.mu.RUnlock()
+	sort.Slice(inventory.VMs, func(i, j int) bool { return inventory.VMs[i].Name < inventory.VMs[j].Name })
+
+	// VMConfig описывает поля только для YAML, поэтому JSON строится из YAML-представления
+	raw, err := yaml.Marshal(inventory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export inventory: %w", err)
+	}
+	var document any
+	if err := yaml.Unmarshal(raw, &document); err != nil {
+		return nil, fmt.Errorf("failed to export inventory: %w", err)
+	}
+	data, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to export inventory: %w", err)
+	}
+
+	log.Printf("[MOCK] Exported inventory of %d virtual machine(s)", len(inventory.VMs))
+	return data, nil
+}
+
+// DiffAgainstExport сравнивает текущие ВМ с документом, полученным ранее от
+// ExportInventory, и ничего не меняет. Конфигурации сравниваются так же, как в DiffVMs,
+// с учетом значений по умолчанию
+func (m *MockVMManager) DiffAgainstExport(data []byte) (InventoryDiff, error) {
+	inventory, err := ParseInventory(data)
+	if err != nil {
+		return InventoryDiff{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	diff := InventoryDiff{
+		Added:     []string{},
+		Removed:   []string{},
+		Changed:   []VMChange{},
+		Unchanged: []string{},
+	}
+	exported := make(map[string]bool, len(inventory.VMs))
+	for _, config := range inventory.VMs {
+		if config.Name == "" {
+			return InventoryDiff{}, fmt.Errorf("VM name cannot be empty")
+		}
+		key := config.Name
+		if m.caseInsensitiveNames {
+			key = strings.ToLower(key)
+		}
+		if exported[key] {
+			return InventoryDiff{}, fmt.Errorf("virtual machine '%s' is listed more than once", config.Name)
+		}
+		exported[key] = true
+
+		name := m.resolveNameLocked(config.Name)
+		vm, exists := m.vms[name]
+		if !exists {
+			diff.Removed = append(diff.Removed, config.Name)
+			continue
+		}
+		if changes := diffConfigs(m.applyDefaults(config), vm.Config); len(changes) > 0 {
+			diff.Changed = append(diff.Changed, VMChange{Name: name, Changes: changes})
+		} else {
+			diff.Unchanged = append(diff.Unchanged, name)
+		}
+	}
+	for name := range m.vms {
+		key := name
+		if m.caseInsensitiveNames {
+			key = strings.ToLower(key)
+		}
+		if !exported[key] {
+			diff.Added = append(diff.Added, name)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].Name < diff.Changed[j].Name })
+	sort.Strings(diff.Unchanged)
+	return diff, nil
+}
@@ -0,0 +1,136 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// VMFilter - условия отбора ВМ. Пустые поля не ограничивают выборку, заданные
+// условия должны выполняться одновременно; пустой фильтр подходит всем ВМ
+type VMFilter struct {
+	NameContains string            `json:"name_contains,omitempty"` // подстрока имени ВМ
+	State        VMState           `json:"state,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"` // все метки должны совпадать
+}
+
+// matches сообщает, подходит ли ВМ с именем name, состоянием state и метками labels
+// под фильтр
+func (f VMFilter) matches(name string, state VMState, labels map[string]string) bool {
+	if !strings.Contains(name, f.NameContains) {
+		return false
+	}
+	if f.State != "" && state != f.State {
+		return false
+	}
+	for key, value := range f.Labels {
+		if actual, ok := labels[key]; !ok || actual != value {
+			return false
+		}
+	}
+	return true
+}
+
+// RelabelBySelector устанавливает метки set и снимает метки unset на всех ВМ,
+// подходящих под selector. Возвращает отсортированные имена ВМ, метки которых
+// действительно изменились
+func (m *MockVMManager) RelabelBySelector(selector VMFilter, set map[string]string, unset []string) (affected []string, err error) {
+	err = m.runHooks("relabel", "", func() (err error) {
+		affected, err = m.relabelBySelector(selector, set, unset)
+		return err
+	})
+	return affected, err
+}
+
+// relabelBySelector выполняет RelabelBySelector без хуков операций
+func (m *MockVMManager) relabelBySelector(selector VMFilter, set map[string]string, unset []string) (affected []string, err error) {
+	if err := validateRelabel(set, unset); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for name, vm := range m.vms {
+		if !selector.matches(name, vm.State, vm.Config.Labels) {
+			continue
+		}
+
+		before := maps.Clone(vm.Config.Labels)
+		if len(set) > 0 && vm.Config.Labels == nil {
+			vm.Config.Labels = make(map[string]string, len(set))
+		}
+		maps.Copy(vm.Config.Labels, set)
+		for _, key := range unset {
+			delete(vm.Config.Labels, key)
+		}
+		if !maps.Equal(before, vm.Config.Labels) {
+			affected = append(affected, name)
+		}
+	}
+	sort.Strings(affected)
+	for _, name := range affected {
+		m.recordVMLocked(AuditLabels, name)
+	}
+
+	log.Printf("[MOCK] Relabeled %d virtual machine(s) by selector", len(affected))
+	return affected, nil
+}
+
+// validateRelabel проверяет метки, устанавливаемые и снимаемые RelabelBySelector
+func validateRelabel(set map[string]string, unset []string) error {
+	for key := range set {
+		if key == "" {
+			return fmt.Errorf("label key cannot be empty")
+		}
+		if slices.Contains(unset, key) {
+			return fmt.Errorf("label '%s' cannot be both set and unset", key)
+		}
+	}
+	return nil
+}
+
+// StopVMsNotMatching останавливает все ВМ, не подходящие под selector (например, все, кроме
+// ВМ с меткой env=prod); уже остановленные ВМ и ВМ в состоянии ошибки пропускаются.
+// Набор ВМ фиксируется в начале. Ошибка одной ВМ не прерывает остановку остальных:
+// возвращаются отсортированные имена остановленных ВМ и объединенные ошибки
+func (m *MockVMManager) StopVMsNotMatching(ctx context.Context, selector VMFilter) (stopped []string, err error) {
+	return stopVMsNotMatching(ctx, m, selector)
+}
+
+// stopVMsNotMatching реализует StopVMsNotMatching через методы manager, чтобы декораторы
+// (TimeoutManager) останавливали каждую ВМ собственным StopVM
+func stopVMsNotMatching(ctx context.Context, manager VMManagerInterface, selector VMFilter) (stopped []string, err error) {
+	var errs []error
+	for _, name := range notMatchingRunning(manager.Snapshot(), selector) {
+		if err := manager.StopVM(ctx, name); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop '%s': %w", name, err))
+			continue
+		}
+		stopped = append(stopped, name)
+	}
+
+	log.Printf("[MOCK] Stopped %d virtual machine(s) not matching selector", len(stopped))
+	return stopped, errors.Join(errs...)
+}
+
+// notMatchingRunning возвращает отсортированные имена запущенных и приостановленных ВМ,
+// не подходящих под selector
+func notMatchingRunning(infos []VMInfo, selector VMFilter) []string {
+	var targets []string
+	for _, info := range infos {
+		if selector.matches(info.Config.Name, info.State, info.Config.Labels) {
+			continue
+		}
+		if info.State == VMStateRunning || info.State == VMStatePaused {
+			targets = append(targets, info.Config.Name)
+		}
+	}
+	sort.Strings(targets)
+	return targets
+}
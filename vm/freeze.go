@@ -0,0 +1,92 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FreezeAll приостанавливает все запущенные ВМ (например, на время резервного копирования
+// хоста) и возвращает функцию, которая возобновляет ровно те ВМ, что были приостановлены
+// этим вызовом; ВМ, приостановленные ранее, остаются на паузе. Функция возобновления
+// выполняется только один раз, повторные вызовы ничего не делают.
+// Приостановка и возобновление проходят те же проверки, что и остальные переходы
+// (правила переходов и WithTransitionFailure с операциями "pause" и "resume"). Если
+// какие-то ВМ приостановить не удалось, FreezeAll возвращает ошибку вместе с функцией
+// возобновления для остальных. ВМ, уже не находящиеся на паузе, пропускаются; об
+// удаленных ВМ и неудачном возобновлении функция возобновления сообщает ошибкой.
+// Для хуков это операции "freeze_all" и "resume_all"; возобновление, отклоненное
+// хуком, не считается выполненным, и функцию можно вызвать снова
+func (m *MockVMManager) FreezeAll() (resume func() error, err error) {
+	err = m.runHooks("freeze_all", "", func() (err error) {
+		resume, err = m.freezeAll()
+		return err
+	})
+	return resume, err
+}
+
+// freezeAll выполняет FreezeAll без хуков операций
+func (m *MockVMManager) freezeAll() (resume func() error, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var frozen []*MockVM
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(m.vms)) {
+		vm := m.vms[name]
+		if vm.State != VMStateRunning {
+			continue
+		}
+		if err := m.checkTransitionLocked(vm, "pause", name, VMStatePaused); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		vm.State = VMStatePaused
+		frozen = append(frozen, vm)
+		log.Printf("[MOCK] Virtual machine '%s' paused", name)
+		m.recordVMLocked(AuditFreeze, name)
+	}
+
+	var once sync.Once
+	var resumeErr error
+	resume = func() error {
+		return m.runHooks("resume_all", "", func() error {
+			once.Do(func() {
+				m.mu.Lock()
+				defer m.mu.Unlock()
+
+				var missing []string
+				var errs []error
+				for _, vm := range frozen {
+					name := vm.Config.Name
+					if current, exists := m.vms[name]; !exists || current != vm {
+						missing = append(missing, name)
+						continue
+					}
+					if vm.State != VMStatePaused {
+						continue
+					}
+					if err := m.checkTransitionLocked(vm, "resume", name, VMStateRunning); err != nil {
+						errs = append(errs, err)
+						continue
+					}
+					vm.State = VMStateRunning
+					log.Printf("[MOCK] Virtual machine '%s' resumed", name)
+					m.recordVMLocked(AuditResume, name)
+				}
+				if len(missing) > 0 {
+					sort.Strings(missing)
+					errs = append(errs, fmt.Errorf("virtual machines removed while frozen were not resumed: %s", strings.Join(missing, ", ")))
+				}
+				resumeErr = errors.Join(errs...)
+			})
+			return resumeErr
+		})
+	}
+	return resume, errors.Join(errs...)
+}
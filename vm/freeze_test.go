@@ -0,0 +1,38 @@
+package vm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFreezeAllChecksTransitions(t *testing.T) {
+	failing := ""
+	m := newTestManager(t, WithTransitionFailure(func(operation, name string) error {
+		if operation+":"+name == failing {
+			return errors.New("hypervisor connection lost")
+		}
+		return nil
+	}))
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+	mustCreate(t, m, VMConfig{Name: "db", Memory: 1024, VCPUs: 1})
+
+	failing = "pause:db"
+	resume, err := m.FreezeAll()
+	if err == nil {
+		t.Fatal("FreezeAll with a failing pause returned no error")
+	}
+	if got := stateOf(t, m, "web"); got != VMStatePaused {
+		t.Errorf("web state = %s, want paused", got)
+	}
+	if got := stateOf(t, m, "db"); got != VMStateError {
+		t.Errorf("db state = %s, want error", got)
+	}
+
+	failing = "resume:web"
+	if err := resume(); err == nil {
+		t.Error("resume with a failing transition returned no error")
+	}
+	if got := stateOf(t, m, "web"); got != VMStateError {
+		t.Errorf("web state after failed resume = %s, want error", got)
+	}
+}
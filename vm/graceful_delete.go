@@ -0,0 +1,62 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DeleteVMGraceful удаляет ВМ, не прерывая работу гостевой ОС: запущенная ВМ сначала
+// корректно останавливается, и менеджер ждет ее выключения не дольше drain. Если ВМ не
+// выключилась за это время, она не удаляется и возвращается ошибка с
+// context.DeadlineExceeded. Снапшоты и связанные клоны проверяются до остановки, как в
+// DeleteVM без Force, поэтому ВМ, которую нельзя удалить, не останавливается зря
+func (m *MockVMManager) DeleteVMGraceful(ctx context.Context, name string, drain time.Duration) error {
+	return m.runHooks("delete", name, func() error { return m.deleteVMGraceful(ctx, name, drain) })
+}
+
+// deleteVMGraceful выполняет DeleteVMGraceful без хуков операций
+func (m *MockVMManager) deleteVMGraceful(ctx context.Context, name string, drain time.Duration) (err error) {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if drain < 0 {
+		return errors.New("drain timeout must not be negative")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = m.resolveNameLocked(name)
+
+	if err := m.checkDeletableLocked(name, DeleteVMOptions{}); err != nil {
+		return err
+	}
+	if m.vms[name].State == VMStateRunning {
+		if err := m.stopGracefullyLocked(ctx, name, drain); err != nil {
+			return fmt.Errorf("failed to stop virtual machine '%s' before deletion: %w", name, err)
+		}
+	}
+
+	if err := m.deleteVMLocked(name, DeleteVMOptions{}); err != nil {
+		return err
+	}
+	m.recordLocked(AuditEntry{Operation: AuditDelete, VMName: name})
+	return nil
+}
+
+// stopGracefullyLocked дожидается выключения ВМ не дольше drain и останавливает ее,
+// записывая остановку в журнал операций. Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) stopGracefullyLocked(ctx context.Context, name string, drain time.Duration) (err error) {
+	defer func() { m.noteResultLocked(name, err) }()
+
+	if err := m.waitStopLocked(ctx, name, drain); err != nil {
+		return err
+	}
+	before := m.statesLocked()
+	if err := m.stopVMLocked(name); err != nil {
+		return err
+	}
+	m.recordTransitionLocked(AuditStop, name, []string{name}, before)
+	return nil
+}
@@ -0,0 +1,68 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDeleteVMGracefulStopsFirst(t *testing.T) {
+	m := newTestManager(t, WithSimulatedStopDelay(10*time.Millisecond))
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+
+	if err := m.DeleteVMGraceful(context.Background(), "web", time.Second); err != nil {
+		t.Fatalf("DeleteVMGraceful: %v", err)
+	}
+	if _, err := m.LookupVM("web"); err == nil {
+		t.Error("VM still exists after a graceful delete")
+	}
+	log := m.AuditLog()
+	if len(log) < 2 || log[len(log)-2].Operation != AuditStop || log[len(log)-1].Operation != AuditDelete {
+		t.Errorf("audit log = %v, want a stop followed by a delete", log)
+	}
+}
+
+func TestDeleteVMGracefulKeepsVMThatDoesNotShutDown(t *testing.T) {
+	m := newTestManager(t, WithSimulatedStopDelay(10*time.Second))
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+
+	err := m.DeleteVMGraceful(context.Background(), "web", 20*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("DeleteVMGraceful = %v, want context.DeadlineExceeded", err)
+	}
+	if got := stateOf(t, m, "web"); got != VMStateRunning {
+		t.Errorf("state = %s, want the VM left running", got)
+	}
+}
+
+func TestDeleteVMGracefulChecksSnapshotsBeforeStopping(t *testing.T) {
+	m := newTestManager(t)
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+	if err := m.CreateSnapshot("web", "before", ""); err != nil {
+		t.Fatalf("CreateSnapshot: %v", err)
+	}
+
+	if err := m.DeleteVMGraceful(context.Background(), "web", time.Second); !errors.Is(err, ErrVMHasSnapshots) {
+		t.Fatalf("DeleteVMGraceful = %v, want ErrVMHasSnapshots", err)
+	}
+	if got := stateOf(t, m, "web"); got != VMStateRunning {
+		t.Errorf("state = %s, want the VM not stopped", got)
+	}
+}
+
+func TestDeleteVMGracefulMarksVMBusy(t *testing.T) {
+	m := newTestManager(t, WithSimulatedStopDelay(200*time.Millisecond))
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+
+	done := make(chan error, 1)
+	go func() { done <- m.DeleteVMGraceful(context.Background(), "web", time.Second) }()
+	waitBusy(t, m, "web")
+
+	if err := m.DeleteVM(context.Background(), "web", DeleteVMOptions{}); !errors.Is(err, ErrVMBusy) {
+		t.Errorf("DeleteVM during a graceful shutdown = %v, want ErrVMBusy", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("DeleteVMGraceful: %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+package vm
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// dotStateColors - цвета узлов графа зависимостей по состоянию ВМ
+var dotStateColors = map[VMState]string{
+	VMStateRunning: "palegreen",
+	VMStateStopped: "lightgray",
+	VMStatePaused:  "khaki",
+	VMStateError:   "salmon",
+}
+
+// DependencyGraphDOT возвращает граф зависимостей между ВМ в формате Graphviz DOT.
+// Ребро a -> b означает, что a зависит от b; узлы окрашены по состоянию ВМ,
+// отсутствующие зависимости показаны пунктиром, а ребра, входящие в цикл, выделены
+// красным и подписаны "cycle"
+func (m *MockVMManager) DependencyGraphDOT() (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.vms))
+	for name := range m.vms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("digraph dependencies {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=filled];\n")
+
+	missing := make(map[string]bool)
+	var edges strings.Builder
+	cycles := 0
+	for _, name := range names {
+		for _, dep := range m.vms[name].Config.DependsOn {
+			if _, exists := m.vms[dep]; !exists {
+				missing[dep] = true
+			}
+			if m.reachableLocked(dep, name) {
+				cycles++
+				fmt.Fprintf(&edges, "  %s -> %s [color=red, fontcolor=red, label=\"cycle\"];\n", strconv.Quote(name), strconv.Quote(dep))
+				continue
+			}
+			fmt.Fprintf(&edges, "  %s -> %s;\n", strconv.Quote(name), strconv.Quote(dep))
+		}
+	}
+
+	for _, name := range names {
+		state := m.vms[name].State
+		fmt.Fprintf(&b, "  %s [fillcolor=%s, tooltip=%s];\n", strconv.Quote(name), dotStateColors[state], strconv.Quote(string(state)))
+	}
+	missingNames := make([]string, 0, len(missing))
+	for name := range missing {
+		missingNames = append(missingNames, name)
+	}
+	sort.Strings(missingNames)
+	for _, name := range missingNames {
+		fmt.Fprintf(&b, "  %s [style=dashed, color=red, tooltip=\"missing\"];\n", strconv.Quote(name))
+	}
+	b.WriteString(edges.String())
+	if cycles > 0 {
+		fmt.Fprintf(&b, "  label=\"dependency cycles detected (%d edges)\";\n", cycles)
+		b.WriteString("  fontcolor=red;\n")
+	}
+	b.WriteString("}\n")
+	return b.String(), nil
+}
+
+// reachableLocked сообщает, достижима ли ВМ to из from по ребрам DependsOn.
+// Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) reachableLocked(from, to string) bool {
+	visited := make(map[string]bool)
+	stack := []string{from}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if n == to {
+			return true
+		}
+		if visited[n] {
+			continue
+		}
+		visited[n] = true
+		if vm, exists := m.vms[n]; exists {
+			stack = append(stack, vm.Config.DependsOn...)
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Capabilities - необязательные возможности бэкенда
+type Capabilities struct {
+	GuestAgent bool // выполнение команд и работа с файлами внутри гостевой ОС
+	// ReadOnly - бэкенд только показывает ВМ: создание, изменение и удаление недоступны.
+	// Mock-менеджер лишь сообщает об этом, сами операции по-прежнему выполняются
+	ReadOnly bool
+}
+
+// WithCapabilities задает возможности, о которых сообщает mock-менеджер.
+// По умолчанию гостевой агент недоступен
+func WithCapabilities(capabilities Capabilities) MockOption {
+	return func(m *MockVMManager) {
+		m.capabilities = capabilities
+	}
+}
+
+// Capabilities возвращает возможности mock-менеджера
+func (m *MockVMManager) Capabilities() Capabilities {
+	return m.capabilities
+}
+
+// RunGuestCommand возвращает заготовленный ответ вместо реального выполнения команды.
+// Требует включенной возможности GuestAgent и запущенной ВМ
+func (m *MockVMManager) RunGuestCommand(ctx context.Context, name, command string, args []string) (stdout, stderr string, exit int, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	name = m.resolveNameLocked(name)
+
+	if _, err := m.guestVMLocked(ctx, name); err != nil {
+		return "", "", 0, err
+	}
+
+	cmdline := strings.TrimSpace(command + " " + strings.Join(args, " "))
+	log.Printf("[MOCK] Guest command on '%s': %s", name, cmdline)
+	return fmt.Sprintf("[mock] executed: %s\n", cmdline), "", 0, nil
+}
+
+// WriteGuestFile сохраняет файл в памяти mock-ВМ
+func (m *MockVMManager) WriteGuestFile(ctx context.Context, name, path string, content []byte) error {
+	return m.runHooks("write_guest_file", name, func() error { return m.writeGuestFile(ctx, name, path, content) })
+}
+
+// writeGuestFile выполняет WriteGuestFile без хуков операций
+func (m *MockVMManager) writeGuestFile(ctx context.Context, name, path string, content []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = m.resolveNameLocked(name)
+
+	vm, err := m.guestVMLocked(ctx, name)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		return fmt.Errorf("guest file path cannot be empty")
+	}
+
+	if vm.guestFiles == nil {
+		vm.guestFiles = make(map[string][]byte)
+	}
+	vm.guestFiles[path] = append([]byte(nil), content...)
+	log.Printf("[MOCK] Wrote %d byte(s) to '%s' on '%s'", len(content), path, name)
+	m.recordVMLocked(AuditWriteGuestFile, name)
+	return nil
+}
+
+// ReadGuestFile возвращает файл, ранее записанный в mock-ВМ
+func (m *MockVMManager) ReadGuestFile(ctx context.Context, name, path string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	name = m.resolveNameLocked(name)
+
+	vm, err := m.guestVMLocked(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	content, exists := vm.guestFiles[path]
+	if !exists {
+		return nil, fmt.Errorf("guest file '%s' not found on virtual machine '%s'", path, name)
+	}
+	return append([]byte(nil), content...), nil
+}
+
+// guestVMLocked проверяет доступность гостевого агента и возвращает запущенную ВМ.
+// Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) guestVMLocked(ctx context.Context, name string) (*MockVM, error) {
+	if !m.capabilities.GuestAgent {
+		return nil, fmt.Errorf("guest agent is not available on the %s backend", m.BackendType())
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	vm, exists := m.vms[name]
+	if !exists {
+		return nil, fmt.Errorf("virtual machine '%s' not found", name)
+	}
+	if vm.State != VMStateRunning {
+		return nil, fmt.Errorf("virtual machine '%s' is not running", name)
+	}
+	return vm, nil
+}
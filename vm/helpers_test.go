@@ -0,0 +1,106 @@
+package vm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/tool"
+)
+
+// testToolContext - tool.Context для вызова инструментов в тестах: методы context.Context
+// берутся из ctx, остальные обработчикам инструментов не нужны
+type testToolContext struct {
+	tool.Context
+	ctx context.Context
+}
+
+func (c testToolContext) Deadline() (time.Time, bool) { return c.ctx.Deadline() }
+func (c testToolContext) Done() <-chan struct{}       { return c.ctx.Done() }
+func (c testToolContext) Err() error                  { return c.ctx.Err() }
+func (c testToolContext) Value(key any) any           { return c.ctx.Value(key) }
+
+// runnableTool - инструмент functiontool, который можно вызвать напрямую
+type runnableTool interface {
+	Run(ctx tool.Context, args any) (map[string]any, error)
+}
+
+// newTestManager создает mock-менеджер, который закрывается по окончании теста
+func newTestManager(t *testing.T, opts ...MockOption) *MockVMManager {
+	t.Helper()
+	m := NewMockVMManager(opts...)
+	t.Cleanup(func() { m.Close() })
+	return m
+}
+
+// newTestTools создает инструменты для менеджера
+func newTestTools(t *testing.T, manager VMManagerInterface) []tool.Tool {
+	t.Helper()
+	tools, err := NewVMTools(manager)
+	if err != nil {
+		t.Fatalf("NewVMTools: %v", err)
+	}
+	return tools
+}
+
+// callTool вызывает инструмент name с аргументами args и возвращает его ответ
+// (поля ToolResponse: success, data, error, dry_run, plan)
+func callTool(t *testing.T, tools []tool.Tool, name string, args map[string]any) map[string]any {
+	t.Helper()
+	for _, tl := range tools {
+		if tl.Name() != name {
+			continue
+		}
+		runnable, ok := tl.(runnableTool)
+		if !ok {
+			t.Fatalf("tool %s cannot be run directly", name)
+		}
+		if args == nil {
+			args = map[string]any{}
+		}
+		result, err := runnable.Run(testToolContext{ctx: context.Background()}, args)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		return result
+	}
+	t.Fatalf("tool %s is not registered", name)
+	return nil
+}
+
+// mustCreate создает ВМ и останавливает тест при ошибке
+func mustCreate(t *testing.T, m VMManagerInterface, config VMConfig) {
+	t.Helper()
+	if err := m.CreateVM(context.Background(), config); err != nil {
+		t.Fatalf("CreateVM(%s): %v", config.Name, err)
+	}
+}
+
+// stateOf возвращает состояние ВМ и останавливает тест, если ВМ нет
+func stateOf(t *testing.T, m *MockVMManager, name string) VMState {
+	t.Helper()
+	for _, info := range m.Snapshot() {
+		if info.Config.Name == name {
+			return info.State
+		}
+	}
+	t.Fatalf("virtual machine '%s' not found", name)
+	return ""
+}
+
+// waitBusy ждет, пока над ВМ не начнется длительная операция
+func waitBusy(t *testing.T, m *MockVMManager, name string) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); ; {
+		m.mu.RLock()
+		busy := m.vms[name] != nil && m.vms[name].busy
+		m.mu.RUnlock()
+		if busy {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("virtual machine '%s' never became busy", name)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
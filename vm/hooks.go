@@ -0,0 +1,47 @@
+package vm
+
+import "slices"
+
+// OperationHook оборачивает изменяющую операцию менеджера: op - имя операции, vmName -
+// ВМ, к которой она относится (для клонирования - источник, для обмена имен - первая ВМ),
+// next выполняет оставшуюся часть цепочки и саму операцию. Хук может выполнить код до и
+// после next, изменить возвращаемую ошибку или отклонить операцию, не вызывая next.
+//
+// Операции с одной ВМ: "create", "start", "start_with_deps", "stop", "restart" (для
+// каждой ВМ RestartAllRunning), "delete", "rename", "swap_names", "clone", "update_config",
+// "transition", "clear_error", "lock_config", "unlock_config", "set_cpu_pinning",
+// "set_next_boot", "set_boot_timeout", "add_label", "remove_label" (для каждой ВМ
+// AddLabelToVMs и RemoveLabelFromVMs), "create_snapshot", "restore_snapshot",
+// "delete_snapshot", "rename_snapshot", "prune_snapshots", "schedule_snapshots",
+// "set_snapshot_space_estimate", "attach_disk", "detach_disk", "resize_disk",
+// "compact_disk", "set_disk_iops", "attach_iso", "set_memory_balloon",
+// "set_network_bandwidth", "write_guest_file" и "undo" (ВМ последней записи журнала).
+//
+// Операции с несколькими ВМ, для которых vmName пуст: "rename_label_key", "relabel",
+// "freeze_all", "resume_all" (функция возобновления FreezeAll) и "populate_random"
+type OperationHook func(op string, vmName string, next func() error) error
+
+// Use добавляет хук в цепочку, окружающую каждую изменяющую операцию. Хуки
+// выполняются в порядке добавления: первый добавленный - самый внешний, до next он
+// срабатывает первым, после next - последним. Хуки вызываются без удержания
+// блокировки менеджера, поэтому внутри них можно обращаться к менеджеру
+func (m *MockVMManager) Use(hook OperationHook) {
+	m.hooksMu.Lock()
+	defer m.hooksMu.Unlock()
+
+	m.hooks = append(m.hooks, hook)
+}
+
+// runHooks выполняет operation внутри цепочки хуков
+func (m *MockVMManager) runHooks(op, vmName string, operation func() error) error {
+	m.hooksMu.Lock()
+	hooks := slices.Clone(m.hooks)
+	m.hooksMu.Unlock()
+
+	next := operation
+	for _, hook := range slices.Backward(hooks) {
+		inner := next
+		next = func() error { return hook(op, vmName, inner) }
+	}
+	return next()
+}
@@ -0,0 +1,101 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestHooksCoverEveryMutation(t *testing.T) {
+	m := newTestManager(t)
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 2})
+	if err := m.StartVM(context.Background(), "web"); err != nil {
+		t.Fatalf("StartVM: %v", err)
+	}
+
+	rejected := errors.New("rejected by policy")
+	var got string
+	m.Use(func(op, vmName string, next func() error) error {
+		got = op + ":" + vmName
+		return rejected
+	})
+
+	tests := []struct {
+		want string
+		call func() error
+	}{
+		{"set_cpu_pinning:web", func() error { return m.SetCPUPinning("web", nil) }},
+		{"set_next_boot:web", func() error { return m.SetNextBoot("web", "cdrom") }},
+		{"set_boot_timeout:web", func() error { return m.SetBootTimeout("web", 0) }},
+		{"lock_config:web", func() error { return m.LockVMConfig("web") }},
+		{"unlock_config:web", func() error { return m.UnlockVMConfig("web") }},
+		{"add_label:web", func() error { return m.AddLabelToVMs([]string{"web"}, "env", "prod")["web"] }},
+		{"remove_label:web", func() error { return m.RemoveLabelFromVMs([]string{"web"}, "env")["web"] }},
+		{"rename_label_key:", func() error { _, err := m.RenameLabelKey("env", "stage"); return err }},
+		{"relabel:", func() error {
+			_, err := m.RelabelBySelector(VMFilter{}, map[string]string{"env": "prod"}, nil)
+			return err
+		}},
+		{"clear_error:web", func() error { return m.ClearError("web") }},
+		{"freeze_all:", func() error { _, err := m.FreezeAll(); return err }},
+		{"restart:web", func() error { return m.RestartAllRunning()["web"] }},
+		{"start_with_deps:web", func() error { _, err := m.StartVMWithDeps(context.Background(), "web"); return err }},
+		{"write_guest_file:web", func() error {
+			return m.WriteGuestFile(context.Background(), "web", "/etc/motd", []byte("hi"))
+		}},
+		{"transition:web", func() error { return m.TransitionVM("web", VMStatePaused) }},
+		{"set_snapshot_space_estimate:web", func() error { return m.SetSnapshotSpaceEstimate("web", 1) }},
+		{"undo:web", m.UndoLast},
+		{"populate_random:", func() error { return m.PopulateRandom(context.Background(), 1, 1) }},
+	}
+	for _, tt := range tests {
+		got = ""
+		if err := tt.call(); !errors.Is(err, rejected) {
+			t.Errorf("%s: error = %v, want the hook error", tt.want, err)
+		}
+		if got != tt.want {
+			t.Errorf("hook saw %q, want %q", got, tt.want)
+		}
+	}
+	if state := stateOf(t, m, "web"); state != VMStateRunning {
+		t.Errorf("state after rejected operations = %s, want %s", state, VMStateRunning)
+	}
+	if len(m.Snapshot()) != 1 {
+		t.Errorf("VMs after rejected operations = %d, want 1", len(m.Snapshot()))
+	}
+}
+
+func TestRejectedResumeCanBeRetried(t *testing.T) {
+	m := newTestManager(t)
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+	if err := m.StartVM(context.Background(), "web"); err != nil {
+		t.Fatalf("StartVM: %v", err)
+	}
+	resume, err := m.FreezeAll()
+	if err != nil {
+		t.Fatalf("FreezeAll: %v", err)
+	}
+
+	rejected := errors.New("rejected by policy")
+	reject := true
+	m.Use(func(op, vmName string, next func() error) error {
+		if reject && op == "resume_all" {
+			return rejected
+		}
+		return next()
+	})
+	if err := resume(); !errors.Is(err, rejected) {
+		t.Fatalf("resume with a rejecting hook = %v, want the hook error", err)
+	}
+	if state := stateOf(t, m, "web"); state != VMStatePaused {
+		t.Fatalf("state after a rejected resume = %s, want %s", state, VMStatePaused)
+	}
+
+	reject = false
+	if err := resume(); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if state := stateOf(t, m, "web"); state != VMStateRunning {
+		t.Errorf("state after resume = %s, want %s", state, VMStateRunning)
+	}
+}
@@ -0,0 +1,88 @@
+package vm
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+)
+
+// VMInfo - копия ВМ на момент вызова Snapshot. Не разделяет память с менеджером:
+// ее можно читать и изменять без блокировок
+type VMInfo struct {
+	Config          VMConfig
+	State           VMState
+	Snapshots       []SnapshotInfo // в порядке создания
+	LinkedSource    string
+	CurrentMemoryMB uint64
+	ErrorReason     string
+	LastError       string
+	Host            string
+	BootDevice      string
+	BootTimeout     time.Duration
+	Locked          bool
+}
+
+// Snapshot возвращает согласованную копию всех ВМ на один момент времени, отсортированную
+// по имени. Блокировка менеджера удерживается только на время копирования, поэтому
+// вызывающий код может обходить результат (например, формируя отчет), не мешая
+// изменяющим операциям и не видя их промежуточных результатов
+func (m *MockVMManager) Snapshot() []VMInfo {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.snapshotLocked()
+}
+
+// snapshotLocked реализует Snapshot; вызывающий код должен удерживать m.mu
+func (m *MockVMManager) snapshotLocked() []VMInfo {
+	vms := make([]VMInfo, 0, len(m.vms))
+	for _, name := range slices.Sorted(maps.Keys(m.vms)) {
+		vms = append(vms, m.vms[name].info())
+	}
+	return vms
+}
+
+// LookupVM возвращает копию одной ВМ в том же виде, что и Snapshot
+func (m *MockVMManager) LookupVM(name string) (VMInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	name = m.resolveNameLocked(name)
+
+	vm, exists := m.vms[name]
+	if !exists {
+		return VMInfo{}, fmt.Errorf("virtual machine '%s' not found", name)
+	}
+	return vm.info(), nil
+}
+
+// info копирует ВМ в VMInfo; вызывающий код должен удерживать m.mu
+func (vm *MockVM) info() VMInfo {
+	info := VMInfo{
+		Config:          copyConfig(vm.Config),
+		State:           vm.State,
+		Snapshots:       make([]SnapshotInfo, 0, len(vm.Snapshots)),
+		LinkedSource:    vm.LinkedSource,
+		CurrentMemoryMB: vm.CurrentMemoryMB,
+		ErrorReason:     vm.ErrorReason,
+		LastError:       vm.LastError,
+		Host:            vm.Host,
+		BootDevice:      vm.BootDevice,
+		BootTimeout:     vm.BootTimeout,
+		Locked:          vm.Locked,
+	}
+	for _, snap := range vm.Snapshots {
+		info.Snapshots = append(info.Snapshots, snap.SnapshotInfo)
+	}
+	return info
+}
+
+// usageOf возвращает ресурсы, выделенные ВМ из Snapshot
+func usageOf(vms []VMInfo) Resources {
+	usage := Resources{VMs: len(vms)}
+	for _, vm := range vms {
+		usage.MemoryMB += vm.Config.Memory
+		usage.VCPUs += vm.Config.VCPUs
+		usage.DiskGB += configDiskGB(vm.Config)
+	}
+	return usage
+}
@@ -0,0 +1,76 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestSnapshotIsIndependentCopy(t *testing.T) {
+	m := newTestManager(t)
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1, Labels: map[string]string{"env": "prod"}})
+	if err := m.CreateSnapshot("web", "before", ""); err != nil {
+		t.Fatalf("CreateSnapshot: %v", err)
+	}
+
+	vms := m.Snapshot()
+	vms[0].Config.Labels["env"] = "changed"
+	vms[0].Config.Memory = 1
+	vms[0].Snapshots[0].Name = "changed"
+
+	info, _ := m.LookupVM("web")
+	if info.Config.Labels["env"] != "prod" || info.Config.Memory != 1024 || info.Snapshots[0].Name != "before" {
+		t.Errorf("changing the snapshot changed the manager: %+v", info)
+	}
+}
+
+func TestSnapshotDuringMutations(t *testing.T) {
+	m := newTestManager(t)
+	ctx := context.Background()
+	for i := range 5 {
+		mustCreate(t, m, VMConfig{Name: fmt.Sprintf("vm%d", i), Memory: 1024, VCPUs: 1})
+	}
+
+	var wg sync.WaitGroup
+	for i := range 5 {
+		name := fmt.Sprintf("vm%d", i)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for range 20 {
+				if err := m.StopVM(ctx, name); err != nil {
+					t.Errorf("StopVM: %v", err)
+				}
+				if err := m.AddLabelToVMs([]string{name}, "touched", "yes")[name]; err != nil {
+					t.Errorf("AddLabelToVMs: %v", err)
+				}
+				if err := m.StartVM(ctx, name); err != nil {
+					t.Errorf("StartVM: %v", err)
+				}
+			}
+		}()
+		// Отчеты строятся по снимку и обходят его без блокировки менеджера
+		go func() {
+			defer wg.Done()
+			for range 20 {
+				for _, vm := range m.Snapshot() {
+					_ = vm.Config.Labels["touched"]
+				}
+				if _, err := m.InventoryReport(); err != nil {
+					t.Errorf("InventoryReport: %v", err)
+				}
+				if _, err := m.ResourceTable(); err != nil {
+					t.Errorf("ResourceTable: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, vm := range m.Snapshot() {
+		if vm.State != VMStateRunning || vm.Config.Labels["touched"] != "yes" {
+			t.Errorf("%s after mutations: state %s, labels %v", vm.Config.Name, vm.State, vm.Config.Labels)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// InventoryKey - ключ результата ValidateInventory для проблем инвентаря в целом
+// (повторяющиеся имена, общие диски, квоты). Пустое имя ВМ всегда недопустимо, поэтому
+// ключ не совпадает с именем ВМ
+const InventoryKey = ""
+
+// ValidateInventory проверяет предлагаемый набор ВМ целиком, ничего не меняя, - как
+// предварительная проверка перед Reconcile. Каждая конфигурация проверяется так же, как в
+// ValidateVMConfigFull, и по ограничениям на одну ВМ; затем набор проверяется в целом:
+// повторяющиеся имена, диски, используемые несколькими ВМ (в том числе существующими ВМ
+// вне набора), и квоты с учетом существующих ВМ, которые набор не заменяет (ВМ с тем же
+// именем считается заменяемой). Результат: имя ВМ -> ее проблемы, а проблемы набора - под
+// ключом InventoryKey. Пустой результат означает, что набор можно применять
+func (m *MockVMManager) ValidateInventory(configs []VMConfig) map[string][]error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	problems := make(map[string][]error)
+	add := func(key string, err error) {
+		problems[key] = append(problems[key], err)
+	}
+
+	proposed := make([]VMConfig, 0, len(configs))
+	replaced := make(map[string]bool)
+	seen := make(map[string]bool)
+	for i, config := range configs {
+		config = m.applyDefaults(config)
+		if config.Name == "" {
+			add(InventoryKey, fmt.Errorf("vms[%d]: VM name cannot be empty", i))
+			continue
+		}
+		key := config.Name
+		if m.caseInsensitiveNames {
+			key = strings.ToLower(key)
+		}
+		if seen[key] {
+			add(InventoryKey, fmt.Errorf("virtual machine '%s' is listed more than once", config.Name))
+			continue
+		}
+		seen[key] = true
+
+		for _, err := range m.configErrors(config) {
+			add(config.Name, err)
+		}
+		if reason := m.perVMLimitReasonLocked(config); reason != "" {
+			add(config.Name, errors.New(reason))
+		}
+		if existing := m.resolveNameLocked(config.Name); m.vms[existing] != nil {
+			replaced[existing] = true
+		}
+		proposed = append(proposed, config)
+	}
+
+	for _, err := range m.inventoryDiskConflictsLocked(proposed, replaced) {
+		add(InventoryKey, err)
+	}
+	for _, err := range m.inventoryQuotaErrorsLocked(proposed, replaced) {
+		add(InventoryKey, err)
+	}
+	return problems
+}
+
+// inventoryDiskConflictsLocked возвращает ErrDiskInUse для каждого диска, который после
+// применения набора использовали бы несколько ВМ, не помечая его Shared у всех.
+// Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) inventoryDiskConflictsLocked(proposed []VMConfig, replaced map[string]bool) []error {
+	configs := append([]VMConfig(nil), proposed...)
+	for name, vm := range m.vms {
+		if !replaced[name] {
+			configs = append(configs, vm.Config)
+		}
+	}
+
+	owners := make(map[string][]VMConfig)
+	for _, config := range configs {
+		for _, path := range diskPaths(config) {
+			path = filepath.Clean(path)
+			if list := owners[path]; len(list) > 0 && list[len(list)-1].Name == config.Name {
+				continue
+			}
+			owners[path] = append(owners[path], config)
+		}
+	}
+
+	var errs []error
+	for _, path := range slices.Sorted(maps.Keys(owners)) {
+		list := owners[path]
+		if len(list) < 2 {
+			continue
+		}
+		names := make([]string, 0, len(list))
+		shared := true
+		for _, config := range list {
+			names = append(names, config.Name)
+			shared = shared && sharesDisk(config, path)
+		}
+		if shared {
+			continue
+		}
+		sort.Strings(names)
+		errs = append(errs, fmt.Errorf("disk '%s' would be used by several virtual machines (%s) without being shared by all of them: %w", path, strings.Join(names, ", "), ErrDiskInUse))
+	}
+	return errs
+}
+
+// inventoryQuotaErrorsLocked проверяет, что набор вместе с существующими ВМ, которые он
+// не заменяет, и активными резервированиями помещается в квоты и пул хранения.
+// Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) inventoryQuotaErrorsLocked(proposed []VMConfig, replaced map[string]bool) []error {
+	usage := m.quotaUsageLocked()
+	storage := m.storageUsedGBLocked()
+	for name := range replaced {
+		config := m.vms[name].Config
+		usage.VMs--
+		usage.MemoryMB -= config.Memory
+		usage.VCPUs -= config.VCPUs
+		storage -= configDiskGB(config)
+	}
+	for _, config := range proposed {
+		usage.VMs++
+		usage.MemoryMB += config.Memory
+		usage.VCPUs += config.VCPUs
+		storage += configDiskGB(config)
+	}
+
+	l := m.limits
+	var errs []error
+	if l.MaxVMs > 0 && usage.VMs > l.MaxVMs {
+		errs = append(errs, fmt.Errorf("VM quota exceeded: the inventory needs %d VMs, %d allowed", usage.VMs, l.MaxVMs))
+	}
+	if l.MaxMemoryMB > 0 && usage.MemoryMB > l.MaxMemoryMB {
+		errs = append(errs, fmt.Errorf("memory quota exceeded: the inventory needs %d MB, %d MB allowed", usage.MemoryMB, l.MaxMemoryMB))
+	}
+	if l.MaxVCPUs > 0 && usage.VCPUs > l.MaxVCPUs {
+		errs = append(errs, fmt.Errorf("VCPU quota exceeded: the inventory needs %d VCPUs, %d allowed", usage.VCPUs, l.MaxVCPUs))
+	}
+	if l.StoragePoolGB > 0 && storage > l.StoragePoolGB {
+		errs = append(errs, fmt.Errorf("insufficient storage: the inventory needs %d GB, the pool holds %d GB", storage, l.StoragePoolGB))
+	}
+	return errs
+}
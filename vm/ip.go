@@ -0,0 +1,91 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"time"
+)
+
+// defaultSimulatedIPDelay - время после запуска ВМ, через которое mock сообщает ее IP-адрес
+const defaultSimulatedIPDelay = 2 * time.Second
+
+// ipPollInterval - интервал опроса адресов в WaitForIP
+const ipPollInterval = 100 * time.Millisecond
+
+// WithSimulatedIPDelay задает, через сколько времени после запуска ВМ (по часам
+// менеджера) у нее появляется IP-адрес
+func WithSimulatedIPDelay(delay time.Duration) MockOption {
+	return func(m *MockVMManager) {
+		m.simulatedIPDelay = delay
+	}
+}
+
+// ipAddressesLocked возвращает адреса гостевой ОС запущенной ВМ: loopback сразу после
+// запуска и фиктивный адрес в сети 192.168.122.0/24 по истечении simulatedIPDelay.
+// Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) ipAddressesLocked(vm *MockVM) []string {
+	if vm.State != VMStateRunning {
+		return nil
+	}
+	addrs := []string{"127.0.0.1"}
+	if m.now().Sub(vm.startedAt) >= m.simulatedIPDelay {
+		h := fnv.New32a()
+		h.Write([]byte(vm.Config.Name))
+		addrs = append(addrs, fmt.Sprintf("192.168.122.%d", 2+h.Sum32()%253))
+	}
+	return addrs
+}
+
+// WaitForIP ждет, пока у запущенной ВМ появится хотя бы один адрес, кроме loopback,
+// и возвращает его. Ожидание ограничено timeout; при timeout 0 - временем загрузки ВМ
+// (SetBootTimeout), а если и оно не задано - только контекстом
+func (m *MockVMManager) WaitForIP(ctx context.Context, name string, timeout time.Duration) (string, error) {
+	if timeout == 0 {
+		var err error
+		if timeout, err = m.BootTimeout(name); err != nil {
+			return "", err
+		}
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	ticker := time.NewTicker(ipPollInterval)
+	defer ticker.Stop()
+	for {
+		addr, err := m.guestIP(name)
+		if err != nil || addr != "" {
+			return addr, err
+		}
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("timed out waiting for IP address of virtual machine '%s': %w", name, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// guestIP возвращает первый адрес ВМ, кроме loopback, или пустую строку, если его еще нет
+func (m *MockVMManager) guestIP(name string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	name = m.resolveNameLocked(name)
+
+	vm, exists := m.vms[name]
+	if !exists {
+		return "", fmt.Errorf("virtual machine '%s' not found", name)
+	}
+	if vm.State != VMStateRunning {
+		return "", fmt.Errorf("virtual machine '%s' is not running (current state: %s)", name, vm.State)
+	}
+	for _, addr := range m.ipAddressesLocked(vm) {
+		if ip := net.ParseIP(addr); ip != nil && !ip.IsLoopback() {
+			return addr, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,59 @@
+package vm
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+)
+
+// StatFunc возвращает информацию о файле
+type StatFunc func(path string) (fs.FileInfo, error)
+
+// WithStatFunc заменяет способ проверки файлов ISO-образов (по умолчанию os.Stat),
+// например в тестах
+func WithStatFunc(stat StatFunc) MockOption {
+	return func(m *MockVMManager) {
+		m.stat = stat
+	}
+}
+
+// validateISO проверяет, что ISO-образ существует и является обычным файлом
+func (m *MockVMManager) validateISO(path string) error {
+	info, err := m.stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return fmt.Errorf("ISO image not found: %s", path)
+	}
+	return nil
+}
+
+// AttachISO подключает ISO-образ к ВМ (заменяя подключенный ранее); в реальных
+// бэкендах это смена носителя в приводе cdrom, поэтому ВМ может быть запущена
+func (m *MockVMManager) AttachISO(name, path string) error {
+	return m.runHooks("attach_iso", name, func() error { return m.attachISO(name, path) })
+}
+
+// attachISO выполняет AttachISO без хуков операций
+func (m *MockVMManager) attachISO(name, path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = m.resolveNameLocked(name)
+
+	vm, exists := m.vms[name]
+	if !exists {
+		return fmt.Errorf("virtual machine '%s' not found", name)
+	}
+	if err := checkUnlockedLocked(vm, name, "attach an ISO image to"); err != nil {
+		return err
+	}
+	if path == "" {
+		return fmt.Errorf("ISO image path cannot be empty")
+	}
+	if err := m.validateISO(path); err != nil {
+		return err
+	}
+
+	vm.Config.ISOImage = path
+	log.Printf("[MOCK] ISO image '%s' attached to virtual machine '%s'", path, name)
+	m.recordVMLocked(AuditAttachISO, name)
+	return nil
+}
@@ -0,0 +1,129 @@
+package vm
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// AddLabelToVMs устанавливает метку key=value на каждую из перечисленных ВМ.
+// Возвращает результат для каждого имени: nil при успехе или ошибку. Хуки операций
+// вызываются для каждой ВМ отдельно ("add_label")
+func (m *MockVMManager) AddLabelToVMs(names []string, key, value string) map[string]error {
+	results := make(map[string]error, len(names))
+	for _, name := range names {
+		results[name] = m.runHooks("add_label", name, func() error { return m.addLabel(name, key, value) })
+	}
+	return results
+}
+
+// addLabel устанавливает метку key=value на ВМ без хуков операций
+func (m *MockVMManager) addLabel(name, key, value string) error {
+	if key == "" {
+		return fmt.Errorf("label key cannot be empty")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	vm, exists := m.vms[m.resolveNameLocked(name)]
+	if !exists {
+		return fmt.Errorf("virtual machine '%s' not found", name)
+	}
+
+	if vm.Config.Labels == nil {
+		vm.Config.Labels = make(map[string]string)
+	}
+	vm.Config.Labels[key] = value
+	m.recordVMLocked(AuditLabels, vm.Config.Name)
+	log.Printf("[MOCK] Label '%s=%s' set on virtual machine '%s'", key, value, name)
+	return nil
+}
+
+// RemoveLabelFromVMs снимает метку key с каждой из перечисленных ВМ.
+// Отсутствие метки на ВМ не считается ошибкой. Хуки операций вызываются для каждой
+// ВМ отдельно ("remove_label")
+func (m *MockVMManager) RemoveLabelFromVMs(names []string, key string) map[string]error {
+	results := make(map[string]error, len(names))
+	for _, name := range names {
+		results[name] = m.runHooks("remove_label", name, func() error { return m.removeLabel(name, key) })
+	}
+	return results
+}
+
+// removeLabel снимает метку key с ВМ без хуков операций
+func (m *MockVMManager) removeLabel(name, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	vm, exists := m.vms[m.resolveNameLocked(name)]
+	if !exists {
+		return fmt.Errorf("virtual machine '%s' not found", name)
+	}
+
+	delete(vm.Config.Labels, key)
+	m.recordVMLocked(AuditLabels, vm.Config.Name)
+	log.Printf("[MOCK] Label '%s' removed from virtual machine '%s'", key, name)
+	return nil
+}
+
+// RenameLabelKey переименовывает ключ метки oldKey в newKey на всех ВМ, у которых он есть,
+// сохраняя значения. Если newKey уже есть хотя бы на одной из этих ВМ, ничего не меняется
+// и возвращается ошибка со списком таких ВМ. Возвращает отсортированные имена измененных ВМ
+func (m *MockVMManager) RenameLabelKey(oldKey, newKey string) (affected []string, err error) {
+	err = m.runHooks("rename_label_key", "", func() (err error) {
+		affected, err = m.renameLabelKey(oldKey, newKey)
+		return err
+	})
+	return affected, err
+}
+
+// renameLabelKey выполняет RenameLabelKey без хуков операций
+func (m *MockVMManager) renameLabelKey(oldKey, newKey string) (affected []string, err error) {
+	if err := validateLabelKeyRename(oldKey, newKey); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var clobbered []string
+	for name, vm := range m.vms {
+		if _, has := vm.Config.Labels[oldKey]; !has {
+			continue
+		}
+		affected = append(affected, name)
+		if _, has := vm.Config.Labels[newKey]; has {
+			clobbered = append(clobbered, name)
+		}
+	}
+	if len(clobbered) > 0 {
+		sort.Strings(clobbered)
+		return nil, fmt.Errorf("label '%s' already exists on virtual machine(s) %s", newKey, strings.Join(clobbered, ", "))
+	}
+
+	for _, name := range affected {
+		labels := m.vms[name].Config.Labels
+		labels[newKey] = labels[oldKey]
+		delete(labels, oldKey)
+	}
+	sort.Strings(affected)
+	for _, name := range affected {
+		m.recordVMLocked(AuditLabels, name)
+	}
+
+	log.Printf("[MOCK] Label '%s' renamed to '%s' on %d virtual machine(s)", oldKey, newKey, len(affected))
+	return affected, nil
+}
+
+// validateLabelKeyRename проверяет ключи меток, передаваемые RenameLabelKey
+func validateLabelKeyRename(oldKey, newKey string) error {
+	if oldKey == "" || newKey == "" {
+		return fmt.Errorf("label key cannot be empty")
+	}
+	if oldKey == newKey {
+		return fmt.Errorf("new label key must differ from '%s'", oldKey)
+	}
+	return nil
+}
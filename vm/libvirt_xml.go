@@ -0,0 +1,417 @@
+package vm
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// libvirtDomain - подмножество XML-описания домена libvirt, используемое при импорте
+// и экспорте конфигурации; остальные элементы игнорируются
+type libvirtDomain struct {
+	XMLName xml.Name        `xml:"domain"`
+	Type    string          `xml:"type,attr,omitempty"`
+	Name    string          `xml:"name"`
+	Memory  libvirtMemory   `xml:"memory"`
+	VCPU    uint            `xml:"vcpu"`
+	CPUTune *libvirtCPUTune `xml:"cputune"`
+	CPU     *libvirtCPU     `xml:"cpu"`
+	OS      *libvirtOS      `xml:"os"`
+	Devices libvirtDevices  `xml:"devices"`
+}
+
+type libvirtCPUTune struct {
+	VCPUPins []libvirtVCPUPin `xml:"vcpupin"`
+}
+
+type libvirtCPU struct {
+	NUMA libvirtNUMA `xml:"numa"`
+}
+
+type libvirtNUMA struct {
+	Cells []libvirtNUMACell `xml:"cell"`
+}
+
+type libvirtNUMACell struct {
+	ID     int    `xml:"id,attr"`
+	CPUs   string `xml:"cpus,attr"`
+	Memory uint64 `xml:"memory,attr"`
+	Unit   string `xml:"unit,attr,omitempty"`
+}
+
+type libvirtVCPUPin struct {
+	VCPU   uint   `xml:"vcpu,attr"`
+	CPUSet string `xml:"cpuset,attr"`
+}
+
+type libvirtMemory struct {
+	Unit  string `xml:"unit,attr,omitempty"`
+	Value uint64 `xml:",chardata"`
+}
+
+type libvirtOS struct {
+	Firmware string         `xml:"firmware,attr,omitempty"`
+	Type     libvirtOSType  `xml:"type"`
+	Loader   *libvirtLoader `xml:"loader"`
+}
+
+type libvirtOSType struct {
+	Arch    string `xml:"arch,attr,omitempty"`
+	Machine string `xml:"machine,attr,omitempty"`
+	Value   string `xml:",chardata"`
+}
+
+type libvirtLoader struct {
+	ReadOnly string `xml:"readonly,attr,omitempty"`
+	Type     string `xml:"type,attr,omitempty"`
+	Path     string `xml:",chardata"`
+}
+
+type libvirtDevices struct {
+	Disks      []libvirtDisk      `xml:"disk"`
+	Interfaces []libvirtInterface `xml:"interface"`
+}
+
+type libvirtDisk struct {
+	Type      string         `xml:"type,attr,omitempty"`
+	Device    string         `xml:"device,attr,omitempty"`
+	Driver    *libvirtDriver `xml:"driver"`
+	Source    libvirtSource  `xml:"source"`
+	Target    *libvirtTarget `xml:"target"`
+	ReadOnly  *struct{}      `xml:"readonly"`
+	Shareable *struct{}      `xml:"shareable"`
+	IOTune    *libvirtIOTune `xml:"iotune"`
+}
+
+type libvirtIOTune struct {
+	ReadIOPS  uint `xml:"read_iops_sec,omitempty"`
+	WriteIOPS uint `xml:"write_iops_sec,omitempty"`
+}
+
+type libvirtDriver struct {
+	Name string `xml:"name,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type libvirtTarget struct {
+	Dev string `xml:"dev,attr"`
+	Bus string `xml:"bus,attr"`
+}
+
+type libvirtInterface struct {
+	Type      string            `xml:"type,attr,omitempty"`
+	Source    libvirtSource     `xml:"source"`
+	Model     *libvirtModel     `xml:"model"`
+	Bandwidth *libvirtBandwidth `xml:"bandwidth"`
+}
+
+// libvirtBandwidth задает среднюю скорость трафика в КБ/с (килобайтах, а не килобитах)
+type libvirtBandwidth struct {
+	Inbound  *libvirtRate `xml:"inbound"`
+	Outbound *libvirtRate `xml:"outbound"`
+}
+
+type libvirtRate struct {
+	Average uint `xml:"average,attr"`
+}
+
+type libvirtModel struct {
+	Type string `xml:"type,attr"`
+}
+
+type libvirtSource struct {
+	File    string `xml:"file,attr,omitempty"`
+	Dev     string `xml:"dev,attr,omitempty"`
+	Network string `xml:"network,attr,omitempty"`
+	Bridge  string `xml:"bridge,attr,omitempty"`
+}
+
+// ovmfLoaderPath - путь к прошивке UEFI, указываемый при экспорте ВМ с FirmwareUEFI
+const ovmfLoaderPath = "/usr/share/OVMF/OVMF_CODE.fd"
+
+// libvirtMemoryUnits - множители единиц памяти libvirt в байтах
+var libvirtMemoryUnits = map[string]uint64{
+	"b": 1, "bytes": 1,
+	"kb": 1000, "k": 1 << 10, "kib": 1 << 10,
+	"mb": 1000 * 1000, "m": 1 << 20, "mib": 1 << 20,
+	"gb": 1000 * 1000 * 1000, "g": 1 << 30, "gib": 1 << 30,
+	"tb": 1000 * 1000 * 1000 * 1000, "t": 1 << 40, "tib": 1 << 40,
+}
+
+// memoryMB переводит память домена в МБ; по умолчанию libvirt использует KiB
+func (mem libvirtMemory) memoryMB() (uint64, error) {
+	unit := strings.ToLower(strings.TrimSpace(mem.Unit))
+	if unit == "" {
+		unit = "kib"
+	}
+	multiplier, ok := libvirtMemoryUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("unsupported memory unit '%s'", mem.Unit)
+	}
+	return mem.Value * multiplier / (1 << 20), nil
+}
+
+// libvirtBandwidthOf переводит ограничения трафика из Кбит/с в КБ/с libvirt, округляя
+// вверх, чтобы малое ограничение не превратилось в его отсутствие; nil - без ограничений
+func libvirtBandwidthOf(bandwidth NetworkSpec) *libvirtBandwidth {
+	rate := func(kbps uint) *libvirtRate {
+		if kbps == 0 {
+			return nil
+		}
+		return &libvirtRate{Average: (kbps + 7) / 8}
+	}
+	if bandwidth == (NetworkSpec{}) {
+		return nil
+	}
+	return &libvirtBandwidth{Inbound: rate(bandwidth.InboundKbps), Outbound: rate(bandwidth.OutboundKbps)}
+}
+
+// networkSpec переводит ограничения трафика libvirt из КБ/с в Кбит/с
+func (bandwidth *libvirtBandwidth) networkSpec() NetworkSpec {
+	kbps := func(rate *libvirtRate) uint {
+		if rate == nil {
+			return 0
+		}
+		return rate.Average * 8
+	}
+	if bandwidth == nil {
+		return NetworkSpec{}
+	}
+	return NetworkSpec{InboundKbps: kbps(bandwidth.Inbound), OutboundKbps: kbps(bandwidth.Outbound)}
+}
+
+// path возвращает путь к файлу или устройству источника диска
+func (src libvirtSource) path() string {
+	if src.File != "" {
+		return src.File
+	}
+	return src.Dev
+}
+
+// ImportFromLibvirtXML разбирает XML-описание домена libvirt и возвращает конфигурацию ВМ:
+// имя, память, VCPU, привязку vCPU (cputune), NUMA-топологию, диски (первый диск -
+// основной, cdrom - ISO-образ) с ограничениями IOPS (iotune), прошивку, сеть первого
+// сетевого интерфейса и ее ограничения трафика (bandwidth).
+// Отсутствующие значения заполняются настройками по умолчанию менеджера;
+// неподдерживаемые элементы игнорируются. ВМ не создается
+func (m *MockVMManager) ImportFromLibvirtXML(data string) (VMConfig, error) {
+	var domain libvirtDomain
+	if err := xml.Unmarshal([]byte(data), &domain); err != nil {
+		return VMConfig{}, fmt.Errorf("failed to parse libvirt domain XML: %w", err)
+	}
+
+	config := VMConfig{
+		Name:  strings.TrimSpace(domain.Name),
+		VCPUs: domain.VCPU,
+	}
+	if config.Name == "" {
+		return VMConfig{}, fmt.Errorf("libvirt domain XML has no name")
+	}
+	memory, err := domain.Memory.memoryMB()
+	if err != nil {
+		return VMConfig{}, fmt.Errorf("invalid memory in libvirt domain '%s': %w", config.Name, err)
+	}
+	config.Memory = memory
+
+	for _, disk := range domain.Devices.Disks {
+		path := disk.Source.path()
+		if path == "" {
+			continue
+		}
+		switch disk.Device {
+		case "cdrom":
+			if config.ISOImage == "" {
+				config.ISOImage = path
+			}
+		case "", "disk":
+			var iotune libvirtIOTune
+			if disk.IOTune != nil {
+				iotune = *disk.IOTune
+			}
+			if config.DiskPath == "" {
+				config.DiskPath = path
+				config.DiskReadIOPS, config.DiskWriteIOPS = iotune.ReadIOPS, iotune.WriteIOPS
+			} else {
+				config.Disks = append(config.Disks, DiskSpec{
+					Path:      path,
+					Shared:    disk.Shareable != nil,
+					ReadOnly:  disk.ReadOnly != nil,
+					ReadIOPS:  iotune.ReadIOPS,
+					WriteIOPS: iotune.WriteIOPS,
+				})
+			}
+		}
+	}
+
+	if domain.CPUTune != nil {
+		for _, pin := range domain.CPUTune.VCPUPins {
+			cpu, err := strconv.ParseUint(strings.TrimSpace(pin.CPUSet), 10, 0)
+			if err != nil {
+				log.Printf("[MOCK] Ignoring vCPU %d pinning to cpuset '%s' of libvirt domain '%s': only single CPUs are supported", pin.VCPU, pin.CPUSet, config.Name)
+				continue
+			}
+			if config.CPUPinning == nil {
+				config.CPUPinning = make(map[uint]uint)
+			}
+			config.CPUPinning[pin.VCPU] = uint(cpu)
+		}
+	}
+
+	if domain.CPU != nil {
+		for _, cell := range domain.CPU.NUMA.Cells {
+			cpus, err := parseCPUList(cell.CPUs)
+			if err != nil {
+				return VMConfig{}, fmt.Errorf("invalid NUMA cell %d in libvirt domain '%s': %w", cell.ID, config.Name, err)
+			}
+			memory, err := libvirtMemory{Unit: cell.Unit, Value: cell.Memory}.memoryMB()
+			if err != nil {
+				return VMConfig{}, fmt.Errorf("invalid NUMA cell %d in libvirt domain '%s': %w", cell.ID, config.Name, err)
+			}
+			config.NUMANodes = append(config.NUMANodes, NUMANode{CPUs: cpus, MemoryMB: memory})
+		}
+	}
+
+	if domain.OS != nil && (domain.OS.Firmware == "efi" || domain.OS.Loader != nil) {
+		config.Firmware = FirmwareUEFI
+	}
+
+	for i, iface := range domain.Devices.Interfaces {
+		network := iface.Source.Network
+		if network == "" {
+			network = iface.Source.Bridge
+		}
+		if i > 0 {
+			log.Printf("[MOCK] Ignoring additional network interface '%s' of libvirt domain '%s'", network, config.Name)
+			continue
+		}
+		config.Network = network
+		config.Bandwidth = iface.Bandwidth.networkSpec()
+	}
+
+	m.mu.RLock()
+	config = m.applyDefaults(config)
+	m.mu.RUnlock()
+	return config, nil
+}
+
+// libvirtDiskDevice возвращает описание диска для домена libvirt: путь в /dev
+// становится блочным устройством, формат определяется по расширению
+func libvirtDiskDevice(path, device, dev, bus string) libvirtDisk {
+	disk := libvirtDisk{
+		Type:   "file",
+		Device: device,
+		Driver: &libvirtDriver{Name: "qemu", Type: "qcow2"},
+		Source: libvirtSource{File: path},
+		Target: &libvirtTarget{Dev: dev, Bus: bus},
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".raw", ".img", ".iso":
+		disk.Driver.Type = "raw"
+	}
+	if strings.HasPrefix(path, "/dev/") {
+		disk.Type = "block"
+		disk.Driver.Type = "raw"
+		disk.Source = libvirtSource{Dev: path}
+	}
+	if device == "cdrom" {
+		disk.ReadOnly = &struct{}{}
+	}
+	return disk
+}
+
+// libvirtDiskTarget возвращает имя целевого устройства диска с индексом i:
+// vda, vdb, ..., vdz, vdaa, vdab и т.д.
+func libvirtDiskTarget(prefix string, i int) string {
+	suffix := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		suffix = string(rune('a'+(i-1)%26)) + suffix
+	}
+	return prefix + suffix
+}
+
+// ExportToLibvirtXML возвращает XML-описание домена libvirt для ВМ: память в KiB,
+// количество VCPU, привязку vCPU (cputune), NUMA-топологию, диски (virtio) с
+// ограничениями IOPS (iotune), ISO-образ (cdrom), сетевой интерфейс с ограничениями
+// трафика (bandwidth) и загрузчик UEFI для FirmwareUEFI. Результат можно передать в
+// `virsh define`
+func (m *MockVMManager) ExportToLibvirtXML(name string) (string, error) {
+	m.mu.RLock()
+	name = m.resolveNameLocked(name)
+	vm, exists := m.vms[name]
+	if !exists {
+		m.mu.RUnlock()
+		return "", fmt.Errorf("virtual machine '%s' not found", name)
+	}
+	config := copyConfig(vm.Config)
+	m.mu.RUnlock()
+
+	domain := libvirtDomain{
+		Type:   "kvm",
+		Name:   config.Name,
+		Memory: libvirtMemory{Unit: "KiB", Value: config.Memory * 1024},
+		VCPU:   config.VCPUs,
+		OS: &libvirtOS{
+			Type: libvirtOSType{Arch: "x86_64", Machine: "q35", Value: "hvm"},
+		},
+	}
+	if len(config.CPUPinning) > 0 {
+		domain.CPUTune = &libvirtCPUTune{}
+		for _, vcpu := range slices.Sorted(maps.Keys(config.CPUPinning)) {
+			domain.CPUTune.VCPUPins = append(domain.CPUTune.VCPUPins, libvirtVCPUPin{
+				VCPU:   vcpu,
+				CPUSet: strconv.FormatUint(uint64(config.CPUPinning[vcpu]), 10),
+			})
+		}
+	}
+	if len(config.NUMANodes) > 0 {
+		domain.CPU = &libvirtCPU{}
+		for i, node := range config.NUMANodes {
+			domain.CPU.NUMA.Cells = append(domain.CPU.NUMA.Cells, libvirtNUMACell{
+				ID:     i,
+				CPUs:   formatCPUList(node.CPUs),
+				Memory: node.MemoryMB,
+				Unit:   "MiB",
+			})
+		}
+	}
+	if config.Firmware == FirmwareUEFI {
+		domain.OS.Firmware = "efi"
+		domain.OS.Loader = &libvirtLoader{ReadOnly: "yes", Type: "pflash", Path: ovmfLoaderPath}
+	}
+
+	for i, spec := range diskSpecs(config) {
+		disk := libvirtDiskDevice(spec.Path, "disk", libvirtDiskTarget("vd", i), "virtio")
+		if spec.ReadOnly {
+			disk.ReadOnly = &struct{}{}
+		}
+		if spec.Shared {
+			disk.Shareable = &struct{}{}
+		}
+		if spec.ReadIOPS != 0 || spec.WriteIOPS != 0 {
+			disk.IOTune = &libvirtIOTune{ReadIOPS: spec.ReadIOPS, WriteIOPS: spec.WriteIOPS}
+		}
+		domain.Devices.Disks = append(domain.Devices.Disks, disk)
+	}
+	if config.ISOImage != "" {
+		domain.Devices.Disks = append(domain.Devices.Disks, libvirtDiskDevice(config.ISOImage, "cdrom", "sda", "sata"))
+	}
+	if config.Network != "" {
+		domain.Devices.Interfaces = append(domain.Devices.Interfaces, libvirtInterface{
+			Type:      "network",
+			Source:    libvirtSource{Network: config.Network},
+			Model:     &libvirtModel{Type: "virtio"},
+			Bandwidth: libvirtBandwidthOf(config.Bandwidth),
+		})
+	}
+
+	data, err := xml.MarshalIndent(domain, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal virtual machine '%s' to libvirt XML: %w", name, err)
+	}
+	return string(data) + "\n", nil
+}
@@ -0,0 +1,75 @@
+package vm
+
+import (
+	"encoding/xml"
+	"testing"
+)
+
+func TestExportToLibvirtXMLStructure(t *testing.T) {
+	m := newTestManager(t, WithAutoStartOnCreate(false))
+	mustCreate(t, m, VMConfig{
+		Name:     "web",
+		Memory:   2048,
+		VCPUs:    2,
+		DiskPath: "/var/lib/vms/web.qcow2",
+		Network:  "lan",
+	})
+
+	data, err := m.ExportToLibvirtXML("web")
+	if err != nil {
+		t.Fatalf("ExportToLibvirtXML: %v", err)
+	}
+	// Разбираем XML независимой от libvirtDomain структурой, чтобы проверять
+	// сам документ, а не симметричность кода экспорта и импорта
+	var domain struct {
+		XMLName xml.Name `xml:"domain"`
+		Type    string   `xml:"type,attr"`
+		Name    string   `xml:"name"`
+		Memory  struct {
+			Unit  string `xml:"unit,attr"`
+			Value uint64 `xml:",chardata"`
+		} `xml:"memory"`
+		VCPU  uint `xml:"vcpu"`
+		Disks []struct {
+			Type   string `xml:"type,attr"`
+			Device string `xml:"device,attr"`
+			Source struct {
+				File string `xml:"file,attr"`
+			} `xml:"source"`
+			Target struct {
+				Dev string `xml:"dev,attr"`
+				Bus string `xml:"bus,attr"`
+			} `xml:"target"`
+		} `xml:"devices>disk"`
+		Interfaces []struct {
+			Type   string `xml:"type,attr"`
+			Source struct {
+				Network string `xml:"network,attr"`
+			} `xml:"source"`
+		} `xml:"devices>interface"`
+	}
+	if err := xml.Unmarshal([]byte(data), &domain); err != nil {
+		t.Fatalf("exported XML does not parse: %v\n%s", err, data)
+	}
+
+	if domain.XMLName.Local != "domain" || domain.Type != "kvm" || domain.Name != "web" {
+		t.Errorf("domain = <%s type=%q> named %q, want <domain type=\"kvm\"> named \"web\"", domain.XMLName.Local, domain.Type, domain.Name)
+	}
+	if domain.Memory.Unit != "KiB" || domain.Memory.Value != 2048*1024 {
+		t.Errorf("memory = %d %s, want %d KiB", domain.Memory.Value, domain.Memory.Unit, 2048*1024)
+	}
+	if domain.VCPU != 2 {
+		t.Errorf("vcpu = %d, want 2", domain.VCPU)
+	}
+	if len(domain.Disks) != 1 {
+		t.Fatalf("disks = %+v, want one", domain.Disks)
+	}
+	disk := domain.Disks[0]
+	if disk.Type != "file" || disk.Device != "disk" || disk.Source.File != "/var/lib/vms/web.qcow2" ||
+		disk.Target.Dev != "vda" || disk.Target.Bus != "virtio" {
+		t.Errorf("disk = %+v, want a virtio file disk vda backed by /var/lib/vms/web.qcow2", disk)
+	}
+	if len(domain.Interfaces) != 1 || domain.Interfaces[0].Type != "network" || domain.Interfaces[0].Source.Network != "lan" {
+		t.Errorf("interfaces = %+v, want one attached to network \"lan\"", domain.Interfaces)
+	}
+}
@@ -1,29 +1,358 @@
 package vm
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"maps"
+	"os"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
 // VMManagerInterface определяет интерфейс для управления виртуальными машинами
 type VMManagerInterface interface {
-	CreateVM(config VMConfig) error
+	// CreateVM создает и запускает ВМ по принципу "всё или ничего":
+	// если любой из этапов (выделение диска, определение, запуск) завершается ошибкой
+	// или ctx отменяется, уже выполненные этапы откатываются и ВМ не остается
+	CreateVM(ctx context.Context, config VMConfig) error
+	// CreateVMWithRetry создает ВМ, повторяя попытки при временных сбоях бэкенда (ErrTransient)
+	CreateVMWithRetry(ctx context.Context, config VMConfig, attempts int, backoff time.Duration) error
 	ListVMs() ([]string, error)
-	StartVM(name string) error
-	StopVM(name string) error
-	DeleteVM(name string) error
+	// ListGroupedByState возвращает имена ВМ, сгруппированные по состояниям
+	ListGroupedByState() (map[VMState][]string, error)
+	// StackReady сообщает, запущены ли все перечисленные ВМ, и возвращает состояние каждой
+	StackReady(names []string) (bool, map[string]VMState, error)
+	StartVM(ctx context.Context, name string) error
+	StopVM(ctx context.Context, name string) error
+	// StartVMs, StopVMs и CreateVMs выполняют операцию для нескольких ВМ (одновременно -
+	// не более WithBatchConcurrency) и возвращают результат для каждого имени
+	StartVMs(ctx context.Context, names []string) map[string]error
+	StopVMs(ctx context.Context, names []string) map[string]error
+	CreateVMs(ctx context.Context, configs []VMConfig) map[string]error
+	DeleteVM(ctx context.Context, name string, opts DeleteVMOptions) error
+	// DeleteVMGraceful корректно останавливает запущенную ВМ, ждет ее выключения не дольше
+	// drain и только затем удаляет
+	DeleteVMGraceful(ctx context.Context, name string, drain time.Duration) error
+	// LockVMConfig запрещает изменять, переименовывать и удалять ВМ (ErrVMLocked);
+	// запуск, остановка и чтение остаются доступны
+	LockVMConfig(name string) error
+	// UnlockVMConfig снимает блокировку LockVMConfig
+	UnlockVMConfig(name string) error
+	// RegisterState регистрирует пользовательское состояние ВМ и состояния, из которых в
+	// него можно перейти; для встроенного состояния дополняет его правила
+	RegisterState(state VMState, validFrom []VMState) error
+	// StateTransitions возвращает разрешенные переходы: состояние -> возможные следующие
+	StateTransitions() map[VMState][]VMState
+	// TransitionVM переводит ВМ в состояние, если это разрешено правилами переходов
+	TransitionVM(name string, to VMState) error
+	// StartVMWithDeps запускает ВМ вместе с ее зависимостями и возвращает порядок запуска
+	StartVMWithDeps(ctx context.Context, name string) ([]string, error)
+	// SetCPUPinning заменяет привязку vCPU к физическим CPU остановленной ВМ
+	SetCPUPinning(name string, pinning map[uint]uint) error
+	// SetDiskIOPS задает ограничения IOPS диска остановленной ВМ (0 - без ограничения)
+	SetDiskIOPS(name, path string, readIOPS, writeIOPS uint) error
+	// SetNetworkBandwidth задает ограничения трафика сети остановленной ВМ в Кбит/с
+	SetNetworkBandwidth(name string, inboundKbps, outboundKbps uint) error
+	// NetworkDependents возвращает имена ВМ, подключенных к сети network
+	NetworkDependents(network string) ([]string, error)
+	// SetNextBoot задает устройство загрузки только для следующего запуска ВМ
+	SetNextBoot(name, device string) error
+	// SetBootTimeout задает ожидаемое время загрузки ВМ (0 - не задано)
+	SetBootTimeout(name string, timeout time.Duration) error
+	// BootTimeout возвращает ожидаемое время загрузки ВМ
+	BootTimeout(name string) (time.Duration, error)
+	// ClearError переводит ВМ из состояния ошибки в остановленное
+	ClearError(name string) error
+	// UndoLast отменяет последнюю изменяющую операцию из журнала операций
+	UndoLast() error
+	// LastAuditEntry возвращает последнюю запись журнала операций - ту, которую отменит UndoLast
+	LastAuditEntry() (AuditEntry, bool)
+	// RestartAllRunning перезапускает все запущенные ВМ и возвращает результат для каждой
+	RestartAllRunning() map[string]error
+	// FreezeAll приостанавливает все запущенные ВМ и возвращает функцию, возобновляющую именно их
+	FreezeAll() (resume func() error, err error)
+	// DependencyGraphDOT возвращает граф зависимостей между ВМ в формате Graphviz DOT
+	DependencyGraphDOT() (string, error)
+	RenameVM(oldName, newName string, opts RenameVMOptions) error
+	// SwapVMNames атомарно меняет местами имена двух ВМ
+	SwapVMNames(a, b string) error
+	// CloneVM клонирует ВМ; связанный клон (linked) использует диски источника как backing-файлы
+	CloneVM(source, target string, linked bool) error
+	// CloneVMWithOverrides клонирует ВМ, заменяя в клоне ненулевые поля overrides
+	CloneVMWithOverrides(source, target string, overrides VMConfig) error
+	// CloneVMFull клонирует ВМ, при необходимости вместе со снапшотами
+	CloneVMFull(source, target string, includeSnapshots bool) error
+	// CreateSnapshot создает снапшот ВМ; если в пуле хранения нет места для него,
+	// возвращает ошибку ErrInsufficientSpace
+	CreateSnapshot(vmName, snapshotName, description string) error
+	ListSnapshots(vmName string) ([]SnapshotInfo, error)
+	// ListAllSnapshots возвращает снапшоты всех ВМ: имя ВМ -> снапшоты
+	ListAllSnapshots() (map[string][]SnapshotInfo, error)
+	RestoreSnapshot(vmName, snapshotName string) error
+	DeleteSnapshot(vmName, snapshotName string) error
+	RenameSnapshot(vmName, oldName, newName string) error
+	// RevertToLatestSnapshot восстанавливает самый новый снапшот и возвращает его имя
+	RevertToLatestSnapshot(vmName string) (string, error)
+	// EnableScheduledSnapshots периодически создает снапшоты ВМ, оставляя keep самых новых
+	EnableScheduledSnapshots(vmName string, interval time.Duration, keep int) error
+	// PruneSnapshots удаляет все снапшоты ВМ, кроме keep самых новых, и возвращает имена удаленных
+	PruneSnapshots(vmName string, keep int) ([]string, error)
+	// SetSnapshotSpaceEstimate задает место в пуле хранения (GB) для одного снапшота ВМ
+	// (0 - оценка по занятому месту дисков)
+	SetSnapshotSpaceEstimate(name string, sizeGB uint64) error
+	// SnapshotSpaceEstimate возвращает оценку места для нового снапшота ВМ
+	SnapshotSpaceEstimate(name string) (uint64, error)
+	AttachDisk(name string, disk DiskSpec) error
+	DetachDisk(name, path string) error
+	// AttachISO подключает ISO-образ к ВМ
+	AttachISO(name, path string) error
+	// ResizeDisk увеличивает размер диска ВМ
+	ResizeDisk(name, path string, sizeGB uint64) error
+	// CompactDisk сжимает диск остановленной ВМ и возвращает освобожденный объем в ГБ
+	CompactDisk(name, diskPath string) (reclaimedGB uint64, err error)
+	DiskUsage(name string) ([]DiskUsage, error)
+	// FindDiskConflicts возвращает диски, используемые несколькими ВМ: путь -> имена ВМ
+	FindDiskConflicts() map[string][]string
+	// FindNameCollisions возвращает имена ВМ, различающиеся только регистром: имя в нижнем регистре -> имена
+	FindNameCollisions() map[string][]string
+	// FindOrphanedDisks возвращает образы дисков в каталоге, не подключенные ни к одной ВМ
+	FindOrphanedDisks(searchDir string) ([]string, error)
+	// AddLabelToVMs устанавливает метку на несколько ВМ и возвращает результат для каждой
+	AddLabelToVMs(names []string, key, value string) map[string]error
+	// RemoveLabelFromVMs снимает метку с нескольких ВМ и возвращает результат для каждой
+	RemoveLabelFromVMs(names []string, key string) map[string]error
+	// StopVMsNotMatching останавливает все ВМ, не подходящие под фильтр, и возвращает остановленные
+	StopVMsNotMatching(ctx context.Context, selector VMFilter) (stopped []string, err error)
+	// RenameLabelKey переименовывает ключ метки на всех ВМ и возвращает измененные ВМ
+	RenameLabelKey(oldKey, newKey string) (affected []string, err error)
+	// RelabelBySelector меняет метки всех ВМ, подходящих под фильтр, и возвращает измененные ВМ
+	RelabelBySelector(selector VMFilter, set map[string]string, unset []string) (affected []string, err error)
+	TotalResources() (vms int, totalMemMB uint64, totalVCPU uint, runningMem uint64)
+	// Snapshot возвращает копию всех ВМ на один момент времени для чтения без блокировок
+	Snapshot() []VMInfo
+	// LookupVM возвращает копию одной ВМ в том же виде, что и Snapshot
+	LookupVM(name string) (VMInfo, error)
+	// ResourceTable возвращает ресурсы ВМ в виде выровненной текстовой таблицы
+	ResourceTable() (string, error)
+	// InventoryReport возвращает отчет обо всех ВМ в формате Markdown
+	InventoryReport() (string, error)
+	// EstimateCost оценивает месячную стоимость каждой ВМ и общую стоимость
+	EstimateCost(pricing CostModel) (map[string]float64, float64, error)
+	// CanSchedule проверяет, поместится ли ВМ в квоты и ограничения, и возвращает причину, если нет
+	CanSchedule(config VMConfig) (bool, string, error)
+	// QuotaForecast проверяет, поместятся ли ВМ в квоты, и возвращает превышение по каждому измерению
+	QuotaForecast(pending []VMConfig) (fits bool, shortfall Resources, err error)
+	// Reserve удерживает память и VCPU в квотах без ВМ и возвращает идентификатор резервирования
+	Reserve(memoryMB uint64, vcpus uint) (reservationID string, err error)
+	// ReleaseReservation освобождает резервирование, созданное Reserve
+	ReleaseReservation(id string) error
+	// ValidateVMConfigFull возвращает все ошибки конфигурации ВМ за один проход
+	ValidateVMConfigFull(config VMConfig) []error
+	// ValidateCreate выполняет проверки CreateVM, ничего не создавая
+	ValidateCreate(config VMConfig) error
+	// ValidateUpdate выполняет проверки UpdateVMConfig, ничего не меняя
+	ValidateUpdate(name string, config VMConfig) error
+	// ValidateAttachDisk выполняет проверки AttachDisk, ничего не подключая
+	ValidateAttachDisk(name string, disk DiskSpec) error
+	// ValidateClone выполняет проверки CloneVM и CloneVMWithOverrides, ничего не клонируя
+	ValidateClone(source, target string, overrides *VMConfig) error
+	// ValidateCPUPinning выполняет проверки SetCPUPinning, ничего не меняя
+	ValidateCPUPinning(name string, pinning map[uint]uint) error
+	// ValidateDiskIOPS выполняет проверки SetDiskIOPS, ничего не меняя
+	ValidateDiskIOPS(name, path string, readIOPS, writeIOPS uint) error
+	// ValidateNetworkBandwidth выполняет проверки SetNetworkBandwidth, ничего не меняя
+	ValidateNetworkBandwidth(name string, inboundKbps, outboundKbps uint) error
+	// PopulateRandom создает n ВМ со случайными конфигурациями в смеси состояний
+	PopulateRandom(ctx context.Context, n int, seed int64) error
+	// SuggestDiskPath предлагает свободный путь к диску новой ВМ
+	SuggestDiskPath(vmName string) string
+	// Sync сохраняет накопленные изменения или перечитывает актуальное состояние бэкенда
+	Sync(ctx context.Context) error
+	// BackendType возвращает тип бэкенда: "mock", "libvirt", "docker" и т.д.
+	BackendType() string
+	// Events возвращает канал событий об изменяющих операциях с ВМ
+	Events() <-chan VMEvent
+	// Subscribe создает отдельную подписку на события; cancel отменяет ее
+	Subscribe() (ch <-chan VMEvent, cancel func())
+	// ManagerInfo возвращает сводное состояние менеджера: время работы, бэкенд, количество ВМ
+	ManagerInfo() ManagerStatus
+	// Capabilities сообщает, какие необязательные возможности поддерживает бэкенд
+	Capabilities() Capabilities
+	// RunGuestCommand выполняет команду внутри гостевой ОС запущенной ВМ
+	// (для реальных бэкендов - через QEMU guest agent)
+	RunGuestCommand(ctx context.Context, name, command string, args []string) (stdout, stderr string, exit int, err error)
+	// WriteGuestFile записывает файл в гостевую ОС запущенной ВМ
+	WriteGuestFile(ctx context.Context, name, path string, content []byte) error
+	// ReadGuestFile читает файл из гостевой ОС запущенной ВМ
+	ReadGuestFile(ctx context.Context, name, path string) ([]byte, error)
+	// WaitForIP ждет, пока у запущенной ВМ появится адрес, кроме loopback; timeout 0 -
+	// время загрузки ВМ (SetBootTimeout)
+	WaitForIP(ctx context.Context, name string, timeout time.Duration) (string, error)
+	// GetVMLogs возвращает последние строки журнала консоли ВМ
+	GetVMLogs(name string, lines int) ([]string, error)
+	// SetMemoryBalloon изменяет текущую память запущенной ВМ (не больше настроенной)
+	SetMemoryBalloon(name string, targetMB uint64) error
+	// GetVMMetrics возвращает метрики ВМ и историю загрузки CPU
+	GetVMMetrics(name string) (VMMetrics, error)
+	// IsIdle сообщает, была ли загрузка CPU ВМ ниже порога на протяжении окна
+	IsIdle(name string, threshold float64, window time.Duration) (bool, error)
+	// UpdateVMConfig заменяет конфигурацию остановленной ВМ
+	UpdateVMConfig(name string, config VMConfig) error
+	// Reconcile приводит ВМ к желаемым конфигурациям: создает отсутствующие и обновляет отличающиеся
+	Reconcile(ctx context.Context, desired []VMConfig) ([]ReconcileResult, error)
+	// ValidateInventory проверяет предлагаемый набор ВМ целиком: имя ВМ -> ее проблемы,
+	// проблемы набора (повторы имен, общие диски, квоты) - под ключом InventoryKey
+	ValidateInventory(configs []VMConfig) map[string][]error
+	// ExecutePlan выполняет шаги плана (create/start/stop/delete/snapshot) по порядку,
+	// по умолчанию останавливаясь на первой ошибке
+	ExecutePlan(ctx context.Context, actions []PlannedAction, opts ExecutePlanOptions) ([]ActionResult, error)
+	// DriftReport сообщает, чем ВМ отличаются от желаемого состояния, ничего не меняя
+	DriftReport(desired []VMConfig, desiredRunning map[string]bool) (DriftReport, error)
+	// DiffVMs возвращает поля конфигурации, которыми различаются две ВМ
+	DiffVMs(a, b string) (VMConfigDiff, error)
+	// ExportInventory возвращает конфигурации всех ВМ в виде JSON-документа инвентаря
+	ExportInventory() ([]byte, error)
+	// DiffAgainstExport сообщает, какие ВМ добавлены, удалены и изменены после ExportInventory
+	DiffAgainstExport(data []byte) (InventoryDiff, error)
+	// ExportToLibvirtXML возвращает XML-описание домена libvirt для ВМ
+	ExportToLibvirtXML(name string) (string, error)
+	// ImportFromLibvirtXML разбирает XML-описание домена libvirt в конфигурацию ВМ
+	ImportFromLibvirtXML(data string) (VMConfig, error)
+	// CreateVMFromOVF создает ВМ по виртуальному оборудованию дескриптора OVF
+	CreateVMFromOVF(ctx context.Context, ovfXML string, name string) (VMConfig, error)
+	// ParseOVF возвращает конфигурацию ВМ из дескриптора OVF, не создавая ВМ
+	ParseOVF(ovfXML string, name string) (VMConfig, error)
+	// ExportVMYAML возвращает конфигурацию ВМ в виде YAML
+	ExportVMYAML(name string) (string, error)
+	// ImportVMYAML создает ВМ или заменяет конфигурацию остановленной ВМ из YAML
+	ImportVMYAML(ctx context.Context, data string) error
 	Close() error
 }
 
 type VMConfig struct {
-	Name string
-	Memory uint64
-	VCPUs uint
-	DiskPath string
-	DiskSize uint64
-	ISOImage string
-	Network string
+	Name      string            `yaml:"name"`
+	Memory    uint64            `yaml:"memory_mb"`
+	VCPUs     uint              `yaml:"vcpus"`
+	DiskPath  string            `yaml:"disk_path,omitempty"`
+	DiskSize  uint64            `yaml:"disk_size_gb,omitempty"`
+	ISOImage  string            `yaml:"iso_image,omitempty"`
+	Network   string            `yaml:"network,omitempty"`
+	Disks     []DiskSpec        `yaml:"disks,omitempty"`      // дополнительные диски
+	DependsOn []string          `yaml:"depends_on,omitempty"` // ВМ, которые должны быть запущены раньше этой
+	Labels    map[string]string `yaml:"labels,omitempty"`
+	Firmware  string            `yaml:"firmware,omitempty"` // FirmwareBIOS (по умолчанию) или FirmwareUEFI
+	// OSType и OSVariant - тип и вариант гостевой ОС, например "linux" и "ubuntu22.04"
+	OSType    string `yaml:"os_type,omitempty"`
+	OSVariant string `yaml:"os_variant,omitempty"`
+	// Affinity и AntiAffinity - ВМ, с которыми эту ВМ нужно или нельзя размещать на одном хосте
+	Affinity     []string `yaml:"affinity,omitempty"`
+	AntiAffinity []string `yaml:"anti_affinity,omitempty"`
+	// CPUPinning привязывает vCPU (по индексу) к физическому CPU
+	CPUPinning map[uint]uint `yaml:"cpu_pinning,omitempty"`
+	// NUMANodes - NUMA-топология гостя; память узлов в сумме равна Memory
+	NUMANodes []NUMANode `yaml:"numa_nodes,omitempty"`
+	// StartOnCreate - запускать ли ВМ сразу после создания; nil - по настройке менеджера
+	// (WithAutoStartOnCreate, по умолчанию запускать)
+	StartOnCreate *bool `yaml:"start_on_create,omitempty"`
+	// DiskReadIOPS и DiskWriteIOPS ограничивают операции чтения и записи основного диска
+	// в секунду (0 - без ограничения)
+	DiskReadIOPS  uint `yaml:"disk_read_iops,omitempty"`
+	DiskWriteIOPS uint `yaml:"disk_write_iops,omitempty"`
+	// Bandwidth - ограничения пропускной способности сетевого интерфейса
+	Bandwidth NetworkSpec `yaml:"bandwidth,omitempty"`
+	// ReservationID - резервирование Reserve, ресурсы которого занимает создаваемая ВМ;
+	// CreateVM освобождает его при успешном создании и не сохраняет в конфигурации ВМ
+	ReservationID string `yaml:"reservation_id,omitempty"`
+}
+
+// Поддерживаемые значения VMConfig.Firmware
+const (
+	FirmwareBIOS = "bios"
+	FirmwareUEFI = "uefi"
+)
+
+// validateConfig проверяет имя, ресурсы, образы дисков и ISO-образ конфигурации ВМ
+func (m *MockVMManager) validateConfig(config VMConfig) error {
+	if errs := m.configErrors(config); len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// configErrors возвращает все ошибки конфигурации в порядке проверки
+func (m *MockVMManager) configErrors(config VMConfig) []error {
+	var errs []error
+	if config.Name == "" {
+		errs = append(errs, fmt.Errorf("VM name cannot be empty"))
+	} else if err := m.validateName(config.Name); err != nil {
+		errs = append(errs, err)
+	}
+	if config.Memory == 0 {
+		errs = append(errs, fmt.Errorf("VM memory cannot be zero"))
+	}
+	if config.VCPUs == 0 {
+		errs = append(errs, fmt.Errorf("VM VCPUs cannot be zero"))
+	}
+	if err := validateCPUPinning(config.CPUPinning, config.VCPUs); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateNUMANodes(config.NUMANodes, config.Memory, config.VCPUs); err != nil {
+		errs = append(errs, err)
+	}
+	if err := validateQoS(config); err != nil {
+		errs = append(errs, err)
+	}
+	switch config.Firmware {
+	case "", FirmwareBIOS, FirmwareUEFI:
+	default:
+		errs = append(errs, fmt.Errorf("unsupported firmware '%s': use '%s' or '%s'", config.Firmware, FirmwareBIOS, FirmwareUEFI))
+	}
+	if err := m.validateOSType(config.OSType, config.OSVariant); err != nil {
+		errs = append(errs, err)
+	}
+	for _, path := range diskPaths(config) {
+		if err := m.validateDiskImage(path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if config.ISOImage != "" {
+		if err := m.validateISO(config.ISOImage); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// ValidateVMConfigFull проверяет конфигурацию так же, как CreateVM (с учетом значений
+// по умолчанию), но возвращает сразу все найденные ошибки, а не только первую.
+// Пустой результат означает, что конфигурация корректна
+func (m *MockVMManager) ValidateVMConfigFull(config VMConfig) []error {
+	return m.configErrors(m.applyDefaults(config))
+}
+
+// copyConfig возвращает глубокую копию конфигурации ВМ
+func copyConfig(config VMConfig) VMConfig {
+	config.Disks = append([]DiskSpec(nil), config.Disks...)
+	config.DependsOn = append([]string(nil), config.DependsOn...)
+	config.Affinity = append([]string(nil), config.Affinity...)
+	config.AntiAffinity = append([]string(nil), config.AntiAffinity...)
+	if config.Labels != nil {
+		labels := make(map[string]string, len(config.Labels))
+		for k, v := range config.Labels {
+			labels[k] = v
+		}
+		config.Labels = labels
+	}
+	config.CPUPinning = maps.Clone(config.CPUPinning)
+	config.NUMANodes = copyNUMANodes(config.NUMANodes)
+	if config.StartOnCreate != nil {
+		start := *config.StartOnCreate
+		config.StartOnCreate = &start
+	}
+	return config
 }
 
 // VMState представляет состояние виртуальной машины
@@ -33,72 +362,382 @@ const (
 	VMStateStopped VMState = "stopped"
 	VMStateRunning VMState = "running"
 	VMStatePaused  VMState = "paused"
+	// VMStateError - операция бэкенда прервалась посреди перехода и состояние ВМ
+	// неизвестно; сбрасывается через ClearError
+	VMStateError VMState = "error"
+)
+
+// VMStates - встроенные состояния ВМ; пользовательские добавляются через RegisterState
+var VMStates = []VMState{VMStateStopped, VMStateRunning, VMStatePaused, VMStateError}
+
+// CreateStep - этап создания виртуальной машины
+type CreateStep string
+
+const (
+	CreateStepAllocateDisk CreateStep = "allocate_disk"
+	CreateStepDefine       CreateStep = "define"
+	CreateStepStart        CreateStep = "start"
 )
 
 // MockVM представляет виртуальную машину в mock-режиме
 type MockVM struct {
-	Config VMConfig
-	State  VMState
+	Config    VMConfig
+	State     VMState
+	Snapshots []Snapshot // в порядке создания
+	// LinkedSource - имя ВМ, диски которой используются этим связанным клоном как backing-файлы
+	LinkedSource string
+	// CurrentMemoryMB - память, выделенная ВМ сейчас (balloon-драйвер может уменьшить ее
+	// относительно Config.Memory); 0 для остановленной ВМ
+	CurrentMemoryMB uint64
+	// ErrorReason - причина перехода в VMStateError
+	ErrorReason string
+	// LastError - ошибка последней неудачной операции с ВМ; сбрасывается при следующей успешной
+	LastError string
+
+	// Host - хост, на котором размещена ВМ
+	Host string
+	// BootDevice - устройство, с которого ВМ загрузилась при последнем запуске
+	BootDevice string
+	// BootTimeout - ожидаемое время загрузки ВМ, ограничение WaitForIP по умолчанию
+	// (0 - не задано, см. SetBootTimeout)
+	BootTimeout time.Duration
+	// SnapshotSpaceEstimate - место в пуле хранения (GB) для одного снапшота ВМ
+	// (0 - по занятому месту дисков, см. SetSnapshotSpaceEstimate)
+	SnapshotSpaceEstimate uint64
+	// Locked - конфигурация ВМ заблокирована от изменений (см. LockVMConfig)
+	Locked bool
+
+	startedAt time.Time // время последнего запуска по часам менеджера
+	nextBoot  string    // устройство загрузки для следующего запуска (см. SetNextBoot)
+
+	guestFiles  map[string][]byte // файлы гостевой ОС: путь -> содержимое
+	compactedGB map[string]uint64 // занятое место сжатых CompactDisk дисков: путь -> ГБ
+	busy        bool              // выполняется длительная операция (например, запуск)
 }
 
 // MockVMManager - mock-реализация менеджера виртуальных машин
 // Хранит все данные в памяти, не создает реальные виртуальные машины
 type MockVMManager struct {
-	vms  map[string]*MockVM
-	mu   sync.RWMutex
-	next int // для генерации уникальных ID
+	vms   map[string]*MockVM
+	disks map[string]string // выделенные диски: путь -> имя ВМ
+	mu    sync.RWMutex
+	next  int // для генерации уникальных ID
+
+	createStepHook func(step CreateStep, name string) error
+	createTimeout  time.Duration
+	createFailures []error // ошибки очередных вызовов CreateVM (см. WithCreateFailures)
+	// simulatedCreateDelay - имитируемая длительность создания ВМ
+	simulatedCreateDelay time.Duration
+	// simulatedStartDelay - имитируемая длительность запуска ВМ
+	simulatedStartDelay time.Duration
+	// simulatedStopDelay - имитируемая длительность корректного завершения работы ВМ
+	simulatedStopDelay time.Duration
+	nameValidator      NameValidator
+	// batchConcurrency - сколько элементов пакетной операции выполняется одновременно
+	batchConcurrency int
+
+	dependencyOrdering   bool
+	limits               Limits
+	capabilities         Capabilities
+	openDiskImage        FileOpener
+	listDir              DirLister
+	now                  func() time.Time
+	defaults             VMConfig
+	cpuSampler           CPUSampler
+	stat                 StatFunc
+	newTicker            TickerFunc
+	transitionFailure    TransitionFailure
+	simulatedIPDelay     time.Duration
+	hosts                []string
+	osTypes              map[string][]string     // известные типы гостевых ОС -> варианты
+	autoStartOnCreate    bool                    // запускать ВМ после создания, если StartOnCreate не задан
+	caseInsensitiveNames bool                    // сравнивать имена ВМ без учета регистра
+	reservations         map[string]*reservation // резервирования ресурсов по идентификатору
+	reservationTTL       time.Duration           // время жизни резервирования (0 - без срока)
+	renameFile           FileRenamer
+	transitions          map[VMState][]VMState // состояние -> состояния, из которых в него можно перейти
+	customStates         []VMState             // пользовательские состояния в порядке регистрации
+	storePath            string                // файл хранилища (см. NewMockVMManagerWithStore); пустой - только память
+	storePassphrase      string                // парольная фраза шифрования хранилища (WithStoreKey); пустая - без шифрования
+	storeSalt            []byte                // соль scrypt, из которой получен storeKey
+	storeKey             []byte                // ключ AES-256, полученный из storePassphrase; nil - без шифрования
+	audit                []AuditEntry          // журнал изменяющих операций, последняя - в конце
+	startedAt            time.Time             // время создания менеджера по его часам
+	broker               eventBroker
+	eventsOnce           sync.Once
+	events               <-chan VMEvent // подписка, возвращаемая Events
+
+	hooksMu sync.Mutex
+	hooks   []OperationHook // цепочка хуков операций в порядке добавления
+
+	schedulesMu sync.Mutex
+	schedules   map[*MockVM]*snapshotSchedule // планировщики снапшотов по ВМ
+	closed      bool
+}
+
+// MockOption настраивает MockVMManager при создании
+type MockOption func(*MockVMManager)
+
+// WithCreateStepHook задает хук, вызываемый перед каждым этапом CreateVM.
+// Ошибка из хука имитирует сбой этапа и приводит к откату уже выполненных этапов
+func WithCreateStepHook(hook func(step CreateStep, name string) error) MockOption {
+	return func(m *MockVMManager) {
+		m.createStepHook = hook
+	}
+}
+
+// WithSimulatedCreateDelay добавляет задержку перед запуском создаваемой ВМ,
+// имитирующую медленный бэкенд. Задержка прерывается отменой контекста. Как и при
+// WithSimulatedStartDelay, менеджер на это время не блокируется: уже определенная ВМ
+// помечается занятой, и конфликтующие операции отклоняются с ErrVMBusy
+func WithSimulatedCreateDelay(delay time.Duration) MockOption {
+	return func(m *MockVMManager) {
+		m.simulatedCreateDelay = delay
+	}
+}
+
+// WithCreateTimeout ограничивает общее время выполнения CreateVM (0 - без ограничения)
+func WithCreateTimeout(timeout time.Duration) MockOption {
+	return func(m *MockVMManager) {
+		m.createTimeout = timeout
+	}
+}
+
+// WithClock подменяет источник текущего времени (например, в тестах)
+func WithClock(now func() time.Time) MockOption {
+	return func(m *MockVMManager) {
+		m.now = now
+	}
 }
 
 // NewMockVMManager создает новый mock-менеджер виртуальных машин
-func NewMockVMManager() *MockVMManager {
-	return &MockVMManager{
-		vms:  make(map[string]*MockVM),
-		next: 1,
+func NewMockVMManager(opts ...MockOption) *MockVMManager {
+	m := &MockVMManager{
+		vms:               make(map[string]*MockVM),
+		disks:             make(map[string]string),
+		next:              1,
+		nameValidator:     DefaultNameValidator,
+		openDiskImage:     openFile,
+		listDir:           listDir,
+		now:               time.Now,
+		cpuSampler:        syntheticCPU,
+		stat:              os.Stat,
+		newTicker:         newTicker,
+		simulatedIPDelay:  defaultSimulatedIPDelay,
+		hosts:             []string{defaultHost},
+		osTypes:           DefaultOSTypes,
+		autoStartOnCreate: true,
+		reservations:      make(map[string]*reservation),
+		reservationTTL:    defaultReservationTTL,
+		renameFile:        renameFile,
+		schedules:         make(map[*MockVM]*snapshotSchedule),
+		transitions:       cloneTransitions(builtinTransitions),
+		batchConcurrency:  1,
+	}
+	for _, opt := range opts {
+		opt(m)
 	}
+	m.startedAt = m.now()
+	return m
 }
 
-// Close закрывает mock-менеджер (ничего не делает, но реализует интерфейс)
+// Close закрывает mock-менеджер и останавливает планировщики снапшотов; менеджер с
+// хранилищем перед этим сохраняет в него состояние
 func (m *MockVMManager) Close() error {
-	log.Println("[MOCK] Closing VM manager (no-op in mock mode)")
+	m.stopSchedules()
+	log.Println("[MOCK] Closing VM manager")
+	return m.saveStore()
+}
+
+// Sync синхронизирует менеджер с хранилищем: постоянные бэкенды сохраняют накопленные
+// изменения, реальные (libvirt и т.д.) перечитывают список доменов. Mock-менеджер с
+// хранилищем (NewMockVMManagerWithStore) записывает состояние в файл, без него - только
+// проверяет контекст
+func (m *MockVMManager) Sync(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if m.storePath != "" {
+		return m.saveStore()
+	}
+	log.Println("[MOCK] Sync requested: in-memory state is always up to date")
 	return nil
 }
 
+// BackendType возвращает "mock": реальные виртуальные машины не создаются
+func (m *MockVMManager) BackendType() string {
+	return "mock"
+}
+
 // CreateVM создает новую виртуальную машину в памяти
-func (m *MockVMManager) CreateVM(config VMConfig) error {
+func (m *MockVMManager) CreateVM(ctx context.Context, config VMConfig) error {
+	return m.runHooks("create", config.Name, func() error { return m.createVM(ctx, config) })
+}
+
+// createVM выполняет CreateVM без хуков операций
+func (m *MockVMManager) createVM(ctx context.Context, config VMConfig) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// Проверяем, не существует ли уже ВМ с таким именем
-	if _, exists := m.vms[config.Name]; exists {
-		return fmt.Errorf("virtual machine with name '%s' already exists", config.Name)
-	}
+	config = m.applyDefaults(config)
 
-	// Валидация конфигурации
-	if config.Name == "" {
-		return fmt.Errorf("VM name cannot be empty")
+	if err := m.nextCreateFailureLocked(); err != nil {
+		return err
 	}
-	if config.Memory == 0 {
-		return fmt.Errorf("VM memory cannot be zero")
+	if err := m.checkCreateLocked(config); err != nil {
+		return err
 	}
-	if config.VCPUs == 0 {
-		return fmt.Errorf("VM VCPUs cannot be zero")
+	reservationID := config.ReservationID
+
+	// Копируем конфигурацию, чтобы не разделять срезы с вызывающим кодом
+	config = copyConfig(config)
+	config.ReservationID = ""
+
+	// Этапы создания; каждый возвращает функцию отката
+	steps := []struct {
+		step CreateStep
+		run  func() func()
+	}{
+		{CreateStepAllocateDisk, func() func() {
+			paths := diskPaths(config)
+			for _, path := range paths {
+				m.disks[path] = config.Name
+			}
+			return func() { m.releaseDisksLocked(config.Name, paths) }
+		}},
+		{CreateStepDefine, func() func() {
+			host, _ := m.placeLocked(config)
+			m.vms[config.Name] = &MockVM{
+				Config: config,
+				State:  VMStateStopped,
+				Host:   host,
+			}
+			log.Printf("[MOCK] Virtual machine '%s' created successfully (Memory: %d MB, VCPUs: %d, Disk: %s)",
+				config.Name, config.Memory, config.VCPUs, config.DiskPath)
+			// Резервирование расходуется вместе с определением ВМ, чтобы на время задержки
+			// создания его ресурсы не учитывались в квотах дважды
+			reserved := m.reservations[reservationID]
+			if reserved != nil {
+				delete(m.reservations, reservationID)
+				log.Printf("[MOCK] Reservation '%s' consumed by virtual machine '%s'", reservationID, config.Name)
+			}
+			return func() {
+				// Пока длилась задержка создания, к ВМ могли подключить диски
+				paths := diskPaths(m.vms[config.Name].Config)
+				delete(m.vms, config.Name)
+				m.releaseDisksLocked(config.Name, paths)
+				if reserved != nil {
+					m.reservations[reservationID] = reserved
+				}
+			}
+		}},
+		{CreateStepStart, func() func() {
+			// Автоматически запускаем ВМ (в mock-режиме это просто изменение состояния)
+			vm := m.vms[config.Name]
+			vm.State = VMStateRunning
+			vm.CurrentMemoryMB = vm.Config.Memory
+			vm.startedAt = m.now()
+			log.Printf("[MOCK] Virtual machine '%s' started successfully", config.Name)
+			return func() {
+				vm.State = VMStateStopped
+				vm.CurrentMemoryMB = 0
+			}
+		}},
+	}
+
+	// Этап запуска выполняется, только если ВМ нужно запустить после создания
+	if !m.startOnCreate(config) {
+		steps = steps[:len(steps)-1]
+	}
+
+	if m.createTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.createTimeout)
+		defer cancel()
 	}
 
-	// Создаем mock-виртуальную машину
-	mockVM := &MockVM{
-		Config: config,
-		State:  VMStateStopped,
+	var undo []func()
+	for _, s := range steps {
+		if err := m.checkCreateStep(ctx, s.step, config.Name); err != nil {
+			// Откатываем выполненные этапы в обратном порядке
+			for i := len(undo) - 1; i >= 0; i-- {
+				undo[i]()
+			}
+			log.Printf("[MOCK] Creation of virtual machine '%s' rolled back after failed step '%s'", config.Name, s.step)
+			return fmt.Errorf("failed to create VM '%s' at step '%s': %w", config.Name, s.step, err)
+		}
+		undo = append(undo, s.run())
 	}
 
-	m.vms[config.Name] = mockVM
+	m.recordLocked(AuditEntry{Operation: AuditCreate, VMName: config.Name})
+	return nil
+}
+
+// ValidateCreate проверяет, что ВМ с конфигурацией config можно создать прямо сейчас:
+// выполняет те же проверки, что CreateVM (имя, конфигурация, конфликты дисков,
+// резервирование, квоты и размещение), ничего не создавая. Имитируемые сбои
+// WithCreateFailures и этапы создания не проверяются
+func (m *MockVMManager) ValidateCreate(config VMConfig) error {
+	m.mu.Lock() // проверка резервирования удаляет истекшие резервирования
+	defer m.mu.Unlock()
+
+	return m.checkCreateLocked(m.applyDefaults(config))
+}
+
+// checkCreateLocked проверяет конфигурацию создаваемой ВМ с уже примененными значениями
+// по умолчанию. Вызывающий код должен удерживать m.mu на запись
+func (m *MockVMManager) checkCreateLocked(config VMConfig) error {
+	// Проверяем, не существует ли уже ВМ с таким именем
+	if err := m.checkNameFreeLocked(config.Name, ""); err != nil {
+		return err
+	}
 
-	log.Printf("[MOCK] Virtual machine '%s' created successfully (Memory: %d MB, VCPUs: %d, Disk: %s)",
-		config.Name, config.Memory, config.VCPUs, config.DiskPath)
+	// Валидация конфигурации
+	if err := m.validateConfig(config); err != nil {
+		return withCategory(err, ErrInvalidConfig)
+	}
+	if err := m.checkDiskConflictsLocked(config.Name, diskSpecs(config)); err != nil {
+		return err
+	}
 
-	// Автоматически запускаем ВМ (в mock-режиме это просто изменение состояния)
-	mockVM.State = VMStateRunning
-	log.Printf("[MOCK] Virtual machine '%s' started successfully", config.Name)
+	// Проверяем квоты и ограничения; ресурсы резервирования ВМ в них не учитываются
+	if config.ReservationID != "" {
+		if err := m.checkReservationLocked(config.ReservationID); err != nil {
+			return err
+		}
+	}
+	if reason := m.scheduleReasonLocked(config); reason != "" {
+		return fmt.Errorf("cannot schedule VM '%s': %s", config.Name, reason)
+	}
+	return nil
+}
 
+// checkCreateStep проверяет, можно ли выполнить очередной этап создания ВМ.
+// Перед запуском выдерживается имитируемая задержка создания, прерываемая отменой ctx:
+// на это время ВМ помечается занятой, а m.mu отпускается, как в waitStartLocked.
+// Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) checkCreateStep(ctx context.Context, step CreateStep, name string) error {
+	if step == CreateStepStart && m.simulatedCreateDelay > 0 {
+		vm := m.vms[name]
+		vm.busy = true
+		m.mu.Unlock()
+
+		timer := time.NewTimer(m.simulatedCreateDelay)
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+		}
+		timer.Stop()
+
+		m.mu.Lock()
+		vm.busy = false
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if m.createStepHook != nil {
+		return m.createStepHook(step, name)
+	}
 	return nil
 }
 
@@ -116,31 +755,141 @@ func (m *MockVMManager) ListVMs() ([]string, error) {
 	return vmNames, nil
 }
 
-// StartVM запускает виртуальную машину по имени
-func (m *MockVMManager) StartVM(name string) error {
+// ListGroupedByState возвращает отсортированные имена ВМ по состояниям.
+// Результат содержит все состояния из VMStates и зарегистрированные через RegisterState,
+// в том числе без ВМ
+func (m *MockVMManager) ListGroupedByState() (map[VMState][]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	states := m.knownStatesLocked()
+	groups := make(map[VMState][]string, len(states))
+	for _, state := range states {
+		groups[state] = []string{}
+	}
+	for name, vm := range m.vms {
+		groups[vm.State] = append(groups[vm.State], name)
+	}
+	for _, names := range groups {
+		sort.Strings(names)
+	}
+	return groups, nil
+}
+
+// StartVM запускает виртуальную машину по имени.
+// Если включен порядок зависимостей, сначала запускаются ВМ из DependsOn
+func (m *MockVMManager) StartVM(ctx context.Context, name string) error {
+	return m.runHooks("start", name, func() error { return m.startVM(ctx, name) })
+}
+
+// startVM выполняет StartVM без хуков операций
+func (m *MockVMManager) startVM(ctx context.Context, name string) (err error) {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	name = m.resolveNameLocked(name)
+	defer func() { m.noteResultLocked(name, err) }()
+
+	if m.simulatedStartDelay > 0 {
+		if err := m.waitStartLocked(ctx, name); err != nil {
+			return err
+		}
+	}
+
+	before := m.statesLocked()
+	order := []string{name}
+	if m.dependencyOrdering {
+		order, err = m.startWithDepsLocked(name)
+	} else {
+		err = m.startVMLocked(name)
+	}
+	if err != nil {
+		return err
+	}
+	m.recordTransitionLocked(AuditStart, name, order, before)
+	return nil
+}
 
+// startVMLocked запускает одну ВМ; вызывающий код должен удерживать m.mu
+func (m *MockVMManager) startVMLocked(name string) error {
 	vm, exists := m.vms[name]
 	if !exists {
 		return fmt.Errorf("virtual machine '%s' not found", name)
 	}
 
+	if err := checkNotBusyLocked(vm, name); err != nil {
+		return err
+	}
+
 	if vm.State == VMStateRunning {
 		log.Printf("[MOCK] Virtual machine '%s' is already running", name)
 		return nil
 	}
+	if err := m.checkTransitionLocked(vm, "start", name, VMStateRunning); err != nil {
+		return err
+	}
 
-	vm.State = VMStateRunning
-	log.Printf("[MOCK] Virtual machine '%s' started", name)
+	m.bootLocked(vm)
+	log.Printf("[MOCK] Virtual machine '%s' started (boot device: %s)", name, vm.BootDevice)
 	return nil
 }
 
-// StopVM останавливает виртуальную машину
-func (m *MockVMManager) StopVM(name string) error {
+// bootLocked переводит ВМ в запущенное состояние: выделяет ей всю настроенную память и
+// загружает с диска или с устройства, заданного SetNextBoot. Вызывающий код должен
+// удерживать m.mu
+func (m *MockVMManager) bootLocked(vm *MockVM) {
+	vm.State = VMStateRunning
+	vm.CurrentMemoryMB = vm.Config.Memory
+	vm.startedAt = m.now()
+	vm.BootDevice = BootDeviceDisk
+	if vm.nextBoot != "" {
+		vm.BootDevice, vm.nextBoot = vm.nextBoot, ""
+	}
+}
+
+// StopVM останавливает виртуальную машину.
+// Если включен порядок зависимостей, сначала останавливаются зависящие от нее ВМ
+func (m *MockVMManager) StopVM(ctx context.Context, name string) error {
+	return m.runHooks("stop", name, func() error { return m.stopVM(ctx, name) })
+}
+
+// stopVM выполняет StopVM без хуков операций
+func (m *MockVMManager) stopVM(ctx context.Context, name string) (err error) {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	name = m.resolveNameLocked(name)
+	defer func() { m.noteResultLocked(name, err) }()
+
+	if err := m.waitStopLocked(ctx, name, m.simulatedStopDelay); err != nil {
+		return err
+	}
 
+	before := m.statesLocked()
+	order := []string{name}
+	if m.dependencyOrdering {
+		var err error
+		if order, err = m.dependentsOrderLocked(name); err != nil {
+			return err
+		}
+	}
+	for _, n := range order {
+		if err := m.stopVMLocked(n); err != nil {
+			return err
+		}
+	}
+	m.recordTransitionLocked(AuditStop, name, order, before)
+	return nil
+}
+
+// stopVMLocked останавливает одну ВМ; вызывающий код должен удерживать m.mu
+func (m *MockVMManager) stopVMLocked(name string) error {
 	vm, exists := m.vms[name]
 	if !exists {
 		return fmt.Errorf("virtual machine '%s' not found", name)
@@ -150,21 +899,51 @@ func (m *MockVMManager) StopVM(name string) error {
 		log.Printf("[MOCK] Virtual machine '%s' is already stopped", name)
 		return nil
 	}
+	if err := m.checkTransitionLocked(vm, "stop", name, VMStateStopped); err != nil {
+		return err
+	}
 
 	vm.State = VMStateStopped
+	vm.CurrentMemoryMB = 0
 	log.Printf("[MOCK] Virtual machine '%s' stopped", name)
 	return nil
 }
 
-// DeleteVM удаляет виртуальную машину
-func (m *MockVMManager) DeleteVM(name string) error {
+// DeleteVMOptions - параметры удаления ВМ
+type DeleteVMOptions struct {
+	// Force разрешает удалить ВМ вместе с ее снапшотами
+	Force bool
+}
+
+// DeleteVM удаляет виртуальную машину. ВМ со снапшотами удаляется только
+// с opts.Force, иначе возвращается ErrVMHasSnapshots
+func (m *MockVMManager) DeleteVM(ctx context.Context, name string, opts DeleteVMOptions) error {
+	return m.runHooks("delete", name, func() error { return m.deleteVM(ctx, name, opts) })
+}
+
+// deleteVM выполняет DeleteVM без хуков операций
+func (m *MockVMManager) deleteVM(ctx context.Context, name string, opts DeleteVMOptions) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	name = m.resolveNameLocked(name)
 
-	vm, exists := m.vms[name]
-	if !exists {
-		return fmt.Errorf("virtual machine '%s' not found", name)
+	if err := m.deleteVMLocked(name, opts); err != nil {
+		return err
 	}
+	m.recordLocked(AuditEntry{Operation: AuditDelete, VMName: name})
+	return nil
+}
+
+// deleteVMLocked удаляет ВМ и освобождает ее диски; вызывающий код должен удерживать m.mu
+func (m *MockVMManager) deleteVMLocked(name string, opts DeleteVMOptions) error {
+	if err := m.checkDeletableLocked(name, opts); err != nil {
+		return err
+	}
+	vm := m.vms[name]
 
 	// Останавливаем, если запущена
 	if vm.State == VMStateRunning {
@@ -172,16 +951,40 @@ func (m *MockVMManager) DeleteVM(name string) error {
 		log.Printf("[MOCK] Stopped virtual machine '%s' before deletion", name)
 	}
 
-	// Удаляем из хранилища
+	// Удаляем из хранилища и освобождаем диски
+	m.releaseDisksLocked(name, diskPaths(vm.Config))
 	delete(m.vms, name)
 	log.Printf("[MOCK] Virtual machine '%s' deleted", name)
 	return nil
 }
 
+// checkDeletableLocked проверяет, что ВМ существует и ее можно удалить с параметрами opts;
+// вызывающий код должен удерживать m.mu
+func (m *MockVMManager) checkDeletableLocked(name string, opts DeleteVMOptions) error {
+	vm, exists := m.vms[name]
+	if !exists {
+		return fmt.Errorf("virtual machine '%s' not found", name)
+	}
+	if err := checkNotBusyLocked(vm, name); err != nil {
+		return err
+	}
+	if err := checkUnlockedLocked(vm, name, "delete"); err != nil {
+		return err
+	}
+	if n := len(vm.Snapshots); n > 0 && !opts.Force {
+		return fmt.Errorf("cannot delete virtual machine '%s' with %d snapshot(s): delete the snapshots first or force the deletion: %w", name, n, ErrVMHasSnapshots)
+	}
+	if clones := m.linkedClonesLocked(name); len(clones) > 0 {
+		return fmt.Errorf("cannot delete virtual machine '%s': linked clones depend on it: %s", name, strings.Join(clones, ", "))
+	}
+	return nil
+}
+
 // GetVMInfo возвращает информацию о виртуальной машине (дополнительный метод для mock)
 func (m *MockVMManager) GetVMInfo(name string) (*MockVM, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
+	name = m.resolveNameLocked(name)
 
 	vm, exists := m.vms[name]
 	if !exists {
@@ -195,6 +998,7 @@ func (m *MockVMManager) GetVMInfo(name string) (*MockVM, error) {
 func (m *MockVMManager) GetVMState(name string) (VMState, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
+	name = m.resolveNameLocked(name)
 
 	vm, exists := m.vms[name]
 	if !exists {
@@ -203,4 +1007,3 @@ func (m *MockVMManager) GetVMState(name string) (VMState, error) {
 
 	return vm.State, nil
 }
-
@@ -0,0 +1,75 @@
+package vm
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// MemorySpec - объем памяти в аргументах инструментов: число с необязательной единицей
+// измерения, например "4096", "4096MB", "4GB", "4Gi" или "1.5G". Число без единицы
+// означает МБ; единицы двоичные (1 ГБ = 1024 МБ), регистр не важен
+type MemorySpec string
+
+// memoryUnitsMB - множители единиц измерения MemorySpec относительно МБ
+var memoryUnitsMB = map[string]float64{
+	"":    1,
+	"m":   1,
+	"mb":  1,
+	"mi":  1,
+	"mib": 1,
+	"g":   1 << 10,
+	"gb":  1 << 10,
+	"gi":  1 << 10,
+	"gib": 1 << 10,
+	"t":   1 << 20,
+	"tb":  1 << 20,
+	"ti":  1 << 20,
+	"tib": 1 << 20,
+}
+
+// minBareMemoryMB - наименьший объем, принимаемый без единицы измерения: меньшие числа
+// почти всегда означают ГБ ("4" вместо "4GB"), поэтому для них требуется единица
+const minBareMemoryMB = 64
+
+// MB возвращает объем памяти в МБ; пустая строка означает 0 (значение по умолчанию).
+// Объем должен быть целым числом МБ
+func (s MemorySpec) MB() (uint64, error) {
+	text := strings.TrimSpace(string(s))
+	if text == "" {
+		return 0, nil
+	}
+
+	split := strings.IndexFunc(text, func(r rune) bool { return r != '.' && !unicode.IsDigit(r) })
+	if split < 0 {
+		split = len(text)
+	}
+	number, unit := text[:split], strings.ToLower(strings.TrimSpace(text[split:]))
+
+	value, err := strconv.ParseFloat(number, 64)
+	if number == "" || err != nil {
+		return 0, fmt.Errorf("invalid memory '%s': expected a number with an optional unit (e.g. \"4096MB\" or \"4GB\")", s)
+	}
+	multiplier, ok := memoryUnitsMB[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid memory '%s': unknown unit '%s', use MB, GB or TB (e.g. \"4096MB\" or \"4GB\")", s, text[split:])
+	}
+	mb := value * multiplier
+	if mb != math.Trunc(mb) || mb > math.MaxUint64 {
+		return 0, fmt.Errorf("invalid memory '%s': must be a whole number of MB", s)
+	}
+	if unit == "" && mb < minBareMemoryMB {
+		return 0, fmt.Errorf("invalid memory '%s': a number without a unit is in MB; write \"%sGB\" for gigabytes or \"%sMB\" if you really mean megabytes", s, number, number)
+	}
+	return uint64(mb), nil
+}
+
+// memorySpecFromMB возвращает MemorySpec для объема в МБ; 0 - пустая строка
+func memorySpecFromMB(mb uint64) MemorySpec {
+	if mb == 0 {
+		return ""
+	}
+	return MemorySpec(fmt.Sprintf("%dMB", mb))
+}
@@ -0,0 +1,63 @@
+package vm
+
+import "testing"
+
+func TestMemorySpecMB(t *testing.T) {
+	tests := []struct {
+		spec MemorySpec
+		want uint64
+	}{
+		{"", 0},
+		{"4096", 4096},
+		{"4096MB", 4096},
+		{"4GB", 4096},
+		{"4Gi", 4096},
+		{"1.5G", 1536},
+		{" 2 gb ", 2048},
+		{"1TB", 1 << 20},
+	}
+	for _, tt := range tests {
+		got, err := tt.spec.MB()
+		if err != nil {
+			t.Errorf("MemorySpec(%q).MB(): %v", tt.spec, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("MemorySpec(%q).MB() = %d, want %d", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestMemorySpecMBRejects(t *testing.T) {
+	for _, spec := range []MemorySpec{
+		"4",      // без единицы - МБ, но такой объем почти наверняка означает ГБ
+		"GB",     // нет числа
+		"4XB",    // неизвестная единица
+		"1.3MB",  // не целое число МБ
+		"-4GB",   // отрицательный объем
+		"4GB4GB", // мусор после единицы
+	} {
+		if mb, err := spec.MB(); err == nil {
+			t.Errorf("MemorySpec(%q).MB() = %d, want an error", spec, mb)
+		}
+	}
+}
+
+func TestCreateVMToolAcceptsMemoryUnits(t *testing.T) {
+	m := newTestManager(t)
+	tools := newTestTools(t, m)
+
+	if resp := callTool(t, tools, "create_vm", map[string]any{"name": "web", "memory": "2GB", "vcpus": 1}); resp["success"] != true {
+		t.Fatalf("create_vm with memory in GB = %v", resp)
+	}
+	info, err := m.LookupVM("web")
+	if err != nil {
+		t.Fatalf("LookupVM: %v", err)
+	}
+	if info.Config.Memory != 2048 {
+		t.Errorf("memory = %d MB, want 2048", info.Config.Memory)
+	}
+	if resp := callTool(t, tools, "create_vm", map[string]any{"name": "db", "memory": "4", "vcpus": 1}); resp["success"] != false {
+		t.Errorf("create_vm with a bare small number = %v, want a failure", resp)
+	}
+}
@@ -0,0 +1,113 @@
+package vm
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+// metricsInterval - интервал между точками синтезируемой истории метрик
+const metricsInterval = time.Minute
+
+// metricsHistoryLen - количество точек истории, возвращаемых GetVMMetrics
+const metricsHistoryLen = 10
+
+// CPUSampler возвращает загрузку CPU запущенной ВМ (в процентах) в момент времени at
+type CPUSampler func(name string, at time.Time) float64
+
+// CPUSample - значение загрузки CPU в момент времени
+type CPUSample struct {
+	Time       time.Time `json:"time"`
+	CPUPercent float64   `json:"cpu_percent"`
+}
+
+// VMMetrics - текущие метрики ВМ и история загрузки CPU (от старых к новым)
+type VMMetrics struct {
+	CPUPercent float64     `json:"cpu_percent"`
+	MemoryMB   uint64      `json:"memory_mb"`
+	CPUHistory []CPUSample `json:"cpu_history"`
+}
+
+// syntheticCPU - загрузка CPU по умолчанию: постоянный для каждой ВМ базовый уровень
+// (по хэшу имени) с небольшими колебаниями от минуты к минуте
+func syntheticCPU(name string, at time.Time) float64 {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	base := float64(h.Sum32() % 90)
+
+	h.Write([]byte(at.Truncate(metricsInterval).Format(time.RFC3339)))
+	jitter := float64(h.Sum32()%1000)/100 - 5 // от -5 до +5
+
+	cpu := base + jitter
+	if cpu < 0 {
+		return 0
+	}
+	return cpu
+}
+
+// WithCPUSampler подменяет источник синтезируемой загрузки CPU (например, в тестах)
+func WithCPUSampler(sampler CPUSampler) MockOption {
+	return func(m *MockVMManager) {
+		m.cpuSampler = sampler
+	}
+}
+
+// cpuAtLocked возвращает загрузку CPU ВМ в момент at; у незапущенной ВМ она равна 0.
+// Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) cpuAtLocked(vm *MockVM, name string, at time.Time) float64 {
+	if vm.State != VMStateRunning {
+		return 0
+	}
+	return m.cpuSampler(name, at)
+}
+
+// GetVMMetrics возвращает текущие метрики ВМ и историю загрузки CPU за последние
+// metricsHistoryLen интервалов. В mock-режиме значения синтезируются
+func (m *MockVMManager) GetVMMetrics(name string) (VMMetrics, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	name = m.resolveNameLocked(name)
+
+	vm, exists := m.vms[name]
+	if !exists {
+		return VMMetrics{}, fmt.Errorf("virtual machine '%s' not found", name)
+	}
+
+	now := m.now()
+	history := make([]CPUSample, 0, metricsHistoryLen)
+	for i := metricsHistoryLen - 1; i >= 0; i-- {
+		at := now.Add(-time.Duration(i) * metricsInterval)
+		history = append(history, CPUSample{Time: at, CPUPercent: m.cpuAtLocked(vm, name, at)})
+	}
+
+	return VMMetrics{
+		CPUPercent: history[len(history)-1].CPUPercent,
+		MemoryMB:   vm.CurrentMemoryMB,
+		CPUHistory: history,
+	}, nil
+}
+
+// IsIdle сообщает, оставалась ли загрузка CPU ВМ ниже threshold (в процентах) на
+// протяжении всего окна window. История проверяется с шагом metricsInterval
+func (m *MockVMManager) IsIdle(name string, threshold float64, window time.Duration) (bool, error) {
+	if window <= 0 {
+		return false, fmt.Errorf("idle window must be positive")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	name = m.resolveNameLocked(name)
+
+	vm, exists := m.vms[name]
+	if !exists {
+		return false, fmt.Errorf("virtual machine '%s' not found", name)
+	}
+
+	now := m.now()
+	for elapsed := time.Duration(0); elapsed <= window; elapsed += metricsInterval {
+		if m.cpuAtLocked(vm, name, now.Add(-elapsed)) >= threshold {
+			return false, nil
+		}
+	}
+	return true, nil
+}
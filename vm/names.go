@@ -0,0 +1,105 @@
+package vm
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// NameValidator проверяет допустимость имени виртуальной машины
+type NameValidator func(name string) error
+
+// defaultNamePattern - имена, допустимые для реальных бэкендов (libvirt и т.д.)
+var defaultNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]{0,62}$`)
+
+// DefaultNameValidator допускает имена из латинских букв, цифр, '_' и '-'
+// длиной до 63 символов, начинающиеся с буквы или цифры
+func DefaultNameValidator(name string) error {
+	if !defaultNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid VM name '%s': must match %s", name, defaultNamePattern)
+	}
+	return nil
+}
+
+// WithNameValidator заменяет проверку имен ВМ (nil отключает проверку)
+func WithNameValidator(validator NameValidator) MockOption {
+	return func(m *MockVMManager) {
+		m.nameValidator = validator
+	}
+}
+
+// validateName проверяет имя ВМ настроенным валидатором.
+// Используется всеми операциями, которые задают ВМ новое имя
+func (m *MockVMManager) validateName(name string) error {
+	if m.nameValidator == nil {
+		return nil
+	}
+	return m.nameValidator(name)
+}
+
+// WithCaseInsensitiveNames включает сравнение имен ВМ без учета регистра, как на бэкендах
+// с нечувствительной к регистру файловой системой: "VM1" и "vm1" считаются одним именем.
+// Поиск ВМ находит ее по имени в любом регистре, а ВМ сохраняет имя в том виде,
+// в котором его задали при создании
+func WithCaseInsensitiveNames(enabled bool) MockOption {
+	return func(m *MockVMManager) {
+		m.caseInsensitiveNames = enabled
+	}
+}
+
+// resolveNameLocked возвращает имя существующей ВМ, совпадающее с name без учета регистра,
+// если включено WithCaseInsensitiveNames. Иначе и при отсутствии такой ВМ возвращает name.
+// Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) resolveNameLocked(name string) string {
+	if !m.caseInsensitiveNames {
+		return name
+	}
+	if _, exists := m.vms[name]; exists {
+		return name
+	}
+	for existing := range m.vms {
+		if strings.EqualFold(existing, name) {
+			return existing
+		}
+	}
+	return name
+}
+
+// checkNameFreeLocked проверяет, что имя name не занято другой ВМ, и возвращает ErrVMExists,
+// если занято, в том числе при WithCaseInsensitiveNames - именем в другом регистре.
+// self - имя переименовываемой ВМ (пустое для новой): ее собственное имя в другом
+// регистре не считается занятым, так что переименование vm1 в VM1 допустимо.
+// Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) checkNameFreeLocked(name, self string) error {
+	if _, exists := m.vms[name]; exists {
+		return withCategory(fmt.Errorf("virtual machine with name '%s' already exists", name), ErrVMExists)
+	}
+	if existing := m.resolveNameLocked(name); existing != name && existing != self {
+		return fmt.Errorf("virtual machine name '%s' differs only in case from existing virtual machine '%s': %w", name, existing, ErrVMExists)
+	}
+	return nil
+}
+
+// FindNameCollisions возвращает имена ВМ, которые различаются только регистром и потому
+// совпали бы на бэкенде без учета регистра: имя в нижнем регистре -> отсортированные имена.
+// При WithCaseInsensitiveNames таких ВМ не бывает
+func (m *MockVMManager) FindNameCollisions() map[string][]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	groups := make(map[string][]string)
+	for name := range m.vms {
+		key := strings.ToLower(name)
+		groups[key] = append(groups[key], name)
+	}
+	collisions := make(map[string][]string)
+	for key, names := range groups {
+		if len(names) < 2 {
+			continue
+		}
+		sort.Strings(names)
+		collisions[key] = names
+	}
+	return collisions
+}
@@ -0,0 +1,63 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRenameChangingOnlyCase(t *testing.T) {
+	m := newTestManager(t, WithCaseInsensitiveNames(true), WithAutoStartOnCreate(false))
+	mustCreate(t, m, VMConfig{Name: "vm1", Memory: 1024, VCPUs: 1})
+	mustCreate(t, m, VMConfig{Name: "vm2", Memory: 1024, VCPUs: 1})
+
+	if err := m.RenameVM("vm1", "VM1", RenameVMOptions{}); err != nil {
+		t.Fatalf("RenameVM(vm1, VM1): %v", err)
+	}
+	info, err := m.GetVMInfo("vm1")
+	if err != nil {
+		t.Fatalf("GetVMInfo: %v", err)
+	}
+	if info.Config.Name != "VM1" {
+		t.Errorf("name after rename = %s, want VM1", info.Config.Name)
+	}
+
+	if err := m.RenameVM("VM1", "Vm2", RenameVMOptions{}); !errors.Is(err, ErrVMExists) {
+		t.Errorf("RenameVM onto another VM's name in another case = %v, want ErrVMExists", err)
+	}
+	if err := m.CreateVM(context.Background(), VMConfig{Name: "vM1", Memory: 1024, VCPUs: 1}); !errors.Is(err, ErrVMExists) {
+		t.Errorf("CreateVM with a name differing only in case = %v, want ErrVMExists", err)
+	}
+}
+
+func TestFindNameCollisions(t *testing.T) {
+	m := newTestManager(t, WithAutoStartOnCreate(false))
+	for _, name := range []string{"web", "Web", "WEB", "db", "cache"} {
+		mustCreate(t, m, VMConfig{Name: name, Memory: 1024, VCPUs: 1})
+	}
+
+	collisions := m.FindNameCollisions()
+	if len(collisions) != 1 {
+		t.Fatalf("collisions = %v, want only the web group", collisions)
+	}
+	want := []string{"WEB", "Web", "web"}
+	got := collisions["web"]
+	if len(got) != len(want) {
+		t.Fatalf("collisions[web] = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("collisions[web] = %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestFindNameCollisionsCaseInsensitive(t *testing.T) {
+	m := newTestManager(t, WithCaseInsensitiveNames(true), WithAutoStartOnCreate(false))
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+
+	if collisions := m.FindNameCollisions(); len(collisions) != 0 {
+		t.Errorf("collisions with case-insensitive names = %v, want none", collisions)
+	}
+}
@@ -0,0 +1,28 @@
+package vm
+
+import (
+	"fmt"
+	"sort"
+)
+
+// NetworkDependents возвращает отсортированные имена ВМ, подключенных к сети network, -
+// то есть ВМ, которые потеряют связь, если сеть удалить. Mock-менеджер хранит у ВМ
+// один сетевой интерфейс (VMConfig.Network), поэтому ВМ зависит от сети, если ее
+// интерфейс подключен к ней. Для неизвестной сети возвращается пустой список
+func (m *MockVMManager) NetworkDependents(network string) ([]string, error) {
+	if network == "" {
+		return nil, fmt.Errorf("network name cannot be empty")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	dependents := []string{}
+	for name, vm := range m.vms {
+		if vm.Config.Network == network {
+			dependents = append(dependents, name)
+		}
+	}
+	sort.Strings(dependents)
+	return dependents, nil
+}
@@ -0,0 +1,98 @@
+package vm
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// NUMANode - NUMA-узел гостевой топологии: vCPU узла и его память
+type NUMANode struct {
+	CPUs     []uint `yaml:"cpus"`      // индексы vCPU
+	MemoryMB uint64 `yaml:"memory_mb"` // в МБ
+}
+
+// validateNUMANodes проверяет NUMA-топологию: у каждого узла есть vCPU, индексы vCPU
+// меньше vcpus и не повторяются между узлами, а сумма памяти узлов равна memory
+func validateNUMANodes(nodes []NUMANode, memory uint64, vcpus uint) error {
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	owner := make(map[uint]int)
+	var total uint64
+	for i, node := range nodes {
+		if len(node.CPUs) == 0 {
+			return fmt.Errorf("NUMA node %d has no vCPUs", i)
+		}
+		for _, cpu := range node.CPUs {
+			if cpu >= vcpus {
+				return fmt.Errorf("NUMA node %d refers to vCPU %d, but the VM has %d vCPU(s)", i, cpu, vcpus)
+			}
+			if prev, ok := owner[cpu]; ok {
+				return fmt.Errorf("vCPU %d is assigned to both NUMA node %d and NUMA node %d", cpu, prev, i)
+			}
+			owner[cpu] = i
+		}
+		total += node.MemoryMB
+	}
+	if total != memory {
+		return fmt.Errorf("NUMA nodes have %d MB of memory in total, but the VM has %d MB", total, memory)
+	}
+	return nil
+}
+
+// copyNUMANodes возвращает независимую копию узлов
+func copyNUMANodes(nodes []NUMANode) []NUMANode {
+	if nodes == nil {
+		return nil
+	}
+	copied := make([]NUMANode, len(nodes))
+	for i, node := range nodes {
+		copied[i] = NUMANode{CPUs: slices.Clone(node.CPUs), MemoryMB: node.MemoryMB}
+	}
+	return copied
+}
+
+// formatNUMANodes возвращает топологию в виде "cpus 0,1: 1024 MB; ..."
+func formatNUMANodes(nodes []NUMANode) string {
+	parts := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		parts = append(parts, fmt.Sprintf("cpus %s: %d MB", formatCPUList(node.CPUs), node.MemoryMB))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// formatCPUList возвращает список CPU через запятую в формате libvirt
+func formatCPUList(cpus []uint) string {
+	parts := make([]string, 0, len(cpus))
+	for _, cpu := range cpus {
+		parts = append(parts, strconv.FormatUint(uint64(cpu), 10))
+	}
+	return strings.Join(parts, ",")
+}
+
+// parseCPUList разбирает список CPU libvirt: номера и диапазоны через запятую
+// ("0-3,6"); исключения вида "^2" не поддерживаются
+func parseCPUList(s string) ([]uint, error) {
+	var cpus []uint
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		first, last, isRange := strings.Cut(part, "-")
+		from, err := strconv.ParseUint(strings.TrimSpace(first), 10, 0)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CPU list '%s'", s)
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.ParseUint(strings.TrimSpace(last), 10, 0); err != nil || to < from {
+				return nil, fmt.Errorf("invalid CPU list '%s'", s)
+			}
+		}
+		for cpu := from; cpu <= to; cpu++ {
+			cpus = append(cpus, uint(cpu))
+		}
+	}
+	return cpus, nil
+}
@@ -0,0 +1,38 @@
+package vm
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCreateVMValidatesNUMANodes(t *testing.T) {
+	tests := []struct {
+		name    string
+		nodes   []NUMANode
+		wantErr string
+	}{
+		{"valid", []NUMANode{{CPUs: []uint{0, 1}, MemoryMB: 2048}, {CPUs: []uint{2, 3}, MemoryMB: 2048}}, ""},
+		{"mismatched memory", []NUMANode{{CPUs: []uint{0, 1}, MemoryMB: 2048}, {CPUs: []uint{2, 3}, MemoryMB: 1024}}, "3072 MB of memory in total"},
+		{"overlapping CPUs", []NUMANode{{CPUs: []uint{0, 1}, MemoryMB: 2048}, {CPUs: []uint{1, 2}, MemoryMB: 2048}}, "vCPU 1 is assigned to both NUMA node 0 and NUMA node 1"},
+		{"CPU out of range", []NUMANode{{CPUs: []uint{0, 4}, MemoryMB: 4096}}, "refers to vCPU 4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, WithAutoStartOnCreate(false))
+			err := m.CreateVM(context.Background(), VMConfig{Name: "db", Memory: 4096, VCPUs: 4, NUMANodes: tt.nodes})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("CreateVM: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("CreateVM = %v, want an error containing %q", err, tt.wantErr)
+			}
+			if _, err := m.LookupVM("db"); err == nil {
+				t.Error("VM was created with an invalid NUMA topology")
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package vm
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// DefaultOSTypes - известные типы гостевых ОС и их варианты (в терминах libosinfo).
+// Реальный бэкенд выбирает по ним устройства по умолчанию (virtio и т.д.)
+var DefaultOSTypes = map[string][]string{
+	"linux":   {"ubuntu20.04", "ubuntu22.04", "ubuntu24.04", "debian11", "debian12", "centos-stream9", "rhel9", "fedora40", "alpine3.19"},
+	"windows": {"win10", "win11", "win2k19", "win2k22"},
+	"bsd":     {"freebsd13", "freebsd14", "openbsd7"},
+}
+
+// WithOSTypes задает известные типы гостевых ОС и их варианты, с которыми сверяются
+// VMConfig.OSType и VMConfig.OSVariant (по умолчанию DefaultOSTypes)
+func WithOSTypes(osTypes map[string][]string) MockOption {
+	return func(m *MockVMManager) {
+		m.osTypes = maps.Clone(osTypes)
+	}
+}
+
+// validateOSType проверяет тип и вариант гостевой ОС по списку известных.
+// Вариант задается только вместе с типом
+func (m *MockVMManager) validateOSType(osType, osVariant string) error {
+	if osType == "" {
+		if osVariant != "" {
+			return fmt.Errorf("OS variant '%s' requires an OS type", osVariant)
+		}
+		return nil
+	}
+	variants, known := m.osTypes[osType]
+	if !known {
+		return fmt.Errorf("unknown OS type '%s' (supported: %s)", osType, strings.Join(slices.Sorted(maps.Keys(m.osTypes)), ", "))
+	}
+	if osVariant != "" && !slices.Contains(variants, osVariant) {
+		return fmt.Errorf("unknown variant '%s' of OS type '%s' (supported: %s)", osVariant, osType, strings.Join(variants, ", "))
+	}
+	return nil
+}
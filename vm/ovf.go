@@ -0,0 +1,178 @@
+package vm
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Структуры дескриптора OVF (DMTF DSP0243); разбираются только элементы, нужные для
+// конфигурации ВМ. Имена сопоставляются без учета пространств имен (ovf:, rasd:)
+
+type ovfEnvelope struct {
+	XMLName       xml.Name         `xml:"Envelope"`
+	Disks         []ovfDisk        `xml:"DiskSection>Disk"`
+	VirtualSystem ovfVirtualSystem `xml:"VirtualSystem"`
+}
+
+type ovfDisk struct {
+	ID       string `xml:"diskId,attr"`
+	Capacity string `xml:"capacity,attr"`
+	Units    string `xml:"capacityAllocationUnits,attr"` // по умолчанию байты
+}
+
+type ovfVirtualSystem struct {
+	ID    string    `xml:"id,attr"`
+	Name  string    `xml:"Name"`
+	Items []ovfItem `xml:"VirtualHardwareSection>Item"`
+}
+
+type ovfItem struct {
+	ResourceType    int    `xml:"ResourceType"`
+	VirtualQuantity uint64 `xml:"VirtualQuantity"`
+	AllocationUnits string `xml:"AllocationUnits"`
+	HostResource    string `xml:"HostResource"` // для дисков: ovf:/disk/<diskId>
+}
+
+// Значения rasd:ResourceType (CIM_ResourceAllocationSettingData)
+const (
+	ovfResourceCPU    = 3
+	ovfResourceMemory = 4
+	ovfResourceDisk   = 17
+)
+
+// ovfUnitPattern - единицы программного формата DMTF: "byte * 2^20" и т.п.
+var ovfUnitPattern = regexp.MustCompile(`^byte(?:\*2\^(\d+))?$`)
+
+// ovfUnitBytes переводит единицу измерения OVF в байты; пустая единица - значение def
+func ovfUnitBytes(units string, def uint64) (uint64, error) {
+	normalized := strings.ToLower(strings.ReplaceAll(units, " ", ""))
+	switch normalized {
+	case "":
+		return def, nil
+	case "kilobytes", "kb":
+		return 1 << 10, nil
+	case "megabytes", "mb":
+		return 1 << 20, nil
+	case "gigabytes", "gb":
+		return 1 << 30, nil
+	}
+	match := ovfUnitPattern.FindStringSubmatch(normalized)
+	if match == nil {
+		return 0, fmt.Errorf("unsupported allocation units '%s'", units)
+	}
+	if match[1] == "" {
+		return 1, nil
+	}
+	exp, err := strconv.Atoi(match[1])
+	if err != nil || exp > 50 {
+		return 0, fmt.Errorf("unsupported allocation units '%s'", units)
+	}
+	return 1 << exp, nil
+}
+
+// parseOVF разбирает виртуальное оборудование дескриптора OVF: количество vCPU, память и
+// диски. Диски размещаются в каталоге образов менеджера: первый - основной
+// (<имя>.qcow2), остальные - дополнительные (<имя>-diskN.qcow2). Имя ВМ берется из name,
+// иначе из дескриптора
+func (m *MockVMManager) parseOVF(ovfXML, name string) (VMConfig, error) {
+	var envelope ovfEnvelope
+	if err := xml.Unmarshal([]byte(ovfXML), &envelope); err != nil {
+		return VMConfig{}, fmt.Errorf("failed to parse OVF descriptor: %w", err)
+	}
+	system := envelope.VirtualSystem
+
+	config := VMConfig{Name: name}
+	if config.Name == "" {
+		config.Name = strings.TrimSpace(system.Name)
+	}
+	if config.Name == "" {
+		config.Name = system.ID
+	}
+
+	disks := make(map[string]ovfDisk, len(envelope.Disks))
+	for _, disk := range envelope.Disks {
+		disks[disk.ID] = disk
+	}
+
+	dir := m.diskDir()
+	for _, item := range system.Items {
+		switch item.ResourceType {
+		case ovfResourceCPU:
+			config.VCPUs = uint(item.VirtualQuantity)
+		case ovfResourceMemory:
+			unit, err := ovfUnitBytes(item.AllocationUnits, 1<<20)
+			if err != nil {
+				return VMConfig{}, fmt.Errorf("invalid memory in OVF descriptor: %w", err)
+			}
+			config.Memory = item.VirtualQuantity * unit / (1 << 20)
+		case ovfResourceDisk:
+			id := item.HostResource[strings.LastIndex(item.HostResource, "/")+1:]
+			disk, exists := disks[id]
+			if !exists {
+				return VMConfig{}, fmt.Errorf("OVF disk item refers to unknown disk '%s'", item.HostResource)
+			}
+			sizeGB, err := disk.sizeGB()
+			if err != nil {
+				return VMConfig{}, fmt.Errorf("invalid capacity of OVF disk '%s': %w", id, err)
+			}
+			if config.DiskPath == "" {
+				config.DiskPath = filepath.Join(dir, config.Name+".qcow2")
+				config.DiskSize = sizeGB
+				continue
+			}
+			path := filepath.Join(dir, fmt.Sprintf("%s-disk%d.qcow2", config.Name, len(config.Disks)+1))
+			config.Disks = append(config.Disks, DiskSpec{Path: path, Size: sizeGB})
+		}
+	}
+	return config, nil
+}
+
+// sizeGB возвращает емкость диска OVF в ГБ с округлением вверх
+func (disk ovfDisk) sizeGB() (uint64, error) {
+	capacity, err := strconv.ParseUint(strings.TrimSpace(disk.Capacity), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("capacity '%s' is not a number", disk.Capacity)
+	}
+	unit, err := ovfUnitBytes(disk.Units, 1)
+	if err != nil {
+		return 0, err
+	}
+	bytes := capacity * unit
+	return (bytes + 1<<30 - 1) / (1 << 30), nil
+}
+
+// ParseOVF возвращает конфигурацию, которую CreateVMFromOVF создал бы из дескриптора OVF,
+// не создавая ВМ
+func (m *MockVMManager) ParseOVF(ovfXML string, name string) (VMConfig, error) {
+	config, err := m.parseOVF(ovfXML, name)
+	if err != nil {
+		return VMConfig{}, err
+	}
+	return m.applyDefaults(config), nil
+}
+
+// CreateVMFromOVF создает ВМ из дескриптора OVF (например, из пакета OVA с виртуальным
+// устройством): количество vCPU, память и диски берутся из раздела VirtualHardwareSection,
+// отсутствующие значения - из настроек по умолчанию. Пустое name означает имя из
+// дескриптора. Возвращает конфигурацию созданной ВМ
+func (m *MockVMManager) CreateVMFromOVF(ctx context.Context, ovfXML string, name string) (VMConfig, error) {
+	return createVMFromOVF(ctx, m, ovfXML, name)
+}
+
+// createVMFromOVF реализует CreateVMFromOVF через методы manager, чтобы декораторы
+// (TimeoutManager) создавали ВМ собственным CreateVM
+func createVMFromOVF(ctx context.Context, manager VMManagerInterface, ovfXML string, name string) (VMConfig, error) {
+	config, err := manager.ParseOVF(ovfXML, name)
+	if err != nil {
+		return VMConfig{}, err
+	}
+	if err := manager.CreateVM(ctx, config); err != nil {
+		return VMConfig{}, err
+	}
+	return config, nil
+}
@@ -0,0 +1,57 @@
+package vm
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestCreateVMFromMinimalOVF(t *testing.T) {
+	m := newTestManager(t, WithAutoStartOnCreate(false), WithDefaults(VMConfig{
+		Memory:   2048,
+		VCPUs:    1,
+		DiskPath: "/images/default.qcow2",
+		Network:  "default",
+		Firmware: FirmwareBIOS,
+	}))
+	// Дескриптор без элемента памяти, с одним диском в байтах по умолчанию
+	ovf := `<?xml version="1.0" encoding="UTF-8"?>
+<Envelope xmlns="http://schemas.dmtf.org/ovf/envelope/1"
+    xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1"
+    xmlns:rasd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ResourceAllocationSettingData">
+  <DiskSection>
+    <Disk ovf:diskId="vmdisk1" ovf:capacity="10737418240"/>
+  </DiskSection>
+  <VirtualSystem ovf:id="appliance">
+    <Name>appliance</Name>
+    <VirtualHardwareSection>
+      <Item><rasd:ResourceType>3</rasd:ResourceType><rasd:VirtualQuantity>4</rasd:VirtualQuantity></Item>
+      <Item><rasd:ResourceType>17</rasd:ResourceType><rasd:HostResource>ovf:/disk/vmdisk1</rasd:HostResource></Item>
+    </VirtualHardwareSection>
+  </VirtualSystem>
+</Envelope>`
+
+	config, err := m.CreateVMFromOVF(context.Background(), ovf, "")
+	if err != nil {
+		t.Fatalf("CreateVMFromOVF: %v", err)
+	}
+	want := VMConfig{
+		Name:     "appliance",
+		Memory:   2048,
+		VCPUs:    4,
+		DiskPath: "/images/appliance.qcow2",
+		DiskSize: 10,
+		Network:  "default",
+		Firmware: FirmwareBIOS,
+	}
+	if !reflect.DeepEqual(config, want) {
+		t.Errorf("CreateVMFromOVF = %+v, want %+v", config, want)
+	}
+	info, err := m.GetVMInfo("appliance")
+	if err != nil {
+		t.Fatalf("GetVMInfo: %v", err)
+	}
+	if !reflect.DeepEqual(info.Config, want) {
+		t.Errorf("created VM config = %+v, want %+v", info.Config, want)
+	}
+}
@@ -0,0 +1,71 @@
+package vm
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// defaultHost - единственный хост mock-менеджера, если WithHosts не задана
+const defaultHost = "mock-host"
+
+// WithHosts задает хосты, между которыми mock-менеджер размещает ВМ с учетом
+// Affinity и AntiAffinity (по умолчанию один хост defaultHost)
+func WithHosts(hosts ...string) MockOption {
+	return func(m *MockVMManager) {
+		m.hosts = append([]string(nil), hosts...)
+	}
+}
+
+// placeLocked выбирает хост для ВМ: на одном хосте с уже размещенными ВМ из Affinity
+// и без ВМ, с которыми она связана AntiAffinity (в любую сторону). Если такого хоста
+// нет, возвращает причину. Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) placeLocked(config VMConfig) (host, reason string) {
+	required := ""
+	requiredBy := ""
+	for _, peer := range config.Affinity {
+		vm, exists := m.vms[peer]
+		if !exists || peer == config.Name {
+			continue
+		}
+		if required != "" && vm.Host != required {
+			return "", fmt.Sprintf("affinity cannot be satisfied: '%s' and '%s' are on different hosts", requiredBy, peer)
+		}
+		required, requiredBy = vm.Host, peer
+	}
+
+	conflict := ""
+	for _, host := range m.hosts {
+		if required != "" && host != required {
+			continue
+		}
+		if conflict = m.antiAffinityConflictLocked(config, host); conflict == "" {
+			return host, ""
+		}
+	}
+	if required != "" {
+		return "", fmt.Sprintf("anti-affinity with '%s' conflicts with affinity to '%s'", conflict, requiredBy)
+	}
+	return "", fmt.Sprintf("anti-affinity with '%s' cannot be satisfied on any host", conflict)
+}
+
+// antiAffinityConflictLocked возвращает имя ВМ на хосте host, с которой новая ВМ
+// не может быть размещена вместе, или пустую строку. Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) antiAffinityConflictLocked(config VMConfig, host string) string {
+	names := make([]string, 0, len(m.vms))
+	for name := range m.vms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		vm := m.vms[name]
+		if name == config.Name || vm.Host != host {
+			continue
+		}
+		if slices.Contains(config.AntiAffinity, name) || slices.Contains(vm.Config.AntiAffinity, config.Name) {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// PlanActionType - тип шага плана ExecutePlan
+type PlanActionType string
+
+const (
+	PlanCreate   PlanActionType = "create"
+	PlanStart    PlanActionType = "start"
+	PlanStop     PlanActionType = "stop"
+	PlanDelete   PlanActionType = "delete"
+	PlanSnapshot PlanActionType = "snapshot"
+)
+
+// PlannedAction - шаг плана ExecutePlan
+type PlannedAction struct {
+	Type     PlanActionType
+	Name     string   // имя ВМ; для PlanCreate можно задать только Config.Name
+	Config   VMConfig // конфигурация создаваемой ВМ (PlanCreate)
+	Snapshot string   // имя снапшота (PlanSnapshot)
+	Force    bool     // удалить ВМ вместе со снапшотами (PlanDelete)
+}
+
+// ActionResult - результат шага плана
+type ActionResult struct {
+	Action  PlannedAction
+	Err     error
+	Skipped bool // шаг не выполнялся, так как предыдущий шаг завершился ошибкой
+}
+
+// ExecutePlanOptions - параметры выполнения плана
+type ExecutePlanOptions struct {
+	// ContinueOnError продолжает выполнение плана после ошибки шага
+	ContinueOnError bool
+}
+
+// ExecutePlan выполняет шаги плана по порядку и возвращает результат каждого шага.
+// План целиком проверяется до выполнения: при ошибке в любом шаге ничего не выполняется.
+// Без opts.ContinueOnError выполнение останавливается на первой ошибке: оставшиеся шаги
+// помечаются Skipped, а ошибка шага возвращается. Выполненные шаги не откатываются
+func (m *MockVMManager) ExecutePlan(ctx context.Context, actions []PlannedAction, opts ExecutePlanOptions) ([]ActionResult, error) {
+	return executePlan(ctx, m, actions, opts)
+}
+
+// executePlan реализует ExecutePlan через методы manager, чтобы декораторы (TimeoutManager)
+// выполняли шаги собственными методами
+func executePlan(ctx context.Context, manager VMManagerInterface, actions []PlannedAction, opts ExecutePlanOptions) ([]ActionResult, error) {
+	if len(actions) == 0 {
+		return nil, fmt.Errorf("plan has no actions")
+	}
+	actions = append([]PlannedAction(nil), actions...)
+	for i := range actions {
+		if err := normalizeAction(&actions[i]); err != nil {
+			return nil, fmt.Errorf("invalid plan step %d: %w", i+1, err)
+		}
+	}
+
+	log.Printf("Executing plan of %d step(s)", len(actions))
+	results := make([]ActionResult, len(actions))
+	var failed error
+	for i, action := range actions {
+		results[i].Action = action
+		if failed != nil {
+			results[i].Skipped = true
+			continue
+		}
+		err := executeAction(ctx, manager, action)
+		results[i].Err = err
+		if err != nil && !opts.ContinueOnError {
+			failed = fmt.Errorf("plan step %d (%s '%s') failed: %w", i+1, action.Type, action.Name, err)
+		}
+	}
+	return results, failed
+}
+
+// normalizeAction проверяет шаг плана и для PlanCreate приводит Name и Config.Name к одному имени
+func normalizeAction(action *PlannedAction) error {
+	switch action.Type {
+	case PlanCreate:
+		if action.Config.Name == "" {
+			action.Config.Name = action.Name
+		}
+		if action.Name == "" {
+			action.Name = action.Config.Name
+		}
+		if action.Name != action.Config.Name {
+			return fmt.Errorf("name '%s' does not match configuration name '%s'", action.Name, action.Config.Name)
+		}
+	case PlanStart, PlanStop, PlanDelete, PlanSnapshot:
+	default:
+		return fmt.Errorf("unknown action '%s': use %s, %s, %s, %s or %s", action.Type, PlanCreate, PlanStart, PlanStop, PlanDelete, PlanSnapshot)
+	}
+	if action.Name == "" {
+		return fmt.Errorf("VM name cannot be empty")
+	}
+	if action.Type == PlanSnapshot && action.Snapshot == "" {
+		return fmt.Errorf("snapshot name cannot be empty")
+	}
+	return nil
+}
+
+// executeAction выполняет один шаг плана
+func executeAction(ctx context.Context, manager VMManagerInterface, action PlannedAction) error {
+	switch action.Type {
+	case PlanCreate:
+		return manager.CreateVM(ctx, action.Config)
+	case PlanStart:
+		return manager.StartVM(ctx, action.Name)
+	case PlanStop:
+		return manager.StopVM(ctx, action.Name)
+	case PlanDelete:
+		return manager.DeleteVM(ctx, action.Name, DeleteVMOptions{Force: action.Force})
+	case PlanSnapshot:
+		return manager.CreateSnapshot(action.Name, action.Snapshot, "created by plan")
+	}
+	return fmt.Errorf("unknown action '%s'", action.Type)
+}
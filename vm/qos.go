@@ -0,0 +1,199 @@
+package vm
+
+import (
+	"fmt"
+	"log"
+)
+
+// Верхние границы ограничений QoS; 0 означает отсутствие ограничения
+const (
+	maxDiskIOPS      = 1_000_000   // операций ввода-вывода в секунду
+	maxBandwidthKbps = 100_000_000 // 100 Гбит/с
+)
+
+// NetworkSpec - ограничения пропускной способности сетевого интерфейса ВМ в Кбит/с
+// (0 - без ограничения)
+type NetworkSpec struct {
+	InboundKbps  uint `yaml:"inbound_kbps,omitempty"`
+	OutboundKbps uint `yaml:"outbound_kbps,omitempty"`
+}
+
+// validateIOPS проверяет ограничения IOPS диска path
+func validateIOPS(path string, readIOPS, writeIOPS uint) error {
+	if readIOPS > maxDiskIOPS || writeIOPS > maxDiskIOPS {
+		return fmt.Errorf("IOPS limits of disk '%s' must be between 0 and %d", path, maxDiskIOPS)
+	}
+	return nil
+}
+
+// validateBandwidth проверяет ограничения пропускной способности сети ВМ
+func validateBandwidth(bandwidth NetworkSpec, network string) error {
+	if bandwidth == (NetworkSpec{}) {
+		return nil
+	}
+	if bandwidth.InboundKbps > maxBandwidthKbps || bandwidth.OutboundKbps > maxBandwidthKbps {
+		return fmt.Errorf("bandwidth limits must be between 0 and %d Kbps", maxBandwidthKbps)
+	}
+	if network == "" {
+		return fmt.Errorf("bandwidth limits require a network")
+	}
+	return nil
+}
+
+// validateQoS проверяет ограничения IOPS всех дисков и пропускной способности сети
+func validateQoS(config VMConfig) error {
+	for _, disk := range diskSpecs(config) {
+		if err := validateIOPS(disk.Path, disk.ReadIOPS, disk.WriteIOPS); err != nil {
+			return err
+		}
+	}
+	return validateBandwidth(config.Bandwidth, config.Network)
+}
+
+// formatIOPS описывает ограничения IOPS диска, например "500 read / 200 write IOPS"
+func formatIOPS(readIOPS, writeIOPS uint) string {
+	if readIOPS == 0 && writeIOPS == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s read / %s write IOPS", formatLimit(readIOPS), formatLimit(writeIOPS))
+}
+
+// formatBandwidth описывает ограничения пропускной способности сети
+func formatBandwidth(bandwidth NetworkSpec) string {
+	if bandwidth == (NetworkSpec{}) {
+		return ""
+	}
+	return fmt.Sprintf("%s in / %s out Kbps", formatLimit(bandwidth.InboundKbps), formatLimit(bandwidth.OutboundKbps))
+}
+
+// formatLimit возвращает значение ограничения или "unlimited" для 0
+func formatLimit(limit uint) string {
+	if limit == 0 {
+		return "unlimited"
+	}
+	return fmt.Sprint(limit)
+}
+
+// SetDiskIOPS задает ограничения операций чтения и записи в секунду для диска
+// остановленной ВМ (основного или дополнительного); 0 снимает ограничение
+func (m *MockVMManager) SetDiskIOPS(name, path string, readIOPS, writeIOPS uint) error {
+	return m.runHooks("set_disk_iops", name, func() error { return m.setDiskIOPS(name, path, readIOPS, writeIOPS) })
+}
+
+// setDiskIOPS выполняет SetDiskIOPS без хуков операций
+func (m *MockVMManager) setDiskIOPS(name, path string, readIOPS, writeIOPS uint) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = m.resolveNameLocked(name)
+	defer func() { m.noteResultLocked(name, err) }()
+
+	read, write, err := m.checkDiskIOPSLocked(name, path, readIOPS, writeIOPS)
+	if err != nil {
+		return err
+	}
+
+	*read, *write = readIOPS, writeIOPS
+	log.Printf("[MOCK] IOPS limits of disk '%s' of virtual machine '%s' set to %d read / %d write", path, name, readIOPS, writeIOPS)
+	m.recordVMLocked(AuditDiskIOPS, name)
+	return nil
+}
+
+// ValidateDiskIOPS выполняет проверки SetDiskIOPS, ничего не меняя
+func (m *MockVMManager) ValidateDiskIOPS(name, path string, readIOPS, writeIOPS uint) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, _, err := m.checkDiskIOPSLocked(m.resolveNameLocked(name), path, readIOPS, writeIOPS)
+	return err
+}
+
+// checkDiskIOPSLocked проверяет ограничения IOPS диска path и возвращает указатели на
+// поля конфигурации, в которые они записываются. Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) checkDiskIOPSLocked(name, path string, readIOPS, writeIOPS uint) (read, write *uint, err error) {
+	vm, err := m.stoppedVMLocked(name, "change disk IOPS limits")
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := validateIOPS(path, readIOPS, writeIOPS); err != nil {
+		return nil, nil, err
+	}
+
+	if path != "" && path == vm.Config.DiskPath {
+		read, write = &vm.Config.DiskReadIOPS, &vm.Config.DiskWriteIOPS
+	}
+	for i := range vm.Config.Disks {
+		if vm.Config.Disks[i].Path == path {
+			read, write = &vm.Config.Disks[i].ReadIOPS, &vm.Config.Disks[i].WriteIOPS
+		}
+	}
+	if read == nil {
+		return nil, nil, fmt.Errorf("disk '%s' is not attached to virtual machine '%s'", path, name)
+	}
+	return read, write, nil
+}
+
+// SetNetworkBandwidth задает ограничения входящего и исходящего трафика сетевого
+// интерфейса остановленной ВМ в Кбит/с; 0 снимает ограничение
+func (m *MockVMManager) SetNetworkBandwidth(name string, inboundKbps, outboundKbps uint) error {
+	return m.runHooks("set_network_bandwidth", name, func() error { return m.setNetworkBandwidth(name, inboundKbps, outboundKbps) })
+}
+
+// setNetworkBandwidth выполняет SetNetworkBandwidth без хуков операций
+func (m *MockVMManager) setNetworkBandwidth(name string, inboundKbps, outboundKbps uint) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = m.resolveNameLocked(name)
+	defer func() { m.noteResultLocked(name, err) }()
+
+	bandwidth := NetworkSpec{InboundKbps: inboundKbps, OutboundKbps: outboundKbps}
+	vm, err := m.checkNetworkBandwidthLocked(name, bandwidth)
+	if err != nil {
+		return err
+	}
+
+	vm.Config.Bandwidth = bandwidth
+	log.Printf("[MOCK] Network bandwidth of virtual machine '%s' set to %d Kbps in / %d Kbps out", name, inboundKbps, outboundKbps)
+	m.recordVMLocked(AuditBandwidth, name)
+	return nil
+}
+
+// ValidateNetworkBandwidth выполняет проверки SetNetworkBandwidth, ничего не меняя
+func (m *MockVMManager) ValidateNetworkBandwidth(name string, inboundKbps, outboundKbps uint) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, err := m.checkNetworkBandwidthLocked(m.resolveNameLocked(name), NetworkSpec{InboundKbps: inboundKbps, OutboundKbps: outboundKbps})
+	return err
+}
+
+// checkNetworkBandwidthLocked проверяет ограничения сети ВМ и возвращает ВМ.
+// Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) checkNetworkBandwidthLocked(name string, bandwidth NetworkSpec) (*MockVM, error) {
+	vm, err := m.stoppedVMLocked(name, "change network bandwidth limits")
+	if err != nil {
+		return nil, err
+	}
+	if err := validateBandwidth(bandwidth, vm.Config.Network); err != nil {
+		return nil, fmt.Errorf("invalid bandwidth for virtual machine '%s': %w", name, err)
+	}
+	return vm, nil
+}
+
+// stoppedVMLocked возвращает остановленную и не занятую ВМ; action описывает операцию
+// для сообщения об ошибке. Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) stoppedVMLocked(name, action string) (*MockVM, error) {
+	vm, exists := m.vms[name]
+	if !exists {
+		return nil, fmt.Errorf("virtual machine '%s' not found", name)
+	}
+	if err := checkNotBusyLocked(vm, name); err != nil {
+		return nil, err
+	}
+	if err := checkUnlockedLocked(vm, name, action+" of"); err != nil {
+		return nil, err
+	}
+	if vm.State != VMStateStopped {
+		return nil, fmt.Errorf("virtual machine '%s' must be stopped to %s (current state: %s)", name, action, vm.State)
+	}
+	return vm, nil
+}
@@ -0,0 +1,133 @@
+package vm
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestLibvirtXMLRendersQoS(t *testing.T) {
+	m := newTestManager(t, WithAutoStartOnCreate(false))
+	mustCreate(t, m, VMConfig{
+		Name:          "web",
+		Memory:        1024,
+		VCPUs:         1,
+		DiskPath:      "/vms/web.qcow2",
+		DiskReadIOPS:  500,
+		DiskWriteIOPS: 200,
+		Disks:         []DiskSpec{{Path: "/vms/web-data.qcow2", Size: 10, WriteIOPS: 100}},
+		Network:       "default",
+		Bandwidth:     NetworkSpec{InboundKbps: 1000, OutboundKbps: 1001},
+	})
+
+	xmlText, err := m.ExportToLibvirtXML("web")
+	if err != nil {
+		t.Fatalf("ExportToLibvirtXML: %v", err)
+	}
+	for _, want := range []string{
+		"<iotune><read_iops_sec>500</read_iops_sec><write_iops_sec>200</write_iops_sec></iotune>",
+		"<iotune><write_iops_sec>100</write_iops_sec></iotune>",
+		`<inbound average="125"></inbound>`,
+		// 1001 Кбит/с округляется вверх до 126 КБ/с
+		`<outbound average="126"></outbound>`,
+	} {
+		if !strings.Contains(compactXML(xmlText), want) {
+			t.Errorf("exported XML has no %s:\n%s", want, xmlText)
+		}
+	}
+
+	config, err := m.ImportFromLibvirtXML(xmlText)
+	if err != nil {
+		t.Fatalf("ImportFromLibvirtXML: %v", err)
+	}
+	if config.DiskReadIOPS != 500 || config.DiskWriteIOPS != 200 {
+		t.Errorf("imported disk IOPS = %d/%d, want 500/200", config.DiskReadIOPS, config.DiskWriteIOPS)
+	}
+	if len(config.Disks) != 1 || config.Disks[0].WriteIOPS != 100 {
+		t.Errorf("imported extra disks = %+v, want write IOPS 100", config.Disks)
+	}
+	if config.Bandwidth != (NetworkSpec{InboundKbps: 1000, OutboundKbps: 1008}) {
+		t.Errorf("imported bandwidth = %+v", config.Bandwidth)
+	}
+}
+
+// compactXML убирает переводы строк и отступы между элементами XML
+func compactXML(text string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		b.WriteString(strings.TrimSpace(line))
+	}
+	return b.String()
+}
+
+func TestSetQoSRequiresStoppedVM(t *testing.T) {
+	m := newTestManager(t)
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1, DiskPath: "/vms/qos-web.qcow2", Network: "default"})
+
+	if err := m.SetDiskIOPS("web", "/vms/qos-web.qcow2", 100, 100); err == nil {
+		t.Error("SetDiskIOPS on a running VM succeeded")
+	}
+	if err := m.SetNetworkBandwidth("web", 1000, 1000); err == nil {
+		t.Error("SetNetworkBandwidth on a running VM succeeded")
+	}
+	if err := m.StopVM(context.Background(), "web"); err != nil {
+		t.Fatalf("StopVM: %v", err)
+	}
+	if err := m.SetDiskIOPS("web", "/vms/qos-web.qcow2", 100, 50); err != nil {
+		t.Errorf("SetDiskIOPS: %v", err)
+	}
+	if err := m.SetDiskIOPS("web", "/vms/missing.qcow2", 100, 50); err == nil {
+		t.Error("SetDiskIOPS for a disk the VM does not have succeeded")
+	}
+	if err := m.SetNetworkBandwidth("web", 1000, 2000); err != nil {
+		t.Errorf("SetNetworkBandwidth: %v", err)
+	}
+	info, _ := m.LookupVM("web")
+	if info.Config.DiskReadIOPS != 100 || info.Config.DiskWriteIOPS != 50 || info.Config.Bandwidth.OutboundKbps != 2000 {
+		t.Errorf("config after QoS changes = %+v", info.Config)
+	}
+}
+
+func TestImportLibvirtXMLToolKeepsQoS(t *testing.T) {
+	src := newTestManager(t, WithAutoStartOnCreate(false))
+	mustCreate(t, src, VMConfig{
+		Name:          "web",
+		Memory:        1024,
+		VCPUs:         1,
+		DiskPath:      "/vms/web.qcow2",
+		DiskReadIOPS:  500,
+		DiskWriteIOPS: 200,
+		Disks:         []DiskSpec{{Path: "/vms/web-data.qcow2", Size: 10, ReadIOPS: 300}},
+		Network:       "default",
+		Bandwidth:     NetworkSpec{InboundKbps: 1000, OutboundKbps: 2000},
+	})
+	xmlText, err := src.ExportToLibvirtXML("web")
+	if err != nil {
+		t.Fatalf("ExportToLibvirtXML: %v", err)
+	}
+
+	// import_libvirt_xml возвращает аргументы create_vm, которые можно передать в create_vm
+	dst := newTestManager(t, WithAutoStartOnCreate(false))
+	resp := callTool(t, newTestTools(t, dst), "import_libvirt_xml", map[string]any{"xml": xmlText})
+	if resp["success"] != true {
+		t.Fatalf("import_libvirt_xml = %v", resp)
+	}
+	args := resp["data"].(map[string]any)["config"].(map[string]any)
+	if resp = callTool(t, newTestTools(t, dst), "create_vm", args); resp["success"] != true {
+		t.Fatalf("create_vm with the imported configuration = %v", resp)
+	}
+
+	info, err := dst.LookupVM("web")
+	if err != nil {
+		t.Fatalf("LookupVM: %v", err)
+	}
+	if info.Config.DiskReadIOPS != 500 || info.Config.DiskWriteIOPS != 200 {
+		t.Errorf("disk IOPS after the round trip = %d/%d, want 500/200", info.Config.DiskReadIOPS, info.Config.DiskWriteIOPS)
+	}
+	if len(info.Config.Disks) != 1 || info.Config.Disks[0].ReadIOPS != 300 {
+		t.Errorf("extra disks after the round trip = %+v, want read IOPS 300", info.Config.Disks)
+	}
+	if info.Config.Bandwidth != (NetworkSpec{InboundKbps: 1000, OutboundKbps: 2000}) {
+		t.Errorf("bandwidth after the round trip = %+v", info.Config.Bandwidth)
+	}
+}
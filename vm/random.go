@@ -0,0 +1,108 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+)
+
+// Параметры случайных конфигураций RandomVMConfig
+var (
+	randomNameAdjectives = []string{"amber", "brisk", "calm", "dusty", "eager", "fuzzy", "gentle", "hollow", "icy", "jolly", "keen", "lucky"}
+	randomNameNouns      = []string{"otter", "falcon", "maple", "comet", "harbor", "canyon", "lynx", "meadow", "pebble", "raven", "spruce", "tundra"}
+	randomMemoryMB       = []uint64{512, 1024, 2048, 4096, 8192}
+	randomMaxVCPUs       = 8
+	randomNetworks       = []string{"default", "bridge"}
+)
+
+// RandomLabel - ключ метки (со значением "random"), которой RandomVMConfig помечает ВМ
+const RandomLabel = "generated"
+
+// RandomVMConfig возвращает случайную, но корректную конфигурацию ВМ для демонстраций
+// и нагрузочного тестирования: имя вида "<прилагательное>-<существительное>-<число>",
+// память от 512 МБ до 8 ГБ, от 1 до 8 vCPU и тип ОС из DefaultOSTypes. Результат
+// полностью определяется seed и проходит ValidateVMConfigFull менеджера без
+// ограничений и с настройками по умолчанию
+func RandomVMConfig(seed int64) VMConfig {
+	rng := rand.New(rand.NewSource(seed))
+	pick := func(values []string) string { return values[rng.Intn(len(values))] }
+
+	config := VMConfig{
+		Name:    fmt.Sprintf("%s-%s-%03d", pick(randomNameAdjectives), pick(randomNameNouns), rng.Intn(1000)),
+		Memory:  randomMemoryMB[rng.Intn(len(randomMemoryMB))],
+		VCPUs:   uint(1 + rng.Intn(randomMaxVCPUs)),
+		Network: pick(randomNetworks),
+		Labels:  map[string]string{RandomLabel: "random"},
+	}
+	// Перебираем типы ОС в фиксированном порядке, чтобы результат не зависел от порядка обхода карты
+	osTypes := []string{"linux", "windows", "bsd"}
+	config.OSType = pick(osTypes)
+	config.OSVariant = pick(DefaultOSTypes[config.OSType])
+	return config
+}
+
+// PopulateRandom создает n ВМ со случайными конфигурациями (RandomVMConfig) в смеси
+// состояний: примерно половина запущена, остальные остановлены или на паузе. Набор ВМ
+// и их состояния полностью определяются seed. Используется для проверки пагинации,
+// фильтров и метрик на сотнях ВМ; имена, совпавшие с существующими ВМ, генерируются
+// заново. При ошибке или отмене ctx уже созданные ВМ остаются. Кроме хука
+// "populate_random" для всей операции, создание каждой ВМ проходит хуки "create"
+func (m *MockVMManager) PopulateRandom(ctx context.Context, n int, seed int64) error {
+	return m.runHooks("populate_random", "", func() error { return m.populateRandom(ctx, n, seed) })
+}
+
+// populateRandom выполняет PopulateRandom без хуков операций
+func (m *MockVMManager) populateRandom(ctx context.Context, n int, seed int64) error {
+	if n < 0 {
+		return fmt.Errorf("number of VMs cannot be negative")
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	for created := 0; created < n; created++ {
+		config := RandomVMConfig(rng.Int63())
+		for m.exists(config.Name) {
+			config = RandomVMConfig(rng.Int63())
+		}
+		// Примерно 35% ВМ остаются остановленными, 15% приостанавливаются после запуска
+		roll := rng.Intn(20)
+		start := roll >= 7
+		config.StartOnCreate = &start
+		if err := m.CreateVM(ctx, config); err != nil {
+			return fmt.Errorf("created %d of %d random VMs: %w", created, n, err)
+		}
+		if roll >= 7 && roll < 10 {
+			if err := m.pause(config.Name); err != nil {
+				return fmt.Errorf("created %d of %d random VMs: %w", created+1, n, err)
+			}
+		}
+	}
+	log.Printf("[MOCK] Populated %d random virtual machines (seed %d)", n, seed)
+	return nil
+}
+
+// exists сообщает, есть ли ВМ с таким именем
+func (m *MockVMManager) exists(name string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, exists := m.vms[m.resolveNameLocked(name)]
+	return exists
+}
+
+// pause приостанавливает запущенную ВМ
+func (m *MockVMManager) pause(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	vm, exists := m.vms[name]
+	if !exists {
+		return fmt.Errorf("virtual machine '%s' not found", name)
+	}
+	if vm.State != VMStateRunning {
+		return fmt.Errorf("virtual machine '%s' is not running (current state: %s)", name, vm.State)
+	}
+	vm.State = VMStatePaused
+	log.Printf("[MOCK] Virtual machine '%s' paused", name)
+	m.recordVMLocked(AuditTransition, name)
+	return nil
+}
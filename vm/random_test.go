@@ -0,0 +1,40 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPopulateRandomStopsOnCancel(t *testing.T) {
+	m := newTestManager(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := m.PopulateRandom(ctx, 5, 1); !errors.Is(err, context.Canceled) {
+		t.Fatalf("PopulateRandom with a cancelled context = %v, want context.Canceled", err)
+	}
+	if n := len(m.Snapshot()); n != 0 {
+		t.Errorf("%d VMs created after the cancellation, want 0", n)
+	}
+}
+
+func TestPopulateRandomCreatesExactlyN(t *testing.T) {
+	m := newTestManager(t)
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+	ctx := context.Background()
+
+	if err := m.PopulateRandom(ctx, 50, 7); err != nil {
+		t.Fatalf("PopulateRandom: %v", err)
+	}
+	if n := len(m.Snapshot()); n != 51 {
+		t.Fatalf("VMs after populating 50 = %d, want 51", n)
+	}
+	// Тот же seed порождает те же имена, но занятые имена заменяются новыми
+	if err := m.PopulateRandom(ctx, 50, 7); err != nil {
+		t.Fatalf("PopulateRandom with a repeated seed: %v", err)
+	}
+	if n := len(m.Snapshot()); n != 101 {
+		t.Errorf("VMs after populating 50 more with the same seed = %d, want 101", n)
+	}
+}
@@ -0,0 +1,113 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Inventory - желаемый набор ВМ, описанный в YAML- или JSON-файле
+type Inventory struct {
+	VMs []VMConfig `yaml:"vms"`
+}
+
+// ParseInventory разбирает файл инвентаря в формате YAML или JSON
+func ParseInventory(data []byte) (Inventory, error) {
+	var inventory Inventory
+	if err := yaml.Unmarshal(data, &inventory); err != nil {
+		return Inventory{}, fmt.Errorf("failed to parse inventory: %w", err)
+	}
+	return inventory, nil
+}
+
+// ReconcileAction - действие, выполненное Reconcile для ВМ
+type ReconcileAction string
+
+const (
+	ReconcileCreate    ReconcileAction = "create"
+	ReconcileUpdate    ReconcileAction = "update"
+	ReconcileUnchanged ReconcileAction = "unchanged"
+)
+
+// ReconcileResult - результат приведения одной ВМ к желаемой конфигурации
+type ReconcileResult struct {
+	Name    string
+	Action  ReconcileAction
+	Changes []FieldDiff // для ReconcileUpdate: текущее (A) и желаемое (B) значения
+	Err     error
+}
+
+// Reconcile приводит ВМ к желаемым конфигурациям в заданном порядке: отсутствующие ВМ
+// создаются, у отличающихся заменяется конфигурация (запущенная ВМ для этого
+// останавливается и запускается снова). ВМ, не указанные в desired, не затрагиваются.
+// Ошибка отдельной ВМ не прерывает обработку остальных и возвращается в ее результате
+func (m *MockVMManager) Reconcile(ctx context.Context, desired []VMConfig) ([]ReconcileResult, error) {
+	return reconcile(ctx, m, desired)
+}
+
+// reconcile реализует Reconcile через методы manager, чтобы декораторы (TimeoutManager)
+// создавали, останавливали и запускали ВМ собственными методами
+func reconcile(ctx context.Context, manager VMManagerInterface, desired []VMConfig) ([]ReconcileResult, error) {
+	seen := make(map[string]bool, len(desired))
+	for _, config := range desired {
+		if config.Name == "" {
+			return nil, fmt.Errorf("VM name cannot be empty")
+		}
+		if seen[config.Name] {
+			return nil, fmt.Errorf("virtual machine '%s' is listed more than once", config.Name)
+		}
+		seen[config.Name] = true
+	}
+
+	results := make([]ReconcileResult, 0, len(desired))
+	for _, config := range desired {
+		results = append(results, reconcileVM(ctx, manager, config))
+	}
+	return results, nil
+}
+
+// reconcileVM приводит одну ВМ к желаемой конфигурации. Отличия считает DriftReport,
+// поэтому значения по умолчанию учитываются так же, как в нем
+func reconcileVM(ctx context.Context, manager VMManagerInterface, config VMConfig) ReconcileResult {
+	result := ReconcileResult{Name: config.Name}
+
+	report, err := manager.DriftReport([]VMConfig{config}, nil)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if len(report.Missing) > 0 {
+		result.Action = ReconcileCreate
+		result.Err = manager.CreateVM(ctx, config)
+		return result
+	}
+	if len(report.ConfigDrift) == 0 {
+		result.Action = ReconcileUnchanged
+		result.Changes = []FieldDiff{}
+		return result
+	}
+
+	result.Action = ReconcileUpdate
+	result.Changes = report.ConfigDrift[0].Changes
+	state, err := vmStateOf(manager, config.Name)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	if state == VMStateRunning {
+		if result.Err = manager.StopVM(ctx, config.Name); result.Err != nil {
+			return result
+		}
+	}
+	if result.Err = manager.UpdateVMConfig(config.Name, config); result.Err != nil {
+		log.Printf("Failed to reconcile virtual machine '%s': %v", config.Name, result.Err)
+	}
+	if state == VMStateRunning {
+		if err := manager.StartVM(ctx, config.Name); err != nil && result.Err == nil {
+			result.Err = err
+		}
+	}
+	return result
+}
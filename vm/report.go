@@ -0,0 +1,76 @@
+package vm
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// InventoryReport возвращает отчет обо всех ВМ в формате Markdown, который можно вставить
+// в тикет или вики: время формирования (по часам менеджера), таблицу ВМ (имя, состояние,
+// VCPU, память, суммарный размер дисков, сеть, метки), итоги и количество ВМ в каждом
+// состоянии. Строки таблицы отсортированы по имени. Отчет строится по Snapshot, поэтому
+// описывает один момент времени и не удерживает блокировку менеджера во время форматирования
+func (m *MockVMManager) InventoryReport() (string, error) {
+	m.mu.RLock()
+	vms, states, now := m.snapshotLocked(), m.knownStatesLocked(), m.now()
+	m.mu.RUnlock()
+
+	var b strings.Builder
+	b.WriteString("# VM inventory report\n\n")
+	fmt.Fprintf(&b, "Generated: %s\n\n", now.UTC().Format(time.RFC3339))
+
+	b.WriteString("## Virtual machines\n\n")
+	if len(vms) == 0 {
+		b.WriteString("_No virtual machines._\n\n")
+	} else {
+		b.WriteString("| Name | State | vCPUs | Memory (MB) | Disk (GB) | Network | Labels |\n")
+		b.WriteString("|---|---|---:|---:|---:|---|---|\n")
+		for _, vm := range vms {
+			fmt.Fprintf(&b, "| %s | %s | %d | %d | %d | %s | %s |\n",
+				markdownCell(vm.Config.Name), markdownCell(string(vm.State)), vm.Config.VCPUs, vm.Config.Memory,
+				configDiskGB(vm.Config), markdownCell(vm.Config.Network), markdownCell(formatLabels(vm.Config.Labels)))
+		}
+		b.WriteString("\n")
+	}
+
+	usage := usageOf(vms)
+	b.WriteString("## Totals\n\n")
+	fmt.Fprintf(&b, "- VMs: %d\n", usage.VMs)
+	fmt.Fprintf(&b, "- vCPUs: %d\n", usage.VCPUs)
+	fmt.Fprintf(&b, "- Memory: %d MB\n", usage.MemoryMB)
+	fmt.Fprintf(&b, "- Disk: %d GB\n\n", usage.DiskGB)
+
+	counts := make(map[VMState]int)
+	for _, vm := range vms {
+		counts[vm.State]++
+	}
+	b.WriteString("## By state\n\n")
+	b.WriteString("| State | VMs |\n")
+	b.WriteString("|---|---:|\n")
+	for _, state := range states {
+		fmt.Fprintf(&b, "| %s | %d |\n", markdownCell(string(state)), counts[state])
+	}
+	return b.String(), nil
+}
+
+// formatLabels возвращает метки в виде "ключ=значение, ..." по возрастанию ключа
+func formatLabels(labels map[string]string) string {
+	parts := make([]string, 0, len(labels))
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		parts = append(parts, key+"="+labels[key])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// markdownCell экранирует значение для ячейки таблицы Markdown; пустое значение
+// заменяется на "-"
+func markdownCell(value string) string {
+	if value == "" {
+		return "-"
+	}
+	value = strings.ReplaceAll(value, "|", `\|`)
+	return strings.Join(strings.Fields(value), " ")
+}
@@ -0,0 +1,116 @@
+package vm
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// defaultReservationTTL - время жизни резервирования ресурсов по умолчанию
+const defaultReservationTTL = 15 * time.Minute
+
+// reservation - ресурсы, удерживаемые в квотах без ВМ (см. Reserve)
+type reservation struct {
+	MemoryMB  uint64
+	VCPUs     uint
+	expiresAt time.Time // нулевое время - без срока действия
+}
+
+// WithReservationTTL задает время жизни резервирований Reserve (0 - без срока действия).
+// По умолчанию резервирование освобождается через 15 минут по часам менеджера
+func WithReservationTTL(ttl time.Duration) MockOption {
+	return func(m *MockVMManager) {
+		m.reservationTTL = ttl
+	}
+}
+
+// Reserve удерживает память и VCPU в квотах WithLimits, не создавая ВМ, например чтобы
+// гарантировать ресурсы перед многошаговым созданием. Зарезервированные ресурсы учитываются
+// CreateVM, CanSchedule, UpdateVMConfig и клонированием так же, как ресурсы ВМ, пока
+// резервирование не освобождено ReleaseReservation, не занято ВМ (VMConfig.ReservationID)
+// или не истек его срок (WithReservationTTL). Возвращает идентификатор резервирования
+func (m *MockVMManager) Reserve(memoryMB uint64, vcpus uint) (reservationID string, err error) {
+	if memoryMB == 0 && vcpus == 0 {
+		return "", fmt.Errorf("reservation must include memory or VCPUs")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.expireReservationsLocked()
+	if reason := m.quotaReasonLocked(m.quotaUsageLocked(), memoryMB, vcpus); reason != "" {
+		return "", fmt.Errorf("cannot reserve resources: %s", reason)
+	}
+
+	id := fmt.Sprintf("res-%d", m.next)
+	m.next++
+	r := &reservation{MemoryMB: memoryMB, VCPUs: vcpus}
+	if m.reservationTTL > 0 {
+		r.expiresAt = m.now().Add(m.reservationTTL)
+	}
+	m.reservations[id] = r
+	log.Printf("[MOCK] Reserved %d MB of memory and %d VCPU(s) as '%s'", memoryMB, vcpus, id)
+	return id, nil
+}
+
+// ReleaseReservation освобождает резервирование, созданное Reserve. Истекшее
+// резервирование уже освобождено, поэтому для него, как и для неизвестного, возвращается ошибка
+func (m *MockVMManager) ReleaseReservation(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.expireReservationsLocked()
+	r, exists := m.reservations[id]
+	if !exists {
+		return fmt.Errorf("reservation '%s' not found", id)
+	}
+	delete(m.reservations, id)
+	log.Printf("[MOCK] Released reservation '%s' (%d MB of memory, %d VCPU(s))", id, r.MemoryMB, r.VCPUs)
+	return nil
+}
+
+// activeAt сообщает, действует ли резервирование в момент now
+func (r *reservation) activeAt(now time.Time) bool {
+	return r.expiresAt.IsZero() || now.Before(r.expiresAt)
+}
+
+// expireReservationsLocked удаляет истекшие резервирования; вызывающий код должен удерживать m.mu
+func (m *MockVMManager) expireReservationsLocked() {
+	now := m.now()
+	for id, r := range m.reservations {
+		if !r.activeAt(now) {
+			delete(m.reservations, id)
+			log.Printf("[MOCK] Reservation '%s' expired", id)
+		}
+	}
+}
+
+// checkReservationLocked проверяет, что резервирование существует и действует;
+// вызывающий код должен удерживать m.mu
+func (m *MockVMManager) checkReservationLocked(id string) error {
+	m.expireReservationsLocked()
+	if _, exists := m.reservations[id]; !exists {
+		return fmt.Errorf("reservation '%s' not found", id)
+	}
+	return nil
+}
+
+// quotaUsageLocked возвращает ресурсы, учитываемые в квотах: выделенные всем ВМ
+// и удерживаемые действующими резервированиями. Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) quotaUsageLocked() Resources {
+	return m.quotaUsageExceptLocked("")
+}
+
+// quotaUsageExceptLocked возвращает то же, что quotaUsageLocked, но без резервирования
+// exceptID, ресурсы которого займет создаваемая ВМ. Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) quotaUsageExceptLocked(exceptID string) Resources {
+	usage := m.usageLocked()
+	now := m.now()
+	for id, r := range m.reservations {
+		if id != exceptID && r.activeAt(now) {
+			usage.MemoryMB += r.MemoryMB
+			usage.VCPUs += r.VCPUs
+		}
+	}
+	return usage
+}
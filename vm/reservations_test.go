@@ -0,0 +1,52 @@
+package vm
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCreateVMConsumesReservation(t *testing.T) {
+	m := newTestManager(t, WithLimits(Limits{MaxMemoryMB: 8192, MaxVCPUs: 4}))
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 2048, VCPUs: 2})
+	id, err := m.Reserve(6144, 2)
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+
+	err = m.CreateVM(context.Background(), VMConfig{Name: "other", Memory: 4096, VCPUs: 1})
+	if err == nil || !strings.Contains(err.Error(), "insufficient memory") {
+		t.Fatalf("CreateVM without the reservation = %v, want an insufficient memory error", err)
+	}
+
+	mustCreate(t, m, VMConfig{Name: "db", Memory: 6144, VCPUs: 2, ReservationID: id})
+	if err := m.ReleaseReservation(id); err == nil {
+		t.Error("reservation is still held after the VM that used it was created")
+	}
+	info, err := m.GetVMInfo("db")
+	if err != nil {
+		t.Fatalf("GetVMInfo: %v", err)
+	}
+	if info.Config.ReservationID != "" {
+		t.Errorf("stored config keeps reservation ID %q", info.Config.ReservationID)
+	}
+}
+
+func TestCreateVMKeepsReservationOnFailure(t *testing.T) {
+	m := newTestManager(t, WithLimits(Limits{MaxMemoryMB: 4096}))
+	id, err := m.Reserve(2048, 0)
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+
+	// Памяти больше, чем удерживает резервирование и остается в квоте
+	if err := m.CreateVM(context.Background(), VMConfig{Name: "db", Memory: 8192, VCPUs: 1, ReservationID: id}); err == nil {
+		t.Fatal("CreateVM succeeded beyond the quota")
+	}
+	if err := m.CreateVM(context.Background(), VMConfig{Name: "db", Memory: 1024, VCPUs: 1, ReservationID: "res-unknown"}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("CreateVM with an unknown reservation = %v, want a not found error", err)
+	}
+	if err := m.ReleaseReservation(id); err != nil {
+		t.Errorf("reservation was lost after a failed create: %v", err)
+	}
+}
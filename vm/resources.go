@@ -0,0 +1,45 @@
+package vm
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// TotalResources возвращает количество ВМ и суммарные выделенные ресурсы:
+// память и VCPU всех ВМ, а также память только запущенных ВМ (с учетом balloon-драйвера)
+func (m *MockVMManager) TotalResources() (vms int, totalMemMB uint64, totalVCPU uint, runningMem uint64) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, vm := range m.vms {
+		totalMemMB += vm.Config.Memory
+		totalVCPU += vm.Config.VCPUs
+		if vm.State == VMStateRunning {
+			runningMem += vm.CurrentMemoryMB
+		}
+	}
+
+	return len(m.vms), totalMemMB, totalVCPU, runningMem
+}
+
+// ResourceTable возвращает выделенные ВМ ресурсы в виде выровненной текстовой таблицы
+// (имя, состояние, VCPU, память, суммарный размер дисков) с итоговой строкой, чтобы агент
+// выводил ее как есть, не форматируя столбцы сам. Строки отсортированы по имени.
+// Таблица строится по Snapshot, без блокировки менеджера во время форматирования
+func (m *MockVMManager) ResourceTable() (string, error) {
+	vms := m.Snapshot()
+
+	var buf strings.Builder
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATE\tVCPUS\tMEMORY (MB)\tDISK (GB)")
+	for _, vm := range vms {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\n", vm.Config.Name, vm.State, vm.Config.VCPUs, vm.Config.Memory, configDiskGB(vm.Config))
+	}
+	usage := usageOf(vms)
+	fmt.Fprintf(w, "TOTAL (%d)\t\t%d\t%d\t%d\n", usage.VMs, usage.VCPUs, usage.MemoryMB, usage.DiskGB)
+	if err := w.Flush(); err != nil {
+		return "", fmt.Errorf("failed to render resource table: %w", err)
+	}
+	return buf.String(), nil
+}
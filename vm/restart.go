@@ -0,0 +1,66 @@
+package vm
+
+import (
+	"fmt"
+	"log"
+	"sort"
+)
+
+// RestartAllRunning перезапускает (останавливает и снова запускает) все ВМ, запущенные
+// на момент вызова; остановленные и приостановленные ВМ не затрагиваются. Набор ВМ
+// фиксируется в начале, поэтому ВМ, запущенные во время перезапуска, в него не попадают.
+// ВМ перезапускаются не более WithBatchConcurrency одновременно.
+// Возвращает результат для каждой ВМ: nil при успехе или ошибку. Хуки операций
+// вызываются для каждой ВМ отдельно ("restart")
+func (m *MockVMManager) RestartAllRunning() map[string]error {
+	m.mu.RLock()
+	var running []string
+	for name, vm := range m.vms {
+		if vm.State == VMStateRunning {
+			running = append(running, name)
+		}
+	}
+	m.mu.RUnlock()
+	sort.Strings(running)
+
+	results := runBatch(running, m.batchConcurrency, func(i int) error {
+		return m.runHooks("restart", running[i], func() error { return m.restartVM(running[i]) })
+	})
+
+	log.Printf("[MOCK] Restarted %d running virtual machine(s)", len(running))
+	return results
+}
+
+// restartVM останавливает и снова запускает ВМ, если она все еще запущена
+func (m *MockVMManager) restartVM(name string) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = m.resolveNameLocked(name)
+	defer func() { m.noteResultLocked(name, err) }()
+
+	vm, exists := m.vms[name]
+	if !exists {
+		return fmt.Errorf("virtual machine '%s' not found", name)
+	}
+	if err := checkNotBusyLocked(vm, name); err != nil {
+		return err
+	}
+	if vm.State != VMStateRunning {
+		return fmt.Errorf("virtual machine '%s' is no longer running (current state: %s)", name, vm.State)
+	}
+
+	if err := m.stopVMLocked(name); err != nil {
+		return err
+	}
+	// Остановка записывается сразу: если запуск не удастся, ВМ останется остановленной
+	// (или перейдет в состояние ошибки), и журнал должен говорить именно об этом
+	m.recordLocked(AuditEntry{Operation: AuditStop, VMName: name, VMs: []string{name}})
+	if err := m.startVMLocked(name); err != nil {
+		return err
+	}
+	// Успешный запуск ничего не записывает в журнал, поэтому остановка - последняя
+	// запись; перезапуск заменяет ее
+	m.audit = m.audit[:len(m.audit)-1]
+	m.recordVMLocked(AuditRestart, name)
+	return nil
+}
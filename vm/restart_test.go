@@ -0,0 +1,52 @@
+package vm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRestartAllRunningRecordsRestart(t *testing.T) {
+	m := newTestManager(t)
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+
+	if err := m.RestartAllRunning()["web"]; err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	if got := stateOf(t, m, "web"); got != VMStateRunning {
+		t.Errorf("state after restart = %s, want running", got)
+	}
+	log := m.AuditLog()
+	if last := log[len(log)-1]; last.Operation != AuditRestart {
+		t.Errorf("last audit entry = %s, want %s", last.Operation, AuditRestart)
+	}
+	if prev := log[len(log)-2]; prev.Operation != AuditCreate {
+		t.Errorf("entry before restart = %s, want %s (no separate stop)", prev.Operation, AuditCreate)
+	}
+}
+
+func TestRestartAllRunningFailedStartIsNotRecordedAsRestart(t *testing.T) {
+	failStart := false
+	m := newTestManager(t, WithTransitionFailure(func(operation, name string) error {
+		if failStart && operation == "start" {
+			return errors.New("qemu crashed")
+		}
+		return nil
+	}))
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+	failStart = true
+
+	if err := m.RestartAllRunning()["web"]; err == nil {
+		t.Fatal("restart with a failing start succeeded")
+	}
+	var ops []AuditOperation
+	for _, entry := range m.AuditLog() {
+		if entry.Operation == AuditRestart {
+			t.Errorf("audit log records a restart after a failed start: %v", m.AuditLog())
+		}
+		ops = append(ops, entry.Operation)
+	}
+	want := []AuditOperation{AuditCreate, AuditStop, AuditError}
+	if len(ops) != len(want) || ops[1] != want[1] || ops[2] != want[2] {
+		t.Errorf("audit operations = %v, want %v", ops, want)
+	}
+}
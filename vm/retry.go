@@ -0,0 +1,70 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// WithCreateFailures задает ошибки, которыми по порядку завершатся первые вызовы CreateVM,
+// например чтобы имитировать нестабильный бэкенд: nil в последовательности означает
+// обычное выполнение вызова. Временные сбои помечаются ErrTransient
+// (fmt.Errorf("libvirt is busy: %w", ErrTransient))
+func WithCreateFailures(failures ...error) MockOption {
+	return func(m *MockVMManager) {
+		m.createFailures = failures
+	}
+}
+
+// nextCreateFailureLocked возвращает очередную ошибку из WithCreateFailures.
+// Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) nextCreateFailureLocked() error {
+	if len(m.createFailures) == 0 {
+		return nil
+	}
+	err := m.createFailures[0]
+	m.createFailures = m.createFailures[1:]
+	if err != nil {
+		log.Printf("[MOCK] Simulated create failure: %v", err)
+	}
+	return err
+}
+
+// CreateVMWithRetry создает ВМ, повторяя CreateVM при временных сбоях (ErrTransient) до
+// attempts попыток. Перед каждой повторной попыткой выжидается пауза: backoff перед второй,
+// затем вдвое больше перед каждой следующей. Прочие ошибки, в том числе ErrInvalidConfig
+// и ErrVMExists, постоянны и возвращаются сразу. Ожидание прерывается отменой ctx
+func (m *MockVMManager) CreateVMWithRetry(ctx context.Context, config VMConfig, attempts int, backoff time.Duration) error {
+	return createVMWithRetry(ctx, m.CreateVM, config, attempts, backoff)
+}
+
+// createVMWithRetry реализует CreateVMWithRetry поверх create, чтобы декораторы
+// (TimeoutManager) повторяли собственный CreateVM
+func createVMWithRetry(ctx context.Context, create func(context.Context, VMConfig) error, config VMConfig, attempts int, backoff time.Duration) error {
+	if attempts < 1 {
+		return fmt.Errorf("number of attempts must be at least 1")
+	}
+
+	delay := backoff
+	for attempt := 1; ; attempt++ {
+		err := create(ctx, config)
+		if err == nil || !errors.Is(err, ErrTransient) {
+			return err
+		}
+		if attempt == attempts {
+			return fmt.Errorf("failed to create virtual machine '%s' after %d attempt(s): %w", config.Name, attempts, err)
+		}
+		log.Printf("Creation of virtual machine '%s' failed with a transient error (attempt %d of %d), retrying in %s: %v", config.Name, attempt, attempts, delay, err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("gave up creating virtual machine '%s' after %d attempt(s): %w", config.Name, attempt, ctx.Err())
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
@@ -0,0 +1,48 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCreateVMWithRetry(t *testing.T) {
+	transient := fmt.Errorf("libvirt is busy: %w", ErrTransient)
+	permanent := fmt.Errorf("bad template: %w", ErrInvalidConfig)
+
+	tests := []struct {
+		name     string
+		failures []error
+		attempts int
+		wantErr  error // nil - ВМ должна быть создана
+		wantLeft int   // сколько имитируемых сбоев осталось неиспользованными
+	}{
+		{"succeeds after transient failures", []error{transient, transient}, 3, nil, 0},
+		{"gives up after the last attempt", []error{transient, transient, transient, transient}, 3, ErrTransient, 1},
+		{"does not retry permanent errors", []error{permanent, transient}, 3, ErrInvalidConfig, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, WithCreateFailures(tt.failures...))
+
+			err := m.CreateVMWithRetry(context.Background(), VMConfig{Name: "web", Memory: 1024, VCPUs: 1}, tt.attempts, time.Millisecond)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("CreateVMWithRetry: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateVMWithRetry = %v, want %v", err, tt.wantErr)
+			}
+			if _, lookupErr := m.LookupVM("web"); (lookupErr == nil) != (tt.wantErr == nil) {
+				t.Errorf("VM exists = %v after CreateVMWithRetry returned %v", lookupErr == nil, err)
+			}
+			m.mu.RLock()
+			left := len(m.createFailures)
+			m.mu.RUnlock()
+			if left != tt.wantLeft {
+				t.Errorf("%d simulated failures left, want %d", left, tt.wantLeft)
+			}
+		})
+	}
+}
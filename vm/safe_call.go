@@ -0,0 +1,30 @@
+package vm
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// safeCall оборачивает обработчик инструмента: паника внутри него (например, разыменование
+// nil в драйвере реального бэкенда) не роняет агента, а превращается в ошибку инструмента
+// в обычном конверте ToolResponse. Стек паники записывается в лог
+func safeCall[A, R any](name string, handler functiontool.Func[A, ToolResponse[R]]) functiontool.Func[A, ToolResponse[R]] {
+	return func(ctx tool.Context, args A) (response ToolResponse[R], err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("Tool '%s' panicked: %v\n%s", name, r, debug.Stack())
+				response, err = toolFailure[R](fmt.Errorf("internal error in tool '%s': %v", name, r))
+			}
+		}()
+		return handler(ctx, args)
+	}
+}
+
+// newTool создает инструмент, обработчик которого защищен safeCall
+func newTool[A, R any](config functiontool.Config, handler functiontool.Func[A, ToolResponse[R]]) (tool.Tool, error) {
+	return functiontool.New(config, safeCall(config.Name, handler))
+}
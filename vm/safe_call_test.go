@@ -0,0 +1,45 @@
+package vm
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/adk/tool"
+)
+
+// panickingManager имитирует драйвер бэкенда, который паникует
+type panickingManager struct {
+	VMManagerInterface
+}
+
+func (panickingManager) ListVMs() ([]string, error) {
+	panic("nil pointer in backend driver")
+}
+
+func TestToolRecoversFromBackendPanic(t *testing.T) {
+	m := newTestManager(t)
+	tools := newTestTools(t, panickingManager{VMManagerInterface: m})
+
+	resp := callTool(t, tools, "list_vms", nil)
+	if resp["success"] != false {
+		t.Fatalf("list_vms with a panicking backend = %v, want a failure", resp)
+	}
+	if errText, _ := resp["error"].(string); !strings.Contains(errText, "internal error in tool 'list_vms'") {
+		t.Errorf("error = %q, want an internal error naming the tool", errText)
+	}
+
+	// Остальные инструменты продолжают работать
+	if resp := callTool(t, tools, "create_vm", map[string]any{"name": "web", "memory": "1GB", "vcpus": 1}); resp["success"] != true {
+		t.Errorf("create_vm after a panic = %v", resp)
+	}
+}
+
+func TestSafeCallPassesResultsThrough(t *testing.T) {
+	handler := safeCall("echo", func(ctx tool.Context, args string) (ToolResponse[string], error) {
+		return toolSuccess(args)
+	})
+	resp, err := handler(nil, "hello")
+	if err != nil || !resp.Success || resp.Data == nil || *resp.Data != "hello" {
+		t.Errorf("safeCall result = %+v, %v", resp, err)
+	}
+}
@@ -0,0 +1,138 @@
+package vm
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// TickerFunc создает тикер с периодом d и возвращает его канал и функцию остановки
+type TickerFunc func(d time.Duration) (<-chan time.Time, func())
+
+// newTicker - TickerFunc по умолчанию на основе time.NewTicker
+func newTicker(d time.Duration) (<-chan time.Time, func()) {
+	t := time.NewTicker(d)
+	return t.C, t.Stop
+}
+
+// WithTicker подменяет тикер планировщика снапшотов (например, в тестах)
+func WithTicker(ticker TickerFunc) MockOption {
+	return func(m *MockVMManager) {
+		m.newTicker = ticker
+	}
+}
+
+// snapshotSchedule - запущенный планировщик снапшотов одной ВМ
+type snapshotSchedule struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// EnableScheduledSnapshots запускает планировщик, который каждые interval создает
+// снапшот ВМ (с именем "auto-<время>") и оставляет keep самых новых из своих снапшотов;
+// снапшоты, созданные вручную, не удаляются и не учитываются. Снапшоты и ротация
+// планировщика публикуют события, но не попадают в журнал операций, поэтому не мешают
+// UndoLast. Повторный вызов для той же ВМ заменяет ее расписание. Планировщик
+// останавливается при удалении ВМ и при Close
+func (m *MockVMManager) EnableScheduledSnapshots(vmName string, interval time.Duration, keep int) error {
+	return m.runHooks("schedule_snapshots", vmName, func() error { return m.enableScheduledSnapshots(vmName, interval, keep) })
+}
+
+// enableScheduledSnapshots выполняет EnableScheduledSnapshots без хуков операций
+func (m *MockVMManager) enableScheduledSnapshots(vmName string, interval time.Duration, keep int) error {
+	if interval <= 0 {
+		return fmt.Errorf("snapshot interval must be positive")
+	}
+	if keep < 1 {
+		return fmt.Errorf("number of snapshots to keep must be at least 1")
+	}
+
+	m.mu.RLock()
+	vmName = m.resolveNameLocked(vmName)
+	vm, exists := m.vms[vmName]
+	m.mu.RUnlock()
+	if !exists {
+		return fmt.Errorf("virtual machine '%s' not found", vmName)
+	}
+
+	m.schedulesMu.Lock()
+	defer m.schedulesMu.Unlock()
+
+	if m.closed {
+		return fmt.Errorf("VM manager is closed")
+	}
+	if old, exists := m.schedules[vm]; exists {
+		close(old.stop)
+		<-old.done
+	}
+
+	sched := &snapshotSchedule{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	m.schedules[vm] = sched
+	ticks, stopTicker := m.newTicker(interval)
+	go m.runSnapshotSchedule(vm, sched, ticks, stopTicker, keep)
+
+	log.Printf("[MOCK] Scheduled snapshots of virtual machine '%s' every %s, keeping %d", vmName, interval, keep)
+	return nil
+}
+
+// runSnapshotSchedule создает снапшоты ВМ по тикам до остановки расписания или удаления ВМ
+func (m *MockVMManager) runSnapshotSchedule(vm *MockVM, sched *snapshotSchedule, ticks <-chan time.Time, stopTicker func(), keep int) {
+	defer close(sched.done)
+	defer stopTicker()
+
+	for {
+		select {
+		case <-sched.stop:
+			return
+		case <-ticks:
+			// Запись в m.schedules остается до Close: ее удаление здесь потребовало бы
+			// m.schedulesMu, пока EnableScheduledSnapshots или Close ждут завершения
+			if !m.takeScheduledSnapshot(vm, keep) {
+				return
+			}
+		}
+	}
+}
+
+// takeScheduledSnapshot создает снапшот ВМ и удаляет лишние; возвращает false,
+// если ВМ удалена и расписание нужно остановить
+func (m *MockVMManager) takeScheduledSnapshot(vm *MockVM, keep int) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	name := vm.Config.Name
+	if m.vms[name] != vm {
+		log.Printf("[MOCK] Virtual machine '%s' was deleted, stopping scheduled snapshots", name)
+		return false
+	}
+
+	base := "auto-" + m.now().UTC().Format("20060102-150405")
+	snapshotName := base
+	for i := 2; vm.findSnapshot(snapshotName) >= 0; i++ {
+		snapshotName = fmt.Sprintf("%s-%d", base, i)
+	}
+	if err := m.createSnapshotLocked(name, snapshotName, "scheduled snapshot", true); err != nil {
+		log.Printf("[MOCK] Scheduled snapshot of virtual machine '%s' failed: %v", name, err)
+		return true
+	}
+	if _, err := m.pruneSnapshotsLocked(name, keep, true); err != nil {
+		log.Printf("[MOCK] Failed to prune snapshots of virtual machine '%s': %v", name, err)
+	}
+	return true
+}
+
+// stopSchedules останавливает все планировщики снапшотов и ждет их завершения
+func (m *MockVMManager) stopSchedules() {
+	m.schedulesMu.Lock()
+	defer m.schedulesMu.Unlock()
+
+	m.closed = true
+	for vm, sched := range m.schedules {
+		close(sched.stop)
+		<-sched.done
+		delete(m.schedules, vm)
+	}
+}
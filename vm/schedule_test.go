@@ -0,0 +1,170 @@
+package vm
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// manualTicker - TickerFunc, тики которого отправляет тест
+type manualTicker struct {
+	ticks chan time.Time
+}
+
+func newManualTicker() *manualTicker {
+	return &manualTicker{ticks: make(chan time.Time)}
+}
+
+func (t *manualTicker) ticker(time.Duration) (<-chan time.Time, func()) {
+	return t.ticks, func() {}
+}
+
+// steppingClock возвращает время, которое сдвигается на секунду при каждом вызове
+func steppingClock() func() time.Time {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	return func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+}
+
+func TestScheduledSnapshotsKeepManualSnapshots(t *testing.T) {
+	ticker := newManualTicker()
+	m := NewMockVMManager(WithTicker(ticker.ticker), WithClock(steppingClock()))
+	mustCreate(t, m, VMConfig{Name: "db", Memory: 512, VCPUs: 1})
+	if err := m.CreateSnapshot("db", "before-upgrade", "manual"); err != nil {
+		t.Fatalf("CreateSnapshot: %v", err)
+	}
+
+	if err := m.EnableScheduledSnapshots("db", time.Hour, 2); err != nil {
+		t.Fatalf("EnableScheduledSnapshots: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		ticker.ticks <- time.Now()
+	}
+	// Close дожидается завершения планировщика, поэтому все тики обработаны
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	snapshots, err := m.ListSnapshots("db")
+	if err != nil {
+		t.Fatalf("ListSnapshots: %v", err)
+	}
+	var manual, scheduled []string
+	for _, snap := range snapshots {
+		if strings.HasPrefix(snap.Name, "auto-") {
+			scheduled = append(scheduled, snap.Name)
+		} else {
+			manual = append(manual, snap.Name)
+		}
+	}
+	if len(manual) != 1 || manual[0] != "before-upgrade" {
+		t.Errorf("manual snapshots = %v, want [before-upgrade]", manual)
+	}
+	if len(scheduled) != 2 {
+		t.Errorf("scheduled snapshots = %v, want the 2 newest", scheduled)
+	}
+}
+
+func TestScheduledSnapshotsIgnoreManualAutoNames(t *testing.T) {
+	ticker := newManualTicker()
+	m := NewMockVMManager(WithTicker(ticker.ticker), WithClock(steppingClock()))
+	mustCreate(t, m, VMConfig{Name: "db", Memory: 512, VCPUs: 1})
+	// Снапшот с префиксом планировщика, созданный вручную, ротация тоже не трогает
+	if err := m.CreateSnapshot("db", "auto-keep-me", ""); err != nil {
+		t.Fatalf("CreateSnapshot: %v", err)
+	}
+
+	if err := m.EnableScheduledSnapshots("db", time.Hour, 1); err != nil {
+		t.Fatalf("EnableScheduledSnapshots: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		ticker.ticks <- time.Now()
+	}
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	snapshots, err := m.ListSnapshots("db")
+	if err != nil {
+		t.Fatalf("ListSnapshots: %v", err)
+	}
+	if len(snapshots) != 2 || snapshots[0].Name != "auto-keep-me" {
+		t.Errorf("snapshots = %v, want auto-keep-me and the newest scheduled one", snapshots)
+	}
+}
+
+func TestPruneSnapshotsRemovesManualSnapshots(t *testing.T) {
+	m := newTestManager(t, WithClock(steppingClock()))
+	mustCreate(t, m, VMConfig{Name: "db", Memory: 512, VCPUs: 1})
+	for _, name := range []string{"s1", "s2", "s3"} {
+		if err := m.CreateSnapshot("db", name, ""); err != nil {
+			t.Fatalf("CreateSnapshot(%s): %v", name, err)
+		}
+	}
+
+	deleted, err := m.PruneSnapshots("db", 1)
+	if err != nil {
+		t.Fatalf("PruneSnapshots: %v", err)
+	}
+	if len(deleted) != 2 || deleted[0] != "s1" || deleted[1] != "s2" {
+		t.Errorf("deleted = %v, want [s1 s2]", deleted)
+	}
+}
+
+func TestScheduledSnapshotsStayOutOfAuditLog(t *testing.T) {
+	ticker := newManualTicker()
+	m := NewMockVMManager(WithTicker(ticker.ticker), WithClock(steppingClock()))
+	mustCreate(t, m, VMConfig{Name: "db", Memory: 512, VCPUs: 1})
+	if err := m.StopVM(context.Background(), "db"); err != nil {
+		t.Fatalf("StopVM: %v", err)
+	}
+	before := len(m.AuditLog())
+
+	if err := m.EnableScheduledSnapshots("db", time.Hour, 1); err != nil {
+		t.Fatalf("EnableScheduledSnapshots: %v", err)
+	}
+	for i := 0; i < auditLogLimit+5; i++ {
+		ticker.ticks <- time.Now()
+	}
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if got := len(m.AuditLog()); got != before {
+		t.Errorf("audit log has %d entries after scheduled snapshots, want %d", got, before)
+	}
+	if err := m.UndoLast(); err != nil {
+		t.Fatalf("UndoLast after scheduled snapshots: %v", err)
+	}
+	if got := stateOf(t, m, "db"); got != VMStateRunning {
+		t.Errorf("state after undoing stop = %s, want running", got)
+	}
+}
+
+func TestAntiAffinityViolationIsRejected(t *testing.T) {
+	m := newTestManager(t, WithAutoStartOnCreate(false))
+	mustCreate(t, m, VMConfig{Name: "db-1", Memory: 1024, VCPUs: 1})
+	replica := VMConfig{Name: "db-2", Memory: 1024, VCPUs: 1, AntiAffinity: []string{"db-1"}}
+
+	ok, reason, err := m.CanSchedule(replica)
+	if err != nil || ok || !strings.Contains(reason, "anti-affinity with 'db-1'") {
+		t.Errorf("CanSchedule on a single host = %v, %q, %v, want an anti-affinity reason", ok, reason, err)
+	}
+	if err := m.CreateVM(context.Background(), replica); err == nil || !strings.Contains(err.Error(), "anti-affinity with 'db-1'") {
+		t.Errorf("CreateVM on a single host = %v, want an anti-affinity error", err)
+	}
+	if _, err := m.LookupVM("db-2"); err == nil {
+		t.Error("db-2 was created despite the anti-affinity violation")
+	}
+
+	// Ограничение действует в обе стороны: его может задать и уже существующая ВМ
+	m.mu.Lock()
+	m.vms["db-1"].Config.AntiAffinity = []string{"db-3"}
+	m.mu.Unlock()
+	if ok, _, err := m.CanSchedule(VMConfig{Name: "db-3", Memory: 1024, VCPUs: 1}); err != nil || ok {
+		t.Errorf("CanSchedule of a VM excluded by db-1 = %v, %v, want false", ok, err)
+	}
+}
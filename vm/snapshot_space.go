@@ -0,0 +1,88 @@
+package vm
+
+import (
+	"fmt"
+	"log"
+)
+
+// SetSnapshotSpaceEstimate задает, сколько гигабайт пула хранения занимает один снапшот ВМ.
+// 0 возвращает оценку по умолчанию - место, занятое данными дисков ВМ (см. DiskUsage)
+func (m *MockVMManager) SetSnapshotSpaceEstimate(name string, sizeGB uint64) error {
+	return m.runHooks("set_snapshot_space_estimate", name, func() error { return m.setSnapshotSpaceEstimate(name, sizeGB) })
+}
+
+// setSnapshotSpaceEstimate выполняет SetSnapshotSpaceEstimate без хуков операций
+func (m *MockVMManager) setSnapshotSpaceEstimate(name string, sizeGB uint64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = m.resolveNameLocked(name)
+
+	vm, exists := m.vms[name]
+	if !exists {
+		return fmt.Errorf("virtual machine '%s' not found", name)
+	}
+
+	vm.SnapshotSpaceEstimate = sizeGB
+	if sizeGB == 0 {
+		log.Printf("[MOCK] Snapshot space estimate of virtual machine '%s' reset to disk usage (%d GB)", name, vm.snapshotSpaceGB())
+	} else {
+		log.Printf("[MOCK] Snapshot space estimate of virtual machine '%s' set to %d GB", name, sizeGB)
+	}
+	m.recordVMLocked(AuditSnapshotEstimate, name)
+	return nil
+}
+
+// SnapshotSpaceEstimate возвращает оценку места в пуле хранения для нового снапшота ВМ
+func (m *MockVMManager) SnapshotSpaceEstimate(name string) (uint64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	name = m.resolveNameLocked(name)
+
+	vm, exists := m.vms[name]
+	if !exists {
+		return 0, fmt.Errorf("virtual machine '%s' not found", name)
+	}
+	return vm.snapshotSpaceGB(), nil
+}
+
+// snapshotSpaceGB возвращает оценку места для нового снапшота: SnapshotSpaceEstimate,
+// если она задана, иначе место, занятое данными всех дисков ВМ
+func (vm *MockVM) snapshotSpaceGB() uint64 {
+	if vm.SnapshotSpaceEstimate > 0 {
+		return vm.SnapshotSpaceEstimate
+	}
+	var used uint64
+	for _, disk := range diskSpecs(vm.Config) {
+		used += vm.diskUsedGB(disk.Path, disk.Size)
+	}
+	return used
+}
+
+// snapshotsGBLocked возвращает место в пуле хранения, занятое снапшотами всех ВМ;
+// вызывающий код должен удерживать m.mu
+func (m *MockVMManager) snapshotsGBLocked() uint64 {
+	var total uint64
+	for _, vm := range m.vms {
+		for _, snap := range vm.Snapshots {
+			total += snap.SizeGB
+		}
+	}
+	return total
+}
+
+// storageUsedGBLocked возвращает место в пуле хранения, занятое дисками и снапшотами ВМ;
+// вызывающий код должен удерживать m.mu
+func (m *MockVMManager) storageUsedGBLocked() uint64 {
+	return m.usageLocked().DiskGB + m.snapshotsGBLocked()
+}
+
+// checkSnapshotSpaceLocked проверяет, что в пуле хранения есть место для нового снапшота
+// ВМ, и возвращает его оценку. Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) checkSnapshotSpaceLocked(vm *MockVM, snapshotName string) (uint64, error) {
+	need := vm.snapshotSpaceGB()
+	if pool, used := m.limits.StoragePoolGB, m.storageUsedGBLocked(); pool > 0 && used+need > pool {
+		return 0, withCategory(fmt.Errorf("insufficient storage for snapshot '%s' of virtual machine '%s': %d GB available in pool, %d GB estimated",
+			snapshotName, vm.Config.Name, pool-min(used, pool), need), ErrInsufficientSpace)
+	}
+	return need, nil
+}
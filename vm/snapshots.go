@@ -0,0 +1,375 @@
+package vm
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// Snapshot - сохраненное состояние виртуальной машины
+type Snapshot struct {
+	SnapshotInfo
+	Config    VMConfig
+	State     VMState
+	Scheduled bool // снапшот создан планировщиком, и только такие удаляет его ротация
+}
+
+// SnapshotInfo - метаданные снапшота
+type SnapshotInfo struct {
+	Name        string
+	Description string
+	CreatedAt   time.Time
+	SizeGB      uint64 // место, занятое снапшотом в пуле хранения
+}
+
+// findSnapshot возвращает индекс снапшота по имени или -1
+func (vm *MockVM) findSnapshot(name string) int {
+	for i, snap := range vm.Snapshots {
+		if snap.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// CreateSnapshot сохраняет текущую конфигурацию и состояние ВМ под именем snapshotName
+func (m *MockVMManager) CreateSnapshot(vmName, snapshotName, description string) error {
+	return m.runHooks("create_snapshot", vmName, func() error { return m.createSnapshot(vmName, snapshotName, description) })
+}
+
+// createSnapshot выполняет CreateSnapshot без хуков операций
+func (m *MockVMManager) createSnapshot(vmName, snapshotName, description string) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	vmName = m.resolveNameLocked(vmName)
+	defer func() { m.noteResultLocked(vmName, err) }()
+
+	return m.createSnapshotLocked(vmName, snapshotName, description, false)
+}
+
+// createSnapshotLocked реализует CreateSnapshot; снапшот планировщика (scheduled)
+// помечается Scheduled и не записывается в журнал операций. Вызывающий код должен
+// удерживать m.mu
+func (m *MockVMManager) createSnapshotLocked(vmName, snapshotName, description string, scheduled bool) error {
+	vm, exists := m.vms[vmName]
+	if !exists {
+		return fmt.Errorf("virtual machine '%s' not found", vmName)
+	}
+	if snapshotName == "" {
+		return fmt.Errorf("snapshot name cannot be empty")
+	}
+	if vm.findSnapshot(snapshotName) >= 0 {
+		return fmt.Errorf("snapshot '%s' already exists for virtual machine '%s'", snapshotName, vmName)
+	}
+	sizeGB, err := m.checkSnapshotSpaceLocked(vm, snapshotName)
+	if err != nil {
+		return err
+	}
+
+	vm.Snapshots = append(vm.Snapshots, Snapshot{
+		SnapshotInfo: SnapshotInfo{
+			Name:        snapshotName,
+			Description: description,
+			CreatedAt:   m.now(),
+			SizeGB:      sizeGB,
+		},
+		Config:    copyConfig(vm.Config),
+		State:     vm.State,
+		Scheduled: scheduled,
+	})
+
+	log.Printf("[MOCK] Snapshot '%s' of virtual machine '%s' created", snapshotName, vmName)
+	if scheduled {
+		m.notifyLocked(AuditCreateSnapshot, vmName)
+	} else {
+		m.recordVMLocked(AuditCreateSnapshot, vmName)
+	}
+	return nil
+}
+
+// ListSnapshots возвращает метаданные снапшотов ВМ в порядке создания
+func (m *MockVMManager) ListSnapshots(vmName string) ([]SnapshotInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	vmName = m.resolveNameLocked(vmName)
+
+	vm, exists := m.vms[vmName]
+	if !exists {
+		return nil, fmt.Errorf("virtual machine '%s' not found", vmName)
+	}
+
+	infos := make([]SnapshotInfo, 0, len(vm.Snapshots))
+	for _, snap := range vm.Snapshots {
+		infos = append(infos, snap.SnapshotInfo)
+	}
+	return infos, nil
+}
+
+// RestoreSnapshot возвращает ВМ к конфигурации и состоянию из снапшота.
+// Имя ВМ при этом не меняется. Если диск снапшота теперь занят другой ВМ, возвращается
+// ErrDiskInUse, а во время длительной операции с ВМ - ErrVMBusy
+func (m *MockVMManager) RestoreSnapshot(vmName, snapshotName string) error {
+	return m.runHooks("restore_snapshot", vmName, func() error { return m.restoreSnapshot(vmName, snapshotName) })
+}
+
+// restoreSnapshot выполняет RestoreSnapshot без хуков операций
+func (m *MockVMManager) restoreSnapshot(vmName, snapshotName string) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	vmName = m.resolveNameLocked(vmName)
+	defer func() { m.noteResultLocked(vmName, err) }()
+
+	return m.restoreSnapshotLocked(vmName, snapshotName)
+}
+
+// RevertToLatestSnapshot восстанавливает самый новый по времени создания снапшот ВМ
+// и возвращает его имя. Для хуков это операция "restore_snapshot"
+func (m *MockVMManager) RevertToLatestSnapshot(vmName string) (string, error) {
+	var snapshot string
+	err := m.runHooks("restore_snapshot", vmName, func() (err error) {
+		snapshot, err = m.revertToLatestSnapshot(vmName)
+		return err
+	})
+	return snapshot, err
+}
+
+// revertToLatestSnapshot выполняет RevertToLatestSnapshot без хуков операций
+func (m *MockVMManager) revertToLatestSnapshot(vmName string) (_ string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	vmName = m.resolveNameLocked(vmName)
+	defer func() { m.noteResultLocked(vmName, err) }()
+
+	vm, exists := m.vms[vmName]
+	if !exists {
+		return "", fmt.Errorf("virtual machine '%s' not found", vmName)
+	}
+	if len(vm.Snapshots) == 0 {
+		return "", fmt.Errorf("virtual machine '%s' has no snapshots", vmName)
+	}
+
+	// При равном времени создания новее тот, что создан позже
+	latest := vm.Snapshots[0]
+	for _, snap := range vm.Snapshots[1:] {
+		if !snap.CreatedAt.Before(latest.CreatedAt) {
+			latest = snap
+		}
+	}
+
+	if err := m.restoreSnapshotLocked(vmName, latest.Name); err != nil {
+		return "", err
+	}
+	return latest.Name, nil
+}
+
+// restoreSnapshotLocked восстанавливает снапшот; вызывающий код должен удерживать m.mu
+func (m *MockVMManager) restoreSnapshotLocked(vmName, snapshotName string) error {
+	vm, exists := m.vms[vmName]
+	if !exists {
+		return fmt.Errorf("virtual machine '%s' not found", vmName)
+	}
+	if err := checkNotBusyLocked(vm, vmName); err != nil {
+		return err
+	}
+	if err := checkUnlockedLocked(vm, vmName, "restore a snapshot of"); err != nil {
+		return err
+	}
+	i := vm.findSnapshot(snapshotName)
+	if i < 0 {
+		return fmt.Errorf("snapshot '%s' not found for virtual machine '%s'", snapshotName, vmName)
+	}
+	snap := vm.Snapshots[i]
+	config := copyConfig(snap.Config)
+	config.Name = vmName
+
+	// С момента создания снапшота могли измениться правила проверки, квоты и
+	// размещение других ВМ, а его диски - перейти к другой ВМ
+	if err := m.validateConfig(config); err != nil {
+		return fmt.Errorf("cannot restore snapshot '%s' of virtual machine '%s': %w", snapshotName, vmName, withCategory(err, ErrInvalidConfig))
+	}
+	if err := m.checkDiskConflictsLocked(vmName, diskSpecs(config)); err != nil {
+		return fmt.Errorf("cannot restore snapshot '%s' of virtual machine '%s': %w", snapshotName, vmName, err)
+	}
+	// Квоты проверяются без учета текущей конфигурации ВМ
+	delete(m.vms, vmName)
+	reason := m.scheduleReasonLocked(config)
+	host, _ := m.placeLocked(config)
+	m.vms[vmName] = vm
+	if reason != "" {
+		return fmt.Errorf("cannot restore snapshot '%s' of virtual machine '%s': %s", snapshotName, vmName, reason)
+	}
+	if snap.State != vm.State {
+		if err := m.checkTransitionLocked(vm, "restore", vmName, snap.State); err != nil {
+			return err
+		}
+	}
+
+	m.releaseDisksLocked(vmName, diskPaths(vm.Config))
+	wasRunning := vm.State == VMStateRunning
+	vm.Config = config
+	vm.Host = host
+	vm.State = snap.State
+	switch {
+	case vm.State != VMStateRunning:
+		vm.CurrentMemoryMB = 0
+	case wasRunning:
+		vm.CurrentMemoryMB = vm.Config.Memory
+	default:
+		// Восстановление запущенного состояния - это запуск ВМ
+		m.bootLocked(vm)
+	}
+	for _, path := range diskPaths(vm.Config) {
+		m.disks[path] = vmName
+	}
+
+	log.Printf("[MOCK] Virtual machine '%s' restored to snapshot '%s'", vmName, snapshotName)
+	m.recordVMLocked(AuditRestoreSnapshot, vmName)
+	return nil
+}
+
+// DeleteSnapshot удаляет снапшот ВМ
+func (m *MockVMManager) DeleteSnapshot(vmName, snapshotName string) error {
+	return m.runHooks("delete_snapshot", vmName, func() error { return m.deleteSnapshot(vmName, snapshotName) })
+}
+
+// deleteSnapshot выполняет DeleteSnapshot без хуков операций
+func (m *MockVMManager) deleteSnapshot(vmName, snapshotName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	vmName = m.resolveNameLocked(vmName)
+
+	vm, exists := m.vms[vmName]
+	if !exists {
+		return fmt.Errorf("virtual machine '%s' not found", vmName)
+	}
+	i := vm.findSnapshot(snapshotName)
+	if i < 0 {
+		return fmt.Errorf("snapshot '%s' not found for virtual machine '%s'", snapshotName, vmName)
+	}
+
+	vm.Snapshots = append(vm.Snapshots[:i], vm.Snapshots[i+1:]...)
+	log.Printf("[MOCK] Snapshot '%s' of virtual machine '%s' deleted", snapshotName, vmName)
+	m.recordVMLocked(AuditDeleteSnapshot, vmName)
+	return nil
+}
+
+// RenameSnapshot переименовывает снапшот ВМ; новое имя должно быть свободно в пределах этой ВМ
+func (m *MockVMManager) RenameSnapshot(vmName, oldName, newName string) error {
+	return m.runHooks("rename_snapshot", vmName, func() error { return m.renameSnapshot(vmName, oldName, newName) })
+}
+
+// renameSnapshot выполняет RenameSnapshot без хуков операций
+func (m *MockVMManager) renameSnapshot(vmName, oldName, newName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	vmName = m.resolveNameLocked(vmName)
+
+	vm, exists := m.vms[vmName]
+	if !exists {
+		return fmt.Errorf("virtual machine '%s' not found", vmName)
+	}
+	i := vm.findSnapshot(oldName)
+	if i < 0 {
+		return fmt.Errorf("snapshot '%s' not found for virtual machine '%s'", oldName, vmName)
+	}
+	if newName == "" {
+		return fmt.Errorf("snapshot name cannot be empty")
+	}
+	if vm.findSnapshot(newName) >= 0 {
+		return fmt.Errorf("snapshot '%s' already exists for virtual machine '%s'", newName, vmName)
+	}
+
+	vm.Snapshots[i].Name = newName
+	log.Printf("[MOCK] Snapshot '%s' of virtual machine '%s' renamed to '%s'", oldName, vmName, newName)
+	m.recordVMLocked(AuditRenameSnapshot, vmName)
+	return nil
+}
+
+// ListAllSnapshots возвращает снапшоты всех ВМ (в порядке создания), сгруппированные
+// по имени ВМ; ВМ без снапшотов включаются с пустым списком
+func (m *MockVMManager) ListAllSnapshots() (map[string][]SnapshotInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	all := make(map[string][]SnapshotInfo, len(m.vms))
+	for name, vm := range m.vms {
+		infos := make([]SnapshotInfo, 0, len(vm.Snapshots))
+		for _, snap := range vm.Snapshots {
+			infos = append(infos, snap.SnapshotInfo)
+		}
+		all[name] = infos
+	}
+	return all, nil
+}
+
+// PruneSnapshots удаляет все снапшоты ВМ, кроме keep самых новых по времени создания
+// (при равном времени новее тот, что создан позже), и возвращает имена удаленных
+// снапшотов от старых к новым
+func (m *MockVMManager) PruneSnapshots(vmName string, keep int) ([]string, error) {
+	var deleted []string
+	err := m.runHooks("prune_snapshots", vmName, func() (err error) {
+		deleted, err = m.pruneSnapshots(vmName, keep)
+		return err
+	})
+	return deleted, err
+}
+
+// pruneSnapshots выполняет PruneSnapshots без хуков операций
+func (m *MockVMManager) pruneSnapshots(vmName string, keep int) (_ []string, err error) {
+	if keep < 0 {
+		return nil, fmt.Errorf("number of snapshots to keep cannot be negative")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	vmName = m.resolveNameLocked(vmName)
+	defer func() { m.noteResultLocked(vmName, err) }()
+
+	return m.pruneSnapshotsLocked(vmName, keep, false)
+}
+
+// pruneSnapshotsLocked реализует PruneSnapshots; при scheduledOnly (ротация планировщика)
+// учитываются и удаляются только снапшоты планировщика, а созданные вручную не трогаются,
+// и удаление не записывается в журнал операций. Вызывающий код должен удерживать m.mu
+func (m *MockVMManager) pruneSnapshotsLocked(vmName string, keep int, scheduledOnly bool) ([]string, error) {
+	vm, exists := m.vms[vmName]
+	if !exists {
+		return nil, fmt.Errorf("virtual machine '%s' not found", vmName)
+	}
+
+	byAge := make([]Snapshot, 0, len(vm.Snapshots))
+	for _, snap := range vm.Snapshots {
+		if !scheduledOnly || snap.Scheduled {
+			byAge = append(byAge, snap)
+		}
+	}
+	sort.SliceStable(byAge, func(i, j int) bool {
+		return byAge[i].CreatedAt.Before(byAge[j].CreatedAt)
+	})
+
+	deleted := []string{}
+	if len(byAge) <= keep {
+		return deleted, nil
+	}
+	remove := make(map[string]bool)
+	for _, snap := range byAge[:len(byAge)-keep] {
+		remove[snap.Name] = true
+		deleted = append(deleted, snap.Name)
+	}
+
+	kept := vm.Snapshots[:0]
+	for _, snap := range vm.Snapshots {
+		if !remove[snap.Name] {
+			kept = append(kept, snap)
+		}
+	}
+	vm.Snapshots = kept
+	log.Printf("[MOCK] Pruned %d snapshots of virtual machine '%s', kept %d", len(deleted), vmName, len(kept))
+	if scheduledOnly {
+		m.notifyLocked(AuditDeleteSnapshot, vmName)
+	} else {
+		m.recordVMLocked(AuditDeleteSnapshot, vmName)
+	}
+	return deleted, nil
+}
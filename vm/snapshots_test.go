@@ -0,0 +1,255 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRestoreSnapshotRejectsDiskOwnedByAnotherVM(t *testing.T) {
+	m := newTestManager(t, WithAutoStartOnCreate(false))
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1, DiskPath: "/tmp/restore-a.qcow2"})
+	if err := m.CreateSnapshot("web", "before", ""); err != nil {
+		t.Fatalf("CreateSnapshot: %v", err)
+	}
+	if err := m.UpdateVMConfig("web", VMConfig{Name: "web", Memory: 1024, VCPUs: 1, DiskPath: "/tmp/restore-b.qcow2"}); err != nil {
+		t.Fatalf("UpdateVMConfig: %v", err)
+	}
+	mustCreate(t, m, VMConfig{Name: "db", Memory: 1024, VCPUs: 1, DiskPath: "/tmp/restore-a.qcow2"})
+
+	if err := m.RestoreSnapshot("web", "before"); !errors.Is(err, ErrDiskInUse) {
+		t.Fatalf("RestoreSnapshot = %v, want ErrDiskInUse", err)
+	}
+	if _, err := m.RevertToLatestSnapshot("web"); !errors.Is(err, ErrDiskInUse) {
+		t.Errorf("RevertToLatestSnapshot = %v, want ErrDiskInUse", err)
+	}
+	if conflicts := m.FindDiskConflicts(); len(conflicts) != 0 {
+		t.Errorf("disk conflicts after rejected restore: %v", conflicts)
+	}
+	info, _ := m.GetVMInfo("web")
+	if info.Config.DiskPath != "/tmp/restore-b.qcow2" {
+		t.Errorf("web disk = %s, want it unchanged", info.Config.DiskPath)
+	}
+
+	if err := m.DeleteVM(context.Background(), "db", DeleteVMOptions{}); err != nil {
+		t.Fatalf("DeleteVM: %v", err)
+	}
+	if err := m.RestoreSnapshot("web", "before"); err != nil {
+		t.Errorf("RestoreSnapshot after the disk was freed: %v", err)
+	}
+}
+
+func TestRestoreSnapshotRejectsBusyVM(t *testing.T) {
+	m := newTestManager(t, WithAutoStartOnCreate(false))
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+	if err := m.CreateSnapshot("web", "before", ""); err != nil {
+		t.Fatalf("CreateSnapshot: %v", err)
+	}
+
+	m.mu.Lock()
+	m.vms["web"].busy = true
+	m.mu.Unlock()
+	if err := m.RestoreSnapshot("web", "before"); !errors.Is(err, ErrVMBusy) {
+		t.Errorf("RestoreSnapshot of a busy VM = %v, want ErrVMBusy", err)
+	}
+}
+
+func TestRestoreSnapshotRechecksQuotas(t *testing.T) {
+	m := newTestManager(t, WithAutoStartOnCreate(false), WithLimits(Limits{MaxMemoryMB: 3072}))
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 2048, VCPUs: 1})
+	if err := m.CreateSnapshot("web", "big", ""); err != nil {
+		t.Fatalf("CreateSnapshot: %v", err)
+	}
+	if err := m.UpdateVMConfig("web", VMConfig{Memory: 1024, VCPUs: 1}); err != nil {
+		t.Fatalf("UpdateVMConfig: %v", err)
+	}
+	mustCreate(t, m, VMConfig{Name: "db", Memory: 2048, VCPUs: 1})
+
+	if err := m.RestoreSnapshot("web", "big"); err == nil {
+		t.Fatal("RestoreSnapshot over the memory quota succeeded")
+	}
+	if info, _ := m.GetVMInfo("web"); info.Config.Memory != 1024 {
+		t.Errorf("web memory after a rejected restore = %d MB, want 1024", info.Config.Memory)
+	}
+}
+
+func TestRestoreRunningSnapshotBootsVM(t *testing.T) {
+	m := newTestManager(t, WithAutoStartOnCreate(false))
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+	if err := m.StartVM(context.Background(), "web"); err != nil {
+		t.Fatalf("StartVM: %v", err)
+	}
+	if err := m.CreateSnapshot("web", "up", ""); err != nil {
+		t.Fatalf("CreateSnapshot: %v", err)
+	}
+	if err := m.StopVM(context.Background(), "web"); err != nil {
+		t.Fatalf("StopVM: %v", err)
+	}
+	if err := m.SetNextBoot("web", BootDeviceNetwork); err != nil {
+		t.Fatalf("SetNextBoot: %v", err)
+	}
+
+	if err := m.RestoreSnapshot("web", "up"); err != nil {
+		t.Fatalf("RestoreSnapshot: %v", err)
+	}
+	info, _ := m.GetVMInfo("web")
+	if info.State != VMStateRunning || info.BootDevice != BootDeviceNetwork {
+		t.Errorf("after restore: state %s, boot device %q, want running from %q", info.State, info.BootDevice, BootDeviceNetwork)
+	}
+	if err := m.StopVM(context.Background(), "web"); err != nil {
+		t.Fatalf("StopVM: %v", err)
+	}
+	if err := m.StartVM(context.Background(), "web"); err != nil {
+		t.Fatalf("StartVM: %v", err)
+	}
+	if info, _ := m.GetVMInfo("web"); info.BootDevice != BootDeviceDisk {
+		t.Errorf("boot device on the next start = %q, want %q", info.BootDevice, BootDeviceDisk)
+	}
+}
+
+func TestRestoreSnapshotChecksTransition(t *testing.T) {
+	backendErr := errors.New("backend failure")
+	m := newTestManager(t, WithAutoStartOnCreate(false), WithTransitionFailure(func(operation, name string) error {
+		if operation == "restore" {
+			return backendErr
+		}
+		return nil
+	}))
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+	if err := m.CreateSnapshot("web", "stopped", ""); err != nil {
+		t.Fatalf("CreateSnapshot: %v", err)
+	}
+
+	// Состояние не меняется: переход не нужен
+	if err := m.RestoreSnapshot("web", "stopped"); err != nil {
+		t.Fatalf("RestoreSnapshot without a state change: %v", err)
+	}
+	if err := m.StartVM(context.Background(), "web"); err != nil {
+		t.Fatalf("StartVM: %v", err)
+	}
+	if err := m.RestoreSnapshot("web", "stopped"); !errors.Is(err, backendErr) {
+		t.Fatalf("RestoreSnapshot = %v, want the backend error", err)
+	}
+	if state := stateOf(t, m, "web"); state != VMStateError {
+		t.Errorf("state after a failed restore = %s, want %s", state, VMStateError)
+	}
+}
+
+func TestRevertAndPruneRunThroughHooks(t *testing.T) {
+	m := newTestManager(t)
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+	for _, snap := range []string{"a", "b", "c"} {
+		if err := m.CreateSnapshot("web", snap, ""); err != nil {
+			t.Fatalf("CreateSnapshot %s: %v", snap, err)
+		}
+	}
+
+	var ops []string
+	m.Use(func(op, vmName string, next func() error) error {
+		ops = append(ops, op+":"+vmName)
+		return next()
+	})
+	if _, err := m.RevertToLatestSnapshot("web"); err != nil {
+		t.Fatalf("RevertToLatestSnapshot: %v", err)
+	}
+	if _, err := m.PruneSnapshots("web", 1); err != nil {
+		t.Fatalf("PruneSnapshots: %v", err)
+	}
+	want := []string{"restore_snapshot:web", "prune_snapshots:web"}
+	if len(ops) != len(want) || ops[0] != want[0] || ops[1] != want[1] {
+		t.Errorf("hooked operations = %v, want %v", ops, want)
+	}
+
+	rejected := errors.New("rejected by policy")
+	m.Use(func(op, vmName string, next func() error) error { return rejected })
+	if _, err := m.PruneSnapshots("web", 0); !errors.Is(err, rejected) {
+		t.Errorf("PruneSnapshots with a rejecting hook = %v, want the hook error", err)
+	}
+	if snaps, _ := m.ListSnapshots("web"); len(snaps) != 1 {
+		t.Errorf("snapshots after a rejected prune = %d, want 1", len(snaps))
+	}
+}
+
+func TestRevertAndPruneRecordLastError(t *testing.T) {
+	m := newTestManager(t)
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+
+	if _, err := m.RevertToLatestSnapshot("web"); err == nil {
+		t.Fatal("RevertToLatestSnapshot without snapshots succeeded")
+	}
+	if info, _ := m.GetVMInfo("web"); info.LastError == "" {
+		t.Error("LastError is empty after a failed revert")
+	}
+
+	if _, err := m.PruneSnapshots("web", 0); err != nil {
+		t.Fatalf("PruneSnapshots: %v", err)
+	}
+	if info, _ := m.GetVMInfo("web"); info.LastError != "" {
+		t.Errorf("LastError after a successful prune = %q, want it cleared", info.LastError)
+	}
+}
+
+func TestRenameSnapshotRejectsCollisionAndMissingSnapshot(t *testing.T) {
+	m := newTestManager(t, WithAutoStartOnCreate(false))
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+	for _, name := range []string{"daily", "weekly"} {
+		if err := m.CreateSnapshot("web", name, ""); err != nil {
+			t.Fatalf("CreateSnapshot(%s): %v", name, err)
+		}
+	}
+	tools := newTestTools(t, m)
+
+	tests := []struct {
+		name             string
+		oldName, newName string
+		want             string
+	}{
+		{"name collision", "daily", "weekly", "snapshot 'weekly' already exists"},
+		{"missing snapshot", "monthly", "yearly", "snapshot 'monthly' not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := m.RenameSnapshot("web", tt.oldName, tt.newName); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("RenameSnapshot = %v, want an error containing %q", err, tt.want)
+			}
+			for _, dryRun := range []bool{true, false} {
+				resp := callTool(t, tools, "rename_snapshot", map[string]any{"vm_name": "web", "old_name": tt.oldName, "new_name": tt.newName, "dry_run": dryRun})
+				if errMsg, _ := resp["error"].(string); resp["success"] != false || !strings.Contains(errMsg, tt.want) {
+					t.Errorf("rename_snapshot (dry_run=%v) = %v, want an error containing %q", dryRun, resp, tt.want)
+				}
+			}
+		})
+	}
+
+	snapshots, err := m.ListSnapshots("web")
+	if err != nil {
+		t.Fatalf("ListSnapshots: %v", err)
+	}
+	if len(snapshots) != 2 || snapshots[0].Name != "daily" || snapshots[1].Name != "weekly" {
+		t.Errorf("snapshots after rejected renames = %+v, want daily and weekly unchanged", snapshots)
+	}
+}
+
+func TestFullPoolBlocksSnapshotCreation(t *testing.T) {
+	m := newTestManager(t, WithLimits(Limits{StoragePoolGB: 50}))
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1, DiskPath: "/tmp/snap-web.qcow2", DiskSize: 40})
+	if err := m.SetSnapshotSpaceEstimate("web", 10); err != nil {
+		t.Fatalf("SetSnapshotSpaceEstimate: %v", err)
+	}
+	// Первый снапшот заполняет пул целиком (40 + 10 ГБ)
+	if err := m.CreateSnapshot("web", "first", ""); err != nil {
+		t.Fatalf("CreateSnapshot within the pool: %v", err)
+	}
+
+	if err := m.CreateSnapshot("web", "second", ""); !errors.Is(err, ErrInsufficientSpace) {
+		t.Fatalf("CreateSnapshot in a full pool = %v, want ErrInsufficientSpace", err)
+	}
+	if snapshots, _ := m.ListSnapshots("web"); len(snapshots) != 1 {
+		t.Errorf("snapshots after the rejected create = %v, want only the first", snapshots)
+	}
+	resp := callTool(t, newTestTools(t, m), "create_snapshot", map[string]any{"vm_name": "web", "snapshot_name": "second"})
+	if resp["success"] != false || !strings.Contains(resp["error"].(string), "free up storage") {
+		t.Errorf("create_snapshot in a full pool = %v, want a failure suggesting to free up storage", resp)
+	}
+}
@@ -0,0 +1,62 @@
+package vm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// EventStream передает события ВМ подключенным клиентам по протоколу Server-Sent
+// Events (text/event-stream), например для веб-панели с обновлением в реальном времени.
+// Каждый клиент получает собственную подписку, которая отменяется при его отключении
+type EventStream struct {
+	subscribe func() (<-chan VMEvent, func())
+}
+
+// NewEventStream создает EventStream поверх функции подписки (например, manager.Subscribe)
+func NewEventStream(subscribe func() (<-chan VMEvent, func())) *EventStream {
+	return &EventStream{subscribe: subscribe}
+}
+
+// ServeHTTP отправляет клиенту события в формате SSE, пока он не отключится:
+// "event: <тип>" и "data: <VMEvent в JSON>"
+func (s *EventStream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	events, cancel := s.subscribe()
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
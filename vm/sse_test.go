@@ -0,0 +1,98 @@
+package vm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// subscriberCount возвращает число активных подписок менеджера
+func subscriberCount(m *MockVMManager) int {
+	m.broker.mu.Lock()
+	defer m.broker.mu.Unlock()
+	return len(m.broker.subscribers)
+}
+
+func TestEventStreamSendsEvents(t *testing.T) {
+	m := newTestManager(t)
+	server := httptest.NewServer(NewEventStream(m.Subscribe))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	// Подписка создается до ответа, поэтому событие не теряется
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+
+	lines := make(chan string, 16)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+	var eventLine, dataLine string
+	timeout := time.After(2 * time.Second)
+	for dataLine == "" {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatal("stream ended before the event")
+			}
+			if strings.HasPrefix(line, "event: ") {
+				eventLine = line
+			} else if strings.HasPrefix(line, "data: ") {
+				dataLine = line
+			}
+		case <-timeout:
+			t.Fatal("no event received")
+		}
+	}
+	if eventLine != "event: "+string(AuditCreate) {
+		t.Errorf("event line = %q", eventLine)
+	}
+	var event VMEvent
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(dataLine, "data: ")), &event); err != nil {
+		t.Fatalf("data is not a VMEvent: %v", err)
+	}
+	if event.VMName != "web" || event.Type != AuditCreate {
+		t.Errorf("event = %+v", event)
+	}
+
+	// Отключение клиента отменяет его подписку
+	cancel()
+	for deadline := time.Now().Add(2 * time.Second); subscriberCount(m) != 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("subscription was not cancelled after the client disconnected")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestEventStreamRejectsNonGet(t *testing.T) {
+	m := newTestManager(t)
+	recorder := httptest.NewRecorder()
+	NewEventStream(m.Subscribe).ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/events", nil))
+
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want %d", recorder.Code, http.StatusMethodNotAllowed)
+	}
+	if subscriberCount(m) != 0 {
+		t.Error("a rejected request left a subscription")
+	}
+}
@@ -0,0 +1,163 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+)
+
+// builtinTransitions - встроенные переходы: состояние -> состояния, из которых в него
+// можно перейти. В VMStateError ВМ попадает из любого состояния при сбое бэкенда
+var builtinTransitions = map[VMState][]VMState{
+	VMStateRunning: {VMStateStopped, VMStatePaused},
+	VMStateStopped: {VMStateRunning, VMStatePaused, VMStateError},
+	VMStatePaused:  {VMStateRunning},
+	VMStateError:   {},
+}
+
+// ErrInvalidTransition возвращается, если переход ВМ из текущего состояния в
+// запрошенное не разрешен правилами переходов (см. RegisterState)
+var ErrInvalidTransition = errors.New("state transition is not allowed")
+
+// cloneTransitions возвращает независимую копию правил переходов
+func cloneTransitions(transitions map[VMState][]VMState) map[VMState][]VMState {
+	clone := maps.Clone(transitions)
+	for state, from := range clone {
+		clone[state] = slices.Clone(from)
+	}
+	return clone
+}
+
+// RegisterState регистрирует пользовательское состояние ВМ (например, "provisioning")
+// вместе с состояниями, из которых в него можно перейти. Повторная регистрация заменяет
+// правила пользовательского состояния. Для встроенного состояния validFrom дополняет
+// встроенные правила (убрать их нельзя): так ВМ можно вывести из пользовательского
+// состояния обычными StartVM и StopVM. Все состояния из validFrom должны быть известны
+func (m *MockVMManager) RegisterState(state VMState, validFrom []VMState) error {
+	if state == "" {
+		return errors.New("state must not be empty")
+	}
+	if state == VMStateError {
+		return fmt.Errorf("state '%s' is entered automatically on backend failures and cannot be registered", state)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, from := range validFrom {
+		if _, known := m.transitions[from]; !known && from != state {
+			return fmt.Errorf("unknown state '%s': register it with RegisterState first", from)
+		}
+	}
+
+	if _, builtin := builtinTransitions[state]; builtin {
+		for _, from := range validFrom {
+			if !slices.Contains(m.transitions[state], from) {
+				m.transitions[state] = append(m.transitions[state], from)
+			}
+		}
+		log.Printf("[MOCK] Built-in state '%s' can now also be entered from: %v", state, validFrom)
+		return nil
+	}
+	if _, registered := m.transitions[state]; !registered {
+		m.customStates = append(m.customStates, state)
+	}
+	m.transitions[state] = slices.Compact(slices.Sorted(slices.Values(validFrom)))
+	log.Printf("[MOCK] Registered state '%s' (valid from: %v)", state, validFrom)
+	return nil
+}
+
+// StateTransitions возвращает разрешенные переходы: состояние -> состояния, в которые из
+// него можно перейти. Каждое известное состояние есть в результате, списки
+// отсортированы. Переход в VMStateError при сбое бэкенда возможен из любого состояния и
+// в списки не входит
+func (m *MockVMManager) StateTransitions() map[VMState][]VMState {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make(map[VMState][]VMState)
+	for _, state := range m.knownStatesLocked() {
+		result[state] = []VMState{}
+	}
+	for to, validFrom := range m.transitions {
+		for _, from := range validFrom {
+			result[from] = append(result[from], to)
+		}
+	}
+	for _, to := range result {
+		slices.Sort(to)
+	}
+	return result
+}
+
+// TransitionVM переводит ВМ в состояние to, если это разрешено правилами переходов.
+// Переход в VMStateRunning и VMStateStopped выполняется как StartVM и StopVM (без
+// зависимостей), в VMStateError ВМ переводится только бэкендом
+func (m *MockVMManager) TransitionVM(name string, to VMState) error {
+	return m.runHooks("transition", name, func() error { return m.transitionVM(name, to) })
+}
+
+// transitionVM выполняет TransitionVM без хуков операций
+func (m *MockVMManager) transitionVM(name string, to VMState) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = m.resolveNameLocked(name)
+	defer func() { m.noteResultLocked(name, err) }()
+
+	vm, exists := m.vms[name]
+	if !exists {
+		return fmt.Errorf("virtual machine '%s' not found", name)
+	}
+	if err := checkNotBusyLocked(vm, name); err != nil {
+		return err
+	}
+
+	before := m.statesLocked()
+	switch to {
+	case VMStateRunning:
+		if err := m.startVMLocked(name); err != nil {
+			return err
+		}
+		m.recordTransitionLocked(AuditStart, name, []string{name}, before)
+		return nil
+	case VMStateStopped:
+		if err := m.stopVMLocked(name); err != nil {
+			return err
+		}
+		m.recordTransitionLocked(AuditStop, name, []string{name}, before)
+		return nil
+	case VMStateError:
+		return fmt.Errorf("virtual machine '%s' cannot be put into state '%s' manually", name, to)
+	}
+
+	if _, known := m.transitions[to]; !known {
+		return fmt.Errorf("unknown state '%s': register it with RegisterState first", to)
+	}
+	if vm.State == to {
+		return nil
+	}
+	if err := m.checkTransitionLocked(vm, "transition", name, to); err != nil {
+		return err
+	}
+	vm.State = to
+	log.Printf("[MOCK] Virtual machine '%s' moved to state '%s'", name, to)
+	m.recordVMLocked(AuditTransition, name)
+	return nil
+}
+
+// knownStatesLocked возвращает встроенные состояния и затем пользовательские в порядке
+// регистрации; вызывающий код должен удерживать m.mu
+func (m *MockVMManager) knownStatesLocked() []VMState {
+	return append(slices.Clone(VMStates), m.customStates...)
+}
+
+// checkAllowedLocked проверяет, что правила разрешают перевести ВМ из ее текущего
+// состояния в to; вызывающий код должен удерживать m.mu
+func (m *MockVMManager) checkAllowedLocked(vm *MockVM, operation, name string, to VMState) error {
+	if to == VMStateError || slices.Contains(m.transitions[to], vm.State) {
+		return nil
+	}
+	return fmt.Errorf("cannot %s virtual machine '%s' in state '%s' (allowed from: %v): %w", operation, name, vm.State, m.transitions[to], ErrInvalidTransition)
+}
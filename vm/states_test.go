@@ -0,0 +1,74 @@
+package vm
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+const stateProvisioning VMState = "provisioning"
+
+func TestCustomStateTransitions(t *testing.T) {
+	m := newTestManager(t, WithAutoStartOnCreate(false))
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+	if err := m.RegisterState(stateProvisioning, []VMState{VMStateStopped}); err != nil {
+		t.Fatalf("RegisterState: %v", err)
+	}
+	// Из пользовательского состояния ВМ запускается обычным StartVM
+	if err := m.RegisterState(VMStateRunning, []VMState{stateProvisioning}); err != nil {
+		t.Fatalf("RegisterState(running): %v", err)
+	}
+
+	if err := m.TransitionVM("web", stateProvisioning); err != nil {
+		t.Fatalf("TransitionVM(provisioning): %v", err)
+	}
+	if got := stateOf(t, m, "web"); got != stateProvisioning {
+		t.Errorf("state = %s, want %s", got, stateProvisioning)
+	}
+	if err := m.TransitionVM("web", VMStatePaused); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("TransitionVM(paused) from provisioning = %v, want ErrInvalidTransition", err)
+	}
+	if err := m.TransitionVM("web", VMStateRunning); err != nil {
+		t.Errorf("TransitionVM(running) from provisioning: %v", err)
+	}
+
+	transitions := m.StateTransitions()
+	if !slices.Contains(transitions[VMStateStopped], stateProvisioning) {
+		t.Errorf("transitions from stopped = %v, want provisioning among them", transitions[VMStateStopped])
+	}
+	if !slices.Contains(transitions[stateProvisioning], VMStateRunning) {
+		t.Errorf("transitions from provisioning = %v, want running among them", transitions[stateProvisioning])
+	}
+}
+
+func TestRegisterStateRejectsInvalidStates(t *testing.T) {
+	m := newTestManager(t)
+
+	if err := m.RegisterState("", nil); err == nil {
+		t.Error("RegisterState with an empty state succeeded")
+	}
+	if err := m.RegisterState(VMStateError, []VMState{VMStateRunning}); err == nil {
+		t.Error("RegisterState(error) succeeded")
+	}
+	if err := m.RegisterState(stateProvisioning, []VMState{"unknown"}); err == nil {
+		t.Error("RegisterState from an unknown state succeeded")
+	}
+	if err := m.TransitionVM("missing", VMStateRunning); err == nil {
+		t.Error("TransitionVM of a missing VM succeeded")
+	}
+}
+
+func TestTransitionToUnregisteredState(t *testing.T) {
+	m := newTestManager(t)
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+
+	if err := m.TransitionVM("web", stateProvisioning); err == nil {
+		t.Error("TransitionVM to an unregistered state succeeded")
+	}
+	if err := m.TransitionVM("web", VMStateError); err == nil {
+		t.Error("TransitionVM to the error state succeeded")
+	}
+	if got := stateOf(t, m, "web"); got != VMStateRunning {
+		t.Errorf("state = %s, want running", got)
+	}
+}
@@ -0,0 +1,57 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ManagerStatus - сводное состояние менеджера ВМ
+type ManagerStatus struct {
+	StartedAt   time.Time     // время создания менеджера
+	Uptime      time.Duration // время работы менеджера
+	BackendType string
+	VMCount     int
+	// MaintenanceMode - включен ли режим обслуживания; mock-бэкенд его не поддерживает
+	MaintenanceMode bool
+}
+
+// ManagerInfo возвращает время запуска и работы менеджера, тип бэкенда и количество ВМ.
+// Время считается по часам менеджера (WithClock)
+func (m *MockVMManager) ManagerInfo() ManagerStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return ManagerStatus{
+		StartedAt:   m.startedAt,
+		Uptime:      m.now().Sub(m.startedAt),
+		BackendType: m.BackendType(),
+		VMCount:     len(m.vms),
+	}
+}
+
+// StackReady сообщает, запущены ли все перечисленные ВМ (например, все ВМ веб-стека),
+// и возвращает состояние каждой из них по переданному имени. Если хотя бы одна ВМ
+// не запущена, возвращается false вместе с состояниями; отсутствующая ВМ - ошибка
+func (m *MockVMManager) StackReady(names []string) (bool, map[string]VMState, error) {
+	if len(names) == 0 {
+		return false, nil, errors.New("no virtual machines given")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	ready := true
+	states := make(map[string]VMState, len(names))
+	for _, name := range names {
+		vm, exists := m.vms[m.resolveNameLocked(name)]
+		if !exists {
+			return false, nil, fmt.Errorf("virtual machine '%s' not found", name)
+		}
+		states[name] = vm.State
+		if vm.State != VMStateRunning {
+			ready = false
+		}
+	}
+	return ready, states, nil
+}
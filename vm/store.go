@@ -0,0 +1,293 @@
+package vm
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// storeEncryption - алгоритм шифрования файла хранилища: ключ AES-256-GCM получается из
+// парольной фразы через scrypt с солью, записанной в файл
+const storeEncryption = "aes-256-gcm+scrypt"
+
+// Параметры scrypt для ключа хранилища (рекомендованные для интерактивного входа)
+const (
+	storeKDFN     = 1 << 15
+	storeKDFR     = 8
+	storeKDFP     = 1
+	storeKeySize  = 32
+	storeSaltSize = 16
+)
+
+// storeFile - содержимое файла хранилища mock-менеджера
+type storeFile struct {
+	Next int        `json:"next"`
+	VMs  []storedVM `json:"vms"`
+}
+
+// storedVM - сохраняемое состояние ВМ
+type storedVM struct {
+	Config       VMConfig          `json:"config"`
+	State        VMState           `json:"state"`
+	Snapshots    []Snapshot        `json:"snapshots,omitempty"`
+	LinkedSource string            `json:"linked_source,omitempty"`
+	Host         string            `json:"host,omitempty"`
+	ErrorReason  string            `json:"error_reason,omitempty"`
+	BootTimeout  time.Duration     `json:"boot_timeout,omitempty"`
+	SnapshotGB   uint64            `json:"snapshot_space_estimate,omitempty"`
+	Locked       bool              `json:"locked,omitempty"`
+	GuestFiles   map[string][]byte `json:"guest_files,omitempty"`
+	MemoryMB     uint64            `json:"current_memory_mb,omitempty"` // память после balloon-драйвера
+}
+
+// encryptedStore - зашифрованный файл хранилища: storeFile в JSON, зашифрованный AES-GCM
+type encryptedStore struct {
+	Encryption string `json:"encryption"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// WithStoreKey задает парольную фразу шифрования файла хранилища
+// (см. NewMockVMManagerWithStore); пустая фраза - без шифрования
+func WithStoreKey(passphrase string) MockOption {
+	return func(m *MockVMManager) {
+		m.storePassphrase = passphrase
+	}
+}
+
+// NewMockVMManagerWithStore создает mock-менеджер, состояние которого хранится в
+// JSON-файле path: ВМ, их снапшоты, файлы гостевой ОС и память после balloon-драйвера
+// загружаются из файла, если он существует, и сохраняются в него при Sync и Close.
+// Резервирования и журнал операций не сохраняются: после перезапуска резервирований
+// нет, а UndoLast не отменяет операции предыдущего запуска. С WithStoreKey файл шифруется
+// AES-256-GCM ключом, полученным из парольной фразы через scrypt со случайной солью,
+// которая хранится в файле; незашифрованный файл при этом читается как есть и шифруется
+// при следующем сохранении. Неверная фраза дает ошибку "failed to decrypt store", а не
+// испорченные данные
+func NewMockVMManagerWithStore(path string, opts ...MockOption) (*MockVMManager, error) {
+	m := NewMockVMManager(opts...)
+	m.storePath = path
+	if err := m.loadStore(); err != nil {
+		return nil, err
+	}
+	if err := m.initStoreKey(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// loadStore загружает ВМ из файла хранилища; отсутствующий файл означает пустое хранилище
+func (m *MockVMManager) loadStore() error {
+	data, err := os.ReadFile(m.storePath)
+	if errors.Is(err, os.ErrNotExist) {
+		log.Printf("[MOCK] Store '%s' does not exist yet, starting empty", m.storePath)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read store '%s': %w", m.storePath, err)
+	}
+	if data, err = m.decryptStore(data); err != nil {
+		return err
+	}
+	var store storeFile
+	if err := json.Unmarshal(data, &store); err != nil {
+		return fmt.Errorf("failed to parse store '%s': %w", m.storePath, err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.next = max(m.next, store.Next)
+	for _, stored := range store.VMs {
+		name := stored.Config.Name
+		if _, exists := m.vms[name]; exists {
+			return fmt.Errorf("failed to load store '%s': duplicate virtual machine '%s'", m.storePath, name)
+		}
+		vm := &MockVM{
+			Config:                stored.Config,
+			State:                 stored.State,
+			Snapshots:             stored.Snapshots,
+			LinkedSource:          stored.LinkedSource,
+			Host:                  stored.Host,
+			ErrorReason:           stored.ErrorReason,
+			BootDevice:            BootDeviceDisk,
+			BootTimeout:           stored.BootTimeout,
+			SnapshotSpaceEstimate: stored.SnapshotGB,
+			Locked:                stored.Locked,
+			guestFiles:            stored.GuestFiles,
+		}
+		if vm.State == VMStateRunning || vm.State == VMStatePaused {
+			vm.CurrentMemoryMB = vm.Config.Memory
+			if stored.MemoryMB != 0 {
+				vm.CurrentMemoryMB = stored.MemoryMB
+			}
+			vm.startedAt = m.now()
+		}
+		m.vms[name] = vm
+		for _, path := range diskPaths(vm.Config) {
+			if _, claimed := m.disks[path]; !claimed {
+				m.disks[path] = name
+			}
+		}
+	}
+	log.Printf("[MOCK] Loaded %d virtual machine(s) from store '%s'", len(store.VMs), m.storePath)
+	return nil
+}
+
+// saveStore атомарно записывает текущее состояние в файл хранилища (через временный
+// файл и переименование); без хранилища ничего не делает
+func (m *MockVMManager) saveStore() error {
+	if m.storePath == "" {
+		return nil
+	}
+
+	m.mu.RLock()
+	store := storeFile{Next: m.next, VMs: make([]storedVM, 0, len(m.vms))}
+	for _, name := range slices.Sorted(maps.Keys(m.vms)) {
+		vm := m.vms[name]
+		store.VMs = append(store.VMs, storedVM{
+			Config:       vm.Config,
+			State:        vm.State,
+			Snapshots:    vm.Snapshots,
+			LinkedSource: vm.LinkedSource,
+			Host:         vm.Host,
+			ErrorReason:  vm.ErrorReason,
+			BootTimeout:  vm.BootTimeout,
+			SnapshotGB:   vm.SnapshotSpaceEstimate,
+			Locked:       vm.Locked,
+			GuestFiles:   vm.guestFiles,
+			MemoryMB:     vm.CurrentMemoryMB,
+		})
+	}
+	data, err := json.MarshalIndent(store, "", "  ")
+	m.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode store '%s': %w", m.storePath, err)
+	}
+	if data, err = m.encryptStore(data); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(m.storePath), filepath.Base(m.storePath)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to save store '%s': %w", m.storePath, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save store '%s': %w", m.storePath, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save store '%s': %w", m.storePath, err)
+	}
+	if err := os.Rename(tmp.Name(), m.storePath); err != nil {
+		return fmt.Errorf("failed to save store '%s': %w", m.storePath, err)
+	}
+	log.Printf("[MOCK] Saved %d virtual machine(s) to store '%s'", len(store.VMs), m.storePath)
+	return nil
+}
+
+// initStoreKey получает ключ шифрования хранилища, если задана парольная фраза. Ключ
+// получается один раз при создании менеджера: соль загруженного зашифрованного файла
+// используется и при сохранении, а для остальных создается случайная
+func (m *MockVMManager) initStoreKey() error {
+	if m.storePassphrase == "" || m.storeKey != nil {
+		return nil
+	}
+	salt := make([]byte, storeSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to create store key: %w", err)
+	}
+	key, err := deriveStoreKey(m.storePassphrase, salt)
+	if err != nil {
+		return err
+	}
+	m.storeSalt, m.storeKey = salt, key
+	return nil
+}
+
+// encryptStore шифрует содержимое хранилища, если задан ключ
+func (m *MockVMManager) encryptStore(data []byte) ([]byte, error) {
+	if m.storeKey == nil {
+		return data, nil
+	}
+	gcm, err := newStoreCipher(m.storeKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to encrypt store '%s': %w", m.storePath, err)
+	}
+	return json.MarshalIndent(encryptedStore{
+		Encryption: storeEncryption,
+		Salt:       m.storeSalt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, data, nil),
+	}, "", "  ")
+}
+
+// decryptStore расшифровывает содержимое зашифрованного хранилища; незашифрованное
+// возвращается как есть
+func (m *MockVMManager) decryptStore(data []byte) ([]byte, error) {
+	var encrypted encryptedStore
+	if err := json.Unmarshal(data, &encrypted); err != nil || encrypted.Encryption == "" {
+		return data, nil
+	}
+	if encrypted.Encryption != storeEncryption {
+		return nil, fmt.Errorf("store '%s' uses unsupported encryption '%s'", m.storePath, encrypted.Encryption)
+	}
+	if m.storePassphrase == "" {
+		return nil, fmt.Errorf("store '%s' is encrypted: pass its key with WithStoreKey", m.storePath)
+	}
+	if len(encrypted.Salt) != storeSaltSize {
+		return nil, fmt.Errorf("failed to decrypt store '%s': corrupted file", m.storePath)
+	}
+	key, err := deriveStoreKey(m.storePassphrase, encrypted.Salt)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newStoreCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(encrypted.Nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("failed to decrypt store '%s': corrupted file", m.storePath)
+	}
+	plain, err := gcm.Open(nil, encrypted.Nonce, encrypted.Ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt store '%s': wrong store key or corrupted file", m.storePath)
+	}
+	m.storeSalt, m.storeKey = encrypted.Salt, key
+	return plain, nil
+}
+
+// deriveStoreKey получает ключ AES-256 из парольной фразы и соли через scrypt
+func deriveStoreKey(passphrase string, salt []byte) ([]byte, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, storeKDFN, storeKDFR, storeKDFP, storeKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive store key: %w", err)
+	}
+	return key, nil
+}
+
+// newStoreCipher создает AES-GCM для ключа хранилища
+func newStoreCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid store key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
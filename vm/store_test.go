@@ -0,0 +1,105 @@
+package vm
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// saveTestStore создает хранилище path с одной ВМ и закрывает его
+func saveTestStore(t *testing.T, path string, opts ...MockOption) {
+	t.Helper()
+	m, err := NewMockVMManagerWithStore(path, opts...)
+	if err != nil {
+		t.Fatalf("NewMockVMManagerWithStore: %v", err)
+	}
+	mustCreate(t, m, VMConfig{Name: "secret-vm", Memory: 1024, VCPUs: 1})
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+}
+
+func TestEncryptedStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vms.json")
+	saveTestStore(t, path, WithStoreKey("correct horse"))
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	if bytes.Contains(data, []byte("secret-vm")) {
+		t.Error("encrypted store contains the VM name in plain text")
+	}
+
+	m, err := NewMockVMManagerWithStore(path, WithStoreKey("correct horse"))
+	if err != nil {
+		t.Fatalf("reopen with the correct key: %v", err)
+	}
+	t.Cleanup(func() { m.Close() })
+	if got := stateOf(t, m, "secret-vm"); got != VMStateRunning {
+		t.Errorf("state after reload = %s, want running", got)
+	}
+}
+
+func TestEncryptedStoreRejectsWrongKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vms.json")
+	saveTestStore(t, path, WithStoreKey("correct horse"))
+
+	if _, err := NewMockVMManagerWithStore(path, WithStoreKey("wrong horse")); err == nil || !strings.Contains(err.Error(), "failed to decrypt store") {
+		t.Errorf("open with a wrong key = %v, want a decrypt error", err)
+	}
+	if _, err := NewMockVMManagerWithStore(path); err == nil || !strings.Contains(err.Error(), "is encrypted") {
+		t.Errorf("open without a key = %v, want an encrypted store error", err)
+	}
+}
+
+func TestStoreEncryptsPlainFileOnSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vms.json")
+	saveTestStore(t, path)
+
+	m, err := NewMockVMManagerWithStore(path, WithStoreKey("correct horse"))
+	if err != nil {
+		t.Fatalf("open plain store with a key: %v", err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if data, _ := os.ReadFile(path); bytes.Contains(data, []byte("secret-vm")) {
+		t.Error("plain store was not encrypted on save")
+	}
+	reopened, err := NewMockVMManagerWithStore(path, WithStoreKey("correct horse"))
+	if err != nil {
+		t.Fatalf("reopen the encrypted store: %v", err)
+	}
+	reopened.Close()
+}
+
+func TestStoreKeepsBalloonMemory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vms.json")
+	m, err := NewMockVMManagerWithStore(path)
+	if err != nil {
+		t.Fatalf("NewMockVMManagerWithStore: %v", err)
+	}
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 2048, VCPUs: 1})
+	if err := m.SetMemoryBalloon("web", 512); err != nil {
+		t.Fatalf("SetMemoryBalloon: %v", err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	m, err = NewMockVMManagerWithStore(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	t.Cleanup(func() { m.Close() })
+	info, err := m.LookupVM("web")
+	if err != nil {
+		t.Fatalf("LookupVM: %v", err)
+	}
+	if info.CurrentMemoryMB != 512 {
+		t.Errorf("memory after reload = %d MB, want the ballooned 512 MB", info.CurrentMemoryMB)
+	}
+}
@@ -0,0 +1,65 @@
+package vm
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSwapVMNamesConcurrently(t *testing.T) {
+	m := newTestManager(t, WithAutoStartOnCreate(false))
+	mustCreate(t, m, VMConfig{Name: "blue", Memory: 1024, VCPUs: 1, DiskPath: "/tmp/swap-1.qcow2"})
+	mustCreate(t, m, VMConfig{Name: "green", Memory: 2048, VCPUs: 1, DiskPath: "/tmp/swap-2.qcow2"})
+
+	const swaps = 50
+	var wg sync.WaitGroup
+	for range swaps {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := m.SwapVMNames("blue", "green"); err != nil {
+				t.Errorf("SwapVMNames: %v", err)
+			}
+		}()
+		// Читатель никогда не видит промежуточного состояния обмена
+		go func() {
+			defer wg.Done()
+			vms := m.Snapshot()
+			if len(vms) != 2 || vms[0].Config.Name != "blue" || vms[1].Config.Name != "green" {
+				t.Errorf("snapshot during swaps = %+v, want blue and green", vms)
+				return
+			}
+			if vms[0].Config.Memory == vms[1].Config.Memory {
+				t.Errorf("both VMs have %d MB during swaps", vms[0].Config.Memory)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Четное число обменов возвращает имена на место
+	info, err := m.LookupVM("blue")
+	if err != nil {
+		t.Fatalf("LookupVM: %v", err)
+	}
+	if info.Config.Memory != 1024 || info.Config.DiskPath != "/tmp/swap-1.qcow2" {
+		t.Errorf("blue after %d swaps = %+v, want the original VM", swaps, info.Config)
+	}
+	if conflicts := m.FindDiskConflicts(); len(conflicts) != 0 {
+		t.Errorf("disk conflicts after swaps: %v", conflicts)
+	}
+	// Диск синей ВМ по-прежнему занят ею
+	if err := m.AttachDisk("green", DiskSpec{Path: "/tmp/swap-1.qcow2", Size: 1}); err == nil {
+		t.Error("green attached the disk owned by blue")
+	}
+}
+
+func TestSwapVMNamesRejectsInvalidPairs(t *testing.T) {
+	m := newTestManager(t)
+	mustCreate(t, m, VMConfig{Name: "blue", Memory: 1024, VCPUs: 1})
+
+	if err := m.SwapVMNames("blue", "blue"); err == nil {
+		t.Error("swapping a VM with itself succeeded")
+	}
+	if err := m.SwapVMNames("blue", "missing"); err == nil {
+		t.Error("swapping with a missing VM succeeded")
+	}
+}
@@ -0,0 +1,183 @@
+package vm
+
+import (
+	"context"
+	"time"
+)
+
+// OperationTimeouts задает ограничения времени для типов операций TimeoutManager.
+// Нулевое поле означает использование Default; нулевой Default - без ограничения
+type OperationTimeouts struct {
+	Default time.Duration
+	Create  time.Duration
+	Start   time.Duration
+	Stop    time.Duration
+	Delete  time.Duration
+	Guest   time.Duration // команды и файлы гостевой ОС
+}
+
+// TimeoutManager - декоратор менеджера ВМ, ограничивающий время каждой операции,
+// принимающей контекст, чтобы зависший бэкенд не блокировал агента бесконечно.
+// По истечении времени операция завершается ошибкой context.DeadlineExceeded.
+// Составные операции (повторы создания, пакеты, StopVMsNotMatching, Reconcile,
+// ExecutePlan, CreateVMFromOVF, ImportVMYAML) выполняются собственными методами
+// декоратора, так что ограничение действует на каждый шаг.
+// StartVMWithDeps ограничивается временем Start целиком: бэкенд запускает цепочку
+// атомарно, с одной записью в журнале операций. RestartAllRunning и FreezeAll не
+// принимают контекст и выполняются бэкендом под его блокировкой (без имитации
+// медленного запуска), поэтому декоратор их не ограничивает.
+// Остальные методы передаются обернутому менеджеру без изменений
+type TimeoutManager struct {
+	VMManagerInterface
+	timeouts OperationTimeouts
+}
+
+// NewTimeoutManager оборачивает backend с заданными ограничениями времени
+func NewTimeoutManager(backend VMManagerInterface, timeouts OperationTimeouts) *TimeoutManager {
+	return &TimeoutManager{
+		VMManagerInterface: backend,
+		timeouts:           timeouts,
+	}
+}
+
+// withTimeout возвращает контекст, ограниченный timeout или (если он нулевой) Default
+func (t *TimeoutManager) withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout == 0 {
+		timeout = t.timeouts.Default
+	}
+	if timeout == 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// CreateVM создает ВМ с ограничением времени Create
+func (t *TimeoutManager) CreateVM(ctx context.Context, config VMConfig) error {
+	ctx, cancel := t.withTimeout(ctx, t.timeouts.Create)
+	defer cancel()
+	return t.VMManagerInterface.CreateVM(ctx, config)
+}
+
+// CreateVMWithRetry создает ВМ с повторами при временных сбоях; ограничение времени
+// Create действует на каждую попытку, паузы между попытками прерывает только ctx
+func (t *TimeoutManager) CreateVMWithRetry(ctx context.Context, config VMConfig, attempts int, backoff time.Duration) error {
+	return createVMWithRetry(ctx, t.CreateVM, config, attempts, backoff)
+}
+
+// CreateVMs создает ВМ пакетом с ограничением времени Create для каждой ВМ
+func (t *TimeoutManager) CreateVMs(ctx context.Context, configs []VMConfig) map[string]error {
+	names := make([]string, len(configs))
+	for i, config := range configs {
+		names[i] = config.Name
+	}
+	return runBatch(names, batchLimitOf(t.VMManagerInterface), func(i int) error { return t.CreateVM(ctx, configs[i]) })
+}
+
+// StartVM запускает ВМ с ограничением времени Start
+func (t *TimeoutManager) StartVM(ctx context.Context, name string) error {
+	ctx, cancel := t.withTimeout(ctx, t.timeouts.Start)
+	defer cancel()
+	return t.VMManagerInterface.StartVM(ctx, name)
+}
+
+// StartVMWithDeps запускает ВМ с зависимостями с ограничением времени Start
+func (t *TimeoutManager) StartVMWithDeps(ctx context.Context, name string) ([]string, error) {
+	ctx, cancel := t.withTimeout(ctx, t.timeouts.Start)
+	defer cancel()
+	return t.VMManagerInterface.StartVMWithDeps(ctx, name)
+}
+
+// StopVM останавливает ВМ с ограничением времени Stop
+func (t *TimeoutManager) StopVM(ctx context.Context, name string) error {
+	ctx, cancel := t.withTimeout(ctx, t.timeouts.Stop)
+	defer cancel()
+	return t.VMManagerInterface.StopVM(ctx, name)
+}
+
+// StartVMs запускает ВМ пакетом с ограничением времени Start для каждой ВМ
+func (t *TimeoutManager) StartVMs(ctx context.Context, names []string) map[string]error {
+	return runBatch(names, batchLimitOf(t.VMManagerInterface), func(i int) error { return t.StartVM(ctx, names[i]) })
+}
+
+// StopVMs останавливает ВМ пакетом с ограничением времени Stop для каждой ВМ
+func (t *TimeoutManager) StopVMs(ctx context.Context, names []string) map[string]error {
+	return runBatch(names, batchLimitOf(t.VMManagerInterface), func(i int) error { return t.StopVM(ctx, names[i]) })
+}
+
+// CreateVMFromOVF создает ВМ из дескриптора OVF с ограничением времени Create
+func (t *TimeoutManager) CreateVMFromOVF(ctx context.Context, ovfXML string, name string) (VMConfig, error) {
+	return createVMFromOVF(ctx, t, ovfXML, name)
+}
+
+// ImportVMYAML импортирует ВМ из YAML; создание новой ВМ ограничено временем Create
+func (t *TimeoutManager) ImportVMYAML(ctx context.Context, data string) error {
+	return importVMYAML(ctx, t, data)
+}
+
+// StopVMsNotMatching останавливает ВМ, не подходящие под фильтр, с ограничением времени
+// Stop для каждой ВМ
+func (t *TimeoutManager) StopVMsNotMatching(ctx context.Context, selector VMFilter) ([]string, error) {
+	return stopVMsNotMatching(ctx, t, selector)
+}
+
+// WaitForIP ждет адрес ВМ не дольше ограничения времени Start: ожидание загрузки -
+// часть запуска
+func (t *TimeoutManager) WaitForIP(ctx context.Context, name string, timeout time.Duration) (string, error) {
+	ctx, cancel := t.withTimeout(ctx, t.timeouts.Start)
+	defer cancel()
+	return t.VMManagerInterface.WaitForIP(ctx, name, timeout)
+}
+
+// Reconcile приводит ВМ к желаемым конфигурациям; создание, остановка и запуск каждой ВМ
+// ограничены соответствующим временем
+func (t *TimeoutManager) Reconcile(ctx context.Context, desired []VMConfig) ([]ReconcileResult, error) {
+	return reconcile(ctx, t, desired)
+}
+
+// ExecutePlan выполняет план; каждый шаг ограничен временем своего типа операции
+func (t *TimeoutManager) ExecutePlan(ctx context.Context, actions []PlannedAction, opts ExecutePlanOptions) ([]ActionResult, error) {
+	return executePlan(ctx, t, actions, opts)
+}
+
+// DeleteVM удаляет ВМ с ограничением времени Delete
+func (t *TimeoutManager) DeleteVM(ctx context.Context, name string, opts DeleteVMOptions) error {
+	ctx, cancel := t.withTimeout(ctx, t.timeouts.Delete)
+	defer cancel()
+	return t.VMManagerInterface.DeleteVM(ctx, name, opts)
+}
+
+// DeleteVMGraceful удаляет ВМ с корректной остановкой; ограничение времени Delete
+// распространяется на весь вызов, включая ожидание выключения
+func (t *TimeoutManager) DeleteVMGraceful(ctx context.Context, name string, drain time.Duration) error {
+	ctx, cancel := t.withTimeout(ctx, t.timeouts.Delete)
+	defer cancel()
+	return t.VMManagerInterface.DeleteVMGraceful(ctx, name, drain)
+}
+
+// Sync синхронизирует бэкенд с ограничением времени Default
+func (t *TimeoutManager) Sync(ctx context.Context) error {
+	ctx, cancel := t.withTimeout(ctx, 0)
+	defer cancel()
+	return t.VMManagerInterface.Sync(ctx)
+}
+
+// RunGuestCommand выполняет команду в гостевой ОС с ограничением времени Guest
+func (t *TimeoutManager) RunGuestCommand(ctx context.Context, name, command string, args []string) (stdout, stderr string, exit int, err error) {
+	ctx, cancel := t.withTimeout(ctx, t.timeouts.Guest)
+	defer cancel()
+	return t.VMManagerInterface.RunGuestCommand(ctx, name, command, args)
+}
+
+// WriteGuestFile записывает файл в гостевую ОС с ограничением времени Guest
+func (t *TimeoutManager) WriteGuestFile(ctx context.Context, name, path string, content []byte) error {
+	ctx, cancel := t.withTimeout(ctx, t.timeouts.Guest)
+	defer cancel()
+	return t.VMManagerInterface.WriteGuestFile(ctx, name, path, content)
+}
+
+// ReadGuestFile читает файл из гостевой ОС с ограничением времени Guest
+func (t *TimeoutManager) ReadGuestFile(ctx context.Context, name, path string) ([]byte, error) {
+	ctx, cancel := t.withTimeout(ctx, t.timeouts.Guest)
+	defer cancel()
+	return t.VMManagerInterface.ReadGuestFile(ctx, name, path)
+}
@@ -0,0 +1,175 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// slowCreateTimeouts - ограничение, которое медленный бэкенд из тестов заведомо превышает
+var slowCreateTimeouts = OperationTimeouts{Create: 50 * time.Millisecond, Start: 50 * time.Millisecond}
+
+// newSlowTimeoutManager оборачивает mock-менеджер, создание и запуск ВМ в котором
+// длятся дольше slowCreateTimeouts
+func newSlowTimeoutManager(t *testing.T) (*MockVMManager, *TimeoutManager) {
+	t.Helper()
+	m := newTestManager(t, WithSimulatedCreateDelay(10*time.Second), WithSimulatedStartDelay(10*time.Second))
+	return m, NewTimeoutManager(m, slowCreateTimeouts)
+}
+
+// within останавливает тест, если fn выполняется дольше limit
+func within(t *testing.T, limit time.Duration, fn func()) {
+	t.Helper()
+	start := time.Now()
+	fn()
+	if elapsed := time.Since(start); elapsed > limit {
+		t.Fatalf("call took %s, want the timeout to cut it at %s", elapsed, limit)
+	}
+}
+
+func TestTimeoutCreateVMToolWithRetries(t *testing.T) {
+	m, tm := newSlowTimeoutManager(t)
+	tools := newTestTools(t, tm)
+
+	var resp map[string]any
+	within(t, 2*time.Second, func() {
+		resp = callTool(t, tools, "create_vm", map[string]any{"name": "web", "memory": "1024", "vcpus": 1, "retries": 2})
+	})
+	if resp["success"] == true {
+		t.Fatalf("create_vm with retries succeeded through a slow backend: %v", resp)
+	}
+	if errText, _ := resp["error"].(string); !strings.Contains(errText, context.DeadlineExceeded.Error()) {
+		t.Errorf("error = %q, want a deadline exceeded error", errText)
+	}
+	if n := len(m.Snapshot()); n != 0 {
+		t.Errorf("%d VMs created after the timeout, want 0", n)
+	}
+}
+
+func TestTimeoutCompositeOperations(t *testing.T) {
+	m, tm := newSlowTimeoutManager(t)
+	ctx := context.Background()
+
+	within(t, 2*time.Second, func() {
+		err := tm.CreateVMs(ctx, []VMConfig{{Name: "a", Memory: 1024, VCPUs: 1}})["a"]
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("CreateVMs: %v, want context.DeadlineExceeded", err)
+		}
+	})
+	within(t, 2*time.Second, func() {
+		results, err := tm.Reconcile(ctx, []VMConfig{{Name: "b", Memory: 1024, VCPUs: 1}})
+		if err != nil || !errors.Is(results[0].Err, context.DeadlineExceeded) {
+			t.Errorf("Reconcile: %v / %+v, want a deadline exceeded result", err, results)
+		}
+	})
+	within(t, 2*time.Second, func() {
+		if err := tm.ImportVMYAML(ctx, "name: y\nmemory_mb: 1024\nvcpus: 1\n"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("ImportVMYAML: %v, want context.DeadlineExceeded", err)
+		}
+	})
+	within(t, 2*time.Second, func() {
+		_, err := tm.ExecutePlan(ctx, []PlannedAction{{Type: PlanCreate, Config: VMConfig{Name: "c", Memory: 1024, VCPUs: 1}}}, ExecutePlanOptions{})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("ExecutePlan: %v, want context.DeadlineExceeded", err)
+		}
+	})
+
+	// ВМ, созданная без декоратора, запускается через него слишком долго
+	fast := newTestManager(t, WithSimulatedStartDelay(10*time.Second), WithAutoStartOnCreate(false))
+	mustCreate(t, fast, VMConfig{Name: "d", Memory: 1024, VCPUs: 1})
+	within(t, 2*time.Second, func() {
+		err := NewTimeoutManager(fast, slowCreateTimeouts).StartVMs(ctx, []string{"d"})["d"]
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("StartVMs: %v, want context.DeadlineExceeded", err)
+		}
+	})
+	if n := len(m.Snapshot()); n != 0 {
+		t.Errorf("%d VMs created after the timeouts, want 0", n)
+	}
+}
+
+func TestTimeoutStartVMWithDeps(t *testing.T) {
+	m, tm := newSlowTimeoutManager(t)
+	m.mu.Lock()
+	m.vms["db"] = &MockVM{Config: VMConfig{Name: "db", Memory: 1024, VCPUs: 1}, State: VMStateStopped}
+	m.vms["web"] = &MockVM{Config: VMConfig{Name: "web", Memory: 1024, VCPUs: 1, DependsOn: []string{"db"}}, State: VMStateStopped}
+	m.mu.Unlock()
+
+	within(t, 2*time.Second, func() {
+		if _, err := tm.StartVMWithDeps(context.Background(), "web"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("StartVMWithDeps = %v, want context.DeadlineExceeded", err)
+		}
+	})
+	for _, name := range []string{"db", "web"} {
+		if state := stateOf(t, m, name); state != VMStateStopped {
+			t.Errorf("%s after a timed out start = %s, want %s", name, state, VMStateStopped)
+		}
+	}
+}
+
+func TestTimeoutPassesThroughContextFreeOperations(t *testing.T) {
+	m, tm := newSlowTimeoutManager(t)
+	m.mu.Lock()
+	m.vms["db"] = &MockVM{Config: VMConfig{Name: "db", Memory: 1024, VCPUs: 1}, State: VMStateRunning}
+	m.vms["web"] = &MockVM{Config: VMConfig{Name: "web", Memory: 1024, VCPUs: 1, DependsOn: []string{"db"}}, State: VMStateRunning}
+	m.mu.Unlock()
+
+	within(t, 2*time.Second, func() {
+		for name, err := range tm.RestartAllRunning() {
+			if err != nil {
+				t.Errorf("RestartAllRunning %s: %v", name, err)
+			}
+		}
+		resume, err := tm.FreezeAll()
+		if err != nil {
+			t.Errorf("FreezeAll: %v", err)
+		}
+		if err := resume(); err != nil {
+			t.Errorf("resume: %v", err)
+		}
+	})
+}
+
+func TestTimeoutStopVMsNotMatching(t *testing.T) {
+	m := newTestManager(t, WithSimulatedStopDelay(10*time.Second))
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1, Labels: map[string]string{"env": "dev"}})
+	mustCreate(t, m, VMConfig{Name: "db", Memory: 1024, VCPUs: 1, Labels: map[string]string{"env": "prod"}})
+	tm := NewTimeoutManager(m, OperationTimeouts{Stop: 50 * time.Millisecond})
+
+	within(t, 2*time.Second, func() {
+		stopped, err := tm.StopVMsNotMatching(context.Background(), VMFilter{Labels: map[string]string{"env": "prod"}})
+		if len(stopped) != 0 || !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("StopVMsNotMatching = %v, %v, want context.DeadlineExceeded", stopped, err)
+		}
+	})
+	for _, name := range []string{"web", "db"} {
+		if state := stateOf(t, m, name); state != VMStateRunning {
+			t.Errorf("%s after a timed out stop = %s, want %s", name, state, VMStateRunning)
+		}
+	}
+}
+
+func TestTimeoutCreateVMFromOVF(t *testing.T) {
+	m, tm := newSlowTimeoutManager(t)
+	ovf := `<Envelope xmlns="http://schemas.dmtf.org/ovf/envelope/1"
+    xmlns:ovf="http://schemas.dmtf.org/ovf/envelope/1"
+    xmlns:rasd="http://schemas.dmtf.org/wbem/wscim/1/cim-schema/2/CIM_ResourceAllocationSettingData">
+  <VirtualSystem ovf:id="appliance">
+    <VirtualHardwareSection>
+      <Item><rasd:ResourceType>3</rasd:ResourceType><rasd:VirtualQuantity>1</rasd:VirtualQuantity></Item>
+      <Item><rasd:ResourceType>4</rasd:ResourceType><rasd:VirtualQuantity>1024</rasd:VirtualQuantity></Item>
+    </VirtualHardwareSection>
+  </VirtualSystem>
+</Envelope>`
+
+	within(t, 2*time.Second, func() {
+		if _, err := tm.CreateVMFromOVF(context.Background(), ovf, ""); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("CreateVMFromOVF = %v, want context.DeadlineExceeded", err)
+		}
+	})
+	if n := len(m.Snapshot()); n != 0 {
+		t.Errorf("%d VMs created after the timeout, want 0", n)
+	}
+}
@@ -0,0 +1,65 @@
+package vm
+
+import (
+	"log"
+	"slices"
+	"strings"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/tool"
+	"google.golang.org/genai"
+)
+
+// guestAgentTools - инструменты, которым нужен Capabilities.GuestAgent
+var guestAgentTools = []string{"run_guest_command", "write_guest_file", "read_guest_file"}
+
+// functionTool - методы инструмента functiontool, через которые его вызывает агент
+type functionTool interface {
+	tool.Tool
+	Declaration() *genai.FunctionDeclaration
+	Run(ctx tool.Context, args any) (map[string]any, error)
+	ProcessRequest(ctx tool.Context, req *model.LLMRequest) error
+}
+
+// mutatingTool помечает инструмент, созданный через newMutatingTool; все методы
+// functiontool передаются ему без изменений
+type mutatingTool struct {
+	functionTool
+}
+
+// isMutatingTool сообщает, создан ли инструмент через newMutatingTool
+func isMutatingTool(t tool.Tool) bool {
+	_, ok := t.(mutatingTool)
+	return ok
+}
+
+// unsupportedReason возвращает причину, по которой бэкенд с возможностями capabilities
+// не поддерживает инструмент, или пустую строку
+func unsupportedReason(capabilities Capabilities, t tool.Tool) string {
+	if !capabilities.GuestAgent && slices.Contains(guestAgentTools, t.Name()) {
+		return "no guest agent"
+	}
+	if capabilities.ReadOnly && isMutatingTool(t) {
+		return "read-only backend"
+	}
+	return ""
+}
+
+// supportedTools оставляет только инструменты, которые поддерживает бэкенд, чтобы модели
+// не предлагались действия, которые всегда завершаются ошибкой. Пропущенные
+// инструменты записываются в лог
+func supportedTools(capabilities Capabilities, tools []tool.Tool) []tool.Tool {
+	supported := make([]tool.Tool, 0, len(tools))
+	var skipped []string
+	for _, t := range tools {
+		if reason := unsupportedReason(capabilities, t); reason != "" {
+			skipped = append(skipped, t.Name()+" ("+reason+")")
+			continue
+		}
+		supported = append(supported, t)
+	}
+	if len(skipped) > 0 {
+		log.Printf("Skipping tools not supported by the backend: %s", strings.Join(skipped, ", "))
+	}
+	return supported
+}
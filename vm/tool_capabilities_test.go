@@ -0,0 +1,105 @@
+package vm
+
+import (
+	"slices"
+	"testing"
+
+	"google.golang.org/adk/tool"
+	"google.golang.org/adk/tool/functiontool"
+)
+
+// toolNames возвращает имена инструментов
+func toolNames(tools []tool.Tool) []string {
+	names := make([]string, 0, len(tools))
+	for _, t := range tools {
+		names = append(names, t.Name())
+	}
+	return names
+}
+
+func TestReadOnlyBackendHidesMutatingTools(t *testing.T) {
+	m := newTestManager(t, WithCapabilities(Capabilities{ReadOnly: true}))
+	names := toolNames(newTestTools(t, m))
+
+	for _, name := range []string{"create_vm", "delete_vm", "start_vm", "stop_vm"} {
+		if slices.Contains(names, name) {
+			t.Errorf("read-only backend exposes mutating tool %s", name)
+		}
+	}
+	for _, name := range []string{"list_vms", "list_snapshots", "get_vm_logs"} {
+		if !slices.Contains(names, name) {
+			t.Errorf("read-only backend hides read-only tool %s", name)
+		}
+	}
+}
+
+func TestDiskToolsOnReadOnlyBackend(t *testing.T) {
+	m := newTestManager(t, WithCapabilities(Capabilities{ReadOnly: true}))
+	tools, err := NewDiskTools(m)
+	if err != nil {
+		t.Fatalf("NewDiskTools: %v", err)
+	}
+	if slices.Contains(toolNames(tools), "attach_disk") {
+		t.Error("read-only backend exposes attach_disk")
+	}
+}
+
+func TestIsMutatingToolIgnoresDescription(t *testing.T) {
+	// Описание, случайно совпадающее с подсказкой dry_run, не делает инструмент изменяющим
+	readOnly, err := newTool(functiontool.Config{
+		Name:        "test_read_only_tool",
+		Description: "Reads something" + dryRunHint,
+	}, func(ctx tool.Context, args struct{}) (ToolResponse[string], error) {
+		return ToolResponse[string]{Success: true}, nil
+	})
+	if err != nil {
+		t.Fatalf("newTool: %v", err)
+	}
+	if isMutatingTool(readOnly) {
+		t.Error("tool created with newTool is reported as mutating")
+	}
+
+	mutating, err := newMutatingTool(functiontool.Config{
+		Name:        "test_mutating_tool",
+		Description: "Changes something",
+	}, func(args DryRunArg) (string, error) {
+		return "would change something", nil
+	}, func(ctx tool.Context, args DryRunArg) (ToolResponse[string], error) {
+		return ToolResponse[string]{Success: true}, nil
+	})
+	if err != nil {
+		t.Fatalf("newMutatingTool: %v", err)
+	}
+	if !isMutatingTool(mutating) {
+		t.Error("tool created with newMutatingTool is not reported as mutating")
+	}
+}
+
+func TestIsMutatingToolDoesNotGoByName(t *testing.T) {
+	// Изменяющий инструмент с тем же именем не делает изменяющим другой инструмент
+	if _, err := newMutatingTool(functiontool.Config{
+		Name:        "test_shared_name",
+		Description: "Changes something",
+	}, func(args DryRunArg) (string, error) {
+		return "would change something", nil
+	}, func(ctx tool.Context, args DryRunArg) (ToolResponse[string], error) {
+		return ToolResponse[string]{Success: true}, nil
+	}); err != nil {
+		t.Fatalf("newMutatingTool: %v", err)
+	}
+	readOnly, err := newTool(functiontool.Config{
+		Name:        "test_shared_name",
+		Description: "Reads something",
+	}, func(ctx tool.Context, args struct{}) (ToolResponse[string], error) {
+		return ToolResponse[string]{Success: true}, nil
+	})
+	if err != nil {
+		t.Fatalf("newTool: %v", err)
+	}
+	if isMutatingTool(readOnly) {
+		t.Error("read-only tool is reported as mutating because of its name")
+	}
+	if reason := unsupportedReason(Capabilities{ReadOnly: true}, readOnly); reason != "" {
+		t.Errorf("read-only backend rejects a read-only tool: %s", reason)
+	}
+}
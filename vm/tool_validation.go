@@ -0,0 +1,363 @@
+package vm
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+)
+
+// Предварительная проверка аргументов инструментов. Она ловит простые ошибки модели
+// (пустое имя, нулевой размер) до обращения к менеджеру и подсказывает, как их
+// исправить; окончательную проверку по-прежнему выполняет менеджер
+
+// requireArg возвращает ошибку с подсказкой, если обязательный аргумент пуст
+func requireArg(arg, value, hint string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("missing required argument '%s': %s", arg, hint)
+	}
+	return nil
+}
+
+const (
+	vmNameHint       = "pass the name of an existing virtual machine (use list_vms to see them)"
+	snapshotNameHint = "pass the snapshot name (use list_snapshots to see them)"
+	diskPathHint     = "pass the path of the disk image file"
+)
+
+// validate проверяет аргументы инструмента create_vm вместе с числом повторов
+func (args CreateVMToolArgs) validate() error {
+	if args.Retries < 0 || args.Retries > maxCreateRetries {
+		return fmt.Errorf("invalid argument 'retries': pass a number from 0 to %d", maxCreateRetries)
+	}
+	return args.CreateVMArgs.validate()
+}
+
+// validate проверяет аргументы конфигурации ВМ инструмента create_vm
+func (args CreateVMArgs) validate() error {
+	if err := requireArg("name", args.Name, "choose a name for the new virtual machine"); err != nil {
+		return err
+	}
+	if _, err := args.Memory.MB(); err != nil {
+		return err
+	}
+	switch args.Firmware {
+	case "", FirmwareBIOS, FirmwareUEFI:
+	default:
+		return fmt.Errorf("invalid argument 'firmware': use '%s' or '%s', or omit it", FirmwareBIOS, FirmwareUEFI)
+	}
+	for i, disk := range args.Disks {
+		if strings.TrimSpace(disk.Path) == "" {
+			return fmt.Errorf("missing 'path' in disks[%d]: every additional disk needs an image file path", i)
+		}
+	}
+	return validateCPUPins(args.CPUPinning)
+}
+
+// validate проверяет аргументы инструмента validate_inventory
+func (args ValidateInventoryArgs) validate() error {
+	if len(args.VMs) == 0 {
+		return fmt.Errorf("missing required argument 'vms': pass the configurations of all VMs in the proposed inventory")
+	}
+	return nil
+}
+
+// validate проверяет аргументы инструмента quota_forecast
+func (args QuotaForecastArgs) validate() error {
+	if len(args.VMs) == 0 {
+		return fmt.Errorf("missing required argument 'vms': pass the configurations of the VMs you plan to create")
+	}
+	return nil
+}
+
+// validate проверяет аргументы инструмента execute_plan
+func (args ExecutePlanArgs) validate() error {
+	if len(args.Steps) == 0 {
+		return fmt.Errorf("missing required argument 'steps': pass at least one step with an action and a VM name")
+	}
+	for i, step := range args.Steps {
+		if err := requireArg(fmt.Sprintf("steps[%d].action", i), step.Action, "use create, start, stop, delete or snapshot"); err != nil {
+			return err
+		}
+		if step.Action == string(PlanCreate) && step.Config == nil {
+			return fmt.Errorf("missing 'config' in steps[%d]: the create action needs the configuration of the new VM", i)
+		}
+		if step.Config != nil {
+			config := *step.Config
+			config.Name = cmp.Or(config.Name, step.Name)
+			if err := config.validate(); err != nil {
+				return fmt.Errorf("invalid steps[%d].config: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateCPUPins проверяет, что каждый vCPU привязан не более одного раза
+func validateCPUPins(pins []CPUPin) error {
+	seen := make(map[uint]bool, len(pins))
+	for _, pin := range pins {
+		if seen[pin.VCPU] {
+			return fmt.Errorf("invalid argument 'cpu_pinning': vCPU %d is listed more than once, pin each vCPU to a single CPU", pin.VCPU)
+		}
+		seen[pin.VCPU] = true
+	}
+	return nil
+}
+
+// validate проверяет аргументы инструмента set_cpu_pinning
+func (args SetCPUPinningArgs) validate() error {
+	if err := requireArg("name", args.Name, vmNameHint); err != nil {
+		return err
+	}
+	return validateCPUPins(args.CPUPinning)
+}
+
+// validate проверяет аргументы инструмента set_network_bandwidth
+func (args SetNetworkBandwidthArgs) validate() error {
+	if err := requireArg("name", args.Name, vmNameHint); err != nil {
+		return err
+	}
+	if err := requireLimit("inbound_kbps", args.InboundKbps, maxBandwidthKbps); err != nil {
+		return err
+	}
+	return requireLimit("outbound_kbps", args.OutboundKbps, maxBandwidthKbps)
+}
+
+// maxPopulateCount - наибольшее количество ВМ, создаваемых populate_random_vms за вызов
+const maxPopulateCount = 1000
+
+// validate проверяет аргументы инструмента populate_random_vms
+func (args PopulateRandomVMsArgs) validate() error {
+	if args.Count <= 0 || args.Count > maxPopulateCount {
+		return fmt.Errorf("invalid argument 'count': pass the number of VMs to create, from 1 to %d", maxPopulateCount)
+	}
+	return nil
+}
+
+// validate проверяет аргументы инструмента set_boot_timeout
+func (args SetBootTimeoutArgs) validate() error {
+	if err := requireArg("name", args.Name, vmNameHint); err != nil {
+		return err
+	}
+	_, err := args.bootTimeout()
+	return err
+}
+
+// validate проверяет аргументы инструмента network_dependents
+func (args NetworkDependentsArgs) validate() error {
+	return requireArg("network", args.Network, "pass the network name, as in the network field of a VM configuration")
+}
+
+// validate проверяет аргументы инструмента diff_against_export
+func (args DiffAgainstExportArgs) validate() error {
+	return requireArg("export", args.Export, "pass the document returned by export_inventory")
+}
+
+// validate проверяет аргументы инструмента set_next_boot
+func (args SetNextBootArgs) validate() error {
+	if err := requireArg("name", args.Name, vmNameHint); err != nil {
+		return err
+	}
+	if args.Device != "" {
+		if err := validateBootDevice(args.Device); err != nil {
+			return fmt.Errorf("invalid argument 'device': %w", err)
+		}
+	}
+	return nil
+}
+
+// validate проверяет аргументы инструментов start_vm и start_vm_with_deps
+func (args StartVMArgs) validate() error {
+	return requireArg("name", args.Name, vmNameHint)
+}
+
+// validate проверяет аргументы инструмента stop_vm
+func (args StopVMArgs) validate() error {
+	return requireArg("name", args.Name, vmNameHint)
+}
+
+// validate проверяет аргументы инструмента delete_vm
+func (args DeleteVMArgs) validate() error {
+	return requireArg("name", args.Name, vmNameHint)
+}
+
+// validate проверяет аргументы инструмента delete_vm_graceful
+func (args DeleteVMGracefulArgs) validate() error {
+	if err := requireArg("name", args.Name, vmNameHint); err != nil {
+		return err
+	}
+	_, err := args.drainTimeout()
+	return err
+}
+
+// validate проверяет аргументы инструмента lock_vm
+func (args LockVMArgs) validate() error {
+	return requireArg("name", args.Name, vmNameHint)
+}
+
+// validate проверяет аргументы инструмента unlock_vm
+func (args UnlockVMArgs) validate() error {
+	return requireArg("name", args.Name, vmNameHint)
+}
+
+// validate проверяет аргументы инструмента rename_vm
+func (args RenameVMArgs) validate() error {
+	if err := requireArg("name", args.Name, vmNameHint); err != nil {
+		return err
+	}
+	return requireArg("new_name", args.NewName, "pass the new name for the virtual machine")
+}
+
+// validate проверяет аргументы инструмента swap_vm_names
+func (args SwapVMNamesArgs) validate() error {
+	if err := requireArg("a", args.A, vmNameHint); err != nil {
+		return err
+	}
+	return requireArg("b", args.B, vmNameHint)
+}
+
+// validate проверяет аргументы инструмента clone_vm
+func (args CloneVMArgs) validate() error {
+	if err := requireArg("source", args.Source, vmNameHint); err != nil {
+		return err
+	}
+	if err := requireArg("target", args.Target, "choose a name for the clone"); err != nil {
+		return err
+	}
+	if args.Linked && (args.Memory != "" || args.VCPUs != 0 || args.Network != "") {
+		return fmt.Errorf("invalid arguments: memory, vcpus and network can only be changed in a full clone; omit 'linked' or the changes")
+	}
+	return nil
+}
+
+// validate проверяет аргументы инструмента clone_vm_full
+func (args CloneVMFullArgs) validate() error {
+	return CloneVMArgs{Source: args.Source, Target: args.Target}.validate()
+}
+
+// validate проверяет аргументы операций со снапшотом
+func (args SnapshotArgs) validate() error {
+	if err := requireArg("vm_name", args.VMName, vmNameHint); err != nil {
+		return err
+	}
+	return requireArg("snapshot_name", args.SnapshotName, snapshotNameHint)
+}
+
+// validate проверяет аргументы инструмента create_snapshot
+func (args CreateSnapshotArgs) validate() error {
+	if err := requireArg("vm_name", args.VMName, vmNameHint); err != nil {
+		return err
+	}
+	return requireArg("snapshot_name", args.SnapshotName, "choose a name for the new snapshot")
+}
+
+// validate проверяет аргументы инструмента rename_snapshot
+func (args RenameSnapshotArgs) validate() error {
+	if err := requireArg("vm_name", args.VMName, vmNameHint); err != nil {
+		return err
+	}
+	if err := requireArg("old_name", args.OldName, snapshotNameHint); err != nil {
+		return err
+	}
+	return requireArg("new_name", args.NewName, "pass the new snapshot name")
+}
+
+// validate проверяет аргументы инструмента attach_disk
+func (args AttachDiskArgs) validate() error {
+	if err := requireArg("name", args.Name, vmNameHint); err != nil {
+		return err
+	}
+	return requireArg("path", args.Path, diskPathHint)
+}
+
+// validate проверяет аргументы инструмента detach_disk
+func (args DetachDiskArgs) validate() error {
+	if err := requireArg("name", args.Name, vmNameHint); err != nil {
+		return err
+	}
+	return requireArg("path", args.Path, "pass the path of an attached disk (use disk_usage to see them)")
+}
+
+// validate проверяет аргументы инструмента resize_disk
+func (args ResizeDiskArgs) validate() error {
+	if err := requireArg("name", args.Name, vmNameHint); err != nil {
+		return err
+	}
+	if err := requireArg("path", args.Path, "pass the path of the disk to resize (use disk_usage to see them)"); err != nil {
+		return err
+	}
+	if args.Size == 0 {
+		return fmt.Errorf("invalid argument 'size': pass the new disk size in GB, larger than the current one")
+	}
+	return nil
+}
+
+// validate проверяет аргументы инструмента attach_iso
+func (args AttachISOArgs) validate() error {
+	if err := requireArg("name", args.Name, vmNameHint); err != nil {
+		return err
+	}
+	return requireArg("path", args.Path, "pass the path of the ISO image file")
+}
+
+// validate проверяет аргументы инструмента compact_disk
+func (args CompactDiskArgs) validate() error {
+	if err := requireArg("name", args.Name, vmNameHint); err != nil {
+		return err
+	}
+	return requireArg("path", args.Path, diskPathHint+" (use disk_usage to see the disks of the VM)")
+}
+
+// validate проверяет аргументы инструмента set_disk_iops
+func (args SetDiskIOPSArgs) validate() error {
+	if err := requireArg("name", args.Name, vmNameHint); err != nil {
+		return err
+	}
+	if err := requireArg("path", args.Path, diskPathHint+" (use disk_usage to see the disks of the VM)"); err != nil {
+		return err
+	}
+	if err := requireLimit("read_iops", args.ReadIOPS, maxDiskIOPS); err != nil {
+		return err
+	}
+	return requireLimit("write_iops", args.WriteIOPS, maxDiskIOPS)
+}
+
+// requireLimit проверяет, что ограничение QoS arg лежит в диапазоне от 0 до limit
+func requireLimit(arg string, value, limit int) error {
+	if value < 0 || value > limit {
+		return fmt.Errorf("invalid argument '%s': pass a limit between 0 and %d, or 0 to remove it", arg, limit)
+	}
+	return nil
+}
+
+// validate проверяет аргументы инструмента get_vm_logs
+func (args GetVMLogsArgs) validate() error {
+	if err := requireArg("name", args.Name, vmNameHint); err != nil {
+		return err
+	}
+	if args.Lines < 0 {
+		return fmt.Errorf("invalid argument 'lines': pass a positive number of lines or omit it for the default")
+	}
+	return nil
+}
+
+// validate проверяет аргументы инструмента reserve_resources
+func (args ReserveResourcesArgs) validate() error {
+	if args.Memory == "" && args.VCPUs == 0 {
+		return fmt.Errorf("missing arguments: pass memory, vcpus or both to reserve")
+	}
+	return nil
+}
+
+// validate проверяет аргументы инструмента release_reservation
+func (args ReleaseReservationArgs) validate() error {
+	return requireArg("reservation_id", args.ReservationID, "pass the ID returned by reserve_resources")
+}
+
+// validate проверяет аргументы инструмента stack_ready
+func (args StackReadyArgs) validate() error {
+	if len(args.Names) == 0 {
+		return fmt.Errorf("missing required argument 'names': pass the names of the virtual machines to check (use list_vms to see them)")
+	}
+	return nil
+}
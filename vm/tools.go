@@ -1,182 +1,3361 @@
 package vm
 
 import (
+	"cmp"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"google.golang.org/adk/tool"
 	"google.golang.org/adk/tool/functiontool"
+	"gopkg.in/yaml.v3"
 )
 
 // Структуры аргументов и результатов каждого действия
 
+// ToolResponse - единый конверт результата всех инструментов.
+// Ошибки передаются в поле Error, а не как Go-ошибка, чтобы модель всегда
+// получала ответ одной и той же формы
+type ToolResponse[T any] struct {
+	Success bool `json:"success"`
+	// Data - nil при ошибке и пробном запуске: нулевое значение T может не пройти
+	// проверку схемы результата (nil-срез вместо массива)
+	Data  *T     `json:"data"`
+	Error string `json:"error,omitempty"`
+	// DryRun - вызов с dry_run=true: ничего не изменено, Plan описывает, что было бы сделано
+	DryRun bool   `json:"dry_run,omitempty"`
+	Plan   string `json:"plan,omitempty"`
+}
+
+// toolSuccess оборачивает успешный результат инструмента
+func toolSuccess[T any](data T) (ToolResponse[T], error) {
+	return ToolResponse[T]{Success: true, Data: &data}, nil
+}
+
+// toolFailure оборачивает ошибку инструмента
+func toolFailure[T any](err error) (ToolResponse[T], error) {
+	return ToolResponse[T]{Error: err.Error()}, nil
+}
+
 // CreateVMArgs - аргументы для создания ВМ
 type CreateVMArgs struct {
+	Name      string            `json:"name"`
+	Memory    MemorySpec        `json:"memory,omitempty"` // "4096", "4096MB", "4GB" или "4Gi"; по умолчанию берется из настроек менеджера
+	VCPUs     uint              `json:"vcpus,omitempty"`
+	DiskPath  string            `json:"disk_path,omitempty"`
+	DiskSize  uint64            `json:"disk_size,omitempty"` // в ГБ
+	ISOImage  string            `json:"iso_image,omitempty"`
+	Network   string            `json:"network,omitempty"`
+	Disks     []DiskArgs        `json:"disks,omitempty"`
+	DependsOn []string          `json:"depends_on,omitempty"` // ВМ, запускаемые раньше этой
+	Labels    map[string]string `json:"labels,omitempty"`
+	Firmware  string            `json:"firmware,omitempty"`   // "bios" или "uefi"
+	OSType    string            `json:"os_type,omitempty"`    // например "linux" или "windows"
+	OSVariant string            `json:"os_variant,omitempty"` // например "ubuntu22.04"
+	// ВМ, с которыми эту ВМ нужно или нельзя размещать на одном хосте
+	Affinity     []string       `json:"affinity,omitempty"`
+	AntiAffinity []string       `json:"anti_affinity,omitempty"`
+	CPUPinning   []CPUPin       `json:"cpu_pinning,omitempty"`
+	NUMANodes    []NUMANodeArgs `json:"numa_nodes,omitempty"` // память узлов в сумме равна memory
+	// Ограничения IOPS основного диска и трафика сети (Кбит/с); 0 - без ограничения
+	DiskReadIOPS  uint `json:"disk_read_iops,omitempty"`
+	DiskWriteIOPS uint `json:"disk_write_iops,omitempty"`
+	InboundKbps   uint `json:"inbound_kbps,omitempty"`
+	OutboundKbps  uint `json:"outbound_kbps,omitempty"`
+	// Запустить ли ВМ сразу после создания; по умолчанию - по настройке менеджера
+	StartOnCreate *bool `json:"start_on_create,omitempty"`
+	// Резервирование reserve_resources, ресурсы которого займет ВМ
+	ReservationID string `json:"reservation_id,omitempty"`
+}
+
+// CreateVMToolArgs - аргументы инструмента create_vm: конфигурация ВМ и dry_run
+type CreateVMToolArgs struct {
+	CreateVMArgs
+	// Сколько раз повторить создание при временном сбое бэкенда (по умолчанию 0)
+	Retries int `json:"retries,omitempty"`
+	DryRunArg
+}
+
+const (
+	// maxCreateRetries - наибольшее число повторов в create_vm
+	maxCreateRetries = 5
+	// createRetryBackoff - пауза перед первым повтором в create_vm, дальше она удваивается
+	createRetryBackoff = time.Second
+)
+
+// NUMANodeArgs - описание NUMA-узла
+type NUMANodeArgs struct {
+	CPUs     []uint `json:"cpus"`      // индексы vCPU узла
+	MemoryMB uint64 `json:"memory_mb"` // в МБ
+}
+
+// CPUPin - привязка vCPU к физическому CPU
+type CPUPin struct {
+	VCPU uint `json:"vcpu"` // индекс vCPU, от 0 до vcpus-1
+	CPU  uint `json:"cpu"`  // номер физического CPU
+}
+
+// cpuPinningFromArgs преобразует список привязок в карту vCPU -> CPU
+func cpuPinningFromArgs(pins []CPUPin) map[uint]uint {
+	if len(pins) == 0 {
+		return nil
+	}
+	pinning := make(map[uint]uint, len(pins))
+	for _, pin := range pins {
+		pinning[pin.VCPU] = pin.CPU
+	}
+	return pinning
+}
+
+// cpuPinningToArgs преобразует карту vCPU -> CPU в список по возрастанию vCPU
+func cpuPinningToArgs(pinning map[uint]uint) []CPUPin {
+	var pins []CPUPin
+	for _, vcpu := range slices.Sorted(maps.Keys(pinning)) {
+		pins = append(pins, CPUPin{VCPU: vcpu, CPU: pinning[vcpu]})
+	}
+	return pins
+}
+
+// toConfig преобразует аргументы инструмента в конфигурацию ВМ
+func (args CreateVMArgs) toConfig() (VMConfig, error) {
+	memory, err := args.Memory.MB()
+	if err != nil {
+		return VMConfig{}, err
+	}
+	config := VMConfig{
+		Name:          args.Name,
+		Memory:        memory,
+		VCPUs:         args.VCPUs,
+		DiskPath:      args.DiskPath,
+		DiskSize:      args.DiskSize,
+		DiskReadIOPS:  args.DiskReadIOPS,
+		DiskWriteIOPS: args.DiskWriteIOPS,
+		ISOImage:      args.ISOImage,
+		Network:       args.Network,
+		Bandwidth:     NetworkSpec{InboundKbps: args.InboundKbps, OutboundKbps: args.OutboundKbps},
+		DependsOn:     args.DependsOn,
+		Labels:        args.Labels,
+		Firmware:      args.Firmware,
+		OSType:        args.OSType,
+		OSVariant:     args.OSVariant,
+		Affinity:      args.Affinity,
+		AntiAffinity:  args.AntiAffinity,
+		CPUPinning:    cpuPinningFromArgs(args.CPUPinning),
+		StartOnCreate: args.StartOnCreate,
+		ReservationID: args.ReservationID,
+	}
+	for _, disk := range args.Disks {
+		config.Disks = append(config.Disks, DiskSpec{
+			Path: disk.Path, Size: disk.Size, Shared: disk.Shared, ReadOnly: disk.ReadOnly,
+			ReadIOPS: disk.ReadIOPS, WriteIOPS: disk.WriteIOPS,
+		})
+	}
+	for _, node := range args.NUMANodes {
+		config.NUMANodes = append(config.NUMANodes, NUMANode{CPUs: node.CPUs, MemoryMB: node.MemoryMB})
+	}
+	return config, nil
+}
+
+// createVMArgsFromConfig преобразует конфигурацию ВМ в аргументы create_vm
+func createVMArgsFromConfig(config VMConfig) CreateVMArgs {
+	args := CreateVMArgs{
+		Name:          config.Name,
+		Memory:        memorySpecFromMB(config.Memory),
+		VCPUs:         config.VCPUs,
+		DiskPath:      config.DiskPath,
+		DiskSize:      config.DiskSize,
+		DiskReadIOPS:  config.DiskReadIOPS,
+		DiskWriteIOPS: config.DiskWriteIOPS,
+		ISOImage:      config.ISOImage,
+		Network:       config.Network,
+		InboundKbps:   config.Bandwidth.InboundKbps,
+		OutboundKbps:  config.Bandwidth.OutboundKbps,
+		DependsOn:     config.DependsOn,
+		Labels:        config.Labels,
+		Firmware:      config.Firmware,
+		OSType:        config.OSType,
+		OSVariant:     config.OSVariant,
+		Affinity:      config.Affinity,
+		AntiAffinity:  config.AntiAffinity,
+		CPUPinning:    cpuPinningToArgs(config.CPUPinning),
+		StartOnCreate: config.StartOnCreate,
+	}
+	for _, disk := range config.Disks {
+		args.Disks = append(args.Disks, DiskArgs{
+			Path: disk.Path, Size: disk.Size, Shared: disk.Shared, ReadOnly: disk.ReadOnly,
+			ReadIOPS: disk.ReadIOPS, WriteIOPS: disk.WriteIOPS,
+		})
+	}
+	for _, node := range config.NUMANodes {
+		args.NUMANodes = append(args.NUMANodes, NUMANodeArgs{CPUs: node.CPUs, MemoryMB: node.MemoryMB})
+	}
+	return args
+}
+
+// DiskArgs - описание дополнительного диска
+type DiskArgs struct {
+	Path     string `json:"path"`
+	Size     uint64 `json:"size,omitempty"`      // в ГБ
+	Shared   bool   `json:"shared,omitempty"`    // диск могут разделять ВМ, у которых он тоже shared
+	ReadOnly bool   `json:"read_only,omitempty"` // только для чтения
+	// Ограничения операций чтения и записи в секунду; 0 - без ограничения
+	ReadIOPS  uint `json:"read_iops,omitempty"`
+	WriteIOPS uint `json:"write_iops,omitempty"`
+}
+
+// CreateVMResult - результат создания ВМ
+type CreateVMResult struct {
+	Message string `json:"message"`
+	VMName  string `json:"vm_name"`
+}
+
+// CreateRandomVMArgs - аргументы для создания случайной ВМ
+type CreateRandomVMArgs struct {
+	Seed int64 `json:"seed,omitempty"` // 0 - выбрать seed по текущему времени
+	DryRunArg
+}
+
+// CreateRandomVMResult - результат создания случайной ВМ
+type CreateRandomVMResult struct {
+	Message string `json:"message"`
+	VMName  string `json:"vm_name"`
+	Seed    int64  `json:"seed"` // повторный вызов с этим seed создает такую же конфигурацию
+}
+
+// PopulateRandomVMsArgs - аргументы для массового создания случайных ВМ
+type PopulateRandomVMsArgs struct {
+	Count int   `json:"count"`
+	Seed  int64 `json:"seed,omitempty"` // 0 - выбрать seed по текущему времени
+	DryRunArg
+}
+
+// PopulateRandomVMsResult - результат массового создания случайных ВМ
+type PopulateRandomVMsResult struct {
+	Message string `json:"message"`
+	Seed    int64  `json:"seed"`
+}
+
+// StartVMArgs - аргументы для запуска ВМ
+type StartVMArgs struct {
+	Name string `json:"name"`
+	DryRunArg
+}
+
+// StartVMResult - результат запуска ВМ
+type StartVMResult struct {
+	Message string `json:"message"`
+}
+
+// StartVMWithDepsResult - результат запуска ВМ с зависимостями
+type StartVMWithDepsResult struct {
+	Message string   `json:"message"`
+	Started []string `json:"started"` // в порядке запуска
+}
+
+// StopVMArgs - аргументы для остановки ВМ
+type StopVMArgs struct {
+	Name string `json:"name"`
+	DryRunArg
+}
+
+// StopVMResult - результат остановки ВМ
+type StopVMResult struct {
+	Message string `json:"message"`
+}
+
+// ListVMsResult - результат списка ВМ
+type ListVMsResult struct {
+	VMs []string `json:"vms"`
+}
+
+// ListGroupedByStateResult - имена ВМ по состояниям
+type ListGroupedByStateResult struct {
+	Groups map[VMState][]string `json:"groups"`
+}
+
+// StackReadyArgs - аргументы для проверки готовности группы ВМ
+type StackReadyArgs struct {
+	Names []string `json:"names"`
+}
+
+// StackReadyResult - готовность группы ВМ и состояние каждой из них
+type StackReadyResult struct {
+	Ready  bool               `json:"ready"`
+	States map[string]VMState `json:"states"`
+}
+
+// StateTransitionsResult - разрешенные переходы между состояниями ВМ
+type StateTransitionsResult struct {
+	Transitions map[VMState][]VMState `json:"transitions"` // состояние -> возможные следующие
+}
+
+// DeleteVMArgs - аргументы для удаления ВМ
+type DeleteVMArgs struct {
+	Name  string `json:"name"`
+	Force bool   `json:"force,omitempty"` // удалить вместе со снапшотами
+	DryRunArg
+}
+
+// DeleteVMResult - результат удаления ВМ
+type DeleteVMResult struct {
+	Message string `json:"message"`
+}
+
+// LockVMArgs - аргументы для блокировки конфигурации ВМ
+type LockVMArgs struct {
+	Name string `json:"name"`
+	DryRunArg
+}
+
+// UnlockVMArgs - аргументы для снятия блокировки конфигурации ВМ
+type UnlockVMArgs struct {
+	Name string `json:"name"`
+	DryRunArg
+}
+
+// LockVMResult - результат блокировки или разблокировки конфигурации ВМ
+type LockVMResult struct {
+	Message string `json:"message"`
+}
+
+// DeleteVMGracefulArgs - аргументы для удаления ВМ с корректной остановкой
+type DeleteVMGracefulArgs struct {
+	Name  string `json:"name"`
+	Drain string `json:"drain,omitempty"` // например, "30s"; по умолчанию defaultDeleteDrain
+	DryRunArg
+}
+
+// defaultDeleteDrain - время ожидания выключения ВМ в delete_vm_graceful по умолчанию
+const defaultDeleteDrain = time.Minute
+
+// drainTimeout возвращает время ожидания выключения ВМ
+func (args DeleteVMGracefulArgs) drainTimeout() (time.Duration, error) {
+	if args.Drain == "" {
+		return defaultDeleteDrain, nil
+	}
+	drain, err := time.ParseDuration(args.Drain)
+	if err != nil {
+		return 0, fmt.Errorf("invalid drain '%s': %w", args.Drain, err)
+	}
+	if drain < 0 {
+		return 0, fmt.Errorf("invalid drain '%s': must not be negative", args.Drain)
+	}
+	return drain, nil
+}
+
+// CanScheduleResult - результат проверки возможности создания ВМ
+type CanScheduleResult struct {
+	CanSchedule bool   `json:"can_schedule"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// QuotaForecastArgs - аргументы для прогноза исчерпания квот
+type QuotaForecastArgs struct {
+	VMs []CreateVMArgs `json:"vms"` // конфигурации ВМ, которые планируется создать
+}
+
+// QuotaShortfall - на сколько квоты превышены по каждому измерению
+type QuotaShortfall struct {
+	VMs      int    `json:"vms"`
+	MemoryMB uint64 `json:"memory_mb"`
+	VCPUs    uint   `json:"vcpus"`
+	DiskGB   uint64 `json:"disk_gb"`
+}
+
+// QuotaForecastResult - результат прогноза исчерпания квот
+type QuotaForecastResult struct {
+	Fits      bool           `json:"fits"`
+	Shortfall QuotaShortfall `json:"shortfall"`
+}
+
+// ReserveResourcesArgs - аргументы для резервирования ресурсов без ВМ
+type ReserveResourcesArgs struct {
+	Memory MemorySpec `json:"memory,omitempty"` // "4096", "4096MB", "4GB" или "4Gi"
+	VCPUs  uint       `json:"vcpus,omitempty"`
+	DryRunArg
+}
+
+// ReserveResourcesResult - результат резервирования ресурсов
+type ReserveResourcesResult struct {
+	ReservationID string `json:"reservation_id"`
+	Message       string `json:"message"`
+}
+
+// ReleaseReservationArgs - аргументы для освобождения резервирования
+type ReleaseReservationArgs struct {
+	ReservationID string `json:"reservation_id"`
+	DryRunArg
+}
+
+// ReleaseReservationResult - результат освобождения резервирования
+type ReleaseReservationResult struct {
+	Message string `json:"message"`
+}
+
+// ValidateVMConfigResult - результат проверки конфигурации ВМ
+type ValidateVMConfigResult struct {
+	Valid  bool     `json:"valid"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// ValidateInventoryArgs - аргументы для проверки предлагаемого набора ВМ
+type ValidateInventoryArgs struct {
+	VMs []CreateVMArgs `json:"vms"`
+}
+
+// ValidateInventoryResult - результат проверки набора ВМ
+type ValidateInventoryResult struct {
+	Valid     bool                `json:"valid"`
+	VMs       map[string][]string `json:"vms,omitempty"`       // имя ВМ -> ее проблемы
+	Inventory []string            `json:"inventory,omitempty"` // проблемы набора в целом
+}
+
+// SuggestDiskPathArgs - аргументы для подбора пути к диску
+type SuggestDiskPathArgs struct {
+	Name string `json:"name"`
+}
+
+// SuggestDiskPathResult - предложенный путь к диску
+type SuggestDiskPathResult struct {
+	DiskPath string `json:"disk_path"`
+}
+
+// RunGuestCommandArgs - аргументы для выполнения команды в гостевой ОС
+type RunGuestCommandArgs struct {
+	Name    string   `json:"name"`
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+	DryRunArg
+}
+
+// RunGuestCommandResult - результат выполнения команды в гостевой ОС
+type RunGuestCommandResult struct {
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// WriteGuestFileArgs - аргументы для записи файла в гостевую ОС
+type WriteGuestFileArgs struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Content string `json:"content"`
+	DryRunArg
+}
+
+// WriteGuestFileResult - результат записи файла в гостевую ОС
+type WriteGuestFileResult struct {
+	Message string `json:"message"`
+}
+
+// ReadGuestFileArgs - аргументы для чтения файла из гостевой ОС
+type ReadGuestFileArgs struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// ReadGuestFileResult - результат чтения файла из гостевой ОС
+type ReadGuestFileResult struct {
+	Content string `json:"content"`
+}
+
+// WaitForVMIPArgs - аргументы для ожидания IP-адреса ВМ
+type WaitForVMIPArgs struct {
+	Name    string `json:"name"`
+	Timeout string `json:"timeout,omitempty"` // например, "2m"; по умолчанию время загрузки ВМ или defaultWaitForIPTimeout
+}
+
+// WaitForVMIPResult - IP-адрес ВМ
+type WaitForVMIPResult struct {
+	IP string `json:"ip"`
+}
+
+// GetVMLogsArgs - аргументы для получения журнала ВМ
+type GetVMLogsArgs struct {
+	Name  string `json:"name"`
+	Lines int    `json:"lines,omitempty"` // по умолчанию defaultVMLogLines
+}
+
+// GetVMLogsResult - последние строки журнала ВМ
+type GetVMLogsResult struct {
+	Lines []string `json:"lines"`
+}
+
+// FindNameCollisionsResult - имена ВМ, различающиеся только регистром
+type FindNameCollisionsResult struct {
+	Collisions map[string][]string `json:"collisions"`
+}
+
+// defaultVMLogLines - количество строк журнала в get_vm_logs по умолчанию
+const defaultVMLogLines = 50
+
+// UndoLastOperationResult - результат отмены последней операции
+type UndoLastOperationResult struct {
+	Message string `json:"message"`
+}
+
+// defaultWaitForIPTimeout - время ожидания IP-адреса в wait_for_vm_ip по умолчанию
+const defaultWaitForIPTimeout = 2 * time.Minute
+
+// BackendTypeResult - тип бэкенда менеджера ВМ
+type BackendTypeResult struct {
+	BackendType string `json:"backend_type"`
+}
+
+// ManagerStatusResult - сводное состояние менеджера ВМ
+type ManagerStatusResult struct {
+	StartedAt       time.Time `json:"started_at"`
+	Uptime          string    `json:"uptime"`
+	BackendType     string    `json:"backend_type"`
+	VMCount         int       `json:"vm_count"`
+	MaintenanceMode bool      `json:"maintenance_mode"`
+}
+
+// SyncManagerResult - результат синхронизации менеджера с хранилищем
+type SyncManagerResult struct {
+	Message string `json:"message"`
+}
+
+// SetMemoryBalloonArgs - аргументы для изменения текущей памяти ВМ
+type SetMemoryBalloonArgs struct {
+	Name     string `json:"name"`
+	TargetMB uint64 `json:"target_mb"`
+	DryRunArg
+}
+
+// SetMemoryBalloonResult - результат изменения текущей памяти ВМ
+type SetMemoryBalloonResult struct {
+	Message         string `json:"message"`
+	CurrentMemoryMB uint64 `json:"current_memory_mb"`
+}
+
+// IsVMIdleArgs - аргументы для проверки простоя ВМ
+type IsVMIdleArgs struct {
+	Name         string  `json:"name"`
+	ThresholdCPU float64 `json:"threshold_cpu"`
+	Window       string  `json:"window"`
+}
+
+// IsVMIdleResult - результат проверки простоя ВМ
+type IsVMIdleResult struct {
+	Idle bool `json:"idle"`
+}
+
+// EstimateCostArgs - тарифы для оценки стоимости ВМ
+type EstimateCostArgs struct {
+	PerVCPUHour           float64 `json:"per_vcpu_hour"`
+	PerGBMemoryHour       float64 `json:"per_gb_memory_hour"`
+	PerGBDiskMonth        float64 `json:"per_gb_disk_month"`
+	IncludeStoppedCompute bool    `json:"include_stopped_compute,omitempty"`
+}
+
+// EstimateCostResult - оценка месячной стоимости ВМ
+type EstimateCostResult struct {
+	PerVM map[string]float64 `json:"per_vm"`
+	Total float64            `json:"total"`
+}
+
+// ExportLibvirtXMLArgs - аргументы для экспорта ВМ в XML домена libvirt
+type ExportLibvirtXMLArgs struct {
+	Name string `json:"name"`
+}
+
+// ExportLibvirtXMLResult - XML-описание домена libvirt
+type ExportLibvirtXMLResult struct {
+	XML string `json:"xml"`
+}
+
+// ImportLibvirtXMLArgs - аргументы для импорта домена libvirt
+type ImportLibvirtXMLArgs struct {
+	XML    string `json:"xml"`
+	Create bool   `json:"create,omitempty"`
+	DryRunArg
+}
+
+// ImportLibvirtXMLResult - конфигурация, полученная из домена libvirt
+type ImportLibvirtXMLResult struct {
+	Config  CreateVMArgs `json:"config"`
+	Created bool         `json:"created"`
+}
+
+// ImportOVFArgs - аргументы для создания ВМ из дескриптора OVF
+type ImportOVFArgs struct {
+	OVF  string `json:"ovf"`            // XML дескриптора OVF (файл .ovf из пакета OVA)
+	Name string `json:"name,omitempty"` // по умолчанию - имя из дескриптора
+	DryRunArg
+}
+
+// ImportOVFResult - результат создания ВМ из дескриптора OVF
+type ImportOVFResult struct {
+	Message string       `json:"message"`
+	Config  CreateVMArgs `json:"config"`
+}
+
+// DependencyGraphResult - граф зависимостей между ВМ
+type DependencyGraphResult struct {
+	DOT string `json:"dot"`
+}
+
+// SetCPUPinningArgs - аргументы для изменения привязки vCPU
+type SetCPUPinningArgs struct {
+	Name       string   `json:"name"`
+	CPUPinning []CPUPin `json:"cpu_pinning"` // пустой список снимает привязку
+	DryRunArg
+}
+
+// SetCPUPinningResult - результат изменения привязки vCPU
+type SetCPUPinningResult struct {
+	Message string `json:"message"`
+}
+
+// SetNetworkBandwidthArgs - аргументы для ограничения трафика сети ВМ
+type SetNetworkBandwidthArgs struct {
+	Name         string `json:"name"`
+	InboundKbps  int    `json:"inbound_kbps,omitempty"`  // 0 - без ограничения
+	OutboundKbps int    `json:"outbound_kbps,omitempty"` // 0 - без ограничения
+	DryRunArg
+}
+
+// SetNetworkBandwidthResult - результат ограничения трафика сети ВМ
+type SetNetworkBandwidthResult struct {
+	Message string `json:"message"`
+}
+
+// NetworkDependentsArgs - аргументы для поиска ВМ, подключенных к сети
+type NetworkDependentsArgs struct {
+	Network string `json:"network"`
+}
+
+// NetworkDependentsResult - ВМ, подключенные к сети
+type NetworkDependentsResult struct {
+	Network string   `json:"network"`
+	VMs     []string `json:"vms"`
+}
+
+// SetNextBootArgs - аргументы для выбора устройства загрузки на следующий запуск
+type SetNextBootArgs struct {
+	Name   string `json:"name"`
+	Device string `json:"device"` // hd, cdrom или network; пустое значение отменяет выбор
+	DryRunArg
+}
+
+// SetNextBootResult - результат выбора устройства загрузки
+type SetNextBootResult struct {
+	Message string `json:"message"`
+}
+
+// SetBootTimeoutArgs - аргументы для задания времени загрузки ВМ
+type SetBootTimeoutArgs struct {
+	Name    string `json:"name"`
+	Timeout string `json:"timeout"` // например, "5m"; "0s" снимает ограничение
+	DryRunArg
+}
+
+// bootTimeout возвращает заданное время загрузки ВМ
+func (args SetBootTimeoutArgs) bootTimeout() (time.Duration, error) {
+	timeout, err := time.ParseDuration(args.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid argument 'timeout': pass a Go duration such as '5m', or '0s' to clear it: %w", err)
+	}
+	if timeout < 0 {
+		return 0, fmt.Errorf("invalid argument 'timeout': must not be negative")
+	}
+	return timeout, nil
+}
+
+// SetBootTimeoutResult - результат задания времени загрузки ВМ
+type SetBootTimeoutResult struct {
+	Message string `json:"message"`
+}
+
+// ClearVMErrorArgs - аргументы для сброса состояния ошибки ВМ
+type ClearVMErrorArgs struct {
+	Name string `json:"name"`
+	DryRunArg
+}
+
+// ClearVMErrorResult - результат сброса состояния ошибки ВМ
+type ClearVMErrorResult struct {
+	Message string `json:"message"`
+}
+
+// FreezeResult - результат приостановки или возобновления всех ВМ
+type FreezeResult struct {
+	Message string `json:"message"`
+}
+
+// DiffVMsArgs - аргументы для сравнения конфигураций двух ВМ
+type DiffVMsArgs struct {
+	A string `json:"a"`
+	B string `json:"b"`
+}
+
+// ExportInventoryResult - конфигурации всех ВМ в формате JSON
+type ExportInventoryResult struct {
+	Export string `json:"export"`
+}
+
+// DiffAgainstExportArgs - аргументы для сравнения текущих ВМ с экспортом инвентаря
+type DiffAgainstExportArgs struct {
+	Export string `json:"export"` // документ, полученный ранее от export_inventory
+}
+
+// DriftReportArgs - аргументы для отчета о расхождениях с желаемым состоянием
+type DriftReportArgs struct {
+	VMs     []CreateVMArgs  `json:"vms"`               // желаемые конфигурации ВМ
+	Running map[string]bool `json:"running,omitempty"` // имя ВМ -> должна ли она быть запущена
+}
+
+// ExportVMYAMLArgs - аргументы для экспорта конфигурации ВМ в YAML
+type ExportVMYAMLArgs struct {
 	Name string `json:"name"`
-	Memory uint64 `json:"memory"` // в МБ
-	VCPUs uint `json:"vcpus"`
-	DiskPath string `json:"disk_path,omitempty"`
-	DiskSize uint64 `json:"disk_size,omitempty"` // в ГБ
-	ISOImage string `json:"iso_image,omitempty"`
-	Network string `json:"network,omitempty"`
 }
 
-// CreateVMResult - результат создания ВМ
-type CreateVMResult struct {
-	Message string `json:"message"`
-	VMName string `json:"vm_name"`
-}
+// ExportVMYAMLResult - конфигурация ВМ в формате YAML
+type ExportVMYAMLResult struct {
+	YAML string `json:"yaml"`
+}
+
+// ImportVMYAMLArgs - аргументы для импорта конфигурации ВМ из YAML
+type ImportVMYAMLArgs struct {
+	YAML string `json:"yaml"`
+	DryRunArg
+}
+
+// ImportVMYAMLResult - результат импорта конфигурации ВМ из YAML
+type ImportVMYAMLResult struct {
+	Message string `json:"message"`
+}
+
+// ListToolsResult - описание всех доступных инструментов
+type ListToolsResult struct {
+	Tools []ToolDescription `json:"tools"`
+}
+
+// RenameVMArgs - аргументы для переименования ВМ
+type RenameVMArgs struct {
+	Name    string `json:"name"`
+	NewName string `json:"new_name"`
+	// Переименовать и файлы дисков, в имени которых есть старое имя ВМ
+	RenameDisk bool `json:"rename_disk,omitempty"`
+	DryRunArg
+}
+
+// RenameVMResult - результат переименования ВМ
+type RenameVMResult struct {
+	Message string `json:"message"`
+}
+
+// SwapVMNamesArgs - аргументы для обмена именами двух ВМ
+type SwapVMNamesArgs struct {
+	A string `json:"a"`
+	B string `json:"b"`
+	DryRunArg
+}
+
+// SwapVMNamesResult - результат обмена именами двух ВМ
+type SwapVMNamesResult struct {
+	Message string `json:"message"`
+}
+
+// CloneVMArgs - аргументы для клонирования ВМ
+type CloneVMArgs struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Linked bool   `json:"linked,omitempty"` // связанный клон поверх дисков источника
+	// Изменения конфигурации клона относительно источника; пустые значения не меняют ее
+	Memory  MemorySpec `json:"memory,omitempty"`
+	VCPUs   uint       `json:"vcpus,omitempty"`
+	Network string     `json:"network,omitempty"`
+	DryRunArg
+}
+
+// overrides возвращает изменения конфигурации клона и сообщает, заданы ли они
+func (args CloneVMArgs) overrides() (VMConfig, bool, error) {
+	memory, err := args.Memory.MB()
+	if err != nil {
+		return VMConfig{}, false, err
+	}
+	overrides := VMConfig{Memory: memory, VCPUs: args.VCPUs, Network: args.Network}
+	return overrides, overrides.Memory != 0 || overrides.VCPUs != 0 || overrides.Network != "", nil
+}
+
+// CloneVMResult - результат клонирования ВМ
+type CloneVMResult struct {
+	Message string `json:"message"`
+}
+
+// CloneVMFullArgs - аргументы для полного клонирования ВМ
+type CloneVMFullArgs struct {
+	Source           string `json:"source"`
+	Target           string `json:"target"`
+	IncludeSnapshots bool   `json:"include_snapshots,omitempty"`
+	DryRunArg
+}
+
+// SnapshotArgs - аргументы для операций со снапшотом
+type SnapshotArgs struct {
+	VMName       string `json:"vm_name"`
+	SnapshotName string `json:"snapshot_name"`
+	DryRunArg
+}
+
+// SnapshotResult - результат операции со снапшотом
+type SnapshotResult struct {
+	Message string `json:"message"`
+}
+
+// RenameSnapshotArgs - аргументы для переименования снапшота
+type RenameSnapshotArgs struct {
+	VMName  string `json:"vm_name"`
+	OldName string `json:"old_name"`
+	NewName string `json:"new_name"`
+	DryRunArg
+}
+
+// RevertLatestSnapshotResult - результат возврата к последнему снапшоту
+type RevertLatestSnapshotResult struct {
+	Message  string `json:"message"`
+	Snapshot string `json:"snapshot"`
+}
+
+// ListSnapshotsArgs - аргументы для списка снапшотов
+type ListSnapshotsArgs struct {
+	VMName string `json:"vm_name"`
+}
+
+// RevertLatestSnapshotArgs - аргументы для возврата к последнему снапшоту
+type RevertLatestSnapshotArgs struct {
+	VMName string `json:"vm_name"`
+	DryRunArg
+}
+
+// CreateSnapshotArgs - аргументы для создания снапшота
+type CreateSnapshotArgs struct {
+	VMName       string `json:"vm_name"`
+	SnapshotName string `json:"snapshot_name"`
+	Description  string `json:"description,omitempty"`
+	DryRunArg
+}
+
+// SnapshotInfoResult - описание снапшота
+type SnapshotInfoResult struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	CreatedAt   string `json:"created_at"` // RFC 3339
+}
+
+// ListSnapshotsResult - результат списка снапшотов
+type ListSnapshotsResult struct {
+	Snapshots []SnapshotInfoResult `json:"snapshots"`
+}
+
+// PruneSnapshotsArgs - аргументы для удаления старых снапшотов
+type PruneSnapshotsArgs struct {
+	VMName string `json:"vm_name"`
+	Keep   int    `json:"keep"`
+	DryRunArg
+}
+
+// PruneSnapshotsResult - имена удаленных снапшотов
+type PruneSnapshotsResult struct {
+	Deleted []string `json:"deleted"`
+}
+
+// EnableScheduledSnapshotsArgs - аргументы для включения снапшотов по расписанию
+type EnableScheduledSnapshotsArgs struct {
+	VMName   string `json:"vm_name"`
+	Interval string `json:"interval"`
+	Keep     int    `json:"keep"`
+	DryRunArg
+}
+
+// EnableScheduledSnapshotsResult - результат включения снапшотов по расписанию
+type EnableScheduledSnapshotsResult struct {
+	Message string `json:"message"`
+}
+
+// ListAllSnapshotsResult - снапшоты всех ВМ
+type ListAllSnapshotsResult struct {
+	Snapshots map[string][]SnapshotInfoResult `json:"snapshots"` // имя ВМ -> снапшоты
+	Total     int                             `json:"total"`
+}
+
+// snapshotInfoResults преобразует метаданные снапшотов в результат инструмента
+func snapshotInfoResults(infos []SnapshotInfo) []SnapshotInfoResult {
+	results := make([]SnapshotInfoResult, 0, len(infos))
+	for _, info := range infos {
+		results = append(results, SnapshotInfoResult{
+			Name:        info.Name,
+			Description: info.Description,
+			CreatedAt:   info.CreatedAt.Format(time.RFC3339),
+		})
+	}
+	return results
+}
+
+// PlanStepArgs - шаг плана execute_plan
+type PlanStepArgs struct {
+	Action   string        `json:"action"`             // create, start, stop, delete или snapshot
+	Name     string        `json:"name,omitempty"`     // имя ВМ; для create можно задать только в config
+	Config   *CreateVMArgs `json:"config,omitempty"`   // конфигурация создаваемой ВМ (create)
+	Snapshot string        `json:"snapshot,omitempty"` // имя снапшота (snapshot)
+	Force    bool          `json:"force,omitempty"`    // удалить ВМ вместе со снапшотами (delete)
+}
+
+// ExecutePlanArgs - аргументы для выполнения плана из нескольких шагов
+type ExecutePlanArgs struct {
+	Steps           []PlanStepArgs `json:"steps"`
+	ContinueOnError bool           `json:"continue_on_error,omitempty"` // не останавливаться на ошибке шага
+	DryRunArg
+}
+
+// toActions преобразует шаги инструмента в шаги плана ExecutePlan
+func (args ExecutePlanArgs) toActions() ([]PlannedAction, error) {
+	actions := make([]PlannedAction, 0, len(args.Steps))
+	for i, step := range args.Steps {
+		action := PlannedAction{Type: PlanActionType(step.Action), Name: step.Name, Snapshot: step.Snapshot, Force: step.Force}
+		if step.Config != nil {
+			if action.Type != PlanCreate {
+				return nil, fmt.Errorf("invalid steps[%d]: 'config' is only used by the create action", i)
+			}
+			config, err := step.Config.toConfig()
+			if err != nil {
+				return nil, fmt.Errorf("invalid steps[%d]: %w", i, err)
+			}
+			action.Config = config
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
+
+// PlanStepResult - результат шага плана
+type PlanStepResult struct {
+	Step   int    `json:"step"` // номер шага, начиная с 1
+	Action string `json:"action"`
+	Name   string `json:"name"`
+	Status string `json:"status"` // ok, failed или skipped
+	Error  string `json:"error,omitempty"`
+}
+
+// ExecutePlanResult - результаты шагов плана
+type ExecutePlanResult struct {
+	Completed bool             `json:"completed"` // все шаги выполнены успешно
+	Steps     []PlanStepResult `json:"steps"`
+}
+
+// executePlanResult преобразует результаты ExecutePlan в результат инструмента
+func executePlanResult(results []ActionResult) ExecutePlanResult {
+	out := ExecutePlanResult{Completed: true, Steps: make([]PlanStepResult, 0, len(results))}
+	for i, result := range results {
+		step := PlanStepResult{Step: i + 1, Action: string(result.Action.Type), Name: result.Action.Name, Status: "ok"}
+		switch {
+		case result.Skipped:
+			step.Status = "skipped"
+			out.Completed = false
+		case result.Err != nil:
+			step.Status = "failed"
+			step.Error = result.Err.Error()
+			out.Completed = false
+		}
+		out.Steps = append(out.Steps, step)
+	}
+	return out
+}
+
+// VMOperationResult - результат операции над одной ВМ в пакетной операции
+type VMOperationResult struct {
+	Name    string `json:"name"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BatchResult - результаты пакетной операции по каждой ВМ
+type BatchResult struct {
+	Results []VMOperationResult `json:"results"`
+}
+
+// batchResult преобразует ошибки по каждой ВМ в результат инструмента, отсортированный по имени
+func batchResult(errs map[string]error) BatchResult {
+	results := make([]VMOperationResult, 0, len(errs))
+	for name, err := range errs {
+		result := VMOperationResult{Name: name, Success: err == nil}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return BatchResult{Results: results}
+}
+
+// TagVMsArgs - аргументы для установки метки на несколько ВМ
+type TagVMsArgs struct {
+	Names []string `json:"names"`
+	Key   string   `json:"key"`
+	Value string   `json:"value"`
+	DryRunArg
+}
+
+// UntagVMsArgs - аргументы для снятия метки с нескольких ВМ
+type UntagVMsArgs struct {
+	Names []string `json:"names"`
+	Key   string   `json:"key"`
+	DryRunArg
+}
+
+// RelabelBySelectorArgs - аргументы для изменения меток ВМ, подходящих под фильтр
+type RelabelBySelectorArgs struct {
+	Selector VMFilter          `json:"selector"`
+	Set      map[string]string `json:"set,omitempty"`
+	Unset    []string          `json:"unset,omitempty"`
+	DryRunArg
+}
+
+// RelabelBySelectorResult - результат изменения меток по фильтру
+type RelabelBySelectorResult struct {
+	Message  string   `json:"message"`
+	Affected []string `json:"affected"`
+}
+
+// StopVMsNotMatchingArgs - аргументы для остановки ВМ, не подходящих под фильтр
+type StopVMsNotMatchingArgs struct {
+	Selector VMFilter `json:"selector"` // ВМ, которые нужно оставить запущенными
+	DryRunArg
+}
+
+// StopVMsNotMatchingResult - результат остановки ВМ, не подходящих под фильтр
+type StopVMsNotMatchingResult struct {
+	Message string   `json:"message"`
+	Stopped []string `json:"stopped"`
+}
+
+// RenameLabelKeyArgs - аргументы для переименования ключа метки на всех ВМ
+type RenameLabelKeyArgs struct {
+	OldKey string `json:"old_key"`
+	NewKey string `json:"new_key"`
+	DryRunArg
+}
+
+// RenameLabelKeyResult - результат переименования ключа метки
+type RenameLabelKeyResult struct {
+	Message  string   `json:"message"`
+	Affected []string `json:"affected"`
+}
+
+// TotalResourcesResult - суммарные ресурсы всех ВМ
+type TotalResourcesResult struct {
+	VMCount         int    `json:"vm_count"`
+	TotalMemoryMB   uint64 `json:"total_memory_mb"`
+	TotalVCPUs      uint   `json:"total_vcpus"`
+	RunningMemoryMB uint64 `json:"running_memory_mb"`
+}
+
+// ResourceTableResult - ресурсы ВМ в виде текстовой таблицы
+type ResourceTableResult struct {
+	Table string `json:"table"`
+}
+
+// InventoryReportResult - отчет о ВМ в формате Markdown
+type InventoryReportResult struct {
+	Report string `json:"report"`
+}
+
+// NewVMTools создает набор инструментов для управления ВМ. Инструменты, которые
+// бэкенд не поддерживает (см. Capabilities), не регистрируются
+func NewVMTools(manager VMManagerInterface) ([]tool.Tool, error) {
+	var tools []tool.Tool
+
+	// Инструмент для создания ВМ
+	createVMTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "create_vm",
+			Description: "Creates a new virtual machine with the specified configuration. Set retries (up to 5) to retry on transient backend failures; invalid configurations and existing names are never retried.",
+		},
+		func(args CreateVMToolArgs) (string, error) {
+			config, err := args.toConfig()
+			if err != nil {
+				return "", err
+			}
+			return planCreate(manager, config)
+		},
+		func(ctx tool.Context, args CreateVMToolArgs) (ToolResponse[CreateVMResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[CreateVMResult](err)
+			}
+			config, err := args.toConfig()
+			if err != nil {
+				return toolFailure[CreateVMResult](err)
+			}
+			if args.Retries > 0 {
+				err = manager.CreateVMWithRetry(ctx, config, args.Retries+1, createRetryBackoff)
+			} else {
+				err = manager.CreateVM(ctx, config)
+			}
+			if err != nil {
+				return toolFailure[CreateVMResult](fmt.Errorf("failed to create a VM: %w", err))
+			}
+
+			return toolSuccess(CreateVMResult{
+				Message: fmt.Sprintf("VM '%s' has created successfully!", args.Name),
+				VMName:  args.Name,
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create VM tool: %w", err)
+	}
+	tools = append(tools, createVMTool)
+
+	// Инструмент для выполнения плана из нескольких шагов
+	executePlanTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "execute_plan",
+			Description: "Runs an ordered list of steps (create, start, stop, delete or snapshot a VM) as one multi-step operation and returns the result of every step. The whole plan is checked before anything runs. By default it stops at the first failed step and skips the rest; set continue_on_error to run all steps. Completed steps are not rolled back",
+		},
+		func(args ExecutePlanArgs) (string, error) {
+			actions, err := args.toActions()
+			if err != nil {
+				return "", err
+			}
+			steps := make([]string, 0, len(actions))
+			for i, action := range actions {
+				if err := normalizeAction(&action); err != nil {
+					return "", fmt.Errorf("invalid plan step %d: %w", i+1, err)
+				}
+				steps = append(steps, fmt.Sprintf("%d. %s '%s'", i+1, action.Type, action.Name))
+			}
+			return fmt.Sprintf("would run %d step(s): %s", len(steps), strings.Join(steps, "; ")), nil
+		},
+		func(ctx tool.Context, args ExecutePlanArgs) (ToolResponse[ExecutePlanResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[ExecutePlanResult](err)
+			}
+			actions, err := args.toActions()
+			if err != nil {
+				return toolFailure[ExecutePlanResult](err)
+			}
+			results, err := manager.ExecutePlan(ctx, actions, ExecutePlanOptions{ContinueOnError: args.ContinueOnError})
+			if results == nil {
+				return toolFailure[ExecutePlanResult](fmt.Errorf("failed to execute plan: %w", err))
+			}
+			return toolSuccess(executePlanResult(results))
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create execute_plan tool: %w", err)
+	}
+	tools = append(tools, executePlanTool)
+
+	// Инструмент для создания ВМ со случайной конфигурацией
+	createRandomVMTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "create_random_vm",
+			Description: "Creates a virtual machine with a random but valid configuration (name, memory, vcpus, OS type) for demos and UI or load testing. The same seed always produces the same configuration; omit it to pick one from the current time",
+		},
+		func(args CreateRandomVMArgs) (string, error) {
+			seed := args.Seed
+			if seed == 0 {
+				seed = time.Now().UnixNano()
+			}
+			plan, err := planCreate(manager, RandomVMConfig(seed))
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%s (seed %d; pass it to create exactly this VM)", plan, seed), nil
+		},
+		func(ctx tool.Context, args CreateRandomVMArgs) (ToolResponse[CreateRandomVMResult], error) {
+			seed := args.Seed
+			if seed == 0 {
+				seed = time.Now().UnixNano()
+			}
+			config := RandomVMConfig(seed)
+			if err := manager.CreateVM(ctx, config); err != nil {
+				return toolFailure[CreateRandomVMResult](fmt.Errorf("failed to create a random VM: %w", err))
+			}
+			return toolSuccess(CreateRandomVMResult{
+				Message: fmt.Sprintf("VM '%s' has created successfully (%d MB, %d vCPU(s), %s)", config.Name, config.Memory, config.VCPUs, config.OSVariant),
+				VMName:  config.Name,
+				Seed:    seed,
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create create_random_vm tool: %w", err)
+	}
+	tools = append(tools, createRandomVMTool)
+
+	// Инструмент для массового создания случайных ВМ (только mock-бэкенд)
+	populateRandomVMsTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "populate_random_vms",
+			Description: "Creates the given number of virtual machines with random configurations in a mix of running, stopped and paused states, to demo pagination, filtering and metrics at scale. Only available on the mock backend, so real hosts are never filled with junk VMs",
+		},
+		func(args PopulateRandomVMsArgs) (string, error) {
+			if backend := manager.BackendType(); backend != "mock" {
+				return "", fmt.Errorf("populate_random_vms is only available on the mock backend, not %s", backend)
+			}
+			return fmt.Sprintf("would create %d random virtual machines in a mix of running, stopped and paused states", args.Count), nil
+		},
+		func(ctx tool.Context, args PopulateRandomVMsArgs) (ToolResponse[PopulateRandomVMsResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[PopulateRandomVMsResult](err)
+			}
+			if backend := manager.BackendType(); backend != "mock" {
+				return toolFailure[PopulateRandomVMsResult](fmt.Errorf("populate_random_vms is only available on the mock backend, not %s", backend))
+			}
+			seed := args.Seed
+			if seed == 0 {
+				seed = time.Now().UnixNano()
+			}
+			if err := manager.PopulateRandom(ctx, args.Count, seed); err != nil {
+				return toolFailure[PopulateRandomVMsResult](fmt.Errorf("failed to populate random VMs: %w", err))
+			}
+			return toolSuccess(PopulateRandomVMsResult{
+				Message: fmt.Sprintf("Created %d random virtual machines", args.Count),
+				Seed:    seed,
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create populate_random_vms tool: %w", err)
+	}
+	tools = append(tools, populateRandomVMsTool)
+
+	// Инструмент для запуска ВМ
+	startVMTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "start_vm",
+			Description: "Starts a specific virtual machine.",
+		},
+		func(args StartVMArgs) (string, error) {
+			state, err := vmStateOf(manager, args.Name)
+			if err != nil {
+				return "", err
+			}
+			if state == VMStateRunning {
+				return fmt.Sprintf("VM '%s' is already running; nothing would change", args.Name), nil
+			}
+			return fmt.Sprintf("would start VM '%s' (currently %s)", args.Name, state), nil
+		},
+		func(ctx tool.Context, args StartVMArgs) (ToolResponse[StartVMResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[StartVMResult](err)
+			}
+			if err := manager.StartVM(ctx, args.Name); err != nil {
+				return toolFailure[StartVMResult](fmt.Errorf("failed to start '%s' VM; err: %w", args.Name, err))
+			}
+			return toolSuccess(StartVMResult{
+				Message: fmt.Sprintf("Virtual machine '%s' has started successfully!", args.Name),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create start_vm tool: %w", err)
+	}
+	tools = append(tools, startVMTool)
+
+	// Инструмент для запуска ВМ вместе с зависимостями
+	startVMWithDepsTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "start_vm_with_deps",
+			Description: "Starts a virtual machine after starting all VMs it depends on, in dependency order",
+		},
+		func(args StartVMArgs) (string, error) {
+			state, err := vmStateOf(manager, args.Name)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("would start the VMs that '%s' depends on, then VM '%s' (currently %s)", args.Name, args.Name, state), nil
+		},
+		func(ctx tool.Context, args StartVMArgs) (ToolResponse[StartVMWithDepsResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[StartVMWithDepsResult](err)
+			}
+			order, err := manager.StartVMWithDeps(ctx, args.Name)
+			if err != nil {
+				return toolFailure[StartVMWithDepsResult](fmt.Errorf("failed to start '%s' VM with dependencies: %w", args.Name, err))
+			}
+			return toolSuccess(StartVMWithDepsResult{
+				Message: fmt.Sprintf("Virtual machine '%s' started with its dependencies", args.Name),
+				Started: order,
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create start_vm_with_deps tool: %w", err)
+	}
+	tools = append(tools, startVMWithDepsTool)
+
+	// Инструмент для остановки ВМ
+	stopVMTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "stop_vm",
+			Description: "Stops a virtual machine by name",
+		},
+		func(args StopVMArgs) (string, error) {
+			state, err := vmStateOf(manager, args.Name)
+			if err != nil {
+				return "", err
+			}
+			if state == VMStateStopped {
+				return fmt.Sprintf("VM '%s' is already stopped; nothing would change", args.Name), nil
+			}
+			return fmt.Sprintf("would stop VM '%s' (currently %s)", args.Name, state), nil
+		},
+		func(ctx tool.Context, args StopVMArgs) (ToolResponse[StopVMResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[StopVMResult](err)
+			}
+			if err := manager.StopVM(ctx, args.Name); err != nil {
+				return toolFailure[StopVMResult](fmt.Errorf("failed to stop VM: %w", err))
+			}
+			return toolSuccess(StopVMResult{
+				Message: fmt.Sprintf("Virtual machine '%s' stopped successfully", args.Name),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stop_vm tool: %w", err)
+	}
+	tools = append(tools, stopVMTool)
+
+	// Инструмент для списка ВМ
+	listVMsTool, err := newTool(
+		functiontool.Config{
+			Name:        "list_vms",
+			Description: "Lists all available virtual machines",
+		},
+		func(ctx tool.Context, args struct{}) (ToolResponse[ListVMsResult], error) {
+			vms, err := manager.ListVMs()
+			if err != nil {
+				return toolFailure[ListVMsResult](fmt.Errorf("failed to list VMs: %w", err))
+			}
+			return toolSuccess(ListVMsResult{
+				VMs: vms,
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create list_vms tool: %w", err)
+	}
+	tools = append(tools, listVMsTool)
+
+	// Инструмент для списка ВМ по состояниям
+	listGroupedByStateTool, err := newTool(
+		functiontool.Config{
+			Name:        "list_grouped_by_state",
+			Description: "Lists virtual machine names grouped by state (stopped, running, paused, error and any custom states). Every state is present, with an empty list when no VM is in it. Use it to show the user their VMs organized by status",
+		},
+		func(ctx tool.Context, args struct{}) (ToolResponse[ListGroupedByStateResult], error) {
+			groups, err := manager.ListGroupedByState()
+			if err != nil {
+				return toolFailure[ListGroupedByStateResult](fmt.Errorf("failed to list VMs by state: %w", err))
+			}
+			return toolSuccess(ListGroupedByStateResult{
+				Groups: groups,
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create list_grouped_by_state tool: %w", err)
+	}
+	tools = append(tools, listGroupedByStateTool)
+
+	// Инструмент для проверки, запущены ли все ВМ группы
+	stackReadyTool, err := newTool(
+		functiontool.Config{
+			Name:        "stack_ready",
+			Description: "Checks in one call whether every listed virtual machine is running, e.g. to answer \"is my whole web stack up?\". Returns ready=true only if all of them run, plus the state of each VM",
+		},
+		func(ctx tool.Context, args StackReadyArgs) (ToolResponse[StackReadyResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[StackReadyResult](err)
+			}
+			ready, states, err := manager.StackReady(args.Names)
+			if err != nil {
+				return toolFailure[StackReadyResult](fmt.Errorf("failed to check stack readiness: %w", err))
+			}
+			return toolSuccess(StackReadyResult{
+				Ready:  ready,
+				States: states,
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stack_ready tool: %w", err)
+	}
+	tools = append(tools, stackReadyTool)
+
+	// Инструмент для списка разрешенных переходов между состояниями
+	listStateTransitionsTool, err := newTool(
+		functiontool.Config{
+			Name:        "list_state_transitions",
+			Description: "Lists the allowed virtual machine state transitions: for every state, built-in or custom, the states a VM in it can move to. Any state can also move to error on a backend failure. Use it to explain why a VM cannot be started or stopped from its current state",
+		},
+		func(ctx tool.Context, args struct{}) (ToolResponse[StateTransitionsResult], error) {
+			return toolSuccess(StateTransitionsResult{
+				Transitions: manager.StateTransitions(),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create list_state_transitions tool: %w", err)
+	}
+	tools = append(tools, listStateTransitionsTool)
+
+	// Инструмент для удаления ВМ
+	deleteVMTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "delete_vm",
+			Description: "Deletes a virtual machine by name. A VM with snapshots is only deleted when force is true, because its snapshots are deleted too: confirm with the user first",
+		},
+		func(args DeleteVMArgs) (string, error) {
+			state, err := vmStateOf(manager, args.Name)
+			if err != nil {
+				return "", err
+			}
+			snapshots, err := manager.ListSnapshots(args.Name)
+			if err != nil {
+				return "", err
+			}
+			switch {
+			case len(snapshots) > 0 && !args.Force:
+				return "", fmt.Errorf("cannot delete virtual machine '%s' with %d snapshot(s): delete the snapshots first or force the deletion: %w", args.Name, len(snapshots), ErrVMHasSnapshots)
+			case len(snapshots) > 0:
+				return fmt.Sprintf("would delete VM '%s' (currently %s) together with its %d snapshot(s)", args.Name, state, len(snapshots)), nil
+			}
+			return fmt.Sprintf("would delete VM '%s' (currently %s)", args.Name, state), nil
+		},
+		func(ctx tool.Context, args DeleteVMArgs) (ToolResponse[DeleteVMResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[DeleteVMResult](err)
+			}
+			if err := manager.DeleteVM(ctx, args.Name, DeleteVMOptions{Force: args.Force}); err != nil {
+				return toolFailure[DeleteVMResult](fmt.Errorf("failed to delete VM: %w", err))
+			}
+			return toolSuccess(DeleteVMResult{
+				Message: fmt.Sprintf("Virtual machine '%s' deleted successfully", args.Name),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create delete_vm tool: %w", err)
+	}
+	tools = append(tools, deleteVMTool)
+
+	// Инструмент для удаления ВМ с корректной остановкой
+	deleteVMGracefulTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "delete_vm_graceful",
+			Description: "Deletes a virtual machine after shutting it down gracefully: a running VM is stopped first and the deletion waits up to drain (a Go duration such as '30s', default 1m) for it to power off. If it does not power off in time it is left running and not deleted. A VM with snapshots is not deleted; use delete_vm with force for that",
+		},
+		func(args DeleteVMGracefulArgs) (string, error) {
+			drain, err := args.drainTimeout()
+			if err != nil {
+				return "", err
+			}
+			state, err := vmStateOf(manager, args.Name)
+			if err != nil {
+				return "", err
+			}
+			snapshots, err := manager.ListSnapshots(args.Name)
+			if err != nil {
+				return "", err
+			}
+			if len(snapshots) > 0 {
+				return "", fmt.Errorf("cannot delete virtual machine '%s' with %d snapshot(s): delete the snapshots first or force the deletion: %w", args.Name, len(snapshots), ErrVMHasSnapshots)
+			}
+			if state == VMStateRunning {
+				return fmt.Sprintf("would shut down VM '%s', wait up to %s for it to power off and delete it", args.Name, drain), nil
+			}
+			return fmt.Sprintf("would delete VM '%s' (currently %s)", args.Name, state), nil
+		},
+		func(ctx tool.Context, args DeleteVMGracefulArgs) (ToolResponse[DeleteVMResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[DeleteVMResult](err)
+			}
+			drain, _ := args.drainTimeout()
+			if err := manager.DeleteVMGraceful(ctx, args.Name, drain); err != nil {
+				return toolFailure[DeleteVMResult](fmt.Errorf("failed to delete VM: %w", err))
+			}
+			return toolSuccess(DeleteVMResult{
+				Message: fmt.Sprintf("Virtual machine '%s' shut down and deleted successfully", args.Name),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create delete_vm_graceful tool: %w", err)
+	}
+	tools = append(tools, deleteVMGracefulTool)
+
+	// Инструмент для блокировки конфигурации ВМ
+	lockVMTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "lock_vm",
+			Description: "Locks a virtual machine's configuration to protect an important VM from accidental changes: while locked, updating its configuration, disks, ISO image, CPU pinning or limits, restoring its snapshots, renaming and deleting it fail. It can still be read, started, stopped, labeled and snapshotted",
+		},
+		func(args LockVMArgs) (string, error) {
+			if err := requireVMs(manager, args.Name); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("would lock the configuration of VM '%s'", args.Name), nil
+		},
+		func(ctx tool.Context, args LockVMArgs) (ToolResponse[LockVMResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[LockVMResult](err)
+			}
+			if err := manager.LockVMConfig(args.Name); err != nil {
+				return toolFailure[LockVMResult](fmt.Errorf("failed to lock VM: %w", err))
+			}
+			return toolSuccess(LockVMResult{
+				Message: fmt.Sprintf("Configuration of virtual machine '%s' locked", args.Name),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lock_vm tool: %w", err)
+	}
+	tools = append(tools, lockVMTool)
+
+	// Инструмент для снятия блокировки конфигурации ВМ
+	unlockVMTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "unlock_vm",
+			Description: "Unlocks a virtual machine's configuration locked by lock_vm, so it can be changed, renamed and deleted again. Confirm with the user first: the lock exists to protect important VMs",
+		},
+		func(args UnlockVMArgs) (string, error) {
+			if err := requireVMs(manager, args.Name); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("would unlock the configuration of VM '%s'", args.Name), nil
+		},
+		func(ctx tool.Context, args UnlockVMArgs) (ToolResponse[LockVMResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[LockVMResult](err)
+			}
+			if err := manager.UnlockVMConfig(args.Name); err != nil {
+				return toolFailure[LockVMResult](fmt.Errorf("failed to unlock VM: %w", err))
+			}
+			return toolSuccess(LockVMResult{
+				Message: fmt.Sprintf("Configuration of virtual machine '%s' unlocked", args.Name),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create unlock_vm tool: %w", err)
+	}
+	tools = append(tools, unlockVMTool)
+
+	// Инструмент для переименования ВМ
+	renameVMTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "rename_vm",
+			Description: "Renames a virtual machine. With rename_disk=true also renames its disk files whose file name contains the old VM name (e.g. /images/web.qcow2 -> /images/frontend.qcow2); shared disks are left alone",
+		},
+		func(args RenameVMArgs) (string, error) {
+			if err := requireVMs(manager, args.Name); err != nil {
+				return "", err
+			}
+			if err := requireNoVM(manager, args.NewName); err != nil {
+				return "", err
+			}
+			plan := fmt.Sprintf("would rename VM '%s' to '%s'", args.Name, args.NewName)
+			if !args.RenameDisk {
+				return plan, nil
+			}
+			renames, err := diskRenamesOf(manager, args.Name, args.NewName)
+			if err != nil {
+				return "", err
+			}
+			return plan + describeDiskRenames(renames, "and its disk(s) "), nil
+		},
+		func(ctx tool.Context, args RenameVMArgs) (ToolResponse[RenameVMResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[RenameVMResult](err)
+			}
+			var renames []pathRename
+			if args.RenameDisk {
+				var err error
+				if renames, err = diskRenamesOf(manager, args.Name, args.NewName); err != nil {
+					return toolFailure[RenameVMResult](fmt.Errorf("failed to rename VM: %w", err))
+				}
+			}
+			if err := manager.RenameVM(args.Name, args.NewName, RenameVMOptions{RenameDisk: args.RenameDisk}); err != nil {
+				return toolFailure[RenameVMResult](fmt.Errorf("failed to rename VM: %w", err))
+			}
+			return toolSuccess(RenameVMResult{
+				Message: fmt.Sprintf("Virtual machine '%s' renamed to '%s'", args.Name, args.NewName) + describeDiskRenames(renames, "together with disk(s) "),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rename_vm tool: %w", err)
+	}
+	tools = append(tools, renameVMTool)
+
+	// Инструмент для обмена именами двух ВМ
+	swapVMNamesTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "swap_vm_names",
+			Description: "Atomically swaps the names of two existing virtual machines, e.g. for a blue/green cutover. Use it instead of renaming through a temporary name",
+		},
+		func(args SwapVMNamesArgs) (string, error) {
+			if args.A == args.B {
+				return "", fmt.Errorf("cannot swap virtual machine '%s' with itself", args.A)
+			}
+			if err := requireVMs(manager, args.A, args.B); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("would swap the names of VMs '%s' and '%s'", args.A, args.B), nil
+		},
+		func(ctx tool.Context, args SwapVMNamesArgs) (ToolResponse[SwapVMNamesResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[SwapVMNamesResult](err)
+			}
+			if err := manager.SwapVMNames(args.A, args.B); err != nil {
+				return toolFailure[SwapVMNamesResult](fmt.Errorf("failed to swap VM names: %w", err))
+			}
+			return toolSuccess(SwapVMNamesResult{
+				Message: fmt.Sprintf("Virtual machines '%s' and '%s' swapped names", args.A, args.B),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create swap_vm_names tool: %w", err)
+	}
+	tools = append(tools, swapVMNamesTool)
+
+	// Инструмент для клонирования ВМ
+	cloneVMTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "clone_vm",
+			Description: "Creates a stopped copy of a virtual machine under a new name. A linked clone uses the source disks as backing files instead of copying them; the source then cannot be deleted while linked clones exist. memory, vcpus and network, when set, replace the source values in a full clone (e.g. 'copy vm1 but give the copy 8GB')",
+		},
+		func(args CloneVMArgs) (string, error) {
+			overrides, changed, err := args.overrides()
+			if err != nil {
+				return "", err
+			}
+			if !changed {
+				if err := manager.ValidateClone(args.Source, args.Target, nil); err != nil {
+					return "", err
+				}
+				kind := "full"
+				if args.Linked {
+					kind = "linked"
+				}
+				return fmt.Sprintf("would create a stopped %s clone '%s' of VM '%s'", kind, args.Target, args.Source), nil
+			}
+			if err := manager.ValidateClone(args.Source, args.Target, &overrides); err != nil {
+				return "", err
+			}
+			config, err := vmConfigOf(manager, args.Source)
+			if err != nil {
+				return "", err
+			}
+			config = applyCloneOverrides(cloneConfig(config, args.Target), overrides)
+			return fmt.Sprintf("would create a stopped full clone '%s' of VM '%s' with %s", args.Target, args.Source, describeResources(config)), nil
+		},
+		func(ctx tool.Context, args CloneVMArgs) (ToolResponse[CloneVMResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[CloneVMResult](err)
+			}
+			overrides, changed, err := args.overrides()
+			if err != nil {
+				return toolFailure[CloneVMResult](err)
+			}
+			if changed {
+				err = manager.CloneVMWithOverrides(args.Source, args.Target, overrides)
+			} else {
+				err = manager.CloneVM(args.Source, args.Target, args.Linked)
+			}
+			if err != nil {
+				return toolFailure[CloneVMResult](fmt.Errorf("failed to clone VM: %w", err))
+			}
+			return toolSuccess(CloneVMResult{
+				Message: fmt.Sprintf("Virtual machine '%s' cloned to '%s'", args.Source, args.Target),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clone_vm tool: %w", err)
+	}
+	tools = append(tools, cloneVMTool)
+
+	// Инструмент для полного клонирования ВМ
+	cloneVMFullTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "clone_vm_full",
+			Description: "Creates an exact copy of a virtual machine, optionally including all its snapshots",
+		},
+		func(args CloneVMFullArgs) (string, error) {
+			if err := manager.ValidateClone(args.Source, args.Target, nil); err != nil {
+				return "", err
+			}
+			if !args.IncludeSnapshots {
+				return fmt.Sprintf("would create a stopped clone '%s' of VM '%s' without snapshots", args.Target, args.Source), nil
+			}
+			snapshots, err := manager.ListSnapshots(args.Source)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("would create a stopped clone '%s' of VM '%s' with its %d snapshot(s)", args.Target, args.Source, len(snapshots)), nil
+		},
+		func(ctx tool.Context, args CloneVMFullArgs) (ToolResponse[CloneVMResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[CloneVMResult](err)
+			}
+			if err := manager.CloneVMFull(args.Source, args.Target, args.IncludeSnapshots); err != nil {
+				return toolFailure[CloneVMResult](fmt.Errorf("failed to clone VM: %w", err))
+			}
+			return toolSuccess(CloneVMResult{
+				Message: fmt.Sprintf("Virtual machine '%s' cloned to '%s'", args.Source, args.Target),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clone_vm_full tool: %w", err)
+	}
+	tools = append(tools, cloneVMFullTool)
+
+	// Инструмент для создания снапшота
+	createSnapshotTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "create_snapshot",
+			Description: "Creates a named snapshot of a virtual machine's configuration and state. Fails if the storage pool has no room for the snapshot",
+		},
+		func(args CreateSnapshotArgs) (string, error) {
+			if err := requireSnapshot(manager, args.VMName, args.SnapshotName); err == nil {
+				return "", fmt.Errorf("snapshot '%s' already exists for virtual machine '%s'", args.SnapshotName, args.VMName)
+			}
+			if err := requireVMs(manager, args.VMName); err != nil {
+				return "", err
+			}
+			estimate, err := manager.SnapshotSpaceEstimate(args.VMName)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("would create snapshot '%s' of VM '%s' taking about %d GB of storage", args.SnapshotName, args.VMName, estimate), nil
+		},
+		func(ctx tool.Context, args CreateSnapshotArgs) (ToolResponse[SnapshotResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[SnapshotResult](err)
+			}
+			if err := manager.CreateSnapshot(args.VMName, args.SnapshotName, args.Description); err != nil {
+				if errors.Is(err, ErrInsufficientSpace) {
+					return toolFailure[SnapshotResult](fmt.Errorf("failed to create snapshot: %w; free up storage first, e.g. remove old snapshots with prune_snapshots or delete_snapshot", err))
+				}
+				return toolFailure[SnapshotResult](fmt.Errorf("failed to create snapshot: %w", err))
+			}
+			return toolSuccess(SnapshotResult{
+				Message: fmt.Sprintf("Snapshot '%s' of virtual machine '%s' created", args.SnapshotName, args.VMName),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create create_snapshot tool: %w", err)
+	}
+	tools = append(tools, createSnapshotTool)
+
+	// Инструмент для списка снапшотов
+	listSnapshotsTool, err := newTool(
+		functiontool.Config{
+			Name:        "list_snapshots",
+			Description: "Lists snapshots of a virtual machine in creation order with their descriptions and creation times",
+		},
+		func(ctx tool.Context, args ListSnapshotsArgs) (ToolResponse[ListSnapshotsResult], error) {
+			snapshots, err := manager.ListSnapshots(args.VMName)
+			if err != nil {
+				return toolFailure[ListSnapshotsResult](fmt.Errorf("failed to list snapshots: %w", err))
+			}
+			return toolSuccess(ListSnapshotsResult{
+				Snapshots: snapshotInfoResults(snapshots),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create list_snapshots tool: %w", err)
+	}
+	tools = append(tools, listSnapshotsTool)
+
+	// Инструмент для удаления старых снапшотов
+	pruneSnapshotsTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "prune_snapshots",
+			Description: "Deletes all but the newest 'keep' snapshots of a virtual machine (by creation time) and returns the names of the deleted snapshots",
+		},
+		func(args PruneSnapshotsArgs) (string, error) {
+			if args.Keep < 0 {
+				return "", fmt.Errorf("number of snapshots to keep cannot be negative")
+			}
+			byAge, err := manager.ListSnapshots(args.VMName)
+			if err != nil {
+				return "", err
+			}
+			sort.SliceStable(byAge, func(i, j int) bool { return byAge[i].CreatedAt.Before(byAge[j].CreatedAt) })
+			if len(byAge) <= args.Keep {
+				return fmt.Sprintf("VM '%s' has %d snapshot(s); nothing would be deleted", args.VMName, len(byAge)), nil
+			}
+			var names []string
+			for _, snap := range byAge[:len(byAge)-args.Keep] {
+				names = append(names, snap.Name)
+			}
+			return fmt.Sprintf("would delete %d snapshot(s) of VM '%s': %s", len(names), args.VMName, strings.Join(names, ", ")), nil
+		},
+		func(ctx tool.Context, args PruneSnapshotsArgs) (ToolResponse[PruneSnapshotsResult], error) {
+			deleted, err := manager.PruneSnapshots(args.VMName, args.Keep)
+			if err != nil {
+				return toolFailure[PruneSnapshotsResult](fmt.Errorf("failed to prune snapshots: %w", err))
+			}
+			return toolSuccess(PruneSnapshotsResult{
+				Deleted: deleted,
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create prune_snapshots tool: %w", err)
+	}
+	tools = append(tools, pruneSnapshotsTool)
+
+	// Инструмент для включения снапшотов по расписанию
+	enableScheduledSnapshotsTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "enable_scheduled_snapshots",
+			Description: "Takes a snapshot of a virtual machine every interval (a Go duration such as '1h') and keeps only the newest 'keep' scheduled snapshots; manually created snapshots are never deleted. Calling it again for the same VM replaces the schedule",
+		},
+		func(args EnableScheduledSnapshotsArgs) (string, error) {
+			interval, err := time.ParseDuration(args.Interval)
+			if err != nil {
+				return "", fmt.Errorf("invalid interval '%s': %w", args.Interval, err)
+			}
+			if interval <= 0 {
+				return "", fmt.Errorf("snapshot interval must be positive")
+			}
+			if args.Keep < 1 {
+				return "", fmt.Errorf("number of snapshots to keep must be at least 1")
+			}
+			if err := requireVMs(manager, args.VMName); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("would snapshot VM '%s' every %s, keeping the newest %d", args.VMName, interval, args.Keep), nil
+		},
+		func(ctx tool.Context, args EnableScheduledSnapshotsArgs) (ToolResponse[EnableScheduledSnapshotsResult], error) {
+			interval, err := time.ParseDuration(args.Interval)
+			if err != nil {
+				return toolFailure[EnableScheduledSnapshotsResult](fmt.Errorf("invalid interval '%s': %w", args.Interval, err))
+			}
+			if err := manager.EnableScheduledSnapshots(args.VMName, interval, args.Keep); err != nil {
+				return toolFailure[EnableScheduledSnapshotsResult](fmt.Errorf("failed to schedule snapshots: %w", err))
+			}
+			return toolSuccess(EnableScheduledSnapshotsResult{
+				Message: fmt.Sprintf("Snapshots of virtual machine '%s' scheduled every %s, keeping %d", args.VMName, interval, args.Keep),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create enable_scheduled_snapshots tool: %w", err)
+	}
+	tools = append(tools, enableScheduledSnapshotsTool)
+
+	// Инструмент для списка снапшотов всех ВМ
+	listAllSnapshotsTool, err := newTool(
+		functiontool.Config{
+			Name:        "list_all_snapshots",
+			Description: "Lists the snapshots of every virtual machine, keyed by VM name, with the total snapshot count. Useful to audit restore points across all VMs",
+		},
+		func(ctx tool.Context, args struct{}) (ToolResponse[ListAllSnapshotsResult], error) {
+			all, err := manager.ListAllSnapshots()
+			if err != nil {
+				return toolFailure[ListAllSnapshotsResult](fmt.Errorf("failed to list snapshots: %w", err))
+			}
+			result := ListAllSnapshotsResult{
+				Snapshots: make(map[string][]SnapshotInfoResult, len(all)),
+			}
+			for name, infos := range all {
+				result.Snapshots[name] = snapshotInfoResults(infos)
+				result.Total += len(infos)
+			}
+			return toolSuccess(result)
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create list_all_snapshots tool: %w", err)
+	}
+	tools = append(tools, listAllSnapshotsTool)
+
+	// Инструмент для восстановления снапшота
+	restoreSnapshotTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "restore_snapshot",
+			Description: "Restores a virtual machine to the configuration and state saved in a snapshot",
+		},
+		func(args SnapshotArgs) (string, error) {
+			if err := requireSnapshot(manager, args.VMName, args.SnapshotName); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("would restore VM '%s' to snapshot '%s'", args.VMName, args.SnapshotName), nil
+		},
+		func(ctx tool.Context, args SnapshotArgs) (ToolResponse[SnapshotResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[SnapshotResult](err)
+			}
+			if err := manager.RestoreSnapshot(args.VMName, args.SnapshotName); err != nil {
+				return toolFailure[SnapshotResult](fmt.Errorf("failed to restore snapshot: %w", err))
+			}
+			return toolSuccess(SnapshotResult{
+				Message: fmt.Sprintf("Virtual machine '%s' restored to snapshot '%s'", args.VMName, args.SnapshotName),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create restore_snapshot tool: %w", err)
+	}
+	tools = append(tools, restoreSnapshotTool)
+
+	// Инструмент для возврата к последнему снапшоту
+	revertLatestSnapshotTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "revert_latest_snapshot",
+			Description: "Restores a virtual machine to its most recent snapshot and returns the snapshot name used",
+		},
+		func(args RevertLatestSnapshotArgs) (string, error) {
+			snapshots, err := manager.ListSnapshots(args.VMName)
+			if err != nil {
+				return "", err
+			}
+			if len(snapshots) == 0 {
+				return "", fmt.Errorf("virtual machine '%s' has no snapshots", args.VMName)
+			}
+			latest := snapshots[0]
+			for _, snap := range snapshots[1:] {
+				if !snap.CreatedAt.Before(latest.CreatedAt) {
+					latest = snap
+				}
+			}
+			return fmt.Sprintf("would restore VM '%s' to its latest snapshot '%s'", args.VMName, latest.Name), nil
+		},
+		func(ctx tool.Context, args RevertLatestSnapshotArgs) (ToolResponse[RevertLatestSnapshotResult], error) {
+			snapshot, err := manager.RevertToLatestSnapshot(args.VMName)
+			if err != nil {
+				return toolFailure[RevertLatestSnapshotResult](fmt.Errorf("failed to revert to latest snapshot: %w", err))
+			}
+			return toolSuccess(RevertLatestSnapshotResult{
+				Message:  fmt.Sprintf("Virtual machine '%s' reverted to snapshot '%s'", args.VMName, snapshot),
+				Snapshot: snapshot,
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create revert_latest_snapshot tool: %w", err)
+	}
+	tools = append(tools, revertLatestSnapshotTool)
+
+	// Инструмент для удаления снапшота
+	deleteSnapshotTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "delete_snapshot",
+			Description: "Deletes a snapshot of a virtual machine",
+		},
+		func(args SnapshotArgs) (string, error) {
+			if err := requireSnapshot(manager, args.VMName, args.SnapshotName); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("would delete snapshot '%s' of VM '%s'", args.SnapshotName, args.VMName), nil
+		},
+		func(ctx tool.Context, args SnapshotArgs) (ToolResponse[SnapshotResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[SnapshotResult](err)
+			}
+			if err := manager.DeleteSnapshot(args.VMName, args.SnapshotName); err != nil {
+				return toolFailure[SnapshotResult](fmt.Errorf("failed to delete snapshot: %w", err))
+			}
+			return toolSuccess(SnapshotResult{
+				Message: fmt.Sprintf("Snapshot '%s' of virtual machine '%s' deleted", args.SnapshotName, args.VMName),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create delete_snapshot tool: %w", err)
+	}
+	tools = append(tools, deleteSnapshotTool)
+
+	// Инструмент для переименования снапшота
+	renameSnapshotTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "rename_snapshot",
+			Description: "Renames a snapshot of a virtual machine",
+		},
+		func(args RenameSnapshotArgs) (string, error) {
+			if err := requireSnapshot(manager, args.VMName, args.OldName); err != nil {
+				return "", err
+			}
+			if err := requireSnapshot(manager, args.VMName, args.NewName); err == nil {
+				return "", fmt.Errorf("snapshot '%s' already exists for virtual machine '%s'", args.NewName, args.VMName)
+			}
+			return fmt.Sprintf("would rename snapshot '%s' of VM '%s' to '%s'", args.OldName, args.VMName, args.NewName), nil
+		},
+		func(ctx tool.Context, args RenameSnapshotArgs) (ToolResponse[SnapshotResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[SnapshotResult](err)
+			}
+			if err := manager.RenameSnapshot(args.VMName, args.OldName, args.NewName); err != nil {
+				return toolFailure[SnapshotResult](fmt.Errorf("failed to rename snapshot: %w", err))
+			}
+			return toolSuccess(SnapshotResult{
+				Message: fmt.Sprintf("Snapshot '%s' of virtual machine '%s' renamed to '%s'", args.OldName, args.VMName, args.NewName),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rename_snapshot tool: %w", err)
+	}
+	tools = append(tools, renameSnapshotTool)
+
+	// Инструмент для установки метки на несколько ВМ
+	tagVMsTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "tag_vms",
+			Description: "Sets a label key=value on several virtual machines at once and reports the result per VM",
+		},
+		func(args TagVMsArgs) (string, error) {
+			if args.Key == "" {
+				return "", fmt.Errorf("label key cannot be empty")
+			}
+			if err := requireVMs(manager, args.Names...); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("would set label %s=%s on %s", args.Key, args.Value, describeVMs(args.Names)), nil
+		},
+		func(ctx tool.Context, args TagVMsArgs) (ToolResponse[BatchResult], error) {
+			return toolSuccess(batchResult(manager.AddLabelToVMs(args.Names, args.Key, args.Value)))
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tag_vms tool: %w", err)
+	}
+	tools = append(tools, tagVMsTool)
+
+	// Инструмент для снятия метки с нескольких ВМ
+	untagVMsTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "untag_vms",
+			Description: "Removes a label key from several virtual machines at once and reports the result per VM",
+		},
+		func(args UntagVMsArgs) (string, error) {
+			if err := requireVMs(manager, args.Names...); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("would remove label '%s' from %s", args.Key, describeVMs(args.Names)), nil
+		},
+		func(ctx tool.Context, args UntagVMsArgs) (ToolResponse[BatchResult], error) {
+			return toolSuccess(batchResult(manager.RemoveLabelFromVMs(args.Names, args.Key)))
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create untag_vms tool: %w", err)
+	}
+	tools = append(tools, untagVMsTool)
+
+	// Инструмент для изменения меток ВМ, подходящих под фильтр
+	relabelBySelectorTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "relabel_by_selector",
+			Description: "Sets and/or removes labels on every virtual machine matching a selector (name substring, state, labels), e.g. tag all VMs whose name contains 'prod' as tier=critical. An empty selector matches all VMs. Returns the VMs whose labels changed",
+		},
+		func(args RelabelBySelectorArgs) (string, error) {
+			if err := validateRelabel(args.Set, args.Unset); err != nil {
+				return "", err
+			}
+			views, err := listVMViews(manager)
+			if err != nil {
+				return "", err
+			}
+			var affected []string
+			for name, view := range views {
+				if !args.Selector.matches(name, view.State, view.Config.Labels) {
+					continue
+				}
+				labels := maps.Clone(view.Config.Labels)
+				if labels == nil {
+					labels = make(map[string]string)
+				}
+				maps.Copy(labels, args.Set)
+				for _, key := range args.Unset {
+					delete(labels, key)
+				}
+				if !maps.Equal(labels, view.Config.Labels) {
+					affected = append(affected, name)
+				}
+			}
+			return fmt.Sprintf("would change labels on %s", describeVMs(affected)), nil
+		},
+		func(ctx tool.Context, args RelabelBySelectorArgs) (ToolResponse[RelabelBySelectorResult], error) {
+			affected, err := manager.RelabelBySelector(args.Selector, args.Set, args.Unset)
+			if err != nil {
+				return toolFailure[RelabelBySelectorResult](fmt.Errorf("failed to relabel VMs: %w", err))
+			}
+			return toolSuccess(RelabelBySelectorResult{
+				Message:  fmt.Sprintf("Labels changed on %d virtual machine(s)", len(affected)),
+				Affected: affected,
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create relabel_by_selector tool: %w", err)
+	}
+	tools = append(tools, relabelBySelectorTool)
+
+	// Инструмент для остановки всех ВМ, кроме подходящих под фильтр
+	stopVMsNotMatchingTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "stop_vms_not_matching",
+			Description: "Stops every running or paused virtual machine that does NOT match the selector (name substring, state, labels), e.g. stop everything except VMs labeled env=prod. Already stopped VMs are skipped. Returns the VMs that were stopped",
+		},
+		func(args StopVMsNotMatchingArgs) (string, error) {
+			return fmt.Sprintf("would stop %s", describeVMs(notMatchingRunning(manager.Snapshot(), args.Selector))), nil
+		},
+		func(ctx tool.Context, args StopVMsNotMatchingArgs) (ToolResponse[StopVMsNotMatchingResult], error) {
+			stopped, err := manager.StopVMsNotMatching(ctx, args.Selector)
+			if err != nil {
+				return toolFailure[StopVMsNotMatchingResult](fmt.Errorf("failed to stop some VMs (stopped: %s): %w", strings.Join(stopped, ", "), err))
+			}
+			return toolSuccess(StopVMsNotMatchingResult{
+				Message: fmt.Sprintf("Stopped %d virtual machine(s) not matching the selector", len(stopped)),
+				Stopped: stopped,
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stop_vms_not_matching tool: %w", err)
+	}
+	tools = append(tools, stopVMsNotMatchingTool)
+
+	// Инструмент для переименования ключа метки на всех ВМ
+	renameLabelKeyTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "rename_label_key",
+			Description: "Renames a label key on every virtual machine that has it, keeping the values (e.g. env -> environment everywhere). Fails without changing anything if the new key already exists on any of those VMs. Returns the VMs that were changed",
+		},
+		func(args RenameLabelKeyArgs) (string, error) {
+			if err := validateLabelKeyRename(args.OldKey, args.NewKey); err != nil {
+				return "", err
+			}
+			views, err := listVMViews(manager)
+			if err != nil {
+				return "", err
+			}
+			var affected, clobbered []string
+			for name, view := range views {
+				if _, has := view.Config.Labels[args.OldKey]; !has {
+					continue
+				}
+				affected = append(affected, name)
+				if _, has := view.Config.Labels[args.NewKey]; has {
+					clobbered = append(clobbered, name)
+				}
+			}
+			if len(clobbered) > 0 {
+				sort.Strings(clobbered)
+				return "", fmt.Errorf("label '%s' already exists on virtual machine(s) %s", args.NewKey, strings.Join(clobbered, ", "))
+			}
+			return fmt.Sprintf("would rename label '%s' to '%s' on %s", args.OldKey, args.NewKey, describeVMs(affected)), nil
+		},
+		func(ctx tool.Context, args RenameLabelKeyArgs) (ToolResponse[RenameLabelKeyResult], error) {
+			affected, err := manager.RenameLabelKey(args.OldKey, args.NewKey)
+			if err != nil {
+				return toolFailure[RenameLabelKeyResult](fmt.Errorf("failed to rename label key: %w", err))
+			}
+			return toolSuccess(RenameLabelKeyResult{
+				Message:  fmt.Sprintf("Label '%s' renamed to '%s' on %d virtual machine(s)", args.OldKey, args.NewKey, len(affected)),
+				Affected: affected,
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rename_label_key tool: %w", err)
+	}
+	tools = append(tools, renameLabelKeyTool)
+
+	// Инструмент для подсчета суммарных ресурсов
+	totalResourcesTool, err := newTool(
+		functiontool.Config{
+			Name:        "total_resources",
+			Description: "Returns the number of VMs and total allocated memory (MB) and VCPUs across all VMs, plus memory allocated to running VMs only",
+		},
+		func(ctx tool.Context, args struct{}) (ToolResponse[TotalResourcesResult], error) {
+			vms, totalMem, totalVCPU, runningMem := manager.TotalResources()
+			return toolSuccess(TotalResourcesResult{
+				VMCount:         vms,
+				TotalMemoryMB:   totalMem,
+				TotalVCPUs:      totalVCPU,
+				RunningMemoryMB: runningMem,
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create total_resources tool: %w", err)
+	}
+	tools = append(tools, totalResourcesTool)
+
+	// Инструмент для вывода ресурсов ВМ таблицей
+	resourceTableTool, err := newTool(
+		functiontool.Config{
+			Name:        "resource_table",
+			Description: "Returns a pre-formatted, aligned text table of all virtual machines (name, state, vcpus, memory, disk) sorted by name, with a totals row. Show the table to the user as is, in a code block, instead of formatting columns yourself",
+		},
+		func(ctx tool.Context, args struct{}) (ToolResponse[ResourceTableResult], error) {
+			table, err := manager.ResourceTable()
+			if err != nil {
+				return toolFailure[ResourceTableResult](fmt.Errorf("failed to build resource table: %w", err))
+			}
+			return toolSuccess(ResourceTableResult{Table: table})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create resource_table tool: %w", err)
+	}
+	tools = append(tools, resourceTableTool)
+
+	// Инструмент для отчета о всех ВМ в формате Markdown
+	inventoryReportTool, err := newTool(
+		functiontool.Config{
+			Name:        "inventory_report",
+			Description: "Returns a Markdown report of the whole VM inventory (generation time, a table of all VMs, totals and VM counts per state) ready to paste into a ticket or wiki. Show the report to the user as is instead of writing your own summary",
+		},
+		func(ctx tool.Context, args struct{}) (ToolResponse[InventoryReportResult], error) {
+			report, err := manager.InventoryReport()
+			if err != nil {
+				return toolFailure[InventoryReportResult](fmt.Errorf("failed to build inventory report: %w", err))
+			}
+			return toolSuccess(InventoryReportResult{Report: report})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create inventory_report tool: %w", err)
+	}
+	tools = append(tools, inventoryReportTool)
+
+	// Инструмент для проверки возможности создания ВМ
+	canScheduleTool, err := newTool(
+		functiontool.Config{
+			Name:        "can_schedule_vm",
+			Description: "Checks, without creating anything, whether a VM with the given configuration fits into quotas, storage pool and per-VM limits, and explains why not",
+		},
+		func(ctx tool.Context, args CreateVMArgs) (ToolResponse[CanScheduleResult], error) {
+			config, err := args.toConfig()
+			if err != nil {
+				return toolFailure[CanScheduleResult](err)
+			}
+			fits, reason, err := manager.CanSchedule(config)
+			if err != nil {
+				return toolFailure[CanScheduleResult](fmt.Errorf("failed to check VM scheduling: %w", err))
+			}
+			return toolSuccess(CanScheduleResult{
+				CanSchedule: fits,
+				Reason:      reason,
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create can_schedule_vm tool: %w", err)
+	}
+	tools = append(tools, canScheduleTool)
+
+	// Инструмент для прогноза исчерпания квот
+	quotaForecastTool, err := newTool(
+		functiontool.Config{
+			Name:        "quota_forecast",
+			Description: "Checks, without creating anything, whether all the given VMs together fit into the VM, memory and VCPU quotas and the storage pool on top of the current usage, and reports by how much each quota would be exceeded. Use it to plan a batch before creating the VMs",
+		},
+		func(ctx tool.Context, args QuotaForecastArgs) (ToolResponse[QuotaForecastResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[QuotaForecastResult](err)
+			}
+			pending := make([]VMConfig, 0, len(args.VMs))
+			for _, vm := range args.VMs {
+				config, err := vm.toConfig()
+				if err != nil {
+					return toolFailure[QuotaForecastResult](fmt.Errorf("invalid configuration of VM '%s': %w", vm.Name, err))
+				}
+				pending = append(pending, config)
+			}
+			fits, shortfall, err := manager.QuotaForecast(pending)
+			if err != nil {
+				return toolFailure[QuotaForecastResult](fmt.Errorf("failed to forecast quotas: %w", err))
+			}
+			return toolSuccess(QuotaForecastResult{
+				Fits:      fits,
+				Shortfall: QuotaShortfall(shortfall),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create quota_forecast tool: %w", err)
+	}
+	tools = append(tools, quotaForecastTool)
+
+	// Инструмент для резервирования ресурсов без создания ВМ
+	reserveResourcesTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "reserve_resources",
+			Description: "Holds memory and VCPUs in the quotas without creating a VM, so a multi-step create cannot run out of capacity halfway. Returns a reservation ID; pass it as reservation_id to create_vm so the new VM uses the held resources, or release it with release_reservation if it is no longer needed. Reservations expire on their own after a while",
+		},
+		func(args ReserveResourcesArgs) (string, error) {
+			memory, err := args.Memory.MB()
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("would reserve %s", describeResources(VMConfig{Memory: memory, VCPUs: args.VCPUs})), nil
+		},
+		func(ctx tool.Context, args ReserveResourcesArgs) (ToolResponse[ReserveResourcesResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[ReserveResourcesResult](err)
+			}
+			memory, err := args.Memory.MB()
+			if err != nil {
+				return toolFailure[ReserveResourcesResult](err)
+			}
+			id, err := manager.Reserve(memory, args.VCPUs)
+			if err != nil {
+				return toolFailure[ReserveResourcesResult](fmt.Errorf("failed to reserve resources: %w", err))
+			}
+			return toolSuccess(ReserveResourcesResult{
+				ReservationID: id,
+				Message:       fmt.Sprintf("Reserved %s as '%s'", describeResources(VMConfig{Memory: memory, VCPUs: args.VCPUs}), id),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reserve_resources tool: %w", err)
+	}
+	tools = append(tools, reserveResourcesTool)
+
+	// Инструмент для освобождения резервирования ресурсов
+	releaseReservationTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "release_reservation",
+			Description: "Releases a reservation made by reserve_resources and returns its memory and VCPUs to the quotas",
+		},
+		func(args ReleaseReservationArgs) (string, error) {
+			return fmt.Sprintf("would release reservation '%s' and return its resources to the quotas", args.ReservationID), nil
+		},
+		func(ctx tool.Context, args ReleaseReservationArgs) (ToolResponse[ReleaseReservationResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[ReleaseReservationResult](err)
+			}
+			if err := manager.ReleaseReservation(args.ReservationID); err != nil {
+				return toolFailure[ReleaseReservationResult](fmt.Errorf("failed to release reservation: %w", err))
+			}
+			return toolSuccess(ReleaseReservationResult{
+				Message: fmt.Sprintf("Reservation '%s' released", args.ReservationID),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create release_reservation tool: %w", err)
+	}
+	tools = append(tools, releaseReservationTool)
+
+	// Инструмент для проверки конфигурации ВМ
+	validateVMConfigTool, err := newTool(
+		functiontool.Config{
+			Name:        "validate_vm_config",
+			Description: "Validates a VM configuration without creating anything and returns every problem at once (name, memory, VCPUs, firmware, disk images, ISO image), so all of them can be fixed before a single create_vm call",
+		},
+		func(ctx tool.Context, args CreateVMArgs) (ToolResponse[ValidateVMConfigResult], error) {
+			config, err := args.toConfig()
+			if err != nil {
+				return toolFailure[ValidateVMConfigResult](err)
+			}
+			errs := manager.ValidateVMConfigFull(config)
+			result := ValidateVMConfigResult{Valid: len(errs) == 0}
+			for _, err := range errs {
+				result.Errors = append(result.Errors, err.Error())
+			}
+			return toolSuccess(result)
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create validate_vm_config tool: %w", err)
+	}
+	tools = append(tools, validateVMConfigTool)
+
+	// Инструмент для проверки набора ВМ перед применением
+	validateInventoryTool, err := newTool(
+		functiontool.Config{
+			Name:        "validate_inventory",
+			Description: "Validates a proposed set of VMs as a whole without changing anything: every configuration as in validate_vm_config, plus set-level checks that per-VM validation misses - duplicate names, disks used by several VMs (including existing VMs outside the set) and whether the set fits into the quotas and storage pool. Existing VMs with the same name count as replaced. Run it before applying an inventory or creating several VMs",
+		},
+		func(ctx tool.Context, args ValidateInventoryArgs) (ToolResponse[ValidateInventoryResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[ValidateInventoryResult](err)
+			}
+			configs := make([]VMConfig, 0, len(args.VMs))
+			for i, vm := range args.VMs {
+				config, err := vm.toConfig()
+				if err != nil {
+					return toolFailure[ValidateInventoryResult](fmt.Errorf("invalid vms[%d]: %w", i, err))
+				}
+				configs = append(configs, config)
+			}
+			result := ValidateInventoryResult{Valid: true}
+			for name, errs := range manager.ValidateInventory(configs) {
+				result.Valid = false
+				messages := make([]string, 0, len(errs))
+				for _, err := range errs {
+					messages = append(messages, err.Error())
+				}
+				if name == InventoryKey {
+					result.Inventory = messages
+					continue
+				}
+				if result.VMs == nil {
+					result.VMs = make(map[string][]string)
+				}
+				result.VMs[name] = messages
+			}
+			return toolSuccess(result)
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create validate_inventory tool: %w", err)
+	}
+	tools = append(tools, validateInventoryTool)
+
+	// Инструмент для подбора свободного пути к диску
+	suggestDiskPathTool, err := newTool(
+		functiontool.Config{
+			Name:        "suggest_disk_path",
+			Description: "Suggests a disk path for a new virtual machine that is not used by any VM and does not exist on disk. Use it before create_vm when the user has not specified a disk location",
+		},
+		func(ctx tool.Context, args SuggestDiskPathArgs) (ToolResponse[SuggestDiskPathResult], error) {
+			if args.Name == "" {
+				return toolFailure[SuggestDiskPathResult](fmt.Errorf("failed to suggest disk path: VM name cannot be empty"))
+			}
+			return toolSuccess(SuggestDiskPathResult{
+				DiskPath: manager.SuggestDiskPath(args.Name),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create suggest_disk_path tool: %w", err)
+	}
+	tools = append(tools, suggestDiskPathTool)
+
+	// Инструмент для выполнения команды в гостевой ОС
+	runGuestCommandTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "run_guest_command",
+			Description: "Executes a command inside the guest OS of a running virtual machine via the guest agent. Not available on every backend",
+		},
+		func(args RunGuestCommandArgs) (string, error) {
+			if err := requireRunningVM(manager, args.Name); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("would run %q in the guest OS of VM '%s'", strings.Join(append([]string{args.Command}, args.Args...), " "), args.Name), nil
+		},
+		func(ctx tool.Context, args RunGuestCommandArgs) (ToolResponse[RunGuestCommandResult], error) {
+			stdout, stderr, exit, err := manager.RunGuestCommand(ctx, args.Name, args.Command, args.Args)
+			if err != nil {
+				return toolFailure[RunGuestCommandResult](fmt.Errorf("failed to run guest command: %w", err))
+			}
+			return toolSuccess(RunGuestCommandResult{
+				Stdout:   stdout,
+				Stderr:   stderr,
+				ExitCode: exit,
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create run_guest_command tool: %w", err)
+	}
+	tools = append(tools, runGuestCommandTool)
+
+	// Инструмент для записи файла в гостевую ОС
+	writeGuestFileTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "write_guest_file",
+			Description: "Writes a text file into the guest OS of a running virtual machine via the guest agent. Not available on every backend",
+		},
+		func(args WriteGuestFileArgs) (string, error) {
+			if err := requireRunningVM(manager, args.Name); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("would write %d byte(s) to '%s' in the guest OS of VM '%s'", len(args.Content), args.Path, args.Name), nil
+		},
+		func(ctx tool.Context, args WriteGuestFileArgs) (ToolResponse[WriteGuestFileResult], error) {
+			if err := manager.WriteGuestFile(ctx, args.Name, args.Path, []byte(args.Content)); err != nil {
+				return toolFailure[WriteGuestFileResult](fmt.Errorf("failed to write guest file: %w", err))
+			}
+			return toolSuccess(WriteGuestFileResult{
+				Message: fmt.Sprintf("File '%s' written to virtual machine '%s'", args.Path, args.Name),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create write_guest_file tool: %w", err)
+	}
+	tools = append(tools, writeGuestFileTool)
+
+	// Инструмент для чтения файла из гостевой ОС
+	readGuestFileTool, err := newTool(
+		functiontool.Config{
+			Name:        "read_guest_file",
+			Description: "Reads a text file from the guest OS of a running virtual machine via the guest agent. Not available on every backend",
+		},
+		func(ctx tool.Context, args ReadGuestFileArgs) (ToolResponse[ReadGuestFileResult], error) {
+			content, err := manager.ReadGuestFile(ctx, args.Name, args.Path)
+			if err != nil {
+				return toolFailure[ReadGuestFileResult](fmt.Errorf("failed to read guest file: %w", err))
+			}
+			return toolSuccess(ReadGuestFileResult{
+				Content: string(content),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create read_guest_file tool: %w", err)
+	}
+	tools = append(tools, readGuestFileTool)
+
+	// Инструмент для ожидания IP-адреса ВМ
+	waitForVMIPTool, err := newTool(
+		functiontool.Config{
+			Name:        "wait_for_vm_ip",
+			Description: "Waits until a running virtual machine reports a non-loopback IP address and returns it. The optional timeout is a Go duration such as '90s' (default: the VM's boot timeout set with set_boot_timeout, or 2m)",
+		},
+		func(ctx tool.Context, args WaitForVMIPArgs) (ToolResponse[WaitForVMIPResult], error) {
+			var timeout time.Duration
+			if args.Timeout != "" {
+				var err error
+				if timeout, err = time.ParseDuration(args.Timeout); err != nil {
+					return toolFailure[WaitForVMIPResult](fmt.Errorf("invalid timeout '%s': %w", args.Timeout, err))
+				}
+			} else {
+				bootTimeout, err := manager.BootTimeout(args.Name)
+				if err != nil {
+					return toolFailure[WaitForVMIPResult](fmt.Errorf("failed to get VM IP: %w", err))
+				}
+				timeout = cmp.Or(bootTimeout, defaultWaitForIPTimeout)
+			}
+			ip, err := manager.WaitForIP(ctx, args.Name, timeout)
+			if err != nil {
+				return toolFailure[WaitForVMIPResult](fmt.Errorf("failed to get VM IP: %w", err))
+			}
+			return toolSuccess(WaitForVMIPResult{
+				IP: ip,
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create wait_for_vm_ip tool: %w", err)
+	}
+	tools = append(tools, waitForVMIPTool)
+
+	// Инструмент для получения журнала ВМ
+	getVMLogsTool, err := newTool(
+		functiontool.Config{
+			Name:        "get_vm_logs",
+			Description: "Returns the last lines of a virtual machine's console log, oldest first: creation, starts, stops, renames and config changes, ending with the current state. Use it to find out what happened to a VM. lines defaults to 50 and is capped at 500",
+		},
+		func(ctx tool.Context, args GetVMLogsArgs) (ToolResponse[GetVMLogsResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[GetVMLogsResult](err)
+			}
+			lines := args.Lines
+			if lines == 0 {
+				lines = defaultVMLogLines
+			}
+			logs, err := manager.GetVMLogs(args.Name, lines)
+			if err != nil {
+				return toolFailure[GetVMLogsResult](fmt.Errorf("failed to get VM logs: %w", err))
+			}
+			return toolSuccess(GetVMLogsResult{
+				Lines: logs,
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create get_vm_logs tool: %w", err)
+	}
+	tools = append(tools, getVMLogsTool)
+
+	// Инструмент для поиска имен ВМ, различающихся только регистром
+	findNameCollisionsTool, err := newTool(
+		functiontool.Config{
+			Name:        "find_name_collisions",
+			Description: "Lists virtual machine names that differ only in case (e.g. VM1 and vm1), grouped by the lowercase name. Such VMs would collide on a case-insensitive backend; rename one of them before moving there",
+		},
+		func(ctx tool.Context, args struct{}) (ToolResponse[FindNameCollisionsResult], error) {
+			return toolSuccess(FindNameCollisionsResult{
+				Collisions: manager.FindNameCollisions(),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create find_name_collisions tool: %w", err)
+	}
+	tools = append(tools, findNameCollisionsTool)
+
+	// Инструмент для отмены последней изменяющей операции
+	undoLastOperationTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "undo_last_operation",
+			Description: "Reverses the most recent VM operation (create, clone, start, stop, rename, name swap or configuration update), e.g. when the user says \"undo that\". Call repeatedly to step further back. If the most recent operation was anything else (a deletion, snapshot, disk, label or other change) nothing is undone and an error explains why",
+		},
+		func(args DryRunArg) (string, error) {
+			entry, ok := manager.LastAuditEntry()
+			if !ok {
+				return "", errors.New("no operations to undo")
+			}
+			if !entry.Reversible() {
+				return "", fmt.Errorf("the most recent operation (%s) cannot be undone", describeAuditEntry(entry))
+			}
+			return fmt.Sprintf("would undo %s", describeAuditEntry(entry)), nil
+		},
+		func(ctx tool.Context, args DryRunArg) (ToolResponse[UndoLastOperationResult], error) {
+			if err := manager.UndoLast(); err != nil {
+				return toolFailure[UndoLastOperationResult](fmt.Errorf("failed to undo last operation: %w", err))
+			}
+			return toolSuccess(UndoLastOperationResult{
+				Message: "The last operation has been undone",
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create undo_last_operation tool: %w", err)
+	}
+	tools = append(tools, undoLastOperationTool)
+
+	// Инструмент для получения типа бэкенда
+	backendTypeTool, err := newTool(
+		functiontool.Config{
+			Name:        "backend_type",
+			Description: "Returns the VM backend type (e.g. 'mock', 'libvirt'). On the 'mock' backend no real infrastructure is created",
+		},
+		func(ctx tool.Context, args struct{}) (ToolResponse[BackendTypeResult], error) {
+			return toolSuccess(BackendTypeResult{
+				BackendType: manager.BackendType(),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backend_type tool: %w", err)
+	}
+	tools = append(tools, backendTypeTool)
+
+	// Инструмент для получения сводного состояния менеджера
+	managerStatusTool, err := newTool(
+		functiontool.Config{
+			Name:        "manager_status",
+			Description: "Summarizes the VM manager in one call: when it started, its uptime, backend type, number of VMs and whether maintenance mode is on. Use for \"how is the system doing overall?\" questions",
+		},
+		func(ctx tool.Context, args struct{}) (ToolResponse[ManagerStatusResult], error) {
+			status := manager.ManagerInfo()
+			return toolSuccess(ManagerStatusResult{
+				StartedAt:       status.StartedAt,
+				Uptime:          status.Uptime.Round(time.Second).String(),
+				BackendType:     status.BackendType,
+				VMCount:         status.VMCount,
+				MaintenanceMode: status.MaintenanceMode,
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create manager_status tool: %w", err)
+	}
+	tools = append(tools, managerStatusTool)
+
+	// Инструмент для синхронизации менеджера с хранилищем
+	syncManagerTool, err := newTool(
+		functiontool.Config{
+			Name:        "sync_manager",
+			Description: "Makes sure the VM manager state is persisted and fresh: persistent backends flush pending changes, real backends re-read the authoritative domain list",
+		},
+		func(ctx tool.Context, args struct{}) (ToolResponse[SyncManagerResult], error) {
+			if err := manager.Sync(ctx); err != nil {
+				return toolFailure[SyncManagerResult](fmt.Errorf("failed to sync VM manager: %w", err))
+			}
+			return toolSuccess(SyncManagerResult{
+				Message: fmt.Sprintf("VM manager (%s backend) synced", manager.BackendType()),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sync_manager tool: %w", err)
+	}
+	tools = append(tools, syncManagerTool)
+
+	// Инструмент для управления balloon-драйвером памяти
+	setMemoryBalloonTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "set_memory_balloon",
+			Description: "Adjusts the current memory of a running virtual machine via the balloon driver. The target must be greater than 0 and not exceed the configured memory",
+		},
+		func(args SetMemoryBalloonArgs) (string, error) {
+			if args.TargetMB == 0 {
+				return "", fmt.Errorf("balloon target memory cannot be zero")
+			}
+			if err := requireRunningVM(manager, args.Name); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("would set the current memory of VM '%s' to %d MB", args.Name, args.TargetMB), nil
+		},
+		func(ctx tool.Context, args SetMemoryBalloonArgs) (ToolResponse[SetMemoryBalloonResult], error) {
+			if err := manager.SetMemoryBalloon(args.Name, args.TargetMB); err != nil {
+				return toolFailure[SetMemoryBalloonResult](fmt.Errorf("failed to set memory balloon: %w", err))
+			}
+			return toolSuccess(SetMemoryBalloonResult{
+				Message:         fmt.Sprintf("Memory of virtual machine '%s' set to %d MB", args.Name, args.TargetMB),
+				CurrentMemoryMB: args.TargetMB,
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create set_memory_balloon tool: %w", err)
+	}
+	tools = append(tools, setMemoryBalloonTool)
+
+	// Инструмент для проверки простоя ВМ по загрузке CPU
+	isVMIdleTool, err := newTool(
+		functiontool.Config{
+			Name:        "is_vm_idle",
+			Description: "Reports whether the CPU usage of a virtual machine stayed below threshold_cpu percent for the whole window (a Go duration such as '30m' or '2h'). Useful to decide whether a VM can be shut down",
+		},
+		func(ctx tool.Context, args IsVMIdleArgs) (ToolResponse[IsVMIdleResult], error) {
+			window, err := time.ParseDuration(args.Window)
+			if err != nil {
+				return toolFailure[IsVMIdleResult](fmt.Errorf("invalid window '%s': %w", args.Window, err))
+			}
+			idle, err := manager.IsIdle(args.Name, args.ThresholdCPU, window)
+			if err != nil {
+				return toolFailure[IsVMIdleResult](fmt.Errorf("failed to check VM idleness: %w", err))
+			}
+			return toolSuccess(IsVMIdleResult{
+				Idle: idle,
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create is_vm_idle tool: %w", err)
+	}
+	tools = append(tools, isVMIdleTool)
+
+	// Инструмент для оценки месячной стоимости ВМ
+	estimateCostTool, err := newTool(
+		functiontool.Config{
+			Name:        "estimate_cost",
+			Description: "Estimates the monthly cost of each virtual machine and the total from hourly CPU and memory prices and a monthly disk price. Compute is counted only for running VMs unless include_stopped_compute is set; disks are counted for all VMs",
+		},
+		func(ctx tool.Context, args EstimateCostArgs) (ToolResponse[EstimateCostResult], error) {
+			perVM, total, err := manager.EstimateCost(CostModel{
+				PerVCPUHour:           args.PerVCPUHour,
+				PerGBMemoryHour:       args.PerGBMemoryHour,
+				PerGBDiskMonth:        args.PerGBDiskMonth,
+				IncludeStoppedCompute: args.IncludeStoppedCompute,
+			})
+			if err != nil {
+				return toolFailure[EstimateCostResult](fmt.Errorf("failed to estimate cost: %w", err))
+			}
+			return toolSuccess(EstimateCostResult{
+				PerVM: perVM,
+				Total: total,
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create estimate_cost tool: %w", err)
+	}
+	tools = append(tools, estimateCostTool)
 
-// StartVMArgs - аргументы для запуска ВМ
-type StartVMArgs struct {
-	Name string `json:"name"`
-}
+	// Инструмент для построения графа зависимостей
+	dependencyGraphTool, err := newTool(
+		functiontool.Config{
+			Name:        "dependency_graph",
+			Description: "Returns a Graphviz DOT diagram of the dependencies between virtual machines, colored by state. Missing dependencies are dashed and cycles are highlighted in red",
+		},
+		func(ctx tool.Context, args struct{}) (ToolResponse[DependencyGraphResult], error) {
+			dot, err := manager.DependencyGraphDOT()
+			if err != nil {
+				return toolFailure[DependencyGraphResult](fmt.Errorf("failed to build dependency graph: %w", err))
+			}
+			return toolSuccess(DependencyGraphResult{
+				DOT: dot,
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dependency_graph tool: %w", err)
+	}
+	tools = append(tools, dependencyGraphTool)
 
-// StartVMResult - результат запуска ВМ
-type StartVMResult struct {
-	Message string `json:"message"`
-}
+	// Инструмент для изменения привязки vCPU к физическим CPU
+	setCPUPinningTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "set_cpu_pinning",
+			Description: "Pins the vCPUs of a stopped virtual machine to physical host CPUs for performance-sensitive workloads. vCPU indices must be below the VM's vcpus; an empty list removes the pinning",
+		},
+		func(args SetCPUPinningArgs) (string, error) {
+			if err := manager.ValidateCPUPinning(args.Name, cpuPinningFromArgs(args.CPUPinning)); err != nil {
+				return "", err
+			}
+			if len(args.CPUPinning) == 0 {
+				return fmt.Sprintf("would remove the CPU pinning of VM '%s'", args.Name), nil
+			}
+			return fmt.Sprintf("would pin %d vCPU(s) of VM '%s'", len(args.CPUPinning), args.Name), nil
+		},
+		func(ctx tool.Context, args SetCPUPinningArgs) (ToolResponse[SetCPUPinningResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[SetCPUPinningResult](err)
+			}
+			if err := manager.SetCPUPinning(args.Name, cpuPinningFromArgs(args.CPUPinning)); err != nil {
+				return toolFailure[SetCPUPinningResult](fmt.Errorf("failed to set CPU pinning: %w", err))
+			}
+			return toolSuccess(SetCPUPinningResult{
+				Message: fmt.Sprintf("CPU pinning of virtual machine '%s' updated (%d vCPU(s) pinned)", args.Name, len(args.CPUPinning)),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create set_cpu_pinning tool: %w", err)
+	}
+	tools = append(tools, setCPUPinningTool)
 
-// StopVMArgs - аргументы для остановки ВМ
-type StopVMArgs struct {
-	Name string `json:"name"`
-}
+	// Инструмент для ограничения трафика сети ВМ
+	setNetworkBandwidthTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "set_network_bandwidth",
+			Description: "Limits the inbound and outbound traffic of the network interface of a stopped virtual machine, in Kbps. 0 or an omitted limit removes it; the VM must have a network and be stopped",
+		},
+		func(args SetNetworkBandwidthArgs) (string, error) {
+			if err := manager.ValidateNetworkBandwidth(args.Name, uint(args.InboundKbps), uint(args.OutboundKbps)); err != nil {
+				return "", err
+			}
+			bandwidth := NetworkSpec{InboundKbps: uint(args.InboundKbps), OutboundKbps: uint(args.OutboundKbps)}
+			if bandwidth == (NetworkSpec{}) {
+				return fmt.Sprintf("would remove the bandwidth limits of VM '%s'", args.Name), nil
+			}
+			return fmt.Sprintf("would limit the network of VM '%s' to %s", args.Name, formatBandwidth(bandwidth)), nil
+		},
+		func(ctx tool.Context, args SetNetworkBandwidthArgs) (ToolResponse[SetNetworkBandwidthResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[SetNetworkBandwidthResult](err)
+			}
+			if err := manager.SetNetworkBandwidth(args.Name, uint(args.InboundKbps), uint(args.OutboundKbps)); err != nil {
+				return toolFailure[SetNetworkBandwidthResult](fmt.Errorf("failed to set network bandwidth limits: %w", err))
+			}
+			bandwidth := NetworkSpec{InboundKbps: uint(args.InboundKbps), OutboundKbps: uint(args.OutboundKbps)}
+			message := fmt.Sprintf("Network of virtual machine '%s' limited to %s", args.Name, formatBandwidth(bandwidth))
+			if bandwidth == (NetworkSpec{}) {
+				message = fmt.Sprintf("Bandwidth limits of virtual machine '%s' removed", args.Name)
+			}
+			return toolSuccess(SetNetworkBandwidthResult{Message: message})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create set_network_bandwidth tool: %w", err)
+	}
+	tools = append(tools, setNetworkBandwidthTool)
 
-// StopVMResult - результат остановки ВМ
-type StopVMResult struct {
-	Message string `json:"message"`
-}
+	// Инструмент для поиска ВМ, зависящих от сети
+	networkDependentsTool, err := newTool(
+		functiontool.Config{
+			Name:        "network_dependents",
+			Description: "Lists the virtual machines attached to a network, i.e. the VMs that would lose connectivity if the network were removed. Use it before deleting or changing a network to show the blast radius",
+		},
+		func(ctx tool.Context, args NetworkDependentsArgs) (ToolResponse[NetworkDependentsResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[NetworkDependentsResult](err)
+			}
+			vms, err := manager.NetworkDependents(args.Network)
+			if err != nil {
+				return toolFailure[NetworkDependentsResult](fmt.Errorf("failed to find VMs on network: %w", err))
+			}
+			return toolSuccess(NetworkDependentsResult{Network: args.Network, VMs: vms})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create network_dependents tool: %w", err)
+	}
+	tools = append(tools, networkDependentsTool)
 
-// ListVMsResult - результат списка ВМ
-type ListVMsResult struct {
-	VMs []string `json:"vms"`
-}
+	// Инструмент для однократной загрузки ВМ с другого устройства
+	setNextBootTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "set_next_boot",
+			Description: "Makes a virtual machine boot from the given device (hd, cdrom or network) on its next start only, e.g. from CDROM once to reinstall the OS; later starts boot from disk again. An empty device cancels the override",
+		},
+		func(args SetNextBootArgs) (string, error) {
+			if err := requireVMs(manager, args.Name); err != nil {
+				return "", err
+			}
+			if args.Device == "" {
+				return fmt.Sprintf("would clear the next boot override of VM '%s'", args.Name), nil
+			}
+			return fmt.Sprintf("would boot VM '%s' from '%s' on its next start", args.Name, args.Device), nil
+		},
+		func(ctx tool.Context, args SetNextBootArgs) (ToolResponse[SetNextBootResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[SetNextBootResult](err)
+			}
+			if err := manager.SetNextBoot(args.Name, args.Device); err != nil {
+				return toolFailure[SetNextBootResult](fmt.Errorf("failed to set next boot device: %w", err))
+			}
+			message := fmt.Sprintf("Virtual machine '%s' will boot from '%s' on its next start", args.Name, args.Device)
+			if args.Device == "" {
+				message = fmt.Sprintf("Next boot override of virtual machine '%s' cleared", args.Name)
+			}
+			return toolSuccess(SetNextBootResult{Message: message})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create set_next_boot tool: %w", err)
+	}
+	tools = append(tools, setNextBootTool)
 
-// DeleteVMArgs - аргументы для удаления ВМ
-type DeleteVMArgs struct {
-	Name string `json:"name"`
-}
+	// Инструмент для задания времени загрузки ВМ
+	setBootTimeoutTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "set_boot_timeout",
+			Description: "Sets how long a slow-booting virtual machine may take to boot, as a Go duration such as '5m'. wait_for_vm_ip waits this long for the VM when no explicit timeout is given; '0s' clears it",
+		},
+		func(args SetBootTimeoutArgs) (string, error) {
+			timeout, err := args.bootTimeout()
+			if err != nil {
+				return "", err
+			}
+			current, err := manager.BootTimeout(args.Name)
+			if err != nil {
+				return "", err
+			}
+			if timeout == 0 {
+				return fmt.Sprintf("would clear the boot timeout of VM '%s' (currently %s)", args.Name, current), nil
+			}
+			return fmt.Sprintf("would set the boot timeout of VM '%s' to %s (currently %s)", args.Name, timeout, current), nil
+		},
+		func(ctx tool.Context, args SetBootTimeoutArgs) (ToolResponse[SetBootTimeoutResult], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[SetBootTimeoutResult](err)
+			}
+			timeout, _ := args.bootTimeout()
+			if err := manager.SetBootTimeout(args.Name, timeout); err != nil {
+				return toolFailure[SetBootTimeoutResult](fmt.Errorf("failed to set boot timeout: %w", err))
+			}
+			message := fmt.Sprintf("Boot timeout of virtual machine '%s' set to %s", args.Name, timeout)
+			if timeout == 0 {
+				message = fmt.Sprintf("Boot timeout of virtual machine '%s' cleared", args.Name)
+			}
+			return toolSuccess(SetBootTimeoutResult{Message: message})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create set_boot_timeout tool: %w", err)
+	}
+	tools = append(tools, setBootTimeoutTool)
 
-// DeleteVMResult - результат удаления ВМ
-type DeleteVMResult struct {
-	Message string `json:"message"`
-}
+	// Инструмент для сброса состояния ошибки ВМ
+	clearVMErrorTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "clear_vm_error",
+			Description: "Resets a virtual machine from the 'error' state (left by a backend operation that failed mid-transition) to 'stopped' after the operator has fixed the problem",
+		},
+		func(args ClearVMErrorArgs) (string, error) {
+			state, err := vmStateOf(manager, args.Name)
+			if err != nil {
+				return "", err
+			}
+			if state != VMStateError {
+				return "", fmt.Errorf("virtual machine '%s' is not in error state (current state: %s)", args.Name, state)
+			}
+			return fmt.Sprintf("would reset VM '%s' from the error state to stopped", args.Name), nil
+		},
+		func(ctx tool.Context, args ClearVMErrorArgs) (ToolResponse[ClearVMErrorResult], error) {
+			if err := manager.ClearError(args.Name); err != nil {
+				return toolFailure[ClearVMErrorResult](fmt.Errorf("failed to clear VM error: %w", err))
+			}
+			return toolSuccess(ClearVMErrorResult{
+				Message: fmt.Sprintf("Error state of virtual machine '%s' cleared; it is now stopped", args.Name),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clear_vm_error tool: %w", err)
+	}
+	tools = append(tools, clearVMErrorTool)
 
-// NewVMTools создает набор инструментов для управления ВМ
-func NewVMTools(manager VMManagerInterface) ([]tool.Tool, error) {
-	var tools []tool.Tool
+	// Инструмент для перезапуска всех запущенных ВМ
+	restartAllRunningTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "restart_all_running",
+			Description: "Restarts every virtual machine that is currently running (e.g. after applying a host patch) and reports the result per VM. Stopped and paused VMs are left alone",
+		},
+		func(args DryRunArg) (string, error) {
+			groups, err := manager.ListGroupedByState()
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("would restart %s", describeVMs(groups[VMStateRunning])), nil
+		},
+		func(ctx tool.Context, args DryRunArg) (ToolResponse[BatchResult], error) {
+			return toolSuccess(batchResult(manager.RestartAllRunning()))
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create restart_all_running tool: %w", err)
+	}
+	tools = append(tools, restartAllRunningTool)
 
-	// Инструмент для создания ВМ
-	createVMTool, err := functiontool.New(
+	// Функция возобновления ВМ, приостановленных freeze_all; ее вызывает thaw_all
+	var freeze struct {
+		sync.Mutex
+		resume func() error
+	}
+
+	// Инструмент для приостановки всех запущенных ВМ
+	freezeAllTool, err := newMutatingTool(
 		functiontool.Config{
-			Name: "create_vm",
-			Description: "Creates a new virtual machine with the specified configuration.",
+			Name:        "freeze_all",
+			Description: "Pauses every running virtual machine, e.g. for a consistent host-level backup. Use thaw_all afterwards to resume exactly the VMs paused by this call",
+		},
+		func(args DryRunArg) (string, error) {
+			freeze.Lock()
+			defer freeze.Unlock()
+
+			if freeze.resume != nil {
+				return "", fmt.Errorf("virtual machines are already frozen: call thaw_all first")
+			}
+			groups, err := manager.ListGroupedByState()
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("would pause %s", describeVMs(groups[VMStateRunning])), nil
 		},
-		func(ctx tool.Context, args CreateVMArgs) (CreateVMResult, error) {
-			config := VMConfig{
-				Name: args.Name,
-				Memory:   args.Memory,
-				VCPUs:    args.VCPUs,
-				DiskPath: args.DiskPath,
-				DiskSize: args.DiskSize,
-				ISOImage: args.ISOImage,
-				Network:  args.Network,
+		func(ctx tool.Context, args DryRunArg) (ToolResponse[FreezeResult], error) {
+			freeze.Lock()
+			defer freeze.Unlock()
+
+			if freeze.resume != nil {
+				return toolFailure[FreezeResult](fmt.Errorf("virtual machines are already frozen: call thaw_all first"))
+			}
+			resume, err := manager.FreezeAll()
+			if resume != nil {
+				// Даже при ошибке часть ВМ могла быть приостановлена: thaw_all их возобновит
+				freeze.resume = resume
+			}
+			if err != nil {
+				return toolFailure[FreezeResult](fmt.Errorf("failed to freeze VMs (call thaw_all to resume the ones that were paused): %w", err))
 			}
+			return toolSuccess(FreezeResult{
+				Message: "All running virtual machines paused",
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create freeze_all tool: %w", err)
+	}
+	tools = append(tools, freezeAllTool)
 
-			if err := manager.CreateVM(config); err != nil {
-				return CreateVMResult{}, fmt.Errorf("failed to create a VM: %w", err)
+	// Инструмент для возобновления ВМ, приостановленных freeze_all
+	thawAllTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "thaw_all",
+			Description: "Resumes the virtual machines paused by the last freeze_all call. VMs that were already paused before freeze_all stay paused",
+		},
+		func(args DryRunArg) (string, error) {
+			freeze.Lock()
+			defer freeze.Unlock()
+
+			if freeze.resume == nil {
+				return "", fmt.Errorf("no virtual machines are frozen")
 			}
+			return "would resume the virtual machines paused by the last freeze_all call", nil
+		},
+		func(ctx tool.Context, args DryRunArg) (ToolResponse[FreezeResult], error) {
+			freeze.Lock()
+			defer freeze.Unlock()
 
-			return CreateVMResult{
-				Message: fmt.Sprintf("VM '%s' has created successfully!", args.Name),
-				VMName: args.Name,
-			}, nil
+			if freeze.resume == nil {
+				return toolFailure[FreezeResult](fmt.Errorf("no virtual machines are frozen"))
+			}
+			resume := freeze.resume
+			freeze.resume = nil
+			if err := resume(); err != nil {
+				return toolFailure[FreezeResult](fmt.Errorf("failed to thaw VMs: %w", err))
+			}
+			return toolSuccess(FreezeResult{
+				Message: "Frozen virtual machines resumed",
+			})
 		},
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create VM tool: %w", err)
+		return nil, fmt.Errorf("failed to create thaw_all tool: %w", err)
 	}
-	tools = append(tools, createVMTool)
+	tools = append(tools, thawAllTool)
 
-	// Инструмент для запуска ВМ
-	startVMTool, err := functiontool.New(
+	// Инструмент для сравнения конфигураций двух ВМ
+	diffVMsTool, err := newTool(
 		functiontool.Config{
-			Name: "start_vm",
-			Description: "Starts a specific virtual machine.",
+			Name:        "diff_vms",
+			Description: "Compares the configurations of two virtual machines and returns every differing field (memory, vcpus, disks, network, labels, ...) with both values",
 		},
-		func(ctx tool.Context, args StartVMArgs) (StartVMResult, error) {
-			if err := manager.StartVM(args.Name); err != nil {
-				return StartVMResult{}, fmt.Errorf("failed to start '%s' VM; err: %w", args.Name, err)
+		func(ctx tool.Context, args DiffVMsArgs) (ToolResponse[VMConfigDiff], error) {
+			diff, err := manager.DiffVMs(args.A, args.B)
+			if err != nil {
+				return toolFailure[VMConfigDiff](fmt.Errorf("failed to diff VMs: %w", err))
 			}
-			return StartVMResult{
-				Message: "Virtual machine '%s' has started successfully!",
-			}, nil
+			return toolSuccess(diff)
 		},
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create start_vm tool: %w", err)
+		return nil, fmt.Errorf("failed to create diff_vms tool: %w", err)
 	}
-	tools = append(tools, startVMTool)
+	tools = append(tools, diffVMsTool)
 
-	// Инструмент для остановки ВМ
-	stopVMTool, err := functiontool.New(
+	// Инструмент для экспорта инвентаря
+	exportInventoryTool, err := newTool(
 		functiontool.Config{
-			Name:        "stop_vm",
-			Description: "Stops a virtual machine by name",
+			Name:        "export_inventory",
+			Description: "Exports the configurations of all virtual machines as a JSON inventory document that can be kept as a backup and later compared with diff_against_export",
 		},
-		func(ctx tool.Context, args StopVMArgs) (StopVMResult, error) {
-			if err := manager.StopVM(args.Name); err != nil {
-				return StopVMResult{}, fmt.Errorf("failed to stop VM: %w", err)
+		func(ctx tool.Context, args struct{}) (ToolResponse[ExportInventoryResult], error) {
+			data, err := manager.ExportInventory()
+			if err != nil {
+				return toolFailure[ExportInventoryResult](err)
 			}
-			return StopVMResult{
-				Message: fmt.Sprintf("Virtual machine '%s' stopped successfully", args.Name),
-			}, nil
+			return toolSuccess(ExportInventoryResult{
+				Export: string(data),
+			})
 		},
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create stop_vm tool: %w", err)
+		return nil, fmt.Errorf("failed to create export_inventory tool: %w", err)
 	}
-	tools = append(tools, stopVMTool)
+	tools = append(tools, exportInventoryTool)
 
-	// Инструмент для списка ВМ
-	listVMsTool, err := functiontool.New(
+	// Инструмент для сравнения текущих ВМ с экспортом инвентаря
+	diffAgainstExportTool, err := newTool(
 		functiontool.Config{
-			Name:        "list_vms",
-			Description: "Lists all available virtual machines",
+			Name:        "diff_against_export",
+			Description: "Compares the current virtual machines with an inventory document previously produced by export_inventory and reports VMs added and removed since then, VMs whose configuration changed (each differing field with the exported and current values) and unchanged VMs",
 		},
-		func(ctx tool.Context, args struct{}) (ListVMsResult, error) {
-			vms, err := manager.ListVMs()
+		func(ctx tool.Context, args DiffAgainstExportArgs) (ToolResponse[InventoryDiff], error) {
+			if err := args.validate(); err != nil {
+				return toolFailure[InventoryDiff](err)
+			}
+			diff, err := manager.DiffAgainstExport([]byte(args.Export))
 			if err != nil {
-				return ListVMsResult{}, fmt.Errorf("failed to list VMs: %w", err)
+				return toolFailure[InventoryDiff](fmt.Errorf("failed to diff against export: %w", err))
 			}
-			return ListVMsResult{
-				VMs: vms,
-			}, nil
+			return toolSuccess(diff)
 		},
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create list_vms tool: %w", err)
+		return nil, fmt.Errorf("failed to create diff_against_export tool: %w", err)
 	}
-	tools = append(tools, listVMsTool)
+	tools = append(tools, diffAgainstExportTool)
 
-	// Инструмент для удаления ВМ
-	deleteVMTool, err := functiontool.New(
+	// Инструмент для отчета о расхождениях с желаемым состоянием
+	driftReportTool, err := newTool(
 		functiontool.Config{
-			Name:        "delete_vm",
-			Description: "Deletes a virtual machine by name",
+			Name:        "drift_report",
+			Description: "Compares the declared virtual machines (and, optionally, whether each should be running) with the actual ones without changing anything, and reports VMs that exist but are not declared, declared VMs that are missing, VMs in the wrong power state and VMs whose configuration differs",
 		},
-		func(ctx tool.Context, args DeleteVMArgs) (DeleteVMResult, error) {
-			if err := manager.DeleteVM(args.Name); err != nil {
-				return DeleteVMResult{}, fmt.Errorf("failed to delete VM: %w", err)
+		func(ctx tool.Context, args DriftReportArgs) (ToolResponse[DriftReport], error) {
+			desired := make([]VMConfig, 0, len(args.VMs))
+			for _, vm := range args.VMs {
+				config, err := vm.toConfig()
+				if err != nil {
+					return toolFailure[DriftReport](fmt.Errorf("invalid configuration of VM '%s': %w", vm.Name, err))
+				}
+				desired = append(desired, config)
 			}
-			return DeleteVMResult{
-				Message: fmt.Sprintf("Virtual machine '%s' deleted successfully", args.Name),
-			}, nil
+			report, err := manager.DriftReport(desired, args.Running)
+			if err != nil {
+				return toolFailure[DriftReport](fmt.Errorf("failed to build drift report: %w", err))
+			}
+			return toolSuccess(report)
 		},
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create delete_vm tool: %w", err)
+		return nil, fmt.Errorf("failed to create drift_report tool: %w", err)
 	}
-	tools = append(tools, deleteVMTool)
+	tools = append(tools, driftReportTool)
+
+	// Инструмент для импорта домена libvirt
+	importLibvirtXMLTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "import_libvirt_xml",
+			Description: "Parses a libvirt domain XML definition into a VM configuration (name, memory, vcpus, disks, network). Set create to also create the virtual machine from it",
+		},
+		func(args ImportLibvirtXMLArgs) (string, error) {
+			config, err := manager.ImportFromLibvirtXML(args.XML)
+			if err != nil {
+				return "", fmt.Errorf("failed to import libvirt XML: %w", err)
+			}
+			if !args.Create {
+				return fmt.Sprintf("would return the configuration of VM '%s' without creating it", config.Name), nil
+			}
+			return planCreate(manager, config)
+		},
+		func(ctx tool.Context, args ImportLibvirtXMLArgs) (ToolResponse[ImportLibvirtXMLResult], error) {
+			config, err := manager.ImportFromLibvirtXML(args.XML)
+			if err != nil {
+				return toolFailure[ImportLibvirtXMLResult](fmt.Errorf("failed to import libvirt XML: %w", err))
+			}
+			if args.Create {
+				if err := manager.CreateVM(ctx, config); err != nil {
+					return toolFailure[ImportLibvirtXMLResult](fmt.Errorf("failed to create VM: %w", err))
+				}
+			}
+			return toolSuccess(ImportLibvirtXMLResult{
+				Config:  createVMArgsFromConfig(config),
+				Created: args.Create,
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create import_libvirt_xml tool: %w", err)
+	}
+	tools = append(tools, importLibvirtXMLTool)
+
+	// Инструмент для создания ВМ из дескриптора OVF
+	importOVFTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "import_ovf",
+			Description: "Creates a virtual machine from an OVF descriptor (the .ovf file of an OVA appliance): vcpus, memory and disks are taken from its virtual hardware section, missing values from the manager defaults. The name defaults to the one in the descriptor",
+		},
+		func(args ImportOVFArgs) (string, error) {
+			if err := requireArg("ovf", args.OVF, "pass the XML content of the OVF descriptor"); err != nil {
+				return "", err
+			}
+			config, err := manager.ParseOVF(args.OVF, args.Name)
+			if err != nil {
+				return "", fmt.Errorf("failed to import OVF: %w", err)
+			}
+			return planCreate(manager, config)
+		},
+		func(ctx tool.Context, args ImportOVFArgs) (ToolResponse[ImportOVFResult], error) {
+			if err := requireArg("ovf", args.OVF, "pass the XML content of the OVF descriptor"); err != nil {
+				return toolFailure[ImportOVFResult](err)
+			}
+			config, err := manager.CreateVMFromOVF(ctx, args.OVF, args.Name)
+			if err != nil {
+				return toolFailure[ImportOVFResult](fmt.Errorf("failed to import OVF: %w", err))
+			}
+			return toolSuccess(ImportOVFResult{
+				Message: fmt.Sprintf("VM '%s' has created successfully from OVF (%d MB, %d vCPU(s), %d disk(s))", config.Name, config.Memory, config.VCPUs, len(diskPaths(config))),
+				Config:  createVMArgsFromConfig(config),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create import_ovf tool: %w", err)
+	}
+	tools = append(tools, importOVFTool)
+
+	// Инструмент для экспорта ВМ в XML домена libvirt
+	exportLibvirtXMLTool, err := newTool(
+		functiontool.Config{
+			Name:        "export_libvirt_xml",
+			Description: "Renders the configuration of a virtual machine as a libvirt domain XML definition that can be used with 'virsh define' on a real hypervisor",
+		},
+		func(ctx tool.Context, args ExportLibvirtXMLArgs) (ToolResponse[ExportLibvirtXMLResult], error) {
+			data, err := manager.ExportToLibvirtXML(args.Name)
+			if err != nil {
+				return toolFailure[ExportLibvirtXMLResult](fmt.Errorf("failed to export libvirt XML: %w", err))
+			}
+			return toolSuccess(ExportLibvirtXMLResult{
+				XML: data,
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export_libvirt_xml tool: %w", err)
+	}
+	tools = append(tools, exportLibvirtXMLTool)
+
+	// Инструмент для экспорта конфигурации ВМ в YAML
+	exportVMYAMLTool, err := newTool(
+		functiontool.Config{
+			Name:        "export_vm_yaml",
+			Description: "Exports the configuration of a virtual machine as an editable YAML document that can be re-imported with import_vm_yaml",
+		},
+		func(ctx tool.Context, args ExportVMYAMLArgs) (ToolResponse[ExportVMYAMLResult], error) {
+			data, err := manager.ExportVMYAML(args.Name)
+			if err != nil {
+				return toolFailure[ExportVMYAMLResult](fmt.Errorf("failed to export VM: %w", err))
+			}
+			return toolSuccess(ExportVMYAMLResult{
+				YAML: data,
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export_vm_yaml tool: %w", err)
+	}
+	tools = append(tools, exportVMYAMLTool)
+
+	// Инструмент для импорта конфигурации ВМ из YAML
+	importVMYAMLTool, err := newMutatingTool(
+		functiontool.Config{
+			Name:        "import_vm_yaml",
+			Description: "Imports a virtual machine from a YAML document produced by export_vm_yaml. Creates the VM if it does not exist, otherwise replaces the configuration of the stopped VM",
+		},
+		func(args ImportVMYAMLArgs) (string, error) {
+			var config VMConfig
+			if err := yaml.Unmarshal([]byte(args.YAML), &config); err != nil {
+				return "", fmt.Errorf("failed to parse VM YAML: %w", err)
+			}
+			if _, err := vmStateOf(manager, config.Name); err != nil {
+				return planCreate(manager, config)
+			}
+			if err := manager.ValidateUpdate(config.Name, config); err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("would replace the configuration of VM '%s' with %s", config.Name, describeResources(config)), nil
+		},
+		func(ctx tool.Context, args ImportVMYAMLArgs) (ToolResponse[ImportVMYAMLResult], error) {
+			if err := manager.ImportVMYAML(ctx, args.YAML); err != nil {
+				return toolFailure[ImportVMYAMLResult](fmt.Errorf("failed to import VM: %w", err))
+			}
+			return toolSuccess(ImportVMYAMLResult{
+				Message: "Virtual machine imported from YAML successfully",
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create import_vm_yaml tool: %w", err)
+	}
+	tools = append(tools, importVMYAMLTool)
+
+	tools = supportedTools(manager.Capabilities(), tools)
+
+	// Мета-инструмент со списком всех инструментов; замыкание видит итоговый срез tools
+	listToolsTool, err := newTool(
+		functiontool.Config{
+			Name:        "list_tools",
+			Description: "Lists every available tool with its description and parameters",
+		},
+		func(ctx tool.Context, args struct{}) (ToolResponse[ListToolsResult], error) {
+			return toolSuccess(ListToolsResult{
+				Tools: DescribeTools(tools),
+			})
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create list_tools tool: %w", err)
+	}
+	tools = append(tools, listToolsTool)
 
+	if err := checkUniqueToolNames(tools); err != nil {
+		return nil, err
+	}
 	return tools, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,89 @@
+package vm
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestStartVMToolMessageNamesVM(t *testing.T) {
+	m := newTestManager(t, WithAutoStartOnCreate(false))
+	tools := newTestTools(t, m)
+	mustCreate(t, m, VMConfig{Name: "web-01", Memory: 1024, VCPUs: 1})
+
+	resp := callTool(t, tools, "start_vm", map[string]any{"name": "web-01"})
+	if resp["success"] != true {
+		t.Fatalf("start_vm failed: %v", resp)
+	}
+	data, _ := resp["data"].(map[string]any)
+	message, _ := data["message"].(string)
+	if !strings.Contains(message, "'web-01'") {
+		t.Errorf("message = %q, want it to contain the VM name", message)
+	}
+	if strings.Contains(message, "%") {
+		t.Errorf("message = %q contains an uninterpolated format verb", message)
+	}
+}
+
+func TestResultMessagesNameVM(t *testing.T) {
+	m := newTestManager(t)
+	tools := newTestTools(t, m)
+
+	for _, call := range []struct {
+		tool string
+		args map[string]any
+	}{
+		{"create_vm", map[string]any{"name": "db-01", "memory": "1024", "vcpus": 1}},
+		{"stop_vm", map[string]any{"name": "db-01"}},
+		{"start_vm", map[string]any{"name": "db-01"}},
+		{"delete_vm", map[string]any{"name": "db-01", "force": true}},
+	} {
+		resp := callTool(t, tools, call.tool, call.args)
+		if resp["success"] != true {
+			t.Fatalf("%s failed: %v", call.tool, resp)
+		}
+		data, _ := resp["data"].(map[string]any)
+		if message, _ := data["message"].(string); !strings.Contains(message, "'db-01'") || strings.Contains(message, "%") {
+			t.Errorf("%s message = %q, want the VM name interpolated", call.tool, message)
+		}
+	}
+}
+
+func TestToolResponseEnvelope(t *testing.T) {
+	m := newTestManager(t)
+	mustCreate(t, m, VMConfig{Name: "web", Memory: 1024, VCPUs: 1})
+	tools := newTestTools(t, m)
+
+	tests := []struct {
+		name    string
+		args    map[string]any
+		success bool
+	}{
+		{"success", map[string]any{"name": "db", "memory": "1024", "vcpus": 1}, true},
+		{"failure", map[string]any{"name": "web", "memory": "1024", "vcpus": 1}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := json.Marshal(callTool(t, tools, "create_vm", tt.args))
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			var resp ToolResponse[CreateVMResult]
+			if err := json.Unmarshal(raw, &resp); err != nil {
+				t.Fatalf("response %s does not decode into the envelope: %v", raw, err)
+			}
+			if resp.Success != tt.success {
+				t.Fatalf("success = %v, want %v: %s", resp.Success, tt.success, raw)
+			}
+			if tt.success {
+				if resp.Data == nil || resp.Data.VMName != "db" || resp.Error != "" {
+					t.Errorf("successful response = %s, want data with the VM name and no error", raw)
+				}
+				return
+			}
+			if resp.Data != nil || !strings.Contains(resp.Error, "already exists") {
+				t.Errorf("failed response = %s, want null data and the error", raw)
+			}
+		})
+	}
+}
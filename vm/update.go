@@ -0,0 +1,107 @@
+package vm
+
+import (
+	"fmt"
+	"log"
+)
+
+// UpdateVMConfig заменяет конфигурацию остановленной ВМ. Имя в config должно быть
+// пустым или совпадать с name (для переименования используется RenameVM); нулевые поля
+// заполняются значениями по умолчанию, после чего конфигурация проверяется так же,
+// как в CreateVM
+func (m *MockVMManager) UpdateVMConfig(name string, config VMConfig) error {
+	return m.runHooks("update_config", name, func() error { return m.updateVMConfig(name, config) })
+}
+
+// updateVMConfig выполняет UpdateVMConfig без хуков операций
+func (m *MockVMManager) updateVMConfig(name string, config VMConfig) (err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name = m.resolveNameLocked(name)
+	defer func() { m.noteResultLocked(name, err) }()
+
+	vm, exists := m.vms[name]
+	if !exists {
+		return fmt.Errorf("virtual machine '%s' not found", name)
+	}
+	prev := copyConfig(vm.Config)
+	if err := m.updateVMConfigLocked(name, config); err != nil {
+		return err
+	}
+	m.recordLocked(AuditEntry{Operation: AuditUpdate, VMName: name, PrevConfig: &prev})
+	return nil
+}
+
+// ValidateUpdate проверяет, что конфигурацию ВМ можно заменить на config прямо сейчас:
+// выполняет те же проверки, что UpdateVMConfig (состояние и блокировка ВМ,
+// конфигурация, конфликты дисков, квоты), ничего не меняя
+func (m *MockVMManager) ValidateUpdate(name string, config VMConfig) error {
+	m.mu.Lock() // проверка квот временно исключает ВМ из m.vms
+	defer m.mu.Unlock()
+
+	_, _, err := m.checkUpdateLocked(m.resolveNameLocked(name), config)
+	return err
+}
+
+// updateVMConfigLocked выполняет обновление; вызывающий код должен удерживать m.mu
+func (m *MockVMManager) updateVMConfigLocked(name string, config VMConfig) error {
+	config, host, err := m.checkUpdateLocked(name, config)
+	if err != nil {
+		return err
+	}
+	vm := m.vms[name]
+
+	m.releaseDisksLocked(name, diskPaths(vm.Config))
+	vm.Config = copyConfig(config)
+	vm.Host = host
+	for _, path := range diskPaths(vm.Config) {
+		m.disks[path] = name
+	}
+
+	log.Printf("[MOCK] Virtual machine '%s' configuration updated", name)
+	return nil
+}
+
+// checkUpdateLocked проверяет новую конфигурацию ВМ name и возвращает ее с примененными
+// значениями по умолчанию вместе с хостом размещения. Вызывающий код должен удерживать
+// m.mu на запись
+func (m *MockVMManager) checkUpdateLocked(name string, config VMConfig) (VMConfig, string, error) {
+	vm, exists := m.vms[name]
+	if !exists {
+		return VMConfig{}, "", fmt.Errorf("virtual machine '%s' not found", name)
+	}
+	if config.Name == "" || m.resolveNameLocked(config.Name) == name {
+		config.Name = name
+	}
+	if config.Name != name {
+		return VMConfig{}, "", fmt.Errorf("cannot change name of virtual machine '%s' to '%s': use RenameVM", name, config.Name)
+	}
+	if err := checkNotBusyLocked(vm, name); err != nil {
+		return VMConfig{}, "", err
+	}
+	if err := checkUnlockedLocked(vm, name, "update the configuration of"); err != nil {
+		return VMConfig{}, "", err
+	}
+	if vm.State != VMStateStopped {
+		return VMConfig{}, "", fmt.Errorf("virtual machine '%s' must be stopped to update its configuration (current state: %s)", name, vm.State)
+	}
+
+	config = m.applyDefaults(config)
+	config.ReservationID = "" // резервирование занимает только CreateVM
+	if err := m.validateConfig(config); err != nil {
+		return VMConfig{}, "", err
+	}
+	if err := m.checkDiskConflictsLocked(name, diskSpecs(config)); err != nil {
+		return VMConfig{}, "", err
+	}
+
+	// Проверяем квоты без учета текущей конфигурации обновляемой ВМ
+	delete(m.vms, name)
+	reason := m.scheduleReasonLocked(config)
+	host, _ := m.placeLocked(config)
+	m.vms[name] = vm
+	if reason != "" {
+		return VMConfig{}, "", fmt.Errorf("cannot schedule VM '%s': %s", name, reason)
+	}
+	return config, host, nil
+}
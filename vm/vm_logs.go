@@ -0,0 +1,98 @@
+package vm
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// maxVMLogLines - наибольшее количество строк журнала, возвращаемых GetVMLogs
+const maxVMLogLines = 500
+
+// GetVMLogs возвращает последние lines строк журнала ВМ (не больше maxVMLogLines), от старых
+// к новым. Реальный бэкенд читает журнал последовательной консоли домена; mock-менеджер
+// формирует строки из журнала операций: создание, запуски, остановки, изменения
+// конфигурации, переименования и обмены именами (в том числе под прежними именами), а
+// последняя строка сообщает текущее состояние
+func (m *MockVMManager) GetVMLogs(name string, lines int) ([]string, error) {
+	if lines <= 0 {
+		return nil, fmt.Errorf("number of log lines must be positive")
+	}
+	lines = min(lines, maxVMLogLines)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	name = m.resolveNameLocked(name)
+
+	vm, exists := m.vms[name]
+	if !exists {
+		return nil, fmt.Errorf("virtual machine '%s' not found", name)
+	}
+
+	// Идем от новых записей к старым, отслеживая прежнее имя ВМ; запись об удалении
+	// ВМ с тем же именем означает, что более старые записи относятся к другой ВМ
+	current := name
+	var history []string
+loop:
+	for _, entry := range slices.Backward(m.audit) {
+		line := ""
+		switch entry.Operation {
+		case AuditDelete:
+			if entry.VMName == current {
+				break loop
+			}
+		case AuditRename:
+			if entry.NewName == current {
+				current = entry.VMName
+				line = fmt.Sprintf("renamed from '%s' to '%s'", entry.VMName, entry.NewName)
+			}
+		case AuditSwap:
+			if entry.VMName == current || entry.NewName == current {
+				previous := entry.VMName
+				if previous == current {
+					previous = entry.NewName
+				}
+				line = fmt.Sprintf("renamed from '%s' to '%s' (names swapped)", previous, current)
+				current = previous
+			}
+		case AuditStart, AuditStop:
+			if slices.Contains(entry.VMs, current) {
+				line = vmLogVerbs[entry.Operation]
+				if entry.VMName != current {
+					line = fmt.Sprintf("%s (requested for '%s')", line, entry.VMName)
+				}
+			}
+		default:
+			if entry.VMName == current {
+				line = vmLogVerbs[entry.Operation]
+			}
+		}
+		if line != "" {
+			history = append(history, formatVMLogLine(entry.Time, line))
+		}
+		if (entry.Operation == AuditCreate || entry.Operation == AuditClone) && entry.VMName == current {
+			break
+		}
+	}
+	slices.Reverse(history)
+
+	history = append(history, formatVMLogLine(m.now(), fmt.Sprintf("current state: %s", vm.State)))
+	if len(history) > lines {
+		history = history[len(history)-lines:]
+	}
+	return history, nil
+}
+
+// vmLogVerbs - описания операций журнала для строк GetVMLogs
+var vmLogVerbs = map[AuditOperation]string{
+	AuditCreate: "virtual machine created",
+	AuditClone:  "virtual machine created as a clone",
+	AuditStart:  "virtual machine started",
+	AuditStop:   "virtual machine stopped",
+	AuditUpdate: "configuration updated",
+}
+
+// formatVMLogLine возвращает строку журнала ВМ с меткой времени
+func formatVMLogLine(t time.Time, message string) string {
+	return fmt.Sprintf("%s %s", t.UTC().Format(time.RFC3339), message)
+}
@@ -0,0 +1,54 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExportVMYAML возвращает конфигурацию ВМ в виде YAML-документа, пригодного
+// для редактирования и повторного импорта через ImportVMYAML
+func (m *MockVMManager) ExportVMYAML(name string) (string, error) {
+	m.mu.RLock()
+	name = m.resolveNameLocked(name)
+	vm, exists := m.vms[name]
+	if !exists {
+		m.mu.RUnlock()
+		return "", fmt.Errorf("virtual machine '%s' not found", name)
+	}
+	config := copyConfig(vm.Config)
+	m.mu.RUnlock()
+
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal virtual machine '%s' to YAML: %w", name, err)
+	}
+	return string(data), nil
+}
+
+// ImportVMYAML создает ВМ из YAML-документа. Если ВМ с таким именем уже существует,
+// ее конфигурация заменяется импортированной; для этого ВМ должна быть остановлена
+func (m *MockVMManager) ImportVMYAML(ctx context.Context, data string) error {
+	return importVMYAML(ctx, m, data)
+}
+
+// importVMYAML реализует ImportVMYAML через методы manager, чтобы декораторы
+// (TimeoutManager) создавали ВМ собственным CreateVM
+func importVMYAML(ctx context.Context, manager VMManagerInterface, data string) error {
+	var config VMConfig
+	if err := yaml.Unmarshal([]byte(data), &config); err != nil {
+		return fmt.Errorf("failed to parse VM YAML: %w", err)
+	}
+
+	if _, err := manager.LookupVM(config.Name); err != nil {
+		return manager.CreateVM(ctx, config)
+	}
+	if err := manager.UpdateVMConfig(config.Name, config); err != nil {
+		return err
+	}
+
+	log.Printf("[MOCK] Virtual machine '%s' configuration imported from YAML", config.Name)
+	return nil
+}
@@ -0,0 +1,71 @@
+package vm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// newYAMLManager создает менеджер с двумя хостами и ВМ db и cache на разных хостах,
+// на которые ссылаются зависимости и правила размещения импортируемой ВМ
+func newYAMLManager(t *testing.T) *MockVMManager {
+	t.Helper()
+	m := newTestManager(t, WithHosts("host-a", "host-b"), WithAutoStartOnCreate(false))
+	mustCreate(t, m, VMConfig{Name: "db", Memory: 1024, VCPUs: 1})
+	mustCreate(t, m, VMConfig{Name: "cache", Memory: 1024, VCPUs: 1, AntiAffinity: []string{"db"}})
+	return m
+}
+
+func TestExportImportVMYAMLRoundTrip(t *testing.T) {
+	iso := filepath.Join(t.TempDir(), "ubuntu.iso")
+	if err := os.WriteFile(iso, nil, 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	startOnCreate := false
+	config := VMConfig{
+		Name:     "web",
+		Memory:   2048,
+		VCPUs:    2,
+		DiskPath: "/var/lib/vms/web.qcow2",
+		DiskSize: 20,
+		ISOImage: iso,
+		Network:  "lan",
+		Disks: []DiskSpec{
+			{Path: "/var/lib/vms/web-data.qcow2", Size: 50, Shared: true, ReadIOPS: 500, WriteIOPS: 250},
+			{Path: "/var/lib/vms/base.qcow2", Size: 10, ReadOnly: true},
+		},
+		DependsOn:     []string{"db"},
+		Labels:        map[string]string{"env": "prod", "team": "web"},
+		Firmware:      FirmwareUEFI,
+		OSType:        "linux",
+		OSVariant:     "ubuntu22.04",
+		Affinity:      []string{"cache"},
+		AntiAffinity:  []string{"db"},
+		CPUPinning:    map[uint]uint{0: 2, 1: 3},
+		NUMANodes:     []NUMANode{{CPUs: []uint{0}, MemoryMB: 1024}, {CPUs: []uint{1}, MemoryMB: 1024}},
+		StartOnCreate: &startOnCreate,
+		DiskReadIOPS:  1000,
+		DiskWriteIOPS: 800,
+		Bandwidth:     NetworkSpec{InboundKbps: 10000, OutboundKbps: 5000},
+	}
+	src := newYAMLManager(t)
+	mustCreate(t, src, config)
+
+	data, err := src.ExportVMYAML("web")
+	if err != nil {
+		t.Fatalf("ExportVMYAML: %v", err)
+	}
+	dst := newYAMLManager(t)
+	if err := dst.ImportVMYAML(context.Background(), data); err != nil {
+		t.Fatalf("ImportVMYAML: %v\n%s", err, data)
+	}
+	info, err := dst.GetVMInfo("web")
+	if err != nil {
+		t.Fatalf("GetVMInfo: %v", err)
+	}
+	if !reflect.DeepEqual(info.Config, config) {
+		t.Errorf("imported config = %+v\nwant %+v\nYAML:\n%s", info.Config, config, data)
+	}
+}